@@ -0,0 +1,70 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts"
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+)
+
+// Kinds of the consensus votes.
+const (
+	VoteBlock   byte = iota // block verify vote of blkverify
+	VoteReelect             // leader reelection vote of leaderelect2.0
+	VoteOnline              // online consensus vote of olconsensus
+)
+
+// VoteInfo identifies the consensus vote a hash is signed for. A signer refuses
+// to sign two different subjects for the same vote. Target is the node of an
+// online consensus vote. Subject is what the vote is for, the signed hash if
+// empty: it is set when the signed message changes between resends of the same
+// vote.
+type VoteInfo struct {
+	Kind        byte           `json:"kind"`
+	Number      uint64         `json:"number"`
+	Turn        uint32         `json:"turn"`
+	ReelectTurn uint32         `json:"reelectTurn"`
+	Target      common.Address `json:"target"`
+	Subject     common.Hash    `json:"subject"`
+}
+
+// SignBackend holds the keys of the sign accounts: the keystore of the node, or
+// a remote signer keeping the keys off the node process.
+type SignBackend interface {
+	// SignAccount returns the first of the sign accounts the backend can sign
+	// with, and its password for the keystore.
+	SignAccount(reader AuthReader, signAccounts []common.Address) (common.Address, string, error)
+	SignHash(account common.Address, password string, hash []byte, validate bool, vote *VoteInfo) ([]byte, error)
+	SignVrf(account common.Address, password string, msg []byte) ([]byte, []byte, []byte, error)
+	SignTx(account common.Address, password string, tx types.SelfTransaction, chainID *big.Int) (types.SelfTransaction, error)
+}
+
+// keyStoreBackend signs with the keystore of the node, the passwords of the sign
+// accounts come from the entrust file.
+type keyStoreBackend struct {
+	ks *keystore.KeyStore
+}
+
+func (b *keyStoreBackend) SignAccount(reader AuthReader, signAccounts []common.Address) (common.Address, string, error) {
+	if reader == nil {
+		return common.Address{}, "", ErrReader
+	}
+	return reader.GetSignAccountPassword(signAccounts)
+}
+
+func (b *keyStoreBackend) SignHash(account common.Address, password string, hash []byte, validate bool, vote *VoteInfo) ([]byte, error) {
+	return b.ks.SignHashValidateWithPass(accounts.Account{Address: account}, password, hash, validate)
+}
+
+func (b *keyStoreBackend) SignVrf(account common.Address, password string, msg []byte) ([]byte, []byte, []byte, error) {
+	return b.ks.SignVrfWithPass(accounts.Account{Address: account}, password, msg)
+}
+
+func (b *keyStoreBackend) SignTx(account common.Address, password string, tx types.SelfTransaction, chainID *big.Int) (types.SelfTransaction, error) {
+	return b.ks.SignTxWithPassAndTemp(accounts.Account{Address: account}, password, tx, chainID)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+	"github.com/pkg/errors"
+)
+
+const remoteSignTimeout = 5 * time.Second
+
+var (
+	ErrRemoteNoSignAccount = errors.New("remote signer holds none of the sign accounts")
+	ErrRemoteSignature     = errors.New("remote signer returned an invalid signature")
+	ErrRemoteTxSender      = errors.New("remote signer signed the transaction with another account")
+)
+
+// RemoteSigner is the SignBackend of an external signer daemon, reached over
+// IPC or HTTP. See SignerService for the protocol.
+type RemoteSigner struct {
+	endpoint string
+	dial     func() (*rpc.Client, error)
+
+	mu       sync.Mutex
+	client   *rpc.Client
+	accounts map[common.Address]bool
+}
+
+// NewRemoteSigner returns the backend of the signer daemon at the endpoint, an
+// IPC path or an http(s) URL. The daemon is dialed on first use, and again after
+// a connection failure.
+func NewRemoteSigner(endpoint string) *RemoteSigner {
+	return &RemoteSigner{
+		endpoint: endpoint,
+		dial:     func() (*rpc.Client, error) { return rpc.Dial(endpoint) },
+	}
+}
+
+func (rs *RemoteSigner) call(result interface{}, method string, args ...interface{}) error {
+	rs.mu.Lock()
+	client := rs.client
+	if client == nil {
+		var err error
+		if client, err = rs.dial(); err != nil {
+			rs.mu.Unlock()
+			log.Error(ModeLog, "连接远程签名服务失败", err, "endpoint", rs.endpoint)
+			return err
+		}
+		rs.client = client
+	}
+	rs.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteSignTimeout)
+	defer cancel()
+	err := client.CallContext(ctx, result, method, args...)
+	if _, ok := err.(rpc.Error); err != nil && !ok {
+		// connection failure, redial on the next call
+		rs.mu.Lock()
+		if rs.client == client {
+			rs.client = nil
+			client.Close()
+		}
+		rs.mu.Unlock()
+	}
+	return err
+}
+
+// Accounts returns the accounts the signer holds the keys of.
+func (rs *RemoteSigner) Accounts() ([]common.Address, error) {
+	var result []common.Address
+	if err := rs.call(&result, "signer_accounts"); err != nil {
+		return nil, err
+	}
+	accounts := make(map[common.Address]bool, len(result))
+	for _, account := range result {
+		accounts[account] = true
+	}
+	rs.mu.Lock()
+	rs.accounts = accounts
+	rs.mu.Unlock()
+	return result, nil
+}
+
+func (rs *RemoteSigner) findAccount(signAccounts []common.Address) (common.Address, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for _, account := range signAccounts {
+		if rs.accounts[account] {
+			return account, true
+		}
+	}
+	return common.Address{}, false
+}
+
+// SignAccount returns the first of the sign accounts the signer holds, the
+// password is left empty.
+func (rs *RemoteSigner) SignAccount(reader AuthReader, signAccounts []common.Address) (common.Address, string, error) {
+	if account, ok := rs.findAccount(signAccounts); ok {
+		return account, "", nil
+	}
+	// the signer may have loaded new keys
+	if _, err := rs.Accounts(); err != nil {
+		return common.Address{}, "", err
+	}
+	if account, ok := rs.findAccount(signAccounts); ok {
+		return account, "", nil
+	}
+	return common.Address{}, "", ErrRemoteNoSignAccount
+}
+
+func (rs *RemoteSigner) SignHash(account common.Address, password string, hash []byte, validate bool, vote *VoteInfo) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := rs.call(&sig, "signer_signHash", account, hexutil.Bytes(hash), validate, vote); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func (rs *RemoteSigner) SignVrf(account common.Address, password string, msg []byte) ([]byte, []byte, []byte, error) {
+	var result RemoteVrf
+	if err := rs.call(&result, "signer_signVrf", account, hexutil.Bytes(msg)); err != nil {
+		return []byte{}, []byte{}, []byte{}, err
+	}
+	return result.PublicKey, result.Value, result.Proof, nil
+}
+
+// SignTx sends the transaction to the signer, and applies the signature it
+// returns. The signer computes the signing hash itself.
+func (rs *RemoteSigner) SignTx(account common.Address, password string, tx types.SelfTransaction, chainID *big.Int) (types.SelfTransaction, error) {
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	var sig hexutil.Bytes
+	if err := rs.call(&sig, "signer_signTx", account, hexutil.Uint64(tx.TxType()), hexutil.Bytes(data), (*hexutil.Big)(chainID)); err != nil {
+		return nil, err
+	}
+	if len(sig) != 65 {
+		return nil, ErrRemoteSignature
+	}
+	signer := types.NewEIP155Signer(chainID)
+	signed, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		return nil, err
+	}
+	if from, err := signer.Sender(signed); err != nil || from != account {
+		return nil, ErrRemoteTxSender
+	}
+	return signed, nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+func newTestRemoteSigner(t *testing.T, dir string) (*RemoteSigner, *VoteGuard, common.Address) {
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("password")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	guard, err := NewVoteGuard(filepath.Join(dir, "votes"))
+	if err != nil {
+		t.Fatalf("failed to create vote guard: %v", err)
+	}
+	service, err := NewSignerService(ks, map[common.Address]string{account.Address: "password"}, guard)
+	if err != nil {
+		t.Fatalf("failed to create signer service: %v", err)
+	}
+	server := rpc.NewServer()
+	for _, api := range service.APIs() {
+		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+			t.Fatalf("failed to register service: %v", err)
+		}
+	}
+	return &RemoteSigner{dial: func() (*rpc.Client, error) { return rpc.DialInProc(server), nil }}, guard, account.Address
+}
+
+func TestRemoteSignHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signhelper-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rs, guard, account := newTestRemoteSigner(t, dir)
+
+	other := common.BytesToAddress([]byte{0x01})
+	if signAccount, _, err := rs.SignAccount(nil, []common.Address{other, account}); err != nil || signAccount != account {
+		t.Fatalf("sign account mismatch: have %s, %v, want %s", signAccount.Hex(), err, account.Hex())
+	}
+	if _, _, err := rs.SignAccount(nil, []common.Address{other}); err != ErrRemoteNoSignAccount {
+		t.Fatalf("unknown sign account returned: %v", err)
+	}
+
+	hash := common.BytesToHash([]byte{0x11})
+	sig, err := rs.SignHash(account, "", hash.Bytes(), true, &VoteInfo{Number: 100, Turn: 1})
+	if err != nil {
+		t.Fatalf("failed to sign vote: %v", err)
+	}
+	if signer, validate, err := crypto.VerifySignWithValidate(hash.Bytes(), sig); err != nil || signer != account || !validate {
+		t.Fatalf("signature mismatch: have %s, %v, %v", signer.Hex(), validate, err)
+	}
+	// the same vote again is fine, another hash for the height and turn isn't
+	if _, err := rs.SignHash(account, "", hash.Bytes(), false, &VoteInfo{Number: 100, Turn: 1}); err != nil {
+		t.Fatalf("failed to sign the same vote again: %v", err)
+	}
+	conflict := common.BytesToHash([]byte{0x22})
+	if _, err := rs.SignHash(account, "", conflict.Bytes(), true, &VoteInfo{Number: 100, Turn: 1}); err == nil {
+		t.Fatalf("conflicting vote signed")
+	}
+	if _, err := rs.SignHash(account, "", conflict.Bytes(), true, &VoteInfo{Number: 100, Turn: 2}); err != nil {
+		t.Fatalf("failed to sign the vote of another turn: %v", err)
+	}
+	if _, err := rs.SignHash(account, "", conflict.Bytes(), true, nil); err != nil {
+		t.Fatalf("failed to sign a hash: %v", err)
+	}
+
+	// the records survive a restart
+	guard.Close()
+	guard, err = NewVoteGuard(filepath.Join(dir, "votes"))
+	if err != nil {
+		t.Fatalf("failed to reload vote guard: %v", err)
+	}
+	defer guard.Close()
+	if err := guard.Check(account, VoteInfo{Number: 100, Turn: 1}, conflict); err != ErrDoubleSign {
+		t.Fatalf("conflicting vote accepted after reload: %v", err)
+	}
+	if err := guard.Check(account, VoteInfo{Number: 100 + voteGuardKeepBlocks + 1}, hash); err != nil {
+		t.Fatalf("failed to record vote: %v", err)
+	}
+	if err := guard.Check(account, VoteInfo{Number: 99}, hash); err != ErrStaleVote {
+		t.Fatalf("stale vote accepted: %v", err)
+	}
+}
+
+func TestRemoteSignTx(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signhelper-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rs, guard, account := newTestRemoteSigner(t, dir)
+	defer guard.Close()
+
+	chainID := big.NewInt(1)
+	tx := types.NewBroadCastTransaction(1, []byte("broadcast"))
+	signed, err := rs.SignTx(account, "", tx, chainID)
+	if err != nil {
+		t.Fatalf("failed to sign broadcast tx: %v", err)
+	}
+	if from, err := types.NewEIP155Signer(chainID).Sender(signed); err != nil || from != account {
+		t.Fatalf("broadcast tx sender mismatch: have %s, %v", from.Hex(), err)
+	}
+
+	normal := types.NewTransaction(0, common.BytesToAddress([]byte{0x02}), big.NewInt(1), 21000, big.NewInt(1), nil, nil, nil, nil, 0, 0, "MAN", 0)
+	if signed, err = rs.SignTx(account, "", normal, chainID); err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+	if from, err := types.NewEIP155Signer(chainID).Sender(signed); err != nil || from != account {
+		t.Fatalf("tx sender mismatch: have %s, %v", from.Hex(), err)
+	}
+	if _, err := rs.SignTx(common.BytesToAddress([]byte{0x01}), "", normal, chainID); err == nil {
+		t.Fatalf("tx signed with an unknown account")
+	}
+}
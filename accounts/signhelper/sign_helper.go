@@ -47,6 +47,8 @@ var (
 type SignHelper struct {
 	mu         sync.RWMutex
 	keyStore   *keystore.KeyStore
+	remote     SignBackend
+	voteGuard  *VoteGuard
 	authReader AuthReader
 }
 
@@ -86,46 +88,96 @@ func (sh *SignHelper) SetAccountManager(am *accounts.Manager) error {
 	return nil
 }
 
-func (sh *SignHelper) SignHashWithValidateByReader(reader AuthReader, hash []byte, validate bool, blkHash common.Hash) (common.Signature, error) {
-	signAccount, signPassword, err := sh.getSignAccountAndPassword(reader, blkHash)
+// SetRemoteSigner makes the helper sign with the remote signer instead of the
+// keystore, the keys of the sign accounts then stay off the node.
+func (sh *SignHelper) SetRemoteSigner(remote SignBackend) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.remote = remote
+}
+
+// SetVoteGuard sets the slashing protection of the node, consulted before any
+// consensus vote is signed.
+func (sh *SignHelper) SetVoteGuard(guard *VoteGuard) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.voteGuard = guard
+}
+
+func (sh *SignHelper) guard() *VoteGuard {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return sh.voteGuard
+}
+
+func (sh *SignHelper) backend() (SignBackend, error) {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	if sh.remote != nil {
+		return sh.remote, nil
+	}
+	if nil == sh.keyStore {
+		return nil, ErrNilKeyStore
+	}
+	return &keyStoreBackend{ks: sh.keyStore}, nil
+}
+
+func (sh *SignHelper) signHashWithValidate(reader AuthReader, hash []byte, validate bool, blkHash common.Hash, vote *VoteInfo) (common.Signature, error) {
+	backend, err := sh.backend()
+	if err != nil {
+		return common.Signature{}, err
+	}
+	signAccount, signPassword, err := sh.getSignAccountAndPassword(backend, reader, blkHash)
 	if err != nil {
 		return common.Signature{}, ErrGetAccountAndPassword
 	}
 	if (signAccount.Address == common.Address{}) {
 		return common.Signature{}, ErrIllegalSignAccount
 	}
-
-	sh.mu.RLock()
-	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
-		return common.Signature{}, ErrNilKeyStore
+	if guard := sh.guard(); vote != nil && guard != nil {
+		if err := guard.Check(signAccount.Address, *vote, common.BytesToHash(hash)); err != nil {
+			log.Error(ModeLog, "拒绝签名", err, "kind", vote.Kind, "number", vote.Number, "turn", vote.Turn, "reelect turn", vote.ReelectTurn, "hash", common.BytesToHash(hash).TerminalString())
+			return common.Signature{}, err
+		}
 	}
-	sign, err := sh.keyStore.SignHashValidateWithPass(signAccount, signPassword, hash, validate)
+
+	sign, err := backend.SignHash(signAccount.Address, signPassword, hash, validate, vote)
 	if err != nil {
 		return common.Signature{}, err
 	}
 	return common.BytesToSignature(sign), nil
 }
 
+func (sh *SignHelper) SignHashWithValidateByReader(reader AuthReader, hash []byte, validate bool, blkHash common.Hash) (common.Signature, error) {
+	return sh.signHashWithValidate(reader, hash, validate, blkHash, nil)
+}
+
 func (sh *SignHelper) SignHashWithValidate(hash []byte, validate bool, blkHash common.Hash) (common.Signature, error) {
-	return sh.SignHashWithValidateByReader(sh.authReader, hash, validate, blkHash)
+	return sh.signHashWithValidate(sh.authReader, hash, validate, blkHash, nil)
+}
+
+// SignVoteWithValidate signs the consensus vote. The vote guard of the node, and
+// a remote signer, refuse to sign another subject for it.
+func (sh *SignHelper) SignVoteWithValidate(hash []byte, validate bool, blkHash common.Hash, vote VoteInfo) (common.Signature, error) {
+	return sh.signHashWithValidate(sh.authReader, hash, validate, blkHash, &vote)
+}
+
+func (sh *SignHelper) SignVoteWithValidateByReader(reader AuthReader, hash []byte, validate bool, blkHash common.Hash, vote VoteInfo) (common.Signature, error) {
+	return sh.signHashWithValidate(reader, hash, validate, blkHash, &vote)
 }
 
 func (sh *SignHelper) SignHashWithValidateByAccount(hash []byte, validate bool, account common.Address) (common.Signature, error) {
-	signAccount, password, err := sh.authReader.GetSignAccountPassword([]common.Address{account})
+	backend, err := sh.backend()
+	if err != nil {
+		return common.Signature{}, err
+	}
+	signAccount, password, err := backend.SignAccount(sh.authReader, []common.Address{account})
 	if err != nil {
 		log.Error(ModeLog, "account", account.Hex(), "签名失败", err)
 		return common.Signature{}, errors.New("get sign account password err!")
 	}
 
-	sh.mu.RLock()
-	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
-		return common.Signature{}, ErrNilKeyStore
-	}
-
-	ac := accounts.Account{Address: signAccount}
-	sign, err := sh.keyStore.SignHashValidateWithPass(ac, password, hash, validate)
+	sign, err := backend.SignHash(signAccount, password, hash, validate, nil)
 	if err != nil {
 		return common.Signature{}, err
 	}
@@ -134,40 +186,41 @@ func (sh *SignHelper) SignHashWithValidateByAccount(hash []byte, validate bool,
 }
 
 func (sh *SignHelper) SignTx(tx types.SelfTransaction, chainID *big.Int, blkHash common.Hash, signHeight uint64, usingEntrust bool) (types.SelfTransaction, error) {
+	backend, err := sh.backend()
+	if err != nil {
+		return nil, err
+	}
 	// Sign the requested hash with the wallet
-	signAccount, signPassword, err := sh.getSignAccountAndPasswordAtSignHeight(sh.authReader, blkHash, signHeight, usingEntrust)
+	signAccount, signPassword, err := sh.getSignAccountAndPasswordAtSignHeight(backend, sh.authReader, blkHash, signHeight, usingEntrust)
 	if err != nil {
 		return nil, ErrGetAccountAndPassword
 	}
 	if (signAccount.Address == common.Address{}) {
 		return nil, ErrIllegalSignAccount
 	}
-	sh.mu.RLock()
-	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
-		return nil, ErrNilKeyStore
-	}
-	return sh.keyStore.SignTxWithPassAndTemp(signAccount, signPassword, tx, chainID)
+	return backend.SignTx(signAccount.Address, signPassword, tx, chainID)
 }
 
 func (sh *SignHelper) SignVrfByAccount(msg []byte, account common.Address) ([]byte, []byte, []byte, error) {
-	signAccount, password, err := sh.authReader.GetSignAccountPassword([]common.Address{account})
+	backend, err := sh.backend()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	signAccount, password, err := backend.SignAccount(sh.authReader, []common.Address{account})
 	if err != nil {
 		log.Error(ModeLog, "VRFaccount", account.Hex(), "签名失败", err)
 		return nil, nil, nil, errors.New("get sign account password err!")
 	}
 
-	sh.mu.RLock()
-	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
-		return nil, nil, nil, ErrNilKeyStore
-	}
-	ac := accounts.Account{Address: signAccount}
-	return sh.keyStore.SignVrfWithPass(ac, password, msg)
+	return backend.SignVrf(signAccount, password, msg)
 }
 
 func (sh *SignHelper) SignVrf(msg []byte, blkHash common.Hash) ([]byte, []byte, []byte, error) {
-	signAccount, signPassword, err := sh.getSignAccountAndPassword(sh.authReader, blkHash)
+	backend, err := sh.backend()
+	if err != nil {
+		return []byte{}, []byte{}, []byte{}, err
+	}
+	signAccount, signPassword, err := sh.getSignAccountAndPassword(backend, sh.authReader, blkHash)
 	//log.ERROR(ModeLog, "signAccount", signAccount, "signPassword", signPassword, "err", err, "blkhash", blkHash)
 	if err != nil {
 		return []byte{}, []byte{}, []byte{}, ErrGetAccountAndPassword
@@ -176,15 +229,10 @@ func (sh *SignHelper) SignVrf(msg []byte, blkHash common.Hash) ([]byte, []byte,
 		return []byte{}, []byte{}, []byte{}, ErrIllegalSignAccount
 	}
 
-	sh.mu.RLock()
-	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
-		return []byte{}, []byte{}, []byte{}, ErrNilKeyStore
-	}
-	return sh.keyStore.SignVrfWithPass(signAccount, signPassword, msg)
+	return backend.SignVrf(signAccount.Address, signPassword, msg)
 }
 
-func (sh *SignHelper) getSignAccountAndPasswordAtSignHeight(reader AuthReader, blkHash common.Hash, signHeight uint64, usingEntrust bool) (accounts.Account, string, error) {
+func (sh *SignHelper) getSignAccountAndPasswordAtSignHeight(backend SignBackend, reader AuthReader, blkHash common.Hash, signHeight uint64, usingEntrust bool) (accounts.Account, string, error) {
 	account := accounts.Account{}
 
 	var addrs []common.Address
@@ -198,19 +246,19 @@ func (sh *SignHelper) getSignAccountAndPasswordAtSignHeight(reader AuthReader, b
 		addrs = []common.Address{ca.GetSignAddress()}
 	}
 
-	addr, password, err := reader.GetSignAccountPassword(addrs)
+	addr, password, err := backend.SignAccount(reader, addrs)
 	account.Address = addr
 	return account, password, err
 }
 
-func (sh *SignHelper) getSignAccountAndPassword(reader AuthReader, blkHash common.Hash) (accounts.Account, string, error) {
+func (sh *SignHelper) getSignAccountAndPassword(backend SignBackend, reader AuthReader, blkHash common.Hash) (accounts.Account, string, error) {
 	account := accounts.Account{}
 	addrs, err := reader.GetA2AccountsFromA0Account(ca.GetDepositAddress(), blkHash)
 	if err != nil {
 		return account, "", err
 	}
 
-	addr, password, err := reader.GetSignAccountPassword(addrs)
+	addr, password, err := backend.SignAccount(reader, addrs)
 	account.Address = addr
 	return account, password, err
 }
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts"
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnknownSignAccount = errors.New("sign account is not held by the signer")
+	ErrUnknownTxType      = errors.New("unknown transaction type")
+)
+
+// RemoteVrf is the result of signer_signVrf.
+type RemoteVrf struct {
+	PublicKey hexutil.Bytes `json:"publicKey"`
+	Value     hexutil.Bytes `json:"value"`
+	Proof     hexutil.Bytes `json:"proof"`
+}
+
+// SignerService is the API of the signer daemon, served over IPC or HTTP as the
+// JSON-RPC 2.0 namespace "signer":
+//
+//	signer_accounts() -> [account]
+//	signer_signHash(account, hash, validate, vote) -> signature
+//	signer_signVrf(account, msg) -> {publicKey, value, proof}
+//	signer_signTx(account, txType, rlp, chainId) -> signature
+//
+// Accounts are hex addresses, hashes, messages and signatures are hex bytes,
+// a signature is 65 bytes [R || S || V]. The vote of signer_signHash is null or
+// a VoteInfo for a consensus vote: the signer refuses to sign another subject
+// for the same account and vote. signer_signTx takes the RLP of a normal
+// (txType 0) or broadcast (txType 1) transaction and signs its EIP155 hash.
+type SignerService struct {
+	ks        *keystore.KeyStore
+	passwords map[common.Address]string
+	guard     *VoteGuard
+}
+
+// NewSignerService returns the service signing with the keys of the accounts
+// in the keystore, unlocked by their passwords.
+func NewSignerService(ks *keystore.KeyStore, passwords map[common.Address]string, guard *VoteGuard) (*SignerService, error) {
+	for account, password := range passwords {
+		if err := ks.CheckAccountAndPassword(accounts.Account{Address: account}, password); err != nil {
+			return nil, fmt.Errorf("account %s: %v", account.Hex(), err)
+		}
+	}
+	return &SignerService{ks: ks, passwords: passwords, guard: guard}, nil
+}
+
+// APIs returns the rpc API of the service.
+func (s *SignerService) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "signer",
+			Version:   "1.0",
+			Service:   s,
+			Public:    true,
+		},
+	}
+}
+
+func (s *SignerService) account(account common.Address) (accounts.Account, string, error) {
+	password, ok := s.passwords[account]
+	if !ok {
+		return accounts.Account{}, "", ErrUnknownSignAccount
+	}
+	return accounts.Account{Address: account}, password, nil
+}
+
+// Accounts returns the accounts the signer holds, sorted.
+func (s *SignerService) Accounts() []common.Address {
+	list := make([]common.Address, 0, len(s.passwords))
+	for account := range s.passwords {
+		list = append(list, account)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Hex() < list[j].Hex() })
+	return list
+}
+
+// SignHash signs the hash, checking the vote against the signed ones.
+func (s *SignerService) SignHash(account common.Address, hash hexutil.Bytes, validate bool, vote *VoteInfo) (hexutil.Bytes, error) {
+	ac, password, err := s.account(account)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != common.HashLength {
+		return nil, fmt.Errorf("hash is required to be exactly %d bytes (%d)", common.HashLength, len(hash))
+	}
+	if vote != nil && s.guard != nil {
+		if err := s.guard.Check(account, *vote, common.BytesToHash(hash)); err != nil {
+			log.Error(ModeLog, "拒绝签名", err, "account", account.Hex(), "kind", vote.Kind, "number", vote.Number, "turn", vote.Turn, "reelect turn", vote.ReelectTurn, "hash", common.BytesToHash(hash).TerminalString())
+			return nil, err
+		}
+	}
+	return s.ks.SignHashValidateWithPass(ac, password, hash, validate)
+}
+
+// SignVrf computes the vrf of the message.
+func (s *SignerService) SignVrf(account common.Address, msg hexutil.Bytes) (*RemoteVrf, error) {
+	ac, password, err := s.account(account)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, value, proof, err := s.ks.SignVrfWithPass(ac, password, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteVrf{PublicKey: publicKey, Value: value, Proof: proof}, nil
+}
+
+// SignTx signs the EIP155 hash of the transaction.
+func (s *SignerService) SignTx(account common.Address, txType hexutil.Uint64, data hexutil.Bytes, chainID *hexutil.Big) (hexutil.Bytes, error) {
+	ac, password, err := s.account(account)
+	if err != nil {
+		return nil, err
+	}
+	var tx types.SelfTransaction
+	switch byte(txType) {
+	case types.NormalTxIndex:
+		tx = new(types.Transaction)
+	case types.BroadCastTxIndex:
+		tx = new(types.TransactionBroad)
+	default:
+		return nil, ErrUnknownTxType
+	}
+	if err := rlp.DecodeBytes(data, tx); err != nil {
+		return nil, err
+	}
+	hash := types.NewEIP155Signer((*big.Int)(chainID)).Hash(tx)
+	return s.ks.SignHashValidateWithPass(ac, password, hash[:], true)
+}
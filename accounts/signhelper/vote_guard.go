@@ -0,0 +1,313 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+const (
+	// voteGuardKeepBlocks is the number of heights below the highest vote the
+	// records are kept for, older votes are refused.
+	voteGuardKeepBlocks = 1000
+	// voteGuardPruneInterval is the number of heights between two prunes.
+	voteGuardPruneInterval = 100
+
+	// VoteExportVersion is the version of the export format.
+	VoteExportVersion = 1
+)
+
+var (
+	ErrDoubleSign  = errors.New("another hash was signed for the vote")
+	ErrStaleVote   = errors.New("vote height is below the kept records")
+	ErrVoteVersion = errors.New("unsupported vote export version")
+
+	voteKeyPrefix = []byte("v")
+)
+
+// voteKeyLength is the length of a record key: prefix, account, kind, number,
+// turn, reelect turn and target.
+const voteKeyLength = 1 + common.AddressLength + 1 + 8 + 4 + 4 + common.AddressLength
+
+// VoteRecord is a signed vote, the unit of the export format. Subject is the
+// hash the vote was signed for.
+type VoteRecord struct {
+	Account common.Address `json:"account"`
+	VoteInfo
+}
+
+// VoteExport is the export format of the signed votes, used to move a
+// validator to another machine without losing its slashing protection.
+type VoteExport struct {
+	Version uint32       `json:"version"`
+	Records []VoteRecord `json:"records"`
+}
+
+type voteKey struct {
+	account     common.Address
+	kind        byte
+	number      uint64
+	turn        uint32
+	reelectTurn uint32
+	target      common.Address
+}
+
+func newVoteKey(account common.Address, vote VoteInfo) voteKey {
+	return voteKey{account, vote.Kind, vote.Number, vote.Turn, vote.ReelectTurn, vote.Target}
+}
+
+func (key voteKey) bytes() []byte {
+	buf := make([]byte, 0, voteKeyLength)
+	buf = append(buf, voteKeyPrefix...)
+	buf = append(buf, key.account.Bytes()...)
+	buf = append(buf, key.kind)
+	buf = append(buf, make([]byte, 16)...)
+	binary.BigEndian.PutUint64(buf[len(buf)-16:], key.number)
+	binary.BigEndian.PutUint32(buf[len(buf)-8:], key.turn)
+	binary.BigEndian.PutUint32(buf[len(buf)-4:], key.reelectTurn)
+	return append(buf, key.target.Bytes()...)
+}
+
+func decodeVoteKey(data []byte) (voteKey, error) {
+	if len(data) != voteKeyLength {
+		return voteKey{}, fmt.Errorf("invalid vote key length %d", len(data))
+	}
+	data = data[len(voteKeyPrefix):]
+	key := voteKey{account: common.BytesToAddress(data[:common.AddressLength])}
+	data = data[common.AddressLength:]
+	key.kind = data[0]
+	key.number = binary.BigEndian.Uint64(data[1:9])
+	key.turn = binary.BigEndian.Uint32(data[9:13])
+	key.reelectTurn = binary.BigEndian.Uint32(data[13:17])
+	key.target = common.BytesToAddress(data[17:])
+	return key, nil
+}
+
+func (key voteKey) record(subject common.Hash) VoteRecord {
+	return VoteRecord{
+		Account: key.account,
+		VoteInfo: VoteInfo{
+			Kind:        key.kind,
+			Number:      key.number,
+			Turn:        key.turn,
+			ReelectTurn: key.reelectTurn,
+			Target:      key.target,
+			Subject:     subject,
+		},
+	}
+}
+
+// VoteGuard is the slashing protection of the consensus votes. It records the
+// subject signed for each vote, by account, kind, height, turns and target, and
+// refuses to sign another subject for it. With a path the records are kept in
+// a leveldb and survive restarts.
+type VoteGuard struct {
+	mu      sync.Mutex
+	db      *mandb.LDBDatabase
+	votes   map[voteKey]common.Hash
+	highest map[common.Address]uint64
+	pruned  map[common.Address]uint64
+}
+
+// NewVoteGuard opens the guard keeping its records in the leveldb at path, in
+// memory only if path is empty.
+func NewVoteGuard(path string) (*VoteGuard, error) {
+	g := &VoteGuard{
+		votes:   make(map[voteKey]common.Hash),
+		highest: make(map[common.Address]uint64),
+		pruned:  make(map[common.Address]uint64),
+	}
+	if path == "" {
+		return g, nil
+	}
+	db, err := mandb.NewLDBDatabase(path, 16, 16, 2)
+	if err != nil {
+		return nil, err
+	}
+	it := db.NewIteratorWithPrefix(voteKeyPrefix)
+	defer it.Release()
+	for it.Next() {
+		key, err := decodeVoteKey(it.Key())
+		if err != nil || len(it.Value()) != common.HashLength {
+			db.Close()
+			return nil, fmt.Errorf("vote records %s: corrupted record %x", path, it.Key())
+		}
+		g.add(key, common.BytesToHash(it.Value()))
+	}
+	if err := it.Error(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	g.db = db
+	return g, nil
+}
+
+// Close closes the database of the guard.
+func (g *VoteGuard) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.db != nil {
+		g.db.Close()
+		g.db = nil
+	}
+}
+
+func (g *VoteGuard) add(key voteKey, subject common.Hash) {
+	g.votes[key] = subject
+	if key.number > g.highest[key.account] {
+		g.highest[key.account] = key.number
+	}
+}
+
+func (g *VoteGuard) stale(account common.Address, number uint64) bool {
+	highest := g.highest[account]
+	return highest > voteGuardKeepBlocks && number < highest-voteGuardKeepBlocks
+}
+
+// Check records the signing of the hash for the vote, it fails if another
+// subject was signed for the vote already. The subject of the vote is its
+// Subject if set, the hash otherwise.
+func (g *VoteGuard) Check(account common.Address, vote VoteInfo, hash common.Hash) error {
+	subject := vote.Subject
+	if (subject == common.Hash{}) {
+		subject = hash
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := newVoteKey(account, vote)
+	if signed, ok := g.votes[key]; ok {
+		if signed != subject {
+			return ErrDoubleSign
+		}
+		return nil
+	}
+	if g.stale(account, vote.Number) {
+		return ErrStaleVote
+	}
+	if g.db != nil {
+		// the record must be on disk before the signature leaves the process
+		if err := g.db.LDB().Put(key.bytes(), subject.Bytes(), &opt.WriteOptions{Sync: true}); err != nil {
+			return err
+		}
+	}
+	g.add(key, subject)
+	g.prune(account)
+	return nil
+}
+
+func (g *VoteGuard) prune(account common.Address) {
+	highest := g.highest[account]
+	if highest <= voteGuardKeepBlocks || highest < g.pruned[account]+voteGuardPruneInterval {
+		return
+	}
+	g.pruned[account] = highest
+
+	batch := new(leveldb.Batch)
+	for key := range g.votes {
+		if key.account == account && key.number < highest-voteGuardKeepBlocks {
+			delete(g.votes, key)
+			batch.Delete(key.bytes())
+		}
+	}
+	if g.db != nil && batch.Len() > 0 {
+		if err := g.db.LDB().Write(batch, nil); err != nil {
+			log.Warn(ModeLog, "清理投票记录失败", err)
+		}
+	}
+}
+
+// Records returns the kept records, sorted by account, height and turns.
+func (g *VoteGuard) Records() []VoteRecord {
+	g.mu.Lock()
+	records := make([]VoteRecord, 0, len(g.votes))
+	for key, subject := range g.votes {
+		records = append(records, key.record(subject))
+	}
+	g.mu.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		switch {
+		case a.Account != b.Account:
+			return a.Account.Hex() < b.Account.Hex()
+		case a.Number != b.Number:
+			return a.Number < b.Number
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		case a.Turn != b.Turn:
+			return a.Turn < b.Turn
+		case a.ReelectTurn != b.ReelectTurn:
+			return a.ReelectTurn < b.ReelectTurn
+		}
+		return a.Target.Hex() < b.Target.Hex()
+	})
+	return records
+}
+
+// Export writes the kept records as json in the export format.
+func (g *VoteGuard) Export(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&VoteExport{Version: VoteExportVersion, Records: g.Records()})
+}
+
+// Import merges the records of an export into the guard. A record conflicting
+// with a kept one isn't imported, both subjects stay refused for its vote as the
+// kept one is. It returns the numbers of imported and conflicting records.
+func (g *VoteGuard) Import(r io.Reader) (int, int, error) {
+	var export VoteExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return 0, 0, err
+	}
+	if export.Version != VoteExportVersion {
+		return 0, 0, ErrVoteVersion
+	}
+	for _, record := range export.Records {
+		if (record.Subject == common.Hash{}) {
+			return 0, 0, fmt.Errorf("vote of account %s at height %d has no subject", record.Account.Hex(), record.Number)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	imported, conflicts := 0, 0
+	batch := new(leveldb.Batch)
+	for _, record := range export.Records {
+		key := newVoteKey(record.Account, record.VoteInfo)
+		if signed, ok := g.votes[key]; ok {
+			if signed != record.Subject {
+				log.Warn(ModeLog, "导入投票记录冲突", record.Account.Hex(), "kind", record.Kind, "number", record.Number, "turn", record.Turn, "reelect turn", record.ReelectTurn)
+				conflicts++
+			}
+			continue
+		}
+		g.add(key, record.Subject)
+		batch.Put(key.bytes(), record.Subject.Bytes())
+		imported++
+	}
+	if g.db != nil && batch.Len() > 0 {
+		if err := g.db.LDB().Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+			return 0, 0, err
+		}
+	}
+	for account := range g.highest {
+		g.prune(account)
+	}
+	return imported, conflicts, nil
+}
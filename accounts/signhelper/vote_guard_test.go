@@ -0,0 +1,94 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+func TestVoteGuardKinds(t *testing.T) {
+	guard, err := NewVoteGuard("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := common.BytesToAddress([]byte{0x01})
+	hash, conflict := common.BytesToHash([]byte{0x11}), common.BytesToHash([]byte{0x22})
+
+	block := VoteInfo{Kind: VoteBlock, Number: 10, Turn: 1}
+	if err := guard.Check(account, block, hash); err != nil {
+		t.Fatalf("failed to record block vote: %v", err)
+	}
+	// the kinds, reelect turns and targets are different votes
+	for _, vote := range []VoteInfo{
+		{Kind: VoteReelect, Number: 10, Turn: 1},
+		{Kind: VoteBlock, Number: 10, Turn: 1, ReelectTurn: 1},
+		{Kind: VoteOnline, Number: 10, Turn: 1, Target: common.BytesToAddress([]byte{0x02})},
+		{Kind: VoteOnline, Number: 10, Turn: 1, Target: common.BytesToAddress([]byte{0x03})},
+	} {
+		if err := guard.Check(account, vote, conflict); err != nil {
+			t.Fatalf("failed to record vote %+v: %v", vote, err)
+		}
+	}
+	if err := guard.Check(account, block, conflict); err != ErrDoubleSign {
+		t.Fatalf("conflicting block vote accepted: %v", err)
+	}
+
+	// the subject decides when set, whatever the signed hash
+	reelect := VoteInfo{Kind: VoteReelect, Number: 11, Subject: hash}
+	if err := guard.Check(account, reelect, hash); err != nil {
+		t.Fatalf("failed to record reelect vote: %v", err)
+	}
+	if err := guard.Check(account, reelect, conflict); err != nil {
+		t.Fatalf("resent reelect vote refused: %v", err)
+	}
+	reelect.Subject = conflict
+	if err := guard.Check(account, reelect, hash); err != ErrDoubleSign {
+		t.Fatalf("conflicting reelect vote accepted: %v", err)
+	}
+}
+
+func TestVoteGuardExportImport(t *testing.T) {
+	source, _ := NewVoteGuard("")
+	account := common.BytesToAddress([]byte{0x01})
+	hash, conflict := common.BytesToHash([]byte{0x11}), common.BytesToHash([]byte{0x22})
+	votes := []VoteInfo{
+		{Kind: VoteBlock, Number: 10, Turn: 1},
+		{Kind: VoteReelect, Number: 10, Turn: 1, ReelectTurn: 1},
+		{Kind: VoteOnline, Number: 12, Turn: 2, Target: common.BytesToAddress([]byte{0x02})},
+	}
+	for _, vote := range votes {
+		if err := source.Check(account, vote, hash); err != nil {
+			t.Fatalf("failed to record vote %+v: %v", vote, err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := source.Export(&buf); err != nil {
+		t.Fatalf("failed to export votes: %v", err)
+	}
+
+	dest, _ := NewVoteGuard("")
+	if err := dest.Check(account, votes[0], conflict); err != nil {
+		t.Fatalf("failed to record vote: %v", err)
+	}
+	imported, conflicts, err := dest.Import(bytes.NewReader(buf.Bytes()))
+	if err != nil || imported != 2 || conflicts != 1 {
+		t.Fatalf("import mismatch: have %d imported, %d conflicts, %v", imported, conflicts, err)
+	}
+	if err := dest.Check(account, votes[2], conflict); err != ErrDoubleSign {
+		t.Fatalf("conflicting imported vote accepted: %v", err)
+	}
+	if err := dest.Check(account, votes[0], conflict); err != nil {
+		t.Fatalf("local vote overwritten by import: %v", err)
+	}
+	if records := dest.Records(); len(records) != 3 || records[0].Subject != conflict {
+		t.Fatalf("records mismatch: %+v", records)
+	}
+
+	if _, _, err := dest.Import(bytes.NewReader([]byte(`{"version":2,"records":[]}`))); err != ErrVoteVersion {
+		t.Fatalf("unknown version imported: %v", err)
+	}
+}
@@ -1,121 +1,72 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package aidigger implements the AI digging backends used by the amhash engine.
+// The gold backend needs cgo and libgold_digger, build with the "nogolddigger"
+// tag to leave it out and only keep the pure-Go reference backend.
 package aidigger
 
-/*
-#cgo CFLAGS: -I./libdigger/src/
-#cgo LDFLAGS: -L./libdigger/bin/static/ -lgold_digger -lm -pthread -lX11 -lssl -lcrypto -lstdc++ -ljpeg -lgomp
-#include <stdlib.h>
-#include "./libdigger/src/digger_interface.h"
-*/
-import "C"
 import (
 	"errors"
 	"fmt"
-	"github.com/MatrixAINetwork/go-matrix/log"
-	"io"
-	"os"
-	"path/filepath"
-	"runtime"
-	"time"
-	"unsafe"
+	"sync"
 )
 
-var initDataPtr unsafe.Pointer
+const (
+	// DiggerGold is the cgo backend based on libgold_digger (YOLOv3).
+	DiggerGold = "gold"
+	// DiggerReference is the deterministic pure-Go backend, intended for tests and
+	// nodes built without libgold_digger.
+	DiggerReference = "reference"
+
+	// ResultLength is the byte length of an AI digging result.
+	ResultLength = 32
+)
 
 var (
-	errNilTask     = errors.New("task is nil")
-	errNoResultYet = errors.New("no ai digging result yet")
+	errNilTask          = errors.New("task is nil")
+	errNoResultYet      = errors.New("no ai digging result yet")
+	errUnknownDigger    = errors.New("unknown ai digger")
+	errEmptyPictureList = errors.New("picture list is empty")
 )
 
-func Init(cfgPath string, pictures []string) {
-	if initDataPtr == nil {
-		charWeightsPath := C.CString(filepath.Join(cfgPath, "yolov3.weights"))
-		defer C.free(unsafe.Pointer(charWeightsPath))
-		charCfgPath := C.CString(filepath.Join(cfgPath, "yolov3.cfg"))
-		defer C.free(unsafe.Pointer(charCfgPath))
-		charNamesPath := C.CString(filepath.Join(cfgPath, "coco.names"))
-		defer C.free(unsafe.Pointer(charNamesPath))
+// AIDigger is the backend computing the AI digging result of a block. For the same
+// seed and picture list every implementation must always deliver the same
+// ResultLength bytes result on resultCh, or report a failure on errCh. Digging must
+// return without delivering anything once stopCh is closed.
+type AIDigger interface {
+	// Init loads the model data found under cfgPath. It must be called once before
+	// Digging.
+	Init(cfgPath string, pictures []string) error
 
-		cWeightsPath := (*C.char)(unsafe.Pointer(charWeightsPath))
-		cCfgPath := (*C.char)(unsafe.Pointer(charCfgPath))
-		cNamesPath := (*C.char)(unsafe.Pointer(charNamesPath))
+	// Digging runs one AI digging task, it blocks until the task is finished or stopped.
+	Digging(seed int64, pictures []string, stopCh chan struct{}, resultCh chan []byte, errCh chan error)
+}
 
-		cPictures := make([]*C.char, 0)
-		for i := range pictures {
-			char := C.CString(pictures[i])
-			defer C.free(unsafe.Pointer(char))
-			strPtr := (*C.char)(unsafe.Pointer(char))
-			cPictures = append(cPictures, strPtr)
-		}
+var (
+	diggerLock sync.RWMutex
+	diggerMap  = make(map[string]func() AIDigger)
+)
 
-		log.Info("ai digger", "调用C库接口开始", "init_yolov3_data")
-		initDataPtr = C.init_yolov3_data(cWeightsPath, cCfgPath, cNamesPath, (**C.char)(unsafe.Pointer(&cPictures[0])))
-		log.Info("ai digger", "调用C库接口结束", "init_yolov3_data")
-		if initDataPtr == nil {
-			fatalf("init ai mining lib err!")
-		}
-	}
+// RegDigger registers an AI digger backend under the given name.
+func RegDigger(name string, value func() AIDigger) {
+	diggerLock.Lock()
+	defer diggerLock.Unlock()
+	diggerMap[name] = value
 }
 
-func AIDigging(seed int64, pictures []string, stopCh chan struct{}, resultCh chan []byte, errCh chan error) {
-	cSeed := (C.long)(seed)
-	cPictures := make([]*C.char, 0)
-	for i := range pictures {
-		char := C.CString(pictures[i])
-		defer C.free(unsafe.Pointer(char))
-		strPtr := (*C.char)(unsafe.Pointer(char))
-		cPictures = append(cPictures, strPtr)
-	}
-	cThreadCount := (C.int)(0)
-	log.Info("ai digger", "调用C库接口开始", "creat_thread")
-	cThreadId, err := C.creat_thread(cSeed, (**C.char)(unsafe.Pointer(&cPictures[0])), initDataPtr, cThreadCount)
-	//log.Info("ai digger", "调用C库接口结束", "creat_thread", "id", cThreadId, "seed", seed)
-	if err != nil {
-		log.Error("ai digger", "start ai digging err", err)
-		errCh <- fmt.Errorf("start ai digging err: %v", err)
-		return
-	}
-	beginTime := time.Now()
-	log.Info("ai digger", "创建任务成功", cThreadId, "seed", seed, "time", beginTime)
-	result := make([]byte, 32)
-	for {
-		select {
-		case <-stopCh:
-			log.Info("ai digger", "stop ai digging", cThreadId)
-			log.Info("ai digger", "调用C库接口开始", "cancel_thread", "id", cThreadId)
-			_, err := C.cancel_thread(cThreadId)
-			if err != nil {
-				log.Error("ai digger", "stop ai digging err", err)
-				errCh <- fmt.Errorf("stop ai digging err: %v", err)
-				return
-			}
-			return
-		default:
-			log.Info("ai digger", "调用C库接口开始", "get_result", "id", cThreadId)
-			rst := C.get_result(cThreadId, (*C.uchar)(unsafe.Pointer(&result[0])))
-			if rst == 1 {
-				timePass := (time.Now().UnixNano() - beginTime.UnixNano()) / 1000000
-				log.Info("ai digger", "get ai digging result", cThreadId, "result", result, "time pass(ms)", timePass)
-				resultCh <- result
-				return
-			}
-			time.Sleep(100 * time.Millisecond)
-		}
+// NewAIDigger creates the AI digger backend registered under the given name. An
+// empty name selects DefaultDigger.
+func NewAIDigger(name string) (AIDigger, error) {
+	if name == "" {
+		name = DefaultDigger
 	}
-}
-
-func fatalf(format string, args ...interface{}) {
-	w := io.MultiWriter(os.Stdout, os.Stderr)
-	if runtime.GOOS == "windows" {
-		// The SameFile check below doesn't work on Windows.
-		// stdout is unlikely to get redirected though, so just print there.
-		w = os.Stdout
-	} else {
-		outf, _ := os.Stdout.Stat()
-		errf, _ := os.Stderr.Stat()
-		if outf != nil && errf != nil && os.SameFile(outf, errf) {
-			w = os.Stderr
-		}
+	diggerLock.RLock()
+	creator, exist := diggerMap[name]
+	diggerLock.RUnlock()
+	if !exist {
+		return nil, fmt.Errorf("%v: %s", errUnknownDigger, name)
 	}
-	fmt.Fprintf(w, "Fatal: "+format+"\n", args...)
-	os.Exit(1)
+	return creator(), nil
 }
@@ -0,0 +1,7 @@
+// +build !cgo nogolddigger
+
+package aidigger
+
+// DefaultDigger is the backend selected by an empty name, the gold backend is
+// not built in.
+const DefaultDigger = DiggerReference
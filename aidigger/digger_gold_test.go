@@ -1,3 +1,5 @@
+// +build cgo,!nogolddigger
+
 package aidigger
 
 import (
@@ -44,8 +46,11 @@ func digging(t *testing.T, seed int64, pictures []string, stopCh chan struct{},
 	log.Info("挖矿线程开始")
 	defer log.Info("挖矿线程结束")
 
-	err := AIDigging(seed, pictures, stopCh, resultCh)
-	if err != nil {
-		t.Fatalf("digging err: %v", err)
+	errCh := make(chan error, 1)
+	newGoldDigger().Digging(seed, pictures, stopCh, resultCh, errCh)
+	select {
+	case err := <-errCh:
+		t.Errorf("digging err: %v", err)
+	default:
 	}
 }
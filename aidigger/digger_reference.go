@@ -0,0 +1,76 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package aidigger
+
+import (
+	"encoding/binary"
+	"path/filepath"
+
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+)
+
+// referenceRounds is the number of hash rounds run by the reference digger, the
+// stop channel is checked between two rounds.
+const referenceRounds = 1024
+
+func init() {
+	RegDigger(DiggerReference, newReferenceDigger)
+}
+
+// referenceDigger is a deterministic pure-Go AI digger. The result only depends
+// on the seed and on the file names of the pictures, so nodes with different
+// picture store paths compute the same result. It does not perform any image
+// recognition and must not be mixed with the gold digger on the same network.
+type referenceDigger struct{}
+
+func newReferenceDigger() AIDigger {
+	return &referenceDigger{}
+}
+
+func (self *referenceDigger) Init(cfgPath string, pictures []string) error {
+	if len(pictures) == 0 {
+		return errEmptyPictureList
+	}
+	return nil
+}
+
+func (self *referenceDigger) Digging(seed int64, pictures []string, stopCh chan struct{}, resultCh chan []byte, errCh chan error) {
+	if len(pictures) == 0 {
+		errCh <- errEmptyPictureList
+		return
+	}
+	result := ReferenceResult(seed, pictures, stopCh)
+	if result == nil {
+		log.Info("ai digger", "stop reference ai digging, seed", seed)
+		return
+	}
+	select {
+	case <-stopCh:
+		log.Info("ai digger", "stop reference ai digging, seed", seed)
+	case resultCh <- result:
+	}
+}
+
+// ReferenceResult computes the reference AI digging result of the seed and pictures.
+// It returns nil if stopCh is closed before the result is computed, stopCh may be nil.
+func ReferenceResult(seed int64, pictures []string, stopCh chan struct{}) []byte {
+	seedData := make([]byte, 8)
+	binary.BigEndian.PutUint64(seedData, uint64(seed))
+	data := [][]byte{seedData}
+	for _, picture := range pictures {
+		data = append(data, []byte(filepath.Base(picture)))
+	}
+	result := crypto.Keccak256(data...)
+	for i := 0; i < referenceRounds; i++ {
+		select {
+		case <-stopCh:
+			return nil
+		default:
+			result = crypto.Keccak256(result, seedData)
+		}
+	}
+	return result[:ResultLength]
+}
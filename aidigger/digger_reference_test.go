@@ -0,0 +1,75 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package aidigger
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func referencePictures(dir string) []string {
+	pictures := make([]string, 0)
+	for i := 0; i < 16; i++ {
+		pictures = append(pictures, filepath.Join(dir, "test_"+strconv.Itoa(i)+".jpg"))
+	}
+	return pictures
+}
+
+func TestReferenceDigger(t *testing.T) {
+	digger, err := NewAIDigger(DiggerReference)
+	if err != nil {
+		t.Fatalf("new reference digger err: %v", err)
+	}
+	if err := digger.Init("", referencePictures("picstore")); err != nil {
+		t.Fatalf("init reference digger err: %v", err)
+	}
+
+	dig := func(seed int64, dir string) []byte {
+		resultCh := make(chan []byte, 1)
+		errCh := make(chan error, 1)
+		digger.Digging(seed, referencePictures(dir), make(chan struct{}), resultCh, errCh)
+		select {
+		case result := <-resultCh:
+			return result
+		case err := <-errCh:
+			t.Fatalf("digging err: %v", err)
+		}
+		return nil
+	}
+
+	result := dig(54321, "/root/picstore")
+	if len(result) != ResultLength {
+		t.Fatalf("result length mismatch: have %d, want %d", len(result), ResultLength)
+	}
+	if other := dig(54321, "/data/picstore"); !bytes.Equal(result, other) {
+		t.Errorf("result depends on the picture store path: %x != %x", result, other)
+	}
+	if other := dig(12345, "/root/picstore"); bytes.Equal(result, other) {
+		t.Errorf("different seeds give the same result: %x", result)
+	}
+}
+
+func TestReferenceDiggerStop(t *testing.T) {
+	stopCh := make(chan struct{})
+	close(stopCh)
+	resultCh := make(chan []byte, 1)
+	errCh := make(chan error, 1)
+	newReferenceDigger().Digging(54321, referencePictures("picstore"), stopCh, resultCh, errCh)
+	select {
+	case result := <-resultCh:
+		t.Errorf("stopped digger delivered result %x", result)
+	case err := <-errCh:
+		t.Errorf("stopped digger delivered err %v", err)
+	default:
+	}
+}
+
+func TestUnknownDigger(t *testing.T) {
+	if _, err := NewAIDigger("unknown"); err == nil {
+		t.Errorf("expected error for unknown digger")
+	}
+}
@@ -4,6 +4,8 @@
 package baseinterface
 
 import (
+	"sort"
+
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/election/support"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -37,3 +39,13 @@ type ElectionInterface interface {
 	ToPoUpdate(support.AllNative, *mc.TopologyGraph) []mc.Alternative
 	//	PrimarylistUpdate([]mc.TopologyNodeInfo, []mc.TopologyNodeInfo, []mc.TopologyNodeInfo, mc.TopologyNodeInfo, int) ([]mc.TopologyNodeInfo, []mc.TopologyNodeInfo, []mc.TopologyNodeInfo)
 }
+
+// ElectPlugNames returns the names of the registered election plugs, sorted.
+func ElectPlugNames() []string {
+	names := make([]string, 0, len(electionPlugs))
+	for name := range electionPlugs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -8,6 +8,7 @@ import (
 
 	"time"
 
+	"github.com/MatrixAINetwork/go-matrix/blkprofile"
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
@@ -58,6 +59,7 @@ func (p *Process) ProcessRecoveryMsg(msg *mc.RecoveryStateMsg) {
 }
 
 func (p *Process) sendFullBlockReq(hash common.Hash, number uint64, target common.Address) {
+	blkprofile.Begin(number, blkprofile.StageFullBlockFetch, target.Hex())
 	if p.FullBlockReqCache.IsExistMsg(hash) {
 		data, err := p.FullBlockReqCache.ReUseMsg(hash)
 		if err != nil {
@@ -133,12 +135,13 @@ func (p *Process) ProcessFullBlockRsp(rsp *mc.HD_FullBlockRspMsg) {
 		blkType = blkmanage.BroadcastBlk
 	}
 	//运行交易
-	stateDB, finalTxs, receipts, _, err := p.pm.manblk.VerifyTxsAndState(blkType, string(rsp.Header.Version), rsp.Header, rsp.Txs)
+	stateDB, finalTxs, receipts, extraData, err := p.pm.manblk.VerifyTxsAndState(blkType, string(rsp.Header.Version), rsp.Header, rsp.Txs)
 	if err != nil {
 		log.Error(p.logExtraInfo(), "处理完整区块响应", "执行交易错误", "err", err, "高度", p.number)
 		return
 	}
 
+	blkprofile.End(p.number, blkprofile.StageFullBlockFetch, "")
 	p.blockCache.SaveReadyBlock(&mc.BlockLocalVerifyOK{
 		Header:      rsp.Header,
 		BlockHash:   rsp.Header.HashNoSignsAndNonce(),
@@ -146,6 +149,7 @@ func (p *Process) ProcessFullBlockRsp(rsp *mc.HD_FullBlockRspMsg) {
 		FinalTxs:    finalTxs,
 		Receipts:    receipts,
 		State:       stateDB,
+		Rewards:     blkmanage.RewardLedger(extraData),
 	})
 
 	readyMsg := &mc.NewBlockReadyMsg{
@@ -247,6 +251,7 @@ func (p *Process) dealMinerResultVerifyCommon(leader common.Address) {
 		}
 		blockData.block.Header = p.copyHeader(blockData.block.Header, satisfyResult)
 		blockData.state = blockStateReady
+		blkprofile.End(p.number, blkprofile.StageMiningWait, satisfyResult.Coinbase.Hex())
 	}
 	p.stopMinerPikerTimer()
 	p.closeConsensusReqSender()
@@ -393,11 +398,14 @@ func (p *Process) insertAndBcBlock(isSelf bool, leader common.Address, header *t
 	state := blockData.block.State
 	block := types.NewBlockWithTxs(insertHeader, types.MakeCurencyBlock(txs, receipts, nil))
 
-	stat, err := p.blockChain().WriteBlockWithState(block, state)
+	blkprofile.Begin(p.number, blkprofile.StageInsert, "")
+	stat, err := p.blockChain().WriteBlockWithState(block, state, blockData.block.Rewards)
 	if err != nil {
+		blkprofile.End(p.number, blkprofile.StageInsert, err.Error())
 		log.ERROR(p.logExtraInfo(), "插入区块失败", err)
 		return common.Hash{}, err
 	}
+	blkprofile.Finish(p.number)
 	mc.PublishEvent(mc.BlockInserted, &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: block.Hash(), Number: block.NumberU64()}, InsertTime: uint64(time.Now().Unix()), CanonState: stat == core.CanonStatTy})
 	// Broadcast the block and announce chain insertion event
 	hash := block.Hash()
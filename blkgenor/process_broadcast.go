@@ -4,6 +4,7 @@
 package blkgenor
 
 import (
+	"github.com/MatrixAINetwork/go-matrix/blkprofile"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -57,11 +58,12 @@ func (p *Process) dealMinerResultVerifyBroadcast() {
 			continue
 		}
 
-		state, retTxs, receipts, _, err := p.pm.manblk.VerifyTxsAndState(blkmanage.BroadcastBlk, string(result.Header.Version), result.Header, result.Txs)
+		state, retTxs, receipts, extraData, err := p.pm.manblk.VerifyTxsAndState(blkmanage.BroadcastBlk, string(result.Header.Version), result.Header, result.Txs)
 		if nil != err {
 			log.WARN(p.logExtraInfo(), "广播挖矿结果处理", "状态异常", "err", err)
 			continue
 		}
+		blkprofile.End(p.number, blkprofile.StageMiningWait, result.Header.Leader.Hex())
 		p.blockCache.SaveReadyBlock(&mc.BlockLocalVerifyOK{
 			Header:      result.Header,
 			BlockHash:   result.Header.HashNoSignsAndNonce(),
@@ -69,6 +71,7 @@ func (p *Process) dealMinerResultVerifyBroadcast() {
 			FinalTxs:    retTxs,
 			Receipts:    receipts,
 			State:       state,
+			Rewards:     blkmanage.RewardLedger(extraData),
 		})
 
 		readyMsg := &mc.NewBlockReadyMsg{
@@ -4,6 +4,7 @@
 package blkgenor
 
 import (
+	"github.com/MatrixAINetwork/go-matrix/blkprofile"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 
 	"github.com/MatrixAINetwork/go-matrix/ca"
@@ -13,6 +14,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params/manparams"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/txpoolCache"
 	"github.com/pkg/errors"
 )
@@ -20,6 +22,8 @@ import (
 func (p *Process) processBcHeaderGen() error {
 	log.INFO(p.logExtraInfo(), "processBCHeaderGen", "start")
 	defer log.INFO(p.logExtraInfo(), "processBCHeaderGen", "end")
+	blkprofile.Begin(p.number, blkprofile.StageHeaderGen, "broadcast")
+	defer blkprofile.End(p.number, blkprofile.StageHeaderGen, "")
 	if p.bcInterval == nil {
 		log.ERROR(p.logExtraInfo(), "区块生成阶段", "广播周期信息为空")
 		return errors.New("广播周期信息为空")
@@ -36,7 +40,9 @@ func (p *Process) processBcHeaderGen() error {
 		return err
 	}
 
+	blkprofile.Begin(p.number, blkprofile.StageTxPack, "")
 	_, stateDB, receipts, _, finalTxs, _, err := p.pm.manblk.ProcessState(blkmanage.BroadcastBlk, version, originHeader, nil)
+	blkprofile.End(p.number, blkprofile.StageTxPack, "")
 	if err != nil {
 		log.Error(p.logExtraInfo(), "运行交易和状态树失败", err)
 		return err
@@ -60,6 +66,8 @@ func (p *Process) processBcHeaderGen() error {
 func (p *Process) processHeaderGen() error {
 	log.INFO(p.logExtraInfo(), "processHeaderGen", "start")
 	defer log.INFO(p.logExtraInfo(), "processHeaderGen", "end")
+	blkprofile.Begin(p.number, blkprofile.StageHeaderGen, "")
+	defer blkprofile.End(p.number, blkprofile.StageHeaderGen, "")
 	if p.bcInterval == nil {
 		log.ERROR(p.logExtraInfo(), "区块生成阶段", "广播周期信息为空")
 		return errors.New("广播周期信息为空")
@@ -81,7 +89,9 @@ func (p *Process) processHeaderGen() error {
 		return errors.New("反射在线状态失败")
 	}
 
-	txsCode, stateDB, receipts, originalTxs, finalTxs, _, err := p.pm.manblk.ProcessState(blkmanage.CommonBlk, version, originHeader, nil)
+	blkprofile.Begin(p.number, blkprofile.StageTxPack, "")
+	txsCode, stateDB, receipts, originalTxs, finalTxs, rewards, err := p.pm.manblk.ProcessState(blkmanage.CommonBlk, version, originHeader, nil)
+	blkprofile.End(p.number, blkprofile.StageTxPack, "")
 	if err != nil {
 		log.Error(p.logExtraInfo(), "运行交易和状态树失败", err)
 		return err
@@ -92,12 +102,12 @@ func (p *Process) processHeaderGen() error {
 		log.Error(p.logExtraInfo(), "Finalize失败", err)
 		return err
 	}
-	p.sendHeaderVerifyReq(block.Header(), txsCode, onlineConsensusResults, originalTxs, finalTxs, receipts, stateDB)
+	p.sendHeaderVerifyReq(block.Header(), txsCode, onlineConsensusResults, originalTxs, finalTxs, receipts, stateDB, blkmanage.RewardLedger(rewards))
 	return nil
 }
 
 func (p *Process) sendHeaderVerifyReq(header *types.Header, txsCode []*common.RetCallTxN, onlineConsensusResults []*mc.HD_OnlineConsensusVoteResultMsg, originalTxs []types.CoinSelfTransaction,
-	finalTxs []types.CoinSelfTransaction, receipts []types.CoinReceipts, stateDB *state.StateDBManage) {
+	finalTxs []types.CoinSelfTransaction, receipts []types.CoinReceipts, stateDB *state.StateDBManage, rewards []*ledger.Entry) {
 	p2pBlock := &mc.HD_BlkConsensusReqMsg{
 		Header:                 header,
 		TxsCode:                txsCode,
@@ -106,7 +116,7 @@ func (p *Process) sendHeaderVerifyReq(header *types.Header, txsCode []*common.Re
 		From:                   ca.GetSignAddress(),
 	}
 	//send to local block verify module
-	localBlock := &mc.LocalBlockVerifyConsensusReq{BlkVerifyConsensusReq: p2pBlock, OriginalTxs: originalTxs, FinalTxs: finalTxs, Receipts: receipts, State: stateDB}
+	localBlock := &mc.LocalBlockVerifyConsensusReq{BlkVerifyConsensusReq: p2pBlock, OriginalTxs: originalTxs, FinalTxs: finalTxs, Receipts: receipts, State: stateDB, Rewards: rewards}
 	if len(originalTxs) > 0 {
 		txpoolCache.MakeStruck(types.GetTX(originalTxs), header.HashNoSignsAndNonce(), p.number)
 	}
@@ -119,6 +129,7 @@ func (p *Process) sendBroadcastMiningReq(header *types.Header, finalTxs []types.
 	sendMsg := &mc.BlockData{Header: header, Txs: finalTxs}
 	log.INFO(p.logExtraInfo(), "广播挖矿请求(本地), number", sendMsg.Header.Number, "root", header.Roots, "tx数量", len(types.GetTX(finalTxs)))
 	mc.PublishEvent(mc.HD_BroadcastMiningReq, &mc.BlockGenor_BroadcastMiningReqMsg{sendMsg})
+	blkprofile.Begin(p.number, blkprofile.StageMiningWait, "")
 }
 
 func (p *Process) setSignatures(header *types.Header) error {
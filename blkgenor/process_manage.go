@@ -8,6 +8,7 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/blkprofile"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -67,6 +68,8 @@ func (pm *ProcessManage) SetCurNumber(number uint64, superSeq uint64) {
 		}
 		pm.curChainState.Reset(superSeq, number)
 		pm.fixProcessMap()
+		// 前一高度的区块已上链，结束其追踪
+		blkprofile.Finish(number - 1)
 	}
 }
 
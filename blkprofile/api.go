@@ -0,0 +1,50 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package blkprofile
+
+import (
+	"errors"
+)
+
+// maxTraceCount is the max number of traces returned at once.
+const maxTraceCount = maxTraceNumbers
+
+// PublicProfileAPI provides the block production traces.
+type PublicProfileAPI struct {
+	profiler *Profiler
+}
+
+func NewPublicProfileAPI(profiler *Profiler) *PublicProfileAPI {
+	return &PublicProfileAPI{profiler: profiler}
+}
+
+// GetBlockTrace returns the trace of the block height.
+func (api *PublicProfileAPI) GetBlockTrace(number uint64) (*Trace, error) {
+	tr := api.profiler.Trace(number)
+	if tr == nil {
+		return nil, errors.New("trace of the height is not in memory")
+	}
+	return tr, nil
+}
+
+// GetBlockTraces returns the traces of at most count heights from the height
+// from, in ascending order.
+func (api *PublicProfileAPI) GetBlockTraces(from uint64, count uint64) []*Trace {
+	if count == 0 || count > maxTraceCount {
+		count = maxTraceCount
+	}
+	traces := make([]*Trace, 0)
+	for _, number := range api.profiler.Numbers() {
+		if number < from {
+			continue
+		}
+		if uint64(len(traces)) >= count {
+			break
+		}
+		if tr := api.profiler.Trace(number); tr != nil {
+			traces = append(traces, tr)
+		}
+	}
+	return traces
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package blkprofile traces the stages of the block production of every height,
+// from the leader turns to the insert of the block, to find which stage or
+// which validator delays the block times.
+package blkprofile
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+)
+
+// Stages of the block production.
+const (
+	StageLeaderTurn     = "leaderTurn"     // 一个leader轮次，详情为leader及轮次
+	StageHeaderGen      = "headerGen"      // leader生成区块头
+	StageTxPack         = "txPack"         // leader打包并执行交易
+	StageLocalVerify    = "localVerify"    // 验证者验证区块请求
+	StageVoteCollect    = "voteCollect"    // 收集POS投票
+	StageMiningWait     = "miningWait"     // 等待AI/X11挖矿结果
+	StageFullBlockFetch = "fullBlockFetch" // 获取完整区块
+	StageInsert         = "insert"         // 插入区块
+)
+
+const (
+	maxTraceNumbers = 128 // 内存中保留的高度数
+	maxTraceSpans   = 256 // 每个高度保留的阶段数
+)
+
+// Span is a stage of the production of a block. Times are unix milliseconds,
+// the end and duration of a stage still running are 0.
+type Span struct {
+	Stage    string `json:"stage"`
+	Start    uint64 `json:"start"`
+	End      uint64 `json:"end"`
+	Duration uint64 `json:"duration"` // milliseconds
+	Detail   string `json:"detail"`
+
+	start time.Time
+}
+
+// VoterLatency is the first vote of a validator received for a block, its latency
+// counts from the start of the local verify.
+type VoterLatency struct {
+	Voter   common.Address `json:"voter"`
+	Time    uint64         `json:"time"`
+	Latency uint64         `json:"latency"` // milliseconds
+}
+
+// Trace is the profile of the production of a block height.
+type Trace struct {
+	Number uint64          `json:"number"`
+	Start  uint64          `json:"start"`
+	End    uint64          `json:"end"` // time of the insert, 0 while running
+	Spans  []*Span         `json:"spans"`
+	Votes  []*VoterLatency `json:"votes"`
+
+	created time.Time
+	voters  map[common.Address]bool
+}
+
+func msTime(t time.Time) uint64 {
+	return uint64(t.UnixNano() / int64(time.Millisecond))
+}
+
+func (tr *Trace) open(stage string) *Span {
+	for i := len(tr.Spans) - 1; i >= 0; i-- {
+		if span := tr.Spans[i]; span.Stage == stage && span.End == 0 {
+			return span
+		}
+	}
+	return nil
+}
+
+func (tr *Trace) close(span *Span, now time.Time, detail string) {
+	span.End = msTime(now)
+	span.Duration = uint64(now.Sub(span.start) / time.Millisecond)
+	if detail != "" {
+		span.Detail = detail
+	}
+	metrics.GetOrRegisterTimer("blkprofile/stage/"+span.Stage, nil).Update(now.Sub(span.start))
+}
+
+// origin is the time the latencies of the votes count from.
+func (tr *Trace) origin() time.Time {
+	for _, span := range tr.Spans {
+		if span.Stage == StageLocalVerify || span.Stage == StageHeaderGen {
+			return span.start
+		}
+	}
+	return tr.created
+}
+
+func (tr *Trace) copy() *Trace {
+	cpy := &Trace{Number: tr.Number, Start: tr.Start, End: tr.End, Spans: make([]*Span, 0, len(tr.Spans)), Votes: make([]*VoterLatency, 0, len(tr.Votes))}
+	for _, span := range tr.Spans {
+		s := *span
+		cpy.Spans = append(cpy.Spans, &s)
+	}
+	for _, vote := range tr.Votes {
+		v := *vote
+		cpy.Votes = append(cpy.Votes, &v)
+	}
+	return cpy
+}
+
+// Profiler keeps the traces of the recent heights in memory.
+type Profiler struct {
+	mu      sync.Mutex
+	traces  map[uint64]*Trace
+	numbers []uint64 // heights in memory, in insertion order
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{traces: make(map[uint64]*Trace)}
+}
+
+func (p *Profiler) trace(number uint64, now time.Time) *Trace {
+	tr, exist := p.traces[number]
+	if !exist {
+		tr = &Trace{Number: number, Start: msTime(now), created: now, voters: make(map[common.Address]bool)}
+		p.traces[number] = tr
+		p.numbers = append(p.numbers, number)
+		for len(p.numbers) > maxTraceNumbers {
+			delete(p.traces, p.numbers[0])
+			p.numbers = p.numbers[1:]
+		}
+	}
+	return tr
+}
+
+// Begin starts a stage of the height, unless it's running already.
+func (p *Profiler) Begin(number uint64, stage string, detail string) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tr := p.trace(number, now)
+	if tr.End != 0 || tr.open(stage) != nil || len(tr.Spans) >= maxTraceSpans {
+		return
+	}
+	tr.Spans = append(tr.Spans, &Span{Stage: stage, Start: msTime(now), Detail: detail, start: now})
+}
+
+// End ends the running stage of the height, the detail replaces the one of the
+// start if set.
+func (p *Profiler) End(number uint64, stage string, detail string) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tr, exist := p.traces[number]
+	if !exist {
+		return
+	}
+	if span := tr.open(stage); span != nil {
+		tr.close(span, now, detail)
+	}
+}
+
+// Switch ends the running stage of the height and starts the next one of the
+// stage, unless the running one has the same detail.
+func (p *Profiler) Switch(number uint64, stage string, detail string) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tr := p.trace(number, now)
+	if tr.End != 0 {
+		return
+	}
+	if span := tr.open(stage); span != nil {
+		if span.Detail == detail {
+			return
+		}
+		tr.close(span, now, "")
+	}
+	if len(tr.Spans) < maxTraceSpans {
+		tr.Spans = append(tr.Spans, &Span{Stage: stage, Start: msTime(now), Detail: detail, start: now})
+	}
+}
+
+// Vote records the first vote of the voter for the height.
+func (p *Profiler) Vote(number uint64, voter common.Address) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tr := p.trace(number, now)
+	if tr.End != 0 || tr.voters[voter] {
+		return
+	}
+	tr.voters[voter] = true
+	latency := now.Sub(tr.origin())
+	tr.Votes = append(tr.Votes, &VoterLatency{Voter: voter, Time: msTime(now), Latency: uint64(latency / time.Millisecond)})
+	metrics.GetOrRegisterTimer("blkprofile/vote/latency", nil).Update(latency)
+}
+
+// Finish ends the running stages of the height once its block is inserted.
+func (p *Profiler) Finish(number uint64) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tr, exist := p.traces[number]
+	if !exist || tr.End != 0 {
+		return
+	}
+	for _, span := range tr.Spans {
+		if span.End == 0 {
+			tr.close(span, now, "")
+		}
+	}
+	tr.End = msTime(now)
+	metrics.GetOrRegisterTimer("blkprofile/block", nil).Update(now.Sub(tr.created))
+}
+
+// Trace returns a copy of the trace of the height, nil if it's not in memory.
+func (p *Profiler) Trace(number uint64) *Trace {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if tr, exist := p.traces[number]; exist {
+		return tr.copy()
+	}
+	return nil
+}
+
+// Numbers returns the heights in memory, in ascending order.
+func (p *Profiler) Numbers() []uint64 {
+	p.mu.Lock()
+	numbers := append([]uint64(nil), p.numbers...)
+	p.mu.Unlock()
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	return numbers
+}
+
+// DefaultProfiler is the profiler the block production modules record to.
+var DefaultProfiler = NewProfiler()
+
+// Begin starts a stage of the height in the default profiler.
+func Begin(number uint64, stage string, detail string) { DefaultProfiler.Begin(number, stage, detail) }
+
+// End ends a stage of the height in the default profiler.
+func End(number uint64, stage string, detail string) { DefaultProfiler.End(number, stage, detail) }
+
+// Switch switches a stage of the height in the default profiler.
+func Switch(number uint64, stage string, detail string) {
+	DefaultProfiler.Switch(number, stage, detail)
+}
+
+// Vote records a vote for the height in the default profiler.
+func Vote(number uint64, voter common.Address) { DefaultProfiler.Vote(number, voter) }
+
+// Finish ends the height in the default profiler.
+func Finish(number uint64) { DefaultProfiler.Finish(number) }
@@ -0,0 +1,77 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package blkprofile
+
+import (
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+func TestProfilerTrace(t *testing.T) {
+	p := NewProfiler()
+	voter := common.BytesToAddress([]byte{0x01})
+
+	p.Switch(10, StageLeaderTurn, "leader0 0")
+	p.Switch(10, StageLeaderTurn, "leader0 0")
+	p.Begin(10, StageLocalVerify, "")
+	p.Begin(10, StageLocalVerify, "")
+	p.Vote(10, voter)
+	p.Vote(10, voter)
+	p.End(10, StageLocalVerify, "ok")
+	p.Switch(10, StageLeaderTurn, "leader1 1")
+	p.Begin(10, StageMiningWait, "")
+	p.Finish(10)
+	p.Begin(10, StageInsert, "")
+
+	tr := p.Trace(10)
+	if tr == nil || tr.End == 0 {
+		t.Fatalf("trace not finished: %+v", tr)
+	}
+	if len(tr.Spans) != 4 {
+		t.Fatalf("span count mismatch: have %d, want 4", len(tr.Spans))
+	}
+	if tr.Spans[0].Stage != StageLeaderTurn || tr.Spans[0].End == 0 || tr.Spans[2].Detail != "leader1 1" {
+		t.Fatalf("leader turns mismatch: %+v %+v", tr.Spans[0], tr.Spans[2])
+	}
+	if tr.Spans[1].Detail != "ok" {
+		t.Fatalf("end detail mismatch: %q", tr.Spans[1].Detail)
+	}
+	for _, span := range tr.Spans {
+		if span.End == 0 {
+			t.Fatalf("span %s still running", span.Stage)
+		}
+	}
+	if len(tr.Votes) != 1 || tr.Votes[0].Voter != voter {
+		t.Fatalf("votes mismatch: %+v", tr.Votes)
+	}
+
+	// the copy returned doesn't share the spans
+	tr.Spans[0].Detail = "changed"
+	if p.Trace(10).Spans[0].Detail == "changed" {
+		t.Fatalf("trace returned is not a copy")
+	}
+}
+
+func TestProfilerEviction(t *testing.T) {
+	p := NewProfiler()
+	for i := uint64(1); i <= maxTraceNumbers+2; i++ {
+		p.Begin(i, StageHeaderGen, "")
+	}
+	if p.Trace(1) != nil || p.Trace(2) != nil || p.Trace(3) == nil {
+		t.Fatalf("oldest heights not evicted")
+	}
+	numbers := p.Numbers()
+	if len(numbers) != maxTraceNumbers || numbers[0] != 3 {
+		t.Fatalf("numbers mismatch: len %d, first %d", len(numbers), numbers[0])
+	}
+
+	api := NewPublicProfileAPI(p)
+	if traces := api.GetBlockTraces(100, 5); len(traces) != 5 || traces[0].Number != 100 {
+		t.Fatalf("traces page mismatch: %d", len(traces))
+	}
+	if _, err := api.GetBlockTrace(1); err == nil {
+		t.Fatalf("evicted trace returned")
+	}
+}
@@ -84,6 +84,7 @@ func (p *Process) bcFinishedProcess(lvResult verifyResult) {
 		FinalTxs:    p.curProcessReq.finalTxs,
 		Receipts:    p.curProcessReq.receipts,
 		State:       p.curProcessReq.stateDB,
+		Rewards:     p.curProcessReq.rewards,
 	}
 	log.INFO(p.logExtraInfo(), "广播身份", "请求验证完成, 发出区块共识结果消息", "高度", p.number, "block hash", result.BlockHash.TerminalString())
 	mc.PublishEvent(mc.BlkVerify_VerifyConsensusOK, &result)
@@ -120,6 +120,24 @@ func readVerifiedBlocksFromDB(db mandb.Database) (blocks []verifiedBlock, err er
 	return blocks, nil
 }
 
+// VerifiedBlockParents returns the parents of the verified blocks saved for the
+// recovery, the chain must keep them to reload the blocks.
+func VerifiedBlockParents(db mandb.Database) ([]mc.BlockInfo, error) {
+	blocks, err := readVerifiedBlocksFromDB(db)
+	if err != nil {
+		return nil, err
+	}
+	parents := make([]mc.BlockInfo, 0, len(blocks))
+	for _, block := range blocks {
+		number := block.req.Header.Number.Uint64()
+		if number == 0 {
+			continue
+		}
+		parents = append(parents, mc.BlockInfo{Hash: block.req.Header.ParentHash, Number: number - 1})
+	}
+	return parents, nil
+}
+
 func getVerifiedBlockIndex(db rawdb.DatabaseReader) (*VerifiedBlockIndex, error) {
 	if false == rawdb.HasVerifiedBlockIndex(db) {
 		index := newVerifiedBlockIndex(verifiedBlockCacheSize)
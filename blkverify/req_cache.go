@@ -8,6 +8,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/pkg/errors"
 
 	"github.com/MatrixAINetwork/go-matrix/core"
@@ -43,6 +44,7 @@ type reqData struct {
 	finalTxs          []types.CoinSelfTransaction
 	receipts          []types.CoinReceipts
 	stateDB           *state.StateDBManage
+	rewards           []*ledger.Entry
 	localVerifyResult verifyResult
 	posFinished       bool
 	votes             []*common.VerifiedSign
@@ -57,6 +59,7 @@ func newReqData(req *mc.HD_BlkConsensusReqMsg, isDBRecovery bool, reqType reqTyp
 		finalTxs:          nil,
 		receipts:          nil,
 		stateDB:           nil,
+		rewards:           nil,
 		localVerifyResult: localVerifyResultProcessing,
 		posFinished:       false,
 		votes:             make([]*common.VerifiedSign, 0),
@@ -78,6 +81,7 @@ func newReqDataByLocalReq(localReq *mc.LocalBlockVerifyConsensusReq) *reqData {
 		finalTxs:          localReq.FinalTxs,
 		receipts:          localReq.Receipts,
 		stateDB:           localReq.State,
+		rewards:           localReq.Rewards,
 		localVerifyResult: localVerifyResultProcessing,
 		posFinished:       false,
 		votes:             make([]*common.VerifiedSign, 0),
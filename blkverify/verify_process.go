@@ -4,10 +4,12 @@
 package blkverify
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/blkprofile"
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
@@ -340,6 +342,7 @@ func (p *Process) HandleVote(signHash common.Hash, vote common.Signature, from c
 	}
 
 	p.curProcessReq.addVote(verifiedVote)
+	blkprofile.Vote(p.number, from)
 	p.processDPOSOnce()
 }
 
@@ -368,6 +371,7 @@ func (p *Process) startReqVerifyCommon() {
 	}
 
 	p.curProcessReq = req
+	blkprofile.Begin(p.number, blkprofile.StageLocalVerify, req.req.Header.Leader.Hex())
 	log.Trace(p.logExtraInfo(), "请求验证阶段", "开始", "高度", p.number, "HeaderHash", p.curProcessReq.hash.TerminalString(), "parent hash", p.curProcessReq.req.Header.ParentHash.TerminalString(), "之前状态", p.state.String())
 	p.state = StateReqVerify
 	p.processReqOnce()
@@ -513,7 +517,7 @@ func (p *Process) StartVerifyTxsAndState(result *core.RetChan) {
 
 func (p *Process) verifyTxsAndState() {
 	log.Trace(p.logExtraInfo(), "开始交易验证, 数量", len(p.curProcessReq.originalTxs), "高度", p.number)
-	stateDB, finalTxs, receipts, _, err := p.pm.manblk.VerifyTxsAndState(blkmanage.CommonBlk, string(p.curProcessReq.req.Header.Version), p.curProcessReq.req.Header, p.curProcessReq.originalTxs, nil)
+	stateDB, finalTxs, receipts, extraData, err := p.pm.manblk.VerifyTxsAndState(blkmanage.CommonBlk, string(p.curProcessReq.req.Header.Version), p.curProcessReq.req.Header, p.curProcessReq.originalTxs, nil)
 	if nil != err {
 		log.Error(p.logExtraInfo(), "交易及状态验证失败", err, "高度", p.number, "req leader", p.curProcessReq.req.Header.Leader.Hex())
 		p.startDPOSVerify(localVerifyResultStateFailed)
@@ -521,6 +525,7 @@ func (p *Process) verifyTxsAndState() {
 	}
 
 	p.curProcessReq.stateDB, p.curProcessReq.finalTxs, p.curProcessReq.receipts = stateDB, finalTxs, receipts
+	p.curProcessReq.rewards = blkmanage.RewardLedger(extraData)
 
 	// 开始DPOS共识验证
 	p.startDPOSVerify(localVerifyResultSuccess)
@@ -528,7 +533,13 @@ func (p *Process) verifyTxsAndState() {
 
 func (p *Process) sendVote(validate bool) {
 	signHash := p.curProcessReq.hash
-	sign, err := p.signHelper().SignHashWithValidate(signHash.Bytes(), validate, p.curProcessReq.req.Header.ParentHash)
+	vote := signhelper.VoteInfo{
+		Kind:        signhelper.VoteBlock,
+		Number:      p.number,
+		Turn:        p.curProcessReq.req.ConsensusTurn.PreConsensusTurn,
+		ReelectTurn: p.curProcessReq.req.ConsensusTurn.UsedReelectTurn,
+	}
+	sign, err := p.signHelper().SignVoteWithValidate(signHash.Bytes(), validate, p.curProcessReq.req.Header.ParentHash, vote)
 	if err != nil {
 		log.Error(p.logExtraInfo(), "投票签名失败", err, "高度", p.number)
 		return
@@ -554,6 +565,7 @@ func (p *Process) notifyVerifiedBlock() {
 		FinalTxs:    p.curProcessReq.finalTxs,
 		Receipts:    p.curProcessReq.receipts,
 		State:       p.curProcessReq.stateDB,
+		Rewards:     p.curProcessReq.rewards,
 	}
 	mc.PublishEvent(mc.BlkVerify_VerifyConsensusOK, &result)
 }
@@ -570,6 +582,8 @@ func (p *Process) startDPOSVerify(lvResult verifyResult) {
 	}
 
 	log.Trace(p.logExtraInfo(), "开始POS阶段,验证结果", lvResult.String(), "高度", p.number)
+	blkprofile.End(p.number, blkprofile.StageLocalVerify, lvResult.String())
+	blkprofile.Begin(p.number, blkprofile.StageVoteCollect, "")
 	if lvResult == localVerifyResultSuccess {
 		p.sendVote(true)
 		p.notifyVerifiedBlock()
@@ -598,6 +612,7 @@ func (p *Process) startDPOSVerify(lvResult verifyResult) {
 			continue
 		}
 		p.curProcessReq.addVote(verifiedVote)
+		blkprofile.Vote(p.number, vote.from)
 	}
 
 	p.state = StateDPOSVerify
@@ -656,6 +671,8 @@ func (p *Process) finishedProcess() {
 	}
 
 	log.Trace(p.logExtraInfo(), "关键时间点", "共识投票完毕，发送挖矿请求", "time", time.Now(), "块高", p.number)
+	blkprofile.End(p.number, blkprofile.StageVoteCollect, fmt.Sprintf("%d votes", len(p.curProcessReq.req.Header.Signatures)))
+	blkprofile.Begin(p.number, blkprofile.StageMiningWait, "")
 	//给矿工发送区块验证结果
 	p.startSendMineReq(&mc.HD_MiningReqMsg{Header: p.curProcessReq.req.Header})
 	//给广播节点发送区块验证请求(带签名列表)
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package btrie
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/hashicorp/golang-lru"
+)
+
+const txIndexCacheLimit = 16
+
+// txIndexCache caches the indexes by the saved root hash of their btree, the
+// content of a btree never changes under the same hash.
+var txIndexCache, _ = lru.New(txIndexCacheLimit)
+
+type txIndexCacheKey struct {
+	typ  byte
+	root common.Hash
+}
+
+// TxEntry is a pending time or revocable transaction of a btree.
+type TxEntry struct {
+	Hash common.Hash
+	Time uint32 // 执行时间, btree的key
+	Tx   common.RecorbleTx
+}
+
+// TxIndex indexes the pending transactions of a btree by due time and by account,
+// the sender and the recipients of a transaction are indexed.
+type TxIndex struct {
+	entries  []*TxEntry // sorted by time, then hash
+	hashes   map[common.Hash]*TxEntry
+	accounts map[common.Address][]*TxEntry
+}
+
+// NewTxIndex builds the index of the transactions in the btree.
+func NewTxIndex(t *BTree) *TxIndex {
+	idx := &TxIndex{
+		hashes:   make(map[common.Hash]*TxEntry),
+		accounts: make(map[common.Address][]*TxEntry),
+	}
+	if t == nil || t.Root() == nil {
+		return idx
+	}
+	t.Ascend(func(it Item) bool {
+		item, ok := it.(SpcialTxData)
+		if !ok {
+			return true
+		}
+		entries := make([]*TxEntry, 0, len(item.Value_Tx))
+		for hash, data := range item.Value_Tx {
+			entry := &TxEntry{Hash: hash, Time: item.Key_Time}
+			if err := json.Unmarshal(data, &entry.Tx); err != nil {
+				log.Error("btree", "NewTxIndex:Unmarshal err", err, "hash", hash)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i].Hash[:], entries[j].Hash[:]) < 0
+		})
+		for _, entry := range entries {
+			idx.add(entry)
+		}
+		return true
+	})
+	return idx
+}
+
+// GetTxIndex returns the index of the btree saved under root, from the cache
+// when it was built already.
+func GetTxIndex(t *BTree, typ byte, root common.Hash) *TxIndex {
+	key := txIndexCacheKey{typ, root}
+	if cached, ok := txIndexCache.Get(key); ok {
+		return cached.(*TxIndex)
+	}
+	idx := NewTxIndex(t)
+	txIndexCache.Add(key, idx)
+	return idx
+}
+
+func (idx *TxIndex) add(entry *TxEntry) {
+	idx.entries = append(idx.entries, entry)
+	idx.hashes[entry.Hash] = entry
+	idx.accounts[entry.Tx.From] = append(idx.accounts[entry.Tx.From], entry)
+	for _, to := range entry.Tx.Adam {
+		if to.Addr == entry.Tx.From {
+			continue
+		}
+		if list := idx.accounts[to.Addr]; len(list) > 0 && list[len(list)-1] == entry {
+			continue
+		}
+		idx.accounts[to.Addr] = append(idx.accounts[to.Addr], entry)
+	}
+}
+
+// Len returns the number of indexed transactions.
+func (idx *TxIndex) Len() int {
+	return len(idx.entries)
+}
+
+// Get returns the transaction of the hash, nil if it's not pending.
+func (idx *TxIndex) Get(hash common.Hash) *TxEntry {
+	return idx.hashes[hash]
+}
+
+// Account returns the transactions sent from or to the account, by due time.
+func (idx *TxIndex) Account(account common.Address) []*TxEntry {
+	return append([]*TxEntry(nil), idx.accounts[account]...)
+}
+
+// Due returns the transactions due from the time to the time, both included,
+// by due time.
+func (idx *TxIndex) Due(from uint32, to uint32) []*TxEntry {
+	start := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].Time >= from })
+	end := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].Time > to })
+	if start >= end {
+		return nil
+	}
+	return append([]*TxEntry(nil), idx.entries[start:end]...)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package btrie
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+func recorbleTxData(t *testing.T, from common.Address, typ byte, tim uint32, to ...common.Address) []byte {
+	rt := common.RecorbleTx{From: from, Cointyp: "MAN", Tim: tim, Typ: typ}
+	for _, addr := range to {
+		rt.Adam = append(rt.Adam, common.AddrAmont{Addr: addr, Amont: big.NewInt(1)})
+	}
+	data, err := json.Marshal(&rt)
+	if err != nil {
+		t.Fatalf("marshal recorble tx: %v", err)
+	}
+	return data
+}
+
+func TestTxIndex(t *testing.T) {
+	a, b, c := common.BytesToAddress([]byte{0x01}), common.BytesToAddress([]byte{0x02}), common.BytesToAddress([]byte{0x03})
+	h1, h2, h3 := common.BytesToHash([]byte{0x01}), common.BytesToHash([]byte{0x02}), common.BytesToHash([]byte{0x03})
+
+	tree := NewBtree(2, nil)
+	tree.ReplaceOrInsert(SpcialTxData{200, map[common.Hash][]byte{
+		h2: recorbleTxData(t, a, common.ExtraRevocable, 200, b, b),
+		h1: recorbleTxData(t, b, common.ExtraRevocable, 200, c),
+	}})
+	tree.ReplaceOrInsert(SpcialTxData{100, map[common.Hash][]byte{
+		h3: recorbleTxData(t, a, common.ExtraRevocable, 100, c),
+	}})
+
+	idx := NewTxIndex(tree)
+	if idx.Len() != 3 {
+		t.Fatalf("index length mismatch: have %d, want %d", idx.Len(), 3)
+	}
+	if entry := idx.Get(h2); entry == nil || entry.Time != 200 || entry.Tx.From != a {
+		t.Fatalf("entry mismatch: %v", entry)
+	}
+	if entry := idx.Get(common.BytesToHash([]byte{0x04})); entry != nil {
+		t.Fatalf("non existent entry returned: %v", entry)
+	}
+
+	if due := idx.Due(0, 1000); len(due) != 3 || due[0].Hash != h3 || due[1].Hash != h1 || due[2].Hash != h2 {
+		t.Fatalf("due entries mismatch: %v", due)
+	}
+	if due := idx.Due(101, 200); len(due) != 2 {
+		t.Fatalf("due entries mismatch: have %d, want %d", len(due), 2)
+	}
+	if due := idx.Due(201, 300); len(due) != 0 {
+		t.Fatalf("due entries returned past the schedule: %v", due)
+	}
+
+	if txs := idx.Account(a); len(txs) != 2 || txs[0].Hash != h3 || txs[1].Hash != h2 {
+		t.Fatalf("sender entries mismatch: %v", txs)
+	}
+	if txs := idx.Account(b); len(txs) != 2 {
+		t.Fatalf("recipient entries mismatch: have %d, want %d", len(txs), 2)
+	}
+	if txs := idx.Account(c); len(txs) != 2 {
+		t.Fatalf("recipient entries mismatch: have %d, want %d", len(txs), 2)
+	}
+
+	if empty := NewTxIndex(nil); empty.Len() != 0 {
+		t.Fatalf("empty index length mismatch: have %d", empty.Len())
+	}
+}
@@ -45,6 +45,8 @@ func (rt RoleType) String() string {
 		return "backup broadcast"
 	case RoleBroadcast:
 		return "broadcast"
+	case RoleCandidateValidator:
+		return "candidate validator"
 	default:
 		return strconv.Itoa(int(rt))
 	}
@@ -6,7 +6,9 @@
 package amhash
 
 import (
+	"github.com/MatrixAINetwork/go-matrix/aidigger"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/metrics"
 	"github.com/MatrixAINetwork/go-matrix/rpc"
 	"math/big"
@@ -35,6 +37,7 @@ const (
 type Config struct {
 	PowMode          Mode
 	PictureStorePath string
+	AIDigger         string // Name of the AI digger backend, empty for the default digger
 }
 
 // Amhash is a consensus engine based on proot-of-work implementing the amhash
@@ -48,6 +51,9 @@ type Amhash struct {
 	update   chan struct{} // Notification channel to update mining parameters
 	hashrate metrics.Meter // Meter tracking the average hashrate
 
+	digger    aidigger.AIDigger // AI digger backend selected by the config
+	diggerErr error             // Error of the AI digger backend creation
+
 	// The fields below are hooks for testing
 	shared    *Amhash       // Shared PoW verifier to avoid cache regeneration
 	fakeFail  uint64        // Block number which fails PoW check even in fake mode
@@ -58,11 +64,25 @@ type Amhash struct {
 
 // New creates a full sized amhash PoW scheme.
 func New(config Config) *Amhash {
+	digger, err := aidigger.NewAIDigger(config.AIDigger)
+	if err != nil {
+		log.Error("amhash", "create ai digger err", err, "digger", config.AIDigger)
+	}
 	return &Amhash{
-		config:   config,
-		update:   make(chan struct{}),
-		hashrate: metrics.NewMeter(),
+		config:    config,
+		update:    make(chan struct{}),
+		hashrate:  metrics.NewMeter(),
+		digger:    digger,
+		diggerErr: err,
+	}
+}
+
+// InitAIDigger loads the model data of the AI digger backend from cfgPath.
+func (amhash *Amhash) InitAIDigger(cfgPath string) error {
+	if amhash.diggerErr != nil {
+		return amhash.diggerErr
 	}
+	return amhash.digger.Init(cfgPath, amhash.packPictureListByIndex(nil))
 }
 
 // Threads returns the number of mining threads currently enabled. This doesn't
@@ -12,7 +12,6 @@ import (
 	"runtime"
 	"sync"
 
-	"github.com/MatrixAINetwork/go-matrix/aidigger"
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
@@ -224,6 +223,10 @@ func (amhash *Amhash) aiMineProcess(chain consensus.ChainReader, header *types.H
 }
 
 func (amhash *Amhash) startAIMining(chain consensus.ChainReader, header *types.Header, abort chan struct{}, found chan []byte, errCh chan error) {
+	if amhash.diggerErr != nil {
+		errCh <- amhash.diggerErr
+		return
+	}
 	// get seed
 	vrf := baseinterface.NewVrf()
 	_, vrfValue, _ := vrf.GetVrfInfoFromHeader(header.VrfValue)
@@ -233,7 +236,7 @@ func (amhash *Amhash) startAIMining(chain consensus.ChainReader, header *types.H
 	indexList := getRandNums(seed, aiPictureMaxCount, aiPictureSize)
 	pictureList := amhash.packPictureListByIndex(indexList)
 
-	aidigger.AIDigging(seed, pictureList, abort, found, errCh)
+	amhash.digger.Digging(seed, pictureList, abort, found, errCh)
 }
 
 func (amhash *Amhash) packPictureListByIndex(indexList []int) []string {
@@ -1,7 +1,106 @@
 package amhash
 
-import "testing"
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/aidigger"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	_ "github.com/MatrixAINetwork/go-matrix/crypto/vrf"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
 
 func TestMine(t *testing.T) {
 	t.Logf("test")
 }
+
+// testChainReader is a chain reader only serving the topology graph of the parent block.
+type testChainReader struct {
+	miners []common.Address
+}
+
+func (self *testChainReader) Config() *params.ChainConfig                 { return params.TestChainConfig }
+func (self *testChainReader) CurrentHeader() *types.Header                { return nil }
+func (self *testChainReader) GetHeader(common.Hash, uint64) *types.Header { return nil }
+func (self *testChainReader) GetHeaderByNumber(uint64) *types.Header      { return nil }
+func (self *testChainReader) GetHeaderByHash(common.Hash) *types.Header   { return nil }
+func (self *testChainReader) GetBlock(common.Hash, uint64) *types.Block   { return nil }
+func (self *testChainReader) GetMinDifficulty(common.Hash) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+func (self *testChainReader) GetInnerMinerAccounts(common.Hash) ([]common.Address, error) {
+	return nil, nil
+}
+func (self *testChainReader) GetGraphByHash(hash common.Hash) (*mc.TopologyGraph, *mc.ElectGraph, error) {
+	graph := &mc.TopologyGraph{}
+	for i, miner := range self.miners {
+		graph.NodeList = append(graph.NodeList, mc.TopologyNodeInfo{Account: miner, Position: uint16(i), Type: common.RoleMiner})
+	}
+	return graph, nil, nil
+}
+
+func newTestHeader(coinbase common.Address) *types.Header {
+	return &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Number:     big.NewInt(100),
+		Difficulty: big.NewInt(1),
+		Time:       big.NewInt(1000),
+		Coinbase:   coinbase,
+		VrfValue:   make([]byte, 33+65+64),
+	}
+}
+
+func TestSealAndVerifyWithReferenceDigger(t *testing.T) {
+	coinbase := common.HexToAddress("0x0ead6cdb8d214389909a535d4ccc21a393dddba9")
+	chain := &testChainReader{miners: []common.Address{coinbase}}
+	engine := New(Config{PowMode: ModeNormal, PictureStorePath: "picstore", AIDigger: aidigger.DiggerReference})
+	if err := engine.InitAIDigger(""); err != nil {
+		t.Fatalf("init ai digger err: %v", err)
+	}
+
+	sealed, err := engine.Seal(chain, newTestHeader(coinbase), make(chan struct{}), false)
+	if err != nil {
+		t.Fatalf("seal err: %v", err)
+	}
+	if sealed == nil {
+		t.Fatalf("seal returned no header")
+	}
+	if (sealed.AIHash == common.Hash{}) {
+		t.Fatalf("sealed header has no ai hash")
+	}
+	if err := engine.VerifySeal(chain, types.CopyHeader(sealed)); err != nil {
+		t.Fatalf("verify seal err: %v", err)
+	}
+
+	// another engine with a different picture store must accept the seal too
+	verifier := New(Config{PowMode: ModeNormal, PictureStorePath: "/data/picstore", AIDigger: aidigger.DiggerReference})
+	if err := verifier.VerifySeal(chain, types.CopyHeader(sealed)); err != nil {
+		t.Fatalf("verify seal with other picture store err: %v", err)
+	}
+
+	forged := types.CopyHeader(sealed)
+	forged.AIHash = common.HexToHash("0x1234")
+	if err := engine.VerifySeal(chain, forged); err != errInvalidAIMine {
+		t.Errorf("verify forged ai hash: have %v, want %v", err, errInvalidAIMine)
+	}
+
+	other := types.CopyHeader(sealed)
+	other.Coinbase = common.HexToAddress("0x01")
+	if err := engine.VerifySeal(chain, other); err != errCoinbase {
+		t.Errorf("verify unknown coinbase: have %v, want %v", err, errCoinbase)
+	}
+}
+
+func TestUnknownDigger(t *testing.T) {
+	coinbase := common.HexToAddress("0x0ead6cdb8d214389909a535d4ccc21a393dddba9")
+	chain := &testChainReader{miners: []common.Address{coinbase}}
+	engine := New(Config{PowMode: ModeNormal, AIDigger: "unknown"})
+	if err := engine.InitAIDigger(""); err == nil {
+		t.Errorf("expected init error for unknown digger")
+	}
+	if _, err := engine.Seal(chain, newTestHeader(coinbase), make(chan struct{}), false); err == nil {
+		t.Errorf("expected seal error for unknown digger")
+	}
+}
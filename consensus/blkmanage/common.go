@@ -5,6 +5,7 @@ package blkmanage
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/reelection"
@@ -19,6 +20,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 )
 
 type MANBLK interface {
@@ -120,8 +122,8 @@ type VrfMsg struct {
 
 var (
 	LogManBlk    = "区块生成验证引擎"
-	CommonBlk    = "common"
-	BroadcastBlk = "broadcast"
+	CommonBlk    = manversion.BlkPlugCommon
+	BroadcastBlk = manversion.BlkPlugBroadcast
 )
 
 type ManBlkManage struct {
@@ -138,27 +140,19 @@ func New(support BlKSupport) (*ManBlkManage, error) {
 	if err != nil {
 		return nil, err
 	}
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionAlpha, manCommonplug)
 
 	manBcplug, err := NewBCBlkPlug()
 
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionAlpha, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionBeta, manCommonplug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionBeta, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionGamma, manCommonplug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionGamma, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionDelta, manCommonplug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionDelta, manBcplug)
-
-	obj.RegisterManBLkPlugs(CommonBlk, manversion.VersionAIMine, manCommonplug)
-
-	obj.RegisterManBLkPlugs(BroadcastBlk, manversion.VersionAIMine, manBcplug)
+	plugs := map[string]MANBLKPlUGS{CommonBlk: manCommonplug, BroadcastBlk: manBcplug}
+	for _, up := range manversion.Upgrades() {
+		for _, types := range up.BlkPlugins {
+			plug, exist := plugs[types]
+			if !exist {
+				return nil, fmt.Errorf("upgrade %s: unknown block plugin %s", up.Name, types)
+			}
+			obj.RegisterManBLkPlugs(types, up.Version, plug)
+		}
+	}
 
 	return obj, nil
 }
@@ -168,48 +162,23 @@ func (bd *ManBlkManage) RegisterManBLkPlugs(types string, version string, plug M
 }
 
 func (bd *ManBlkManage) ProduceBlockVersion(num uint64, preVersion string) string {
-
-	switch num {
-	case manversion.VersionNumGamma:
-		return manversion.VersionGamma
-	case manversion.VersionNumDelta:
-		return manversion.VersionDelta
-	case manversion.VersionNumAIMine:
-		return manversion.VersionAIMine
-	default:
-		return preVersion
+	if up := manversion.UpgradeAt(num); up != nil {
+		return up.Version
 	}
+	return preVersion
 }
 
 func (bd *ManBlkManage) VerifyBlockVersion(num uint64, curVersion string, preVersion string) error {
-
-	switch num {
-	case manversion.VersionNumGamma:
-		if manversion.VersionCmp(curVersion, manversion.VersionGamma) != 0 {
-			return errors.New("版本号异常")
-		} else {
-			return nil
-		}
-	case manversion.VersionNumDelta:
-		if manversion.VersionCmp(curVersion, manversion.VersionDelta) != 0 {
-			return errors.New("版本号异常")
-		} else {
-			return nil
-		}
-	case manversion.VersionNumAIMine:
-		if manversion.VersionCmp(curVersion, manversion.VersionAIMine) != 0 {
+	if up := manversion.UpgradeAt(num); up != nil {
+		if manversion.VersionCmp(curVersion, up.Version) != 0 {
 			return errors.New("版本号异常")
-		} else {
-			return nil
 		}
-	default:
-		if curVersion != preVersion {
-			return errors.New("版本号异常,不等于父区块版本号")
-		} else {
-			return nil
-		}
-
+		return nil
+	}
+	if curVersion != preVersion {
+		return errors.New("版本号异常,不等于父区块版本号")
 	}
+	return nil
 }
 
 func (bd *ManBlkManage) Prepare(types string, version string, num uint64, interval *mc.BCIntervalInfo, args ...interface{}) (*types.Header, interface{}, error) {
@@ -256,3 +225,10 @@ func (bd *ManBlkManage) VerifyTxsAndState(types string, version string, header *
 	}
 	return plug.VerifyTxsAndState(bd.support, header, Txs, args)
 }
+
+// RewardLedger returns the reward ledger entries carried by the extra result of
+// ProcessState and VerifyTxsAndState.
+func RewardLedger(extra interface{}) []*ledger.Entry {
+	rewards, _ := extra.([]*ledger.Entry)
+	return rewards
+}
@@ -157,7 +157,7 @@ func (bd *ManBCBlkPlug) ProcessState(support BlKSupport, header *types.Header, a
 		log.Error(LogManBlk, "运行matrix状态树失败", err)
 		return nil, nil, nil, nil, nil, nil, err
 	}
-	return nil, work.State, work.Receipts, types.GetCoinTX(Txs), work.GetTxs(), nil, nil
+	return nil, work.State, work.Receipts, types.GetCoinTX(Txs), work.GetTxs(), work.Rewards, nil
 }
 
 func (bd *ManBCBlkPlug) Finalize(support BlKSupport, header *types.Header, state *state.StateDBManage, txs []types.CoinSelfTransaction, uncles []*types.Header, receipts []types.CoinReceipts, args interface{}) (*types.Block, interface{}, error) {
@@ -247,5 +247,5 @@ func (bd *ManBCBlkPlug) VerifyTxsAndState(support BlKSupport, verifyHeader *type
 		}
 	}
 
-	return work.State, retTxs, work.Receipts, nil, nil
+	return work.State, retTxs, work.Receipts, work.Rewards, nil
 }
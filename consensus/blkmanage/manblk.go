@@ -240,7 +240,7 @@ func (bd *ManBlkBasePlug) ProcessState(support BlKSupport, header *types.Header,
 		return nil, nil, nil, nil, nil, nil, err
 	}
 
-	return txsCode, work.State, work.Receipts, types.GetCoinTX(originalTxs), work.GetTxs(), nil, nil
+	return txsCode, work.State, work.Receipts, types.GetCoinTX(originalTxs), work.GetTxs(), work.Rewards, nil
 }
 
 func (bd *ManBlkBasePlug) Finalize(support BlKSupport, header *types.Header, state *state.StateDBManage, txs []types.CoinSelfTransaction, uncles []*types.Header, receipts []types.CoinReceipts, args interface{}) (*types.Block, interface{}, error) {
@@ -394,7 +394,7 @@ func (bd *ManBlkBasePlug) VerifyTxsAndState(support BlKSupport, verifyHeader *ty
 			"local GasUsed", localHeader.GasUsed, "remote GasUsed", verifyHeader.GasUsed)
 		return nil, nil, nil, nil, errors.New("hash 不一致")
 	}
-	return work.State, finalTxs, work.Receipts, nil, nil
+	return work.State, finalTxs, work.Receipts, work.Rewards, nil
 }
 
 func GetVersionSignature(parentBlock *types.Block, version []byte) []common.Signature {
@@ -131,6 +131,12 @@ func (md *MtxDPOS) calcSuperNodeTarget(totalCount int) int {
 	return targetCount
 }
 
+// SuperNodeTarget returns the count of signatures of the super accounts needed
+// by a super block or a version, totalCount is the count of super accounts.
+func (md *MtxDPOS) SuperNodeTarget(totalCount int) int {
+	return md.calcSuperNodeTarget(totalCount)
+}
+
 func (md *MtxDPOS) CheckSuperBlock(reader consensus.StateReader, header *types.Header) error {
 
 	accounts, err := reader.GetBlockSuperAccounts(reader.GetCurrentHash())
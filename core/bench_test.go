@@ -6,20 +6,25 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"runtime"
 	"testing"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/common/math"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
 	"github.com/MatrixAINetwork/go-matrix/consensus/manash"
+	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
 	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/core/vm"
 	"github.com/MatrixAINetwork/go-matrix/crypto"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
 	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 )
 
 func BenchmarkInsertChain_empty_memdb(b *testing.B) {
@@ -64,8 +69,23 @@ var (
 	benchRootKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 	benchRootAddr   = crypto.PubkeyToAddress(benchRootKey.PublicKey)
 	benchRootFunds  = math.BigPow(2, 100)
+	benchSigner     = types.NewEIP155Signer(params.TestChainConfig.ChainId)
 )
 
+// newBenchChain returns a chain of db run by the faker engines.
+func newBenchChain(db mandb.Database, config *params.ChainConfig) (*BlockChain, error) {
+	engines := map[string]consensus.Engine{manversion.VersionAlpha: manash.NewFaker()}
+	dposEngines := map[string]consensus.DPOSEngine{manversion.VersionAlpha: mtxdpos.NewMtxDPOS(true)}
+	return NewBlockChain(db, nil, config, vm.Config{}, engines, dposEngines)
+}
+
+// newBenchTx returns a MAN transfer signed by key.
+func newBenchTx(nonce uint64, to common.Address, amount *big.Int, gas uint64, data []byte, key *ecdsa.PrivateKey) *types.Transaction {
+	tx := types.NewTransaction(nonce, to, amount, gas, nil, data, nil, nil, nil, 0, 0, params.MAN_COIN, 0)
+	signed, _ := types.SignTx(tx, benchSigner, key)
+	return signed.(*types.Transaction)
+}
+
 // genValueTx returns a block generator that includes a single
 // value-transfer transaction with n bytes of extra data in each
 // block.
@@ -73,9 +93,8 @@ func genValueTx(nbytes int) func(int, *BlockGen) {
 	return func(i int, gen *BlockGen) {
 		toaddr := common.Address{}
 		data := make([]byte, nbytes)
-		gas, _ := IntrinsicGas(data, false, false)
-		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(benchRootAddr), toaddr, big.NewInt(1), gas, nil, data), types.HomesteadSigner{}, benchRootKey)
-		gen.AddTx(tx)
+		gas, _ := IntrinsicGas(data)
+		gen.AddTx(newBenchTx(gen.TxNonce(benchRootAddr), toaddr, big.NewInt(1), gas, data, benchRootKey))
 	}
 }
 
@@ -106,16 +125,7 @@ func genTxRing(naccounts int) func(int, *BlockGen) {
 				break
 			}
 			to := (from + 1) % naccounts
-			tx := types.NewTransaction(
-				gen.TxNonce(ringAddrs[from]),
-				ringAddrs[to],
-				benchRootFunds,
-				params.TxGas,
-				nil,
-				nil,
-			)
-			tx, _ = types.SignTx(tx, types.HomesteadSigner{}, ringKeys[from])
-			gen.AddTx(tx)
+			gen.AddTx(newBenchTx(gen.TxNonce(ringAddrs[from]), ringAddrs[to], benchRootFunds, params.TxGas, nil, ringKeys[from]))
 			from = to
 		}
 	}
@@ -144,7 +154,7 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 			b.Fatalf("cannot create temporary directory: %v", err)
 		}
 		defer os.RemoveAll(dir)
-		db, err = mandb.NewLDBDatabase(dir, 128, 128)
+		db, err = mandb.NewLDBDatabase(dir, 128, 128, 2)
 		if err != nil {
 			b.Fatalf("cannot create temporary database: %v", err)
 		}
@@ -162,11 +172,11 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 
 	// Time the insertion of the new chain.
 	// State and blocks are stored in the same DB.
-	chainman, _ := NewBlockChain(db, nil, gspec.Config, manash.NewFaker(), vm.Config{})
+	chainman, _ := newBenchChain(db, gspec.Config)
 	defer chainman.Stop()
 	b.ReportAllocs()
 	b.ResetTimer()
-	if i, err := chainman.InsertChain(chain); err != nil {
+	if i, err := chainman.InsertChain(chain, 0); err != nil {
 		b.Fatalf("insert error (block %d): %v\n", i, err)
 	}
 }
@@ -214,13 +224,12 @@ func makeChainForBench(db mandb.Database, full bool, count uint64) {
 	var hash common.Hash
 	for n := uint64(0); n < count; n++ {
 		header := &types.Header{
-			Coinbase:    common.Address{},
-			Number:      big.NewInt(int64(n)),
-			ParentHash:  hash,
-			Difficulty:  big.NewInt(1),
-			UncleHash:   types.EmptyUncleHash,
-			TxHash:      types.EmptyRootHash,
-			ReceiptHash: types.EmptyRootHash,
+			Coinbase:   common.Address{},
+			Number:     big.NewInt(int64(n)),
+			ParentHash: hash,
+			Difficulty: big.NewInt(1),
+			UncleHash:  types.EmptyUncleHash,
+			Roots:      []common.CoinRoot{{Cointyp: params.MAN_COIN, TxHash: types.EmptyRootHash, ReceiptHash: types.EmptyRootHash}},
 		}
 		hash = header.Hash()
 
@@ -242,7 +251,7 @@ func benchWriteChain(b *testing.B, full bool, count uint64) {
 		if err != nil {
 			b.Fatalf("cannot create temporary directory: %v", err)
 		}
-		db, err := mandb.NewLDBDatabase(dir, 128, 1024)
+		db, err := mandb.NewLDBDatabase(dir, 128, 1024, 2)
 		if err != nil {
 			b.Fatalf("error opening database at %v: %v", dir, err)
 		}
@@ -259,7 +268,7 @@ func benchReadChain(b *testing.B, full bool, count uint64) {
 	}
 	defer os.RemoveAll(dir)
 
-	db, err := mandb.NewLDBDatabase(dir, 128, 1024)
+	db, err := mandb.NewLDBDatabase(dir, 128, 1024, 2)
 	if err != nil {
 		b.Fatalf("error opening database at %v: %v", dir, err)
 	}
@@ -270,11 +279,11 @@ func benchReadChain(b *testing.B, full bool, count uint64) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		db, err := mandb.NewLDBDatabase(dir, 128, 1024)
+		db, err := mandb.NewLDBDatabase(dir, 128, 1024, 2)
 		if err != nil {
 			b.Fatalf("error opening database at %v: %v", dir, err)
 		}
-		chain, err := NewBlockChain(db, nil, params.TestChainConfig, manash.NewFaker(), vm.Config{})
+		chain, err := newBenchChain(db, params.TestChainConfig)
 		if err != nil {
 			b.Fatalf("error creating chain: %v", err)
 		}
@@ -291,3 +300,42 @@ func benchReadChain(b *testing.B, full bool, count uint64) {
 		db.Close()
 	}
 }
+
+func BenchmarkProcessCoins_serial_8x200(b *testing.B) {
+	benchProcessCoins(b, 1, 8, 200)
+}
+func BenchmarkProcessCoins_parallel_8x200(b *testing.B) {
+	benchProcessCoins(b, runtime.NumCPU(), 8, 200)
+}
+func BenchmarkProcessCoins_serial_32x50(b *testing.B) {
+	benchProcessCoins(b, 1, 32, 50)
+}
+func BenchmarkProcessCoins_parallel_32x50(b *testing.B) {
+	benchProcessCoins(b, runtime.NumCPU(), 32, 50)
+}
+
+// benchProcessCoins runs blocks of n transfers in each of the coins, after as
+// many MAN transfers, through the coin scheduler.
+func benchProcessCoins(b *testing.B, workers int, coins int, n int) {
+	names := []string{params.MAN_COIN}
+	for i := 0; i < coins; i++ {
+		names = append(names, fmt.Sprintf("C%03d", i))
+	}
+	txsmap := newSchedulerTxs(names, n)
+	var calls int32
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		statedb := newSchedulerState(names)
+		var usedGas uint64
+		b.StartTimer()
+
+		scheduler := newCoinScheduler(statedb, new(GasPool).AddGas(math.MaxUint64/2), &usedGas, workers, schedulerApplier(100, &calls))
+		if _, err := scheduler.run(names, txsmap); err != nil {
+			b.Fatal(err)
+		}
+		statedb.IntermediateRoot(true)
+	}
+}
@@ -11,10 +11,7 @@ import (
 	"io"
 	"io/ioutil"
 	"math/big"
-	"os"
-	"path"
 	"runtime/debug"
-	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -39,6 +36,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/metrics"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/params/manparams"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 	"github.com/MatrixAINetwork/go-matrix/snapshot"
 	"github.com/MatrixAINetwork/go-matrix/trie"
@@ -124,6 +122,7 @@ type BlockChain struct {
 	bodyRLPCache *lru.Cache // Cache for the most recent block bodies in RLP encoded format
 	blockCache   *lru.Cache // Cache for the most recent entire blocks
 	futureBlocks *lru.Cache // future blocks are blocks added for later processing
+	uptimeCache  *lru.Cache // uptime calculations by the parent hash of their block
 
 	quit    chan struct{} // blockchain quit channel
 	running int32         // running must be called atomically
@@ -156,6 +155,9 @@ type BlockChain struct {
 
 	//bad block dump history
 	badDumpHistory []common.Hash
+
+	//partial node shards, nil on full nodes
+	shards *ShardSubscription
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -174,6 +176,7 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
 	deposits, _ := lru.New(10)
+	uptimeCache, _ := lru.New(uptimeCacheLimit)
 	bc := &BlockChain{
 		chainConfig:     chainConfig,
 		cacheConfig:     cacheConfig,
@@ -186,6 +189,7 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 		blockCache:      blockCache,
 		futureBlocks:    futureBlocks,
 		depCache:        deposits,
+		uptimeCache:     uptimeCache,
 		engine:          make(map[string]consensus.Engine),
 		dposEngine:      make(map[string]consensus.DPOSEngine),
 		processor:       make(map[string]Processor),
@@ -455,6 +459,22 @@ func (bc *BlockChain) SetValidator(version string, validator Validator) {
 	bc.validator[version] = validator
 }
 
+// SetShardSubscription makes the node a partial node keeping the state,
+// transactions and receipts of the subscribed shards only.
+func (bc *BlockChain) SetShardSubscription(shards *ShardSubscription) {
+	bc.shards = shards
+	if shards == nil {
+		state.SetShardFilter(bc.stateCache, nil)
+		return
+	}
+	state.SetShardFilter(bc.stateCache, shards.Contains)
+}
+
+// ShardSubscription returns the shards kept by the node, nil on a full node.
+func (bc *BlockChain) ShardSubscription() *ShardSubscription {
+	return bc.shards
+}
+
 // Validator returns the current validator.
 func (bc *BlockChain) Validator(version []byte) Validator {
 	if validator, ok := bc.validator[string(version)]; ok {
@@ -1040,8 +1060,9 @@ func (bc *BlockChain) WriteBlockWithoutState(block *types.Block, td *big.Int) (e
 	return nil
 }
 
-// WriteBlockWithState writes the block and all associated state to the database.
-func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.StateDBManage) (status WriteStatus, err error) {
+// WriteBlockWithState writes the block and all associated state to the database,
+// with the reward ledger entries of the block.
+func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.StateDBManage, rewards []*ledger.Entry) (status WriteStatus, err error) {
 	bc.wg.Add(1)
 	defer bc.wg.Done()
 
@@ -1071,6 +1092,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.State
 	batch := bc.db.NewBatch()
 
 	rawdb.WriteBlock(batch, block)
+	if rewards != nil {
+		rawdb.WriteRewardLedger(batch, &ledger.Ledger{Number: block.NumberU64(), Hash: block.Hash(), Version: string(block.Header().Version), Entries: rewards})
+	}
 	txcount := uint64(0)
 	for _, cb := range block.Currencies() {
 		txcount += uint64(len(cb.Transactions.GetTransactions()))
@@ -1169,6 +1193,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.State
 		}
 	}
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	bc.writeUptimeDetails(batch, block)
 
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
@@ -1207,6 +1232,8 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.State
 		// Write the positional metadata for transaction/receipt lookups and preimages
 		rawdb.WriteTxLookupEntries(batch, block)
 		rawdb.WritePreimages(batch, block.NumberU64(), state.Preimages())
+		bc.writeCoinIndex(batch, block)
+		bc.writeValidatorGroupIndex(batch, block, receipts, state)
 
 		status = CanonStatTy
 	} else {
@@ -1482,6 +1509,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, flg int) (int, []interface
 			//receipts types.Receipts = nil
 			logs           = make([]types.CoinLogs, 0)
 			usedGas uint64 = 0
+			rewards []*ledger.Entry
 		)
 		if block.IsSuperBlock() {
 			log.Trace("BlockChain insertChain ProcessSuperBlk")
@@ -1491,24 +1519,27 @@ func (bc *BlockChain) insertChain(chain types.Blocks, flg int) (int, []interface
 			}
 		} else {
 			log.Trace("BlockChain insertChain Processor")
-			_, logs, usedGas, err = bc.Processor(block.Header().Version).Process(block, parent, state, bc.vmConfig)
+			_, logs, usedGas, rewards, err = bc.Processor(block.Header().Version).Process(block, parent, state, bc.vmConfig)
 			if nil != err {
 				return i, events, coalescedLogs, err
 			}
-			log.Trace("BlockChain insertChain ValidateState")
-			// Validate the state using the default validator
-			err = bc.Validator(block.Header().Version).ValidateState(block, parent, state, usedGas)
-			if err != nil {
-				log.Trace("BlockChain insertChain in3 Process Block err4")
-				bc.dumpBadBlock(block.Hash(), state)
-				bc.reportBlock(block, nil, err)
-				return i, events, coalescedLogs, err
+			// A partial node doesn't run the whole block, it can't validate it
+			if bc.shards == nil {
+				log.Trace("BlockChain insertChain ValidateState")
+				// Validate the state using the default validator
+				err = bc.Validator(block.Header().Version).ValidateState(block, parent, state, usedGas)
+				if err != nil {
+					log.Trace("BlockChain insertChain in3 Process Block err4")
+					bc.dumpBadBlock(block.Hash(), state)
+					bc.reportBlock(block, nil, err)
+					return i, events, coalescedLogs, err
+				}
 			}
 		}
 		proctime := time.Since(bstart)
 		log.Trace("BlockChain insertChain in3 WriteBlockWithState")
 		// Write the block to the chain and get the status.
-		status, err = bc.WriteBlockWithState(block, state)
+		status, err = bc.WriteBlockWithState(block, state, rewards)
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
@@ -1724,6 +1755,12 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	} else {
 		log.Error("Impossible reorg, please file an issue", "oldnum", oldBlock.Number(), "oldhash", oldBlock.Hash(), "newnum", newBlock.Number(), "newhash", newBlock.Hash())
 	}
+	// Take the events of the dropped blocks out of the validator group totals,
+	// and drop the metadata of the coins they created
+	for _, block := range oldChain {
+		rawdb.DropValidatorGroupEvents(bc.db, bc.db, block.Hash())
+		rawdb.DropCoinMetas(bc.db, bc.db, block.Hash())
+	}
 	// Insert the new chain, taking care of the proper incremental order
 	var addedTxs types.SelfTransactions
 	for i := len(newChain) - 1; i >= 0; i-- {
@@ -1731,6 +1768,8 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		bc.insert(newChain[i], oldBlock)
 		// write lookup entries for hash based transaction/receipt searches
 		rawdb.WriteTxLookupEntries(bc.db, newChain[i])
+		bc.writeCoinIndex(bc.db, newChain[i])
+		bc.writeReorgValidatorGroupIndex(bc.db, newChain[i])
 		for _, currencie := range newChain[i].Currencies() {
 			txss := currencie.Transactions.GetTransactions()
 			addedTxs = append(addedTxs, txss...)
@@ -2469,6 +2508,13 @@ func (bc *BlockChain) SynSnapshot(blockNum uint64, hash string, filePath string)
 		log.DEBUG("BlockChain synSnapshot", "the blockNum is too low ,sblockNum", blockNum)
 		return false
 	}
+	if snapshot.IsSnapshotFile(filePath) {
+		if err := bc.ImportSnapshotFile(filePath, blockNum, hash); err != nil {
+			log.Error("BlockChain synSnapshot", "import snapshot err", err)
+			return false
+		}
+		return true
+	}
 	rb, rerr := ioutil.ReadFile(filePath)
 	if rerr != nil {
 		log.Error("BlockChain synSnapshot", "Read TrieData err: ", rerr)
@@ -2604,105 +2650,17 @@ func (bc *BlockChain) SaveSnapshot(blockNum uint64, period uint64) {
 	NewBlocknum := uint64(period) * times
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
-	getSnapshotNums := func(num uint64, bc *BlockChain) (nums []uint64) {
-		nums = make([]uint64, 0)
 
-		haveSuperBlock := false
-		nums = append(nums, num)
-		nums = append(nums, num-1)
-		nums = append(nums, num-2)
-
-		for _, value := range nums {
-			if bc.GetBlockByNumber(value).IsSuperBlock() {
-				haveSuperBlock = true
-				break
-			}
-		}
-		if haveSuperBlock {
-			nums = append(nums, num-3)
-		}
-		for i, j := 0, len(nums)-1; i < j; i, j = i+1, j-1 {
-			nums[i], nums[j] = nums[j], nums[i]
-		}
-		return nums
-	}
-	nums := getSnapshotNums(NewBlocknum, bc)
-
-	snapshotDatas := snapshot.SnapshotDatas{
-		Datas: make([]snapshot.SnapshotData, 0),
-	}
-
-	tmpstatedb, stateerr := bc.State()
-	if stateerr != nil {
-		log.Error("BlockChain savesnapshot ", "open state fialed,err ", stateerr)
-		return
-	}
-	preBCRoot, err := matrixstate.GetPreBroadcastRoot(tmpstatedb)
+	filePath := SnapshotFilePath(NewBlocknum)
+	manifest, err := bc.ExportSnapshotFile(filePath, NewBlocknum)
 	if err != nil {
-		log.Error(" BlockChain savesnapshot ", "get pre broadcast root err", err)
+		log.ERROR("BlockChain savesnapshot ", "number", NewBlocknum, "err", err)
 		return
 	}
+	log.Info("BlockChain savesnapshot ", "file", filePath, "sections", len(manifest.Sections), "chunks", manifest.Chunks(), "manifest", manifest.Hash().TerminalString())
 
-	//if !preBCRoot.BeforeLastStateRoot.Equal(common.Hash{}) {
-	cs, stateerr := getDumpDB(preBCRoot.BeforeLastStateRoot, bc)
-	if stateerr != nil {
-		log.Error("BlockChain savesnapshot ", "get a dumpdb err", err)
-		return
-	}
-	snapshotDatas.OtherTries = append(snapshotDatas.OtherTries, cs)
-	//}
-
-	//if !preBCRoot.LastStateRoot.Equal(common.Hash{}) {
-	lastcs, Laststateerr := getDumpDB(preBCRoot.LastStateRoot, bc)
-	if Laststateerr != nil {
-		log.Error("BlockChain savesnapshot ", "get a dumpdb err", err)
-		return
-	}
-	snapshotDatas.OtherTries = append(snapshotDatas.OtherTries, lastcs)
-	//}
-
-	var filePath string
-	for _, correct := range nums {
-		log.Info("BlockChain savesnapshot ", "correct###############################: ", correct)
-
-		block := bc.GetBlockByNumber(uint64(correct))
-		td := bc.GetTd(block.Hash(), block.NumberU64())
-		header := block.Header()
-		root := header.Roots
-
-		log.Info("BlockChain savesnapshot ", "root ###############################: ", root)
-		statedb, err := bc.getStateCache(root)
-		if err != nil {
-			log.Error("BlockChain savesnapshot ", "open state fialed,err ", err)
-			return
-		}
-		coinTries := statedb.RawDumpDB()
-		snapshotData := snapshot.SnapshotData{coinTries, td, *block}
-		snapshotDatas.Datas = append(snapshotDatas.Datas, snapshotData)
-		//if correct==300 {
-		//	GetDumpInfo (snapshotDatas.Datas[len(snapshotDatas.Datas)-1].CoinTries)
-		//
-		//}
-	}
-	wb, err := rlp.EncodeToBytes(&snapshotDatas)
-	if err != nil {
-		log.ERROR("BlockChain savesnapshot ", "encode  err: ", err)
-	}
-	filePath = path.Join(snapshot.SNAPDIR, "/TrieData"+strconv.Itoa(int(nums[len(nums)-1])))
-	f, ferr := os.Create(filePath)
-	if ferr != nil {
-		log.ERROR("BlockChain Create TrieData", "ferr", ferr, "f", f)
-	}
-	count, err := f.Write(wb)
-	if err != nil {
-		log.ERROR("BlockChain savesnapshot ", "Write snapshot err: ", err)
-	} else {
-		log.Info("BlockChain savesnapshot ", "Write snapshot bytes : ", count)
-	}
-	f.Close()
-
-	tmpSanpInfo.BlockNum = nums[len(nums)-1]
-	tmpSanpInfo.BlockHash = bc.GetHeaderByNumber(tmpSanpInfo.BlockNum).Hash().String()
+	tmpSanpInfo.BlockNum = manifest.BlockNumber
+	tmpSanpInfo.BlockHash = manifest.BlockHash.String()
 	tmpSanpInfo.SnapPath = filePath
 	if bc.qBlockQueue != nil {
 		bc.qBlockQueue.Push(tmpSanpInfo, -float32(tmpSanpInfo.BlockNum))
@@ -0,0 +1,303 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/MatrixAINetwork/go-matrix/snapshot"
+	"github.com/MatrixAINetwork/go-matrix/trie"
+	"github.com/pkg/errors"
+)
+
+var (
+	errSnapshotBlockNumber = errors.New("snapshot block number mismatch")
+	errSnapshotBlockHash   = errors.New("snapshot block hash mismatch")
+	errSnapshotStateRoot   = errors.New("snapshot state root mismatch")
+)
+
+// SnapshotFilePath returns the path of the snapshot file of the block number in the snapshot dir.
+func SnapshotFilePath(number uint64) string {
+	return path.Join(snapshot.SNAPDIR, "/TrieData"+strconv.FormatUint(number, 10))
+}
+
+// snapshotNumbers returns the blocks saved in the snapshot of block num, in
+// ascending order. One more block is kept if a super block is among them.
+func (bc *BlockChain) snapshotNumbers(num uint64) ([]uint64, error) {
+	if num < 3 {
+		return nil, errors.Errorf("snapshot block number too low: %d", num)
+	}
+	nums := []uint64{num, num - 1, num - 2}
+	for _, value := range nums {
+		block := bc.GetBlockByNumber(value)
+		if block == nil {
+			return nil, errors.Errorf("snapshot block %d not found", value)
+		}
+		if block.IsSuperBlock() {
+			nums = append(nums, num-3)
+			break
+		}
+	}
+	for i, j := 0, len(nums)-1; i < j; i, j = i+1, j-1 {
+		nums[i], nums[j] = nums[j], nums[i]
+	}
+	return nums, nil
+}
+
+// ExportSnapshotFile writes the snapshot of the block number into filePath. The
+// file is written beside and renamed once complete, so a failed export never
+// leaves a partial snapshot behind.
+func (bc *BlockChain) ExportSnapshotFile(filePath string, number uint64) (*snapshot.Manifest, error) {
+	tmpPath := filePath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := bc.WriteSnapshot(f, number)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// WriteSnapshot streams the snapshot of the block number into w: the states of
+// the pre broadcast roots of the current state, then the state and the block of
+// every snapshot block.
+func (bc *BlockChain) WriteSnapshot(w io.Writer, number uint64) (*snapshot.Manifest, error) {
+	nums, err := bc.snapshotNumbers(number)
+	if err != nil {
+		return nil, err
+	}
+	curState, err := bc.State()
+	if err != nil {
+		return nil, errors.Wrap(err, "open current state")
+	}
+	preBCRoot, err := matrixstate.GetPreBroadcastRoot(curState)
+	if err != nil {
+		return nil, errors.Wrap(err, "get pre broadcast root")
+	}
+
+	sw, err := snapshot.NewWriter(w, snapshot.DefaultChunkItems)
+	if err != nil {
+		return nil, err
+	}
+	for i, roots := range [][]common.CoinRoot{preBCRoot.BeforeLastStateRoot, preBCRoot.LastStateRoot} {
+		statedb, err := bc.StateAt(roots)
+		if err != nil {
+			return nil, errors.Wrap(err, "open pre broadcast state")
+		}
+		if err := writeSnapshotState(sw, snapshot.SectionOtherTrie, uint64(i), statedb); err != nil {
+			return nil, err
+		}
+	}
+
+	var lastBlock *types.Block
+	for _, num := range nums {
+		block := bc.GetBlockByNumber(num)
+		statedb, err := bc.getStateCache(block.Root())
+		if err != nil {
+			return nil, errors.Wrapf(err, "open state of block %d", num)
+		}
+		if err := writeSnapshotState(sw, snapshot.SectionBlockTrie, num, statedb); err != nil {
+			return nil, err
+		}
+		if err := sw.WriteBlock(block, bc.GetTd(block.Hash(), num)); err != nil {
+			return nil, err
+		}
+		lastBlock = block
+	}
+	return sw.Finish(lastBlock.NumberU64(), lastBlock.Hash())
+}
+
+func writeSnapshotState(sw *snapshot.Writer, kind uint8, group uint64, statedb *state.StateDBManage) error {
+	return statedb.RawDumpStates(func(coin string, index int, root common.Hash, st *state.StateDB) error {
+		header := snapshot.SectionHeader{Kind: kind, Group: group, Coin: coin, Index: uint64(index), Root: root}
+		if err := sw.BeginSection(header); err != nil {
+			return err
+		}
+		return st.RawDumpIterate(sw.WriteItem)
+	})
+}
+
+// ImportSnapshotFile verifies the snapshot file, then loads its states and blocks
+// into the chain. number and hash are checked against the manifest unless they
+// are zero or empty.
+func (bc *BlockChain) ImportSnapshotFile(filePath string, number uint64, hash string) error {
+	manifest, err := snapshot.VerifyFile(filePath)
+	if err != nil {
+		return errors.Wrap(err, "verify snapshot")
+	}
+	if number != 0 && manifest.BlockNumber != number {
+		return errors.Wrapf(errSnapshotBlockNumber, "have %d, want %d", manifest.BlockNumber, number)
+	}
+	if hash != "" && manifest.BlockHash != common.HexToHash(hash) {
+		return errors.Wrapf(errSnapshotBlockHash, "have %s, want %s", manifest.BlockHash.Hex(), hash)
+	}
+	if manifest.BlockNumber <= bc.CurrentBlock().NumberU64() {
+		return errors.Errorf("snapshot block %d is not above the current block %d", manifest.BlockNumber, bc.CurrentBlock().NumberU64())
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	loader := &snapshotLoader{bc: bc, currentBlock: bc.CurrentBlock()}
+	if _, err := snapshot.Walk(f, loader); err != nil {
+		return err
+	}
+	return nil
+}
+
+// snapshotLoader writes the content of a snapshot file into the chain database
+// while it is read, it rebuilds the tries the same way as LoadDumps.
+type snapshotLoader struct {
+	bc           *BlockChain
+	currentBlock *types.Block
+	triedb       *trie.Database
+
+	stateTrie   *trie.SecureTrie
+	storageAddr common.Address
+	storageTrie *trie.SecureTrie
+
+	group     snapshot.SectionHeader // coin of the pending sharding roots
+	coinRoots []common.Hash
+}
+
+func (l *snapshotLoader) sameCoin(header snapshot.SectionHeader) bool {
+	return l.coinRoots != nil && l.group.Kind == header.Kind && l.group.Group == header.Group && l.group.Coin == header.Coin
+}
+
+// flushCoinRoots saves the roots of the address ranges of one coin, keyed by their hash.
+func (l *snapshotLoader) flushCoinRoots() error {
+	if l.coinRoots == nil {
+		return nil
+	}
+	bshash := types.RlpHash(l.coinRoots)
+	bs, err := rlp.EncodeToBytes(l.coinRoots)
+	if err != nil {
+		return err
+	}
+	if err := l.bc.GetDB().Put(bshash[:], bs); err != nil {
+		return err
+	}
+	log.Info("BlockChain import snapshot shardingRoot", "shardingRoot", bshash.String(), "coin", l.group.Coin)
+	l.coinRoots = nil
+	return nil
+}
+
+func (l *snapshotLoader) BeginSection(header snapshot.SectionHeader) error {
+	if header.Kind == snapshot.SectionBlock || !l.sameCoin(header) {
+		if err := l.flushCoinRoots(); err != nil {
+			return err
+		}
+	}
+	if header.Kind == snapshot.SectionBlock {
+		return nil
+	}
+	if l.coinRoots == nil {
+		l.group = header
+		l.coinRoots = make([]common.Hash, 0)
+	}
+	l.triedb = trie.NewDatabase(l.bc.GetDB())
+	l.stateTrie, _ = trie.NewSecure(common.Hash{}, l.triedb, 0)
+	l.storageTrie = nil
+	return nil
+}
+
+func (l *snapshotLoader) Items(header snapshot.SectionHeader, items []state.DumpItem) error {
+	for _, item := range items {
+		switch item.Kind {
+		case state.DumpItemCode:
+			l.triedb.Insert(common.BytesToHash(item.Value.Key), item.Value.Value)
+			if err := l.triedb.Commit(common.BytesToHash(item.Value.Key), false); err != nil {
+				return err
+			}
+		case state.DumpItemMatrix, state.DumpItemAccount:
+			l.stateTrie.Update(item.Value.GetKey, item.Value.Value)
+		case state.DumpItemStorage:
+			if l.storageTrie == nil || l.storageAddr != item.Addr {
+				if err := l.commitStorage(); err != nil {
+					return err
+				}
+				l.storageAddr = item.Addr
+				l.storageTrie, _ = trie.NewSecure(common.Hash{}, l.triedb, 0)
+			}
+			l.storageTrie.Update(item.Value.GetKey, item.Value.Value)
+		default:
+			return fmt.Errorf("unknown snapshot item kind %d", item.Kind)
+		}
+	}
+	return nil
+}
+
+func (l *snapshotLoader) commitStorage() error {
+	if l.storageTrie == nil {
+		return nil
+	}
+	root, err := l.storageTrie.Commit(nil)
+	if err != nil {
+		return err
+	}
+	l.storageTrie = nil
+	return l.triedb.Commit(root, true)
+}
+
+func (l *snapshotLoader) EndSection(header snapshot.SectionHeader) error {
+	if header.Kind == snapshot.SectionBlock {
+		return nil
+	}
+	if err := l.commitStorage(); err != nil {
+		return err
+	}
+	root, err := l.stateTrie.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if err := l.triedb.Commit(root, true); err != nil {
+		return err
+	}
+	if root != header.Root {
+		return errors.Wrapf(errSnapshotStateRoot, "coin %s range %d: have %s, want %s", header.Coin, header.Index, root.Hex(), header.Root.Hex())
+	}
+	log.Info("BlockChain import snapshot state", "kind", header.Kind, "group", header.Group, "coin", header.Coin, "range", header.Index, "root", root.String())
+	l.coinRoots = append(l.coinRoots, root)
+	return nil
+}
+
+func (l *snapshotLoader) Block(block *types.Block, td *big.Int) error {
+	bc := l.bc
+	l.currentBlock.SetHeadNum(block.Number().Int64())
+	if err := bc.WriteBlockWithoutState(block, td); err != nil {
+		return errors.Wrap(err, "write snapshot block")
+	}
+	rawdb.WriteHeadBlockHash(bc.GetDB(), block.Hash())
+	rawdb.WriteHeadFastBlockHash(bc.GetDB(), block.Hash())
+	rawdb.WriteCanonicalHash(bc.GetDB(), block.Hash(), block.NumberU64())
+	bc.CurrentBlockStore(block)
+	log.INFO("BlockChain import snapshot", "block insert ok, number", block.NumberU64())
+	return nil
+}
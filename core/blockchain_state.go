@@ -196,20 +196,24 @@ func (bd *BlockChain) processStateSwitchAIMine(stateDB *state.StateDBManage, t u
 func (bc *BlockChain) ProcessStateVersionSwitch(num uint64, t uint64, stateDB *state.StateDBManage) error {
 	//提前一个块设置各自算法引擎和配置，切换高度生效
 
-	switch num {
-	case manversion.VersionNumGamma - 1:
+	up := manversion.UpgradeAt(num + 1)
+	if up == nil {
+		return nil
+	}
+	switch up.Version {
+	case manversion.VersionGamma:
 		log.Info("blockchain", "切换版本Gamma高度", num)
 		return bc.processStateSwitchGamma(stateDB)
-	case manversion.VersionNumDelta - 1:
+	case manversion.VersionDelta:
 		log.Info("blockchain", "切换版本Delta 高度", num)
 		return bc.processStateSwitchDelta(stateDB, t)
-	case manversion.VersionNumAIMine - 1:
+	case manversion.VersionAIMine:
 		log.Info("blockchain", "切换版本AI Mine 高度", num)
 		return bc.processStateSwitchAIMine(stateDB, t)
 	default:
+		log.Info("blockchain", "切换版本高度", num, "版本", up.Version)
 		return nil
 	}
-	return nil
 }
 func (bc *BlockChain) ProcessMatrixState(block *types.Block, preVersion string, state *state.StateDBManage) error {
 	return bc.matrixProcessor.ProcessMatrixState(block, preVersion, state)
@@ -0,0 +1,42 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/reward/whatif"
+	"github.com/pkg/errors"
+)
+
+// DryRunInterestSlash replays the interest and slash calculation of the block
+// with the overrides. The transactions of the block are not replayed, both
+// calculations run on the parent state after the uptime processing of the block.
+func (bc *BlockChain) DryRunInterestSlash(block *types.Block, overrides *whatif.Overrides) (*whatif.Result, error) {
+	if block.NumberU64() == 0 {
+		return nil, errors.New("no interest and slash at the genesis block")
+	}
+	parent := bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, errors.Errorf("parent of block %d not found", block.NumberU64())
+	}
+	statedb, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.ProcessStateVersion(block.Version(), statedb); err != nil {
+		return nil, err
+	}
+	if err := bc.ProcessStateVersionSwitch(block.NumberU64(), block.Time().Uint64(), statedb); err != nil {
+		return nil, err
+	}
+	upTime, err := bc.ProcessUpTime(statedb, block.Header())
+	if err != nil {
+		return nil, err
+	}
+	preState, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	return whatif.Run(bc, statedb, preState, block.Header(), upTime, overrides)
+}
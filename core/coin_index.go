@@ -0,0 +1,220 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// coinHolders collects the accounts touched by a block per coin, in the order
+// they're first seen.
+type coinHolders struct {
+	coins    []string
+	accounts map[string][]common.Address
+}
+
+func newCoinHolders() *coinHolders {
+	return &coinHolders{accounts: make(map[string][]common.Address)}
+}
+
+func (h *coinHolders) add(coin string, account common.Address) {
+	if account == (common.Address{}) {
+		return
+	}
+	if coin == "" {
+		coin = params.MAN_COIN
+	}
+	if _, exist := h.accounts[coin]; !exist {
+		h.coins = append(h.coins, coin)
+	}
+	h.accounts[coin] = append(h.accounts[coin], account)
+}
+
+func (h *coinHolders) write(reader rawdb.DatabaseReader, db rawdb.DatabaseWriter) {
+	for _, coin := range h.coins {
+		rawdb.WriteCoinHolders(reader, db, coin, h.accounts[coin])
+	}
+}
+
+// writeCoinIndex indexes the coins created by a canonical block, and per coin the
+// accounts its transactions send from or to. The holders index only grows, an
+// account stays indexed after a reorg or once its balance is spent. The coins
+// created are recorded with the block, a reorg drops their metadata.
+func (bc *BlockChain) writeCoinIndex(db rawdb.DatabaseWriter, block *types.Block) {
+	signer := types.MakeSigner(bc.chainConfig, block.Number())
+	holders := newCoinHolders()
+	created := make(map[string]bool)
+	coins := make([]string, 0)
+	for _, currency := range block.Currencies() {
+		for _, tx := range currency.Transactions.GetTransactions() {
+			coin := tx.GetTxCurrency()
+			from := tx.From()
+			if from == (common.Address{}) {
+				from, _ = types.Sender(signer, tx)
+			}
+			holders.add(coin, from)
+			if to := tx.To(); to != nil {
+				holders.add(coin, *to)
+			}
+			for _, extra := range tx.GetMatrix_EX() {
+				for _, to := range extra.ExtraTo {
+					if to.Recipient != nil {
+						holders.add(coin, *to.Recipient)
+					}
+				}
+			}
+
+			if tx.GetMatrixType() != common.ExtraMakeCoinType {
+				continue
+			}
+			meta, accounts := bc.coinCreation(block, tx, from, created)
+			if meta == nil {
+				continue
+			}
+			created[meta.Coin] = true
+			coins = append(coins, meta.Coin)
+			rawdb.WriteCoinMeta(db, meta)
+			for _, account := range accounts {
+				holders.add(meta.Coin, account)
+			}
+		}
+	}
+	if len(coins) > 0 {
+		rawdb.WriteCoinBlock(db, block.Hash(), coins)
+	}
+	holders.write(bc.db, db)
+}
+
+// coinCreation returns the metadata and the initial holders of the coin created
+// by the make coin transaction, or nil if the transaction failed. A coin is
+// created by the block if it's in the coin config of the block state, but not
+// in the one of the parent state.
+func (bc *BlockChain) coinCreation(block *types.Block, tx types.SelfTransaction, creator common.Address, created map[string]bool) (*rawdb.CoinMeta, []common.Address) {
+	var makecoin common.SMakeCoin
+	if err := json.Unmarshal(tx.Data(), &makecoin); err != nil || !common.IsValidityCurrency(makecoin.CoinName) {
+		return nil, nil
+	}
+	if created[makecoin.CoinName] {
+		return nil, nil
+	}
+	config, err := bc.coinConfigAt(block.Root(), makecoin.CoinName)
+	if err != nil || config == nil {
+		return nil, nil
+	}
+	parent := bc.GetHeaderByHash(block.ParentHash())
+	if parent == nil {
+		return nil, nil
+	}
+	if parentConfig, err := bc.coinConfigAt(parent.Roots, makecoin.CoinName); err != nil || parentConfig != nil {
+		return nil, nil
+	}
+
+	meta := &rawdb.CoinMeta{
+		Coin:        makecoin.CoinName,
+		Creator:     creator,
+		BlockHash:   block.Hash(),
+		BlockNumber: block.NumberU64(),
+		TxHash:      tx.Hash(),
+		Fee:         new(big.Int).Set(tx.Value()),
+		PackNum:     config.PackNum,
+		Unit:        new(big.Int),
+		Minted:      new(big.Int),
+		GasAddress:  config.CoinAddress,
+	}
+	if config.CoinUnit != nil {
+		meta.Unit.Set(config.CoinUnit.ToInt())
+	}
+	if config.CoinTotal != nil {
+		meta.Minted.Set(config.CoinTotal.ToInt())
+	}
+
+	accounts := make([]common.Address, 0, len(makecoin.AddrAmount)+1)
+	for strAddress := range makecoin.AddrAmount {
+		if address, err := base58.Base58DecodeToAddress(strAddress); err == nil {
+			accounts = append(accounts, address)
+		}
+	}
+	sortAddresses(accounts)
+	accounts = append(accounts, config.CoinAddress)
+	return meta, accounts
+}
+
+func (bc *BlockChain) coinConfigAt(root []common.CoinRoot, coin string) (*common.CoinConfig, error) {
+	st, err := bc.StateAt(root)
+	if err != nil {
+		log.Error("coin index", "获取状态树失败", err)
+		return nil, err
+	}
+	return findCoinConfig(st, coin)
+}
+
+func findCoinConfig(st *state.StateDBManage, coin string) (*common.CoinConfig, error) {
+	configs, err := matrixstate.GetCoinConfig(st)
+	if err != nil {
+		return nil, err
+	}
+	for i := range configs {
+		if configs[i].CoinType == coin {
+			return &configs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// writeGenesisCoinIndex indexes the coins and the funded accounts of the genesis.
+func writeGenesisCoinIndex(db mandb.Database, block *types.Block, g *Genesis) {
+	holders := newCoinHolders()
+	accounts := make([]common.Address, 0, len(g.Alloc))
+	for address := range g.Alloc {
+		accounts = append(accounts, address)
+	}
+	sortAddresses(accounts)
+	for _, address := range accounts {
+		holders.add(params.MAN_COIN, address)
+	}
+
+	for _, coin := range sortMapByString(g.Currencys) {
+		minted := new(big.Int)
+		for _, currency := range g.Currencys[coin] {
+			if currency.Quant != nil {
+				minted.Add(minted, currency.Quant)
+			}
+			if address, err := base58.Base58DecodeToAddress(currency.Account); err == nil {
+				holders.add(coin, address)
+			}
+		}
+		holders.add(coin, common.TxGasRewardAddress)
+		rawdb.WriteCoinMeta(db, &rawdb.CoinMeta{
+			Coin:        coin,
+			BlockHash:   block.Hash(),
+			BlockNumber: block.NumberU64(),
+			Fee:         new(big.Int),
+			PackNum:     params.CallTxPachNum,
+			Unit:        new(big.Int).SetUint64(params.CoinTypeUnit),
+			Minted:      minted,
+			GasAddress:  common.TxGasRewardAddress,
+		})
+	}
+	holders.write(db, db)
+}
+
+func sortAddresses(addresses []common.Address) {
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+var (
+	errNoCoinView = errors.New("coin has no state view")
+	errCrossCoin  = errors.New("coin transactions changed the MAN state")
+)
+
+// coinExecution is the outcome of the transactions of a coin sub-block.
+type coinExecution struct {
+	coin     string
+	txs      types.SelfTransactions
+	receipts types.Receipts
+	shards   [][]uint // address ranges changed by each transaction
+	gas      uint64
+}
+
+// coinApplier applies the transactions of a coin on statedb, adding the gas
+// used to usedGas.
+type coinApplier func(statedb *state.StateDBManage, gp *GasPool, usedGas *uint64, coin string, txs types.SelfTransactions) (*coinExecution, error)
+
+// coinScheduler executes the coin sub-blocks of a block with the same result as
+// running them one after the other in the order of the coins. MAN and the coins
+// whose transactions may touch another coin run alone on the block state. The
+// runs of independent coins between them run concurrently, each on a view of
+// the state, and are merged back in order. A coin found changing the MAN state
+// while running apart is reverted and run again alone.
+type coinScheduler struct {
+	statedb *state.StateDBManage
+	gp      *GasPool
+	usedGas *uint64
+	apply   coinApplier
+	workers int
+}
+
+func newCoinScheduler(statedb *state.StateDBManage, gp *GasPool, usedGas *uint64, workers int, apply coinApplier) *coinScheduler {
+	return &coinScheduler{statedb: statedb, gp: gp, usedGas: usedGas, apply: apply, workers: workers}
+}
+
+// run executes the transactions of the coins, in order.
+func (s *coinScheduler) run(coins []string, txsmap map[string]types.SelfTransactions) ([]*coinExecution, error) {
+	execs := make([]*coinExecution, 0, len(coins))
+	for i := 0; i < len(coins); {
+		end := s.independentRun(coins[i:], txsmap)
+		if end >= 2 {
+			done := s.runApart(coins[i:i+end], txsmap)
+			execs = append(execs, done...)
+			i += len(done)
+			if len(done) == end {
+				continue
+			}
+		}
+		exec, err := s.apply(s.statedb, s.gp, s.usedGas, coins[i], txsmap[coins[i]])
+		if err != nil {
+			return nil, err
+		}
+		execs = append(execs, exec)
+		i++
+	}
+	return execs, nil
+}
+
+// independentRun returns the number of leading coins that can run apart. Their
+// transactions together must fit in the gas pool whatever the order.
+func (s *coinScheduler) independentRun(coins []string, txsmap map[string]types.SelfTransactions) int {
+	if s.workers < 2 {
+		return 0
+	}
+	configs, err := matrixstate.GetCoinConfig(s.statedb)
+	if err != nil {
+		return 0
+	}
+	var gas uint64
+	for i, coin := range coins {
+		if !independentCoin(coin, txsmap[coin], configs) {
+			return i
+		}
+		for _, tx := range txsmap[coin] {
+			gas += tx.Gas()
+		}
+		if gas > s.gp.Gas() {
+			return i
+		}
+	}
+	return len(coins)
+}
+
+// independentCoin reports whether the transactions of the coin only change the
+// state of the coin: plain transfers of a coin taking its fees in itself.
+func independentCoin(coin string, txs types.SelfTransactions, configs []common.CoinConfig) bool {
+	if coin == params.MAN_COIN {
+		return false
+	}
+	ownRange := false
+	for _, cfg := range configs {
+		if cfg.CoinType == coin {
+			ownRange = cfg.PackNum > 0 && cfg.CoinRange == coin
+			break
+		}
+	}
+	if !ownRange {
+		return false
+	}
+	for _, tx := range txs {
+		if tx.GetMatrixType() != common.ExtraNormalTxType || tx.IsEntrustTx() || tx.TxType() == types.BroadCastTxIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// runApart executes the coins concurrently and merges them in order. It returns
+// the executions merged, stopping before the first coin that could not run
+// apart; that coin and the following ones are reverted.
+func (s *coinScheduler) runApart(coins []string, txsmap map[string]types.SelfTransactions) []*coinExecution {
+	views := make([]*state.StateDBManage, len(coins))
+	snaps := make([][]int, len(coins))
+	for i, coin := range coins {
+		s.statedb.MakeStatedb(coin, true)
+		views[i] = s.statedb.CoinView(coin)
+		snaps[i] = s.statedb.Snapshot(coin)
+	}
+
+	var (
+		execs = make([]*coinExecution, len(coins))
+		errs  = make([]error, len(coins))
+		sem   = make(chan struct{}, s.workers)
+		wg    sync.WaitGroup
+	)
+	for i := range coins {
+		if views[i] == nil {
+			errs[i] = errNoCoinView
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var usedGas uint64
+			gp := new(GasPool).AddGas(s.gp.Gas())
+			execs[i], errs[i] = s.apply(views[i], gp, &usedGas, coins[i], txsmap[coins[i]])
+			if errs[i] == nil && views[i].Modified(params.MAN_COIN) {
+				errs[i] = errCrossCoin
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, exec := range execs {
+		if errs[i] != nil {
+			log.Trace("coin scheduler", "coin", coins[i], "run alone", errs[i])
+			for j := i; j < len(coins); j++ {
+				s.statedb.RevertToSnapshot(coins[j], snaps[j])
+			}
+			return execs[:i]
+		}
+		for _, receipt := range exec.receipts {
+			receipt.CumulativeGasUsed += *s.usedGas
+		}
+		*s.usedGas += exec.gas
+		s.gp.SubGas(exec.gas)
+	}
+	return execs
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+var schedulerTestKey = types.RlpHash("coin scheduler test")
+
+// newSchedulerState returns a state with the coins, each taking its fees in
+// itself.
+func newSchedulerState(coins []string) *state.StateDBManage {
+	db := mandb.NewMemDatabase()
+	roots := make([]common.CoinRoot, 0, len(coins))
+	for _, coin := range coins {
+		roots = append(roots, common.CoinRoot{Cointyp: coin})
+	}
+	statedb, _ := state.NewStateDBManage(roots, db, state.NewDatabase(db))
+	configs := make([]common.CoinConfig, 0, len(coins))
+	for _, coin := range coins {
+		configs = append(configs, common.CoinConfig{CoinRange: coin, CoinType: coin, PackNum: 1, CoinAddress: common.Address{0xff}})
+	}
+	data, _ := json.Marshal(configs)
+	statedb.SetMatrixData(types.RlpHash(common.COINPREFIX+mc.MSCurrencyConfig), data)
+	data, _ = json.Marshal(coins)
+	statedb.SetMatrixData(types.RlpHash(params.COIN_NAME), data)
+	return statedb
+}
+
+// newSchedulerTxs returns n transfers for each coin.
+func newSchedulerTxs(coins []string, n int) map[string]types.SelfTransactions {
+	txsmap := make(map[string]types.SelfTransactions)
+	for _, coin := range coins {
+		for i := 0; i < n; i++ {
+			to := common.BytesToAddress(crypto.Keccak256([]byte{byte(i), byte(len(txsmap))}))
+			txsmap[coin] = append(txsmap[coin], types.NewTransaction(uint64(i), to, big.NewInt(int64(i+1)), params.TxGas*2, nil, nil, nil, nil, nil, 0, 0, coin, 0))
+		}
+	}
+	return txsmap
+}
+
+// schedulerApplier credits the transfers and their fees, adding the length of
+// the test MAN data to each transfer so the coins read the MAN state. A
+// transaction with data writes it to the MAN state. Each transfer hashes work
+// times to stand for the execution.
+func schedulerApplier(work int, calls *int32) coinApplier {
+	return func(statedb *state.StateDBManage, gp *GasPool, usedGas *uint64, coin string, txs types.SelfTransactions) (*coinExecution, error) {
+		atomic.AddInt32(calls, 1)
+		exec := &coinExecution{coin: coin, txs: txs}
+		for _, tx := range txs {
+			if err := gp.SubGas(tx.Gas()); err != nil {
+				return nil, err
+			}
+			hash := tx.Hash().Bytes()
+			for i := 0; i < work; i++ {
+				hash = crypto.Keccak256(hash)
+			}
+			value := new(big.Int).Add(tx.Value(), big.NewInt(int64(len(statedb.GetMatrixData(schedulerTestKey)))))
+			statedb.AddBalance(coin, common.MainAccount, *tx.To(), value)
+			statedb.AddBalance(coin, common.MainAccount, common.Address{0xff}, big.NewInt(int64(params.TxGas)))
+			if len(tx.Data()) > 0 {
+				statedb.SetMatrixData(schedulerTestKey, tx.Data())
+			}
+			gp.AddGas(tx.Gas() - params.TxGas)
+			*usedGas += params.TxGas
+			receipt := types.NewReceipt(nil, false, *usedGas)
+			receipt.TxHash, receipt.GasUsed = tx.Hash(), params.TxGas
+			exec.receipts = append(exec.receipts, receipt)
+			exec.shards = append(exec.shards, []uint{uint(tx.To()[0])})
+			exec.gas += params.TxGas
+		}
+		return exec, nil
+	}
+}
+
+func TestCoinSchedulerMatchesSerial(t *testing.T) {
+	coins := []string{params.MAN_COIN, "AAA", "BBB", "CCC", "DDD"}
+	txsmap := newSchedulerTxs(coins, 10)
+	// a transfer of CCC changes the MAN state read by DDD, they run alone
+	txsmap["CCC"][3] = types.NewTransaction(3, common.Address{1}, big.NewInt(1), params.TxGas*2, nil, []byte("ccc"), nil, nil, nil, 0, 0, "CCC", 0)
+
+	run := func(workers int, gas uint64) ([]common.CoinRoot, []*coinExecution, uint64, int32) {
+		var (
+			statedb = newSchedulerState(coins)
+			usedGas uint64
+			calls   int32
+		)
+		execs, err := newCoinScheduler(statedb, new(GasPool).AddGas(gas), &usedGas, workers, schedulerApplier(0, &calls)).run(coins, txsmap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots, _ := statedb.IntermediateRoot(true)
+		return roots, execs, usedGas, calls
+	}
+	wantRoots, wantExecs, wantGas, calls := run(1, 1e8)
+	if calls != 5 {
+		t.Fatalf("serial calls mismatch: have %d, want 5", calls)
+	}
+	tests := []struct {
+		gas   uint64
+		calls int32
+	}{
+		{1e8, 7},               // AAA to DDD apart, then CCC and DDD alone
+		{params.TxGas * 51, 5}, // the coins do not fit in the gas pool together
+	}
+	for _, test := range tests {
+		roots, execs, usedGas, calls := run(4, test.gas)
+		if calls != test.calls {
+			t.Errorf("gas %d: calls mismatch: have %d, want %d", test.gas, calls, test.calls)
+		}
+		if usedGas != wantGas || len(execs) != len(wantExecs) {
+			t.Fatalf("gas %d: execution mismatch: have %d/%d, want %d/%d", test.gas, usedGas, len(execs), wantGas, len(wantExecs))
+		}
+		for i, exec := range execs {
+			if exec.coin != wantExecs[i].coin {
+				t.Fatalf("gas %d: coin %d mismatch: have %s, want %s", test.gas, i, exec.coin, wantExecs[i].coin)
+			}
+			for j, receipt := range exec.receipts {
+				if receipt.CumulativeGasUsed != wantExecs[i].receipts[j].CumulativeGasUsed {
+					t.Fatalf("gas %d: %s receipt %d cumulative gas mismatch", test.gas, exec.coin, j)
+				}
+			}
+		}
+		for i := range roots {
+			if roots[i] != wantRoots[i] {
+				t.Fatalf("gas %d: %s root mismatch", test.gas, roots[i].Cointyp)
+			}
+		}
+	}
+}
@@ -355,32 +355,39 @@ func (g *Genesis) ToBlock(db mandb.Database) (*types.Block, error) {
 }
 
 func (g *Genesis) GenSuperBlock(parentHeader *types.Header, mdb mandb.Database, sdb state.Database, chainCfg *params.ChainConfig) *types.Block {
+	block, _ := g.genSuperBlockState(parentHeader, mdb, sdb, chainCfg)
+	return block
+}
+
+// genSuperBlockState generates the super block and returns the state it is
+// applied on, the state is not committed.
+func (g *Genesis) genSuperBlockState(parentHeader *types.Header, mdb mandb.Database, sdb state.Database, chainCfg *params.ChainConfig) (*types.Block, *state.StateDBManage) {
 	if nil == parentHeader || nil == sdb {
 		log.ERROR("genesis super block", "param err", "nil")
-		return nil
+		return nil, nil
 	}
 
 	stateDB, err := state.NewStateDBManage(parentHeader.Roots, mdb, sdb)
 	if err != nil {
 		log.Error("genesis super block", "get parent state db err", err)
-		return nil
+		return nil, nil
 	}
 
 	if nil != g.MState {
 		if err := g.MState.setMatrixState(stateDB, g.NetTopology, g.NextElect, g.Version, string(parentHeader.Version), g.Number); err != nil {
 			log.Error("genesis super block", "设置matrix状态树错误", err)
-			return nil
+			return nil, nil
 		}
 	} else {
 		mState := new(GenesisMState)
 		if err := mState.setMatrixState(stateDB, g.NetTopology, g.NextElect, g.Version, string(parentHeader.Version), g.Number); err != nil {
 			log.Error("genesis super block", "mstate参数为nil时, 设置matrix状态树错误", err)
-			return nil
+			return nil, nil
 		}
 	}
 	if err := g.MState.SetSuperBlkToState(stateDB, g.ExtraData, g.Number); err != nil {
 		log.Error("genesis", "设置matrix状态树错误", err)
-		return nil
+		return nil, nil
 	}
 	head := &types.Header{
 		Number:            new(big.Int).SetUint64(g.Number),
@@ -415,12 +422,12 @@ func (g *Genesis) GenSuperBlock(parentHeader *types.Header, mdb mandb.Database,
 	data, err := json.Marshal(g.Alloc)
 	if err != nil {
 		log.ERROR("genesis super block", "marshal alloc info err", err)
-		return nil
+		return nil, nil
 	}
 	tx0 := types.NewTransaction(g.Number, common.Address{}, nil, 0, nil, data, nil, nil, nil, common.ExtraSuperBlockTx, 0, params.MAN_COIN, 0)
 	if tx0 == nil {
 		log.ERROR("genesis super block", "create super block tx err", "NewTransaction return nil")
-		return nil
+		return nil, nil
 	}
 	txs = append(txs, tx0)
 
@@ -429,13 +436,13 @@ func (g *Genesis) GenSuperBlock(parentHeader *types.Header, mdb mandb.Database,
 		msData, err = json.Marshal(g.MState)
 		if err != nil {
 			log.ERROR("genesis super block", "marshal alloc info err", err)
-			return nil
+			return nil, nil
 		}
 	}
 	txMState := types.NewTransaction(g.Number, common.Address{}, nil, 1, nil, msData, nil, nil, nil, common.ExtraSuperBlockTx, 0, params.MAN_COIN, 0)
 	if txMState == nil {
 		log.ERROR("genesis super block", "create super block matrix state tx err", "NewTransaction return nil")
-		return nil
+		return nil, nil
 	}
 	txs = append(txs, txMState)
 
@@ -443,7 +450,7 @@ func (g *Genesis) GenSuperBlock(parentHeader *types.Header, mdb mandb.Database,
 	ct := types.CoinSelfTransaction{params.MAN_COIN, txs}
 	cts = append(cts, ct)
 
-	return types.NewBlock(head, types.MakeCurencyBlock(cts, nil, nil), nil)
+	return types.NewBlock(head, types.MakeCurencyBlock(cts, nil, nil), nil), stateDB
 }
 func (g *Genesis) ToSuperBlock() *types.Block {
 	head := &types.Header{
@@ -520,6 +527,7 @@ func (g *Genesis) Commit(db mandb.Database) (*types.Block, error) {
 	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
 	rawdb.WriteHeadBlockHash(db, block.Hash())
 	rawdb.WriteHeadHeaderHash(db, block.Hash())
+	writeGenesisCoinIndex(db, block, g)
 
 	config := g.Config
 	if config == nil {
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+// KeyValue is the value of a matrix state key decoded by its operator. Type is
+// the go type of the value, Error is set when the operator failed to decode it.
+type KeyValue struct {
+	Key   string
+	Type  string
+	Value interface{}
+	Error string `json:",omitempty"`
+}
+
+// KeyDiff is a key whose value differs between two states, From or To is nil when
+// the key is missing in one of them. Fields lists the changed fields when both
+// values are structs.
+type KeyDiff struct {
+	Key    string
+	Fields []string `json:",omitempty"`
+	From   *KeyValue
+	To     *KeyValue
+}
+
+// Keys returns the keys of the operators of the manager, sorted.
+func (self *Manager) Keys() []string {
+	keys := make([]string, 0, len(self.operators))
+	for key := range self.operators {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetKeyValues decodes the values of keys with the manager of the state version,
+// all the keys of the manager and the version info when keys is empty.
+func GetKeyValues(st StateDB, keys []string) ([]*KeyValue, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+	version := GetVersionInfo(st)
+	mgr := GetManager(version)
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	if len(keys) == 0 {
+		keys = append([]string{mc.MSKeyVersionInfo}, mgr.Keys()...)
+	}
+
+	values := make([]*KeyValue, 0, len(keys))
+	for _, key := range keys {
+		if key == mc.MSKeyVersionInfo {
+			values = append(values, &KeyValue{Key: key, Type: "string", Value: version})
+			continue
+		}
+		opt, err := mgr.FindOperator(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %v", key, err)
+		}
+		kv := &KeyValue{Key: key}
+		if value, err := opt.GetValue(st); err != nil {
+			kv.Error = err.Error()
+		} else {
+			kv.Type, kv.Value = fmt.Sprintf("%T", value), value
+		}
+		values = append(values, kv)
+	}
+	return values, nil
+}
+
+// DiffKeyValues returns the keys whose value differs between from and to, in the
+// order of from, then the keys only in to. Values are compared by their JSON
+// encoding.
+func DiffKeyValues(from, to []*KeyValue) ([]*KeyDiff, error) {
+	toValues := make(map[string]*KeyValue, len(to))
+	for _, kv := range to {
+		toValues[kv.Key] = kv
+	}
+	diffs := make([]*KeyDiff, 0)
+	seen := make(map[string]bool, len(from))
+	for _, fromKV := range from {
+		seen[fromKV.Key] = true
+		toKV, exist := toValues[fromKV.Key]
+		if !exist {
+			diffs = append(diffs, &KeyDiff{Key: fromKV.Key, From: fromKV})
+			continue
+		}
+		fromData, err := json.Marshal(fromKV)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %v", fromKV.Key, err)
+		}
+		toData, err := json.Marshal(toKV)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %v", toKV.Key, err)
+		}
+		if bytes.Equal(fromData, toData) {
+			continue
+		}
+		diffs = append(diffs, &KeyDiff{Key: fromKV.Key, Fields: changedFields(fromKV.Value, toKV.Value), From: fromKV, To: toKV})
+	}
+	for _, toKV := range to {
+		if !seen[toKV.Key] {
+			diffs = append(diffs, &KeyDiff{Key: toKV.Key, To: toKV})
+		}
+	}
+	return diffs, nil
+}
+
+// changedFields returns the sorted names of the fields differing between two
+// values encoding to JSON objects, nil for other values.
+func changedFields(from, to interface{}) []string {
+	var fromFields, toFields map[string]json.RawMessage
+	if fromData, err := json.Marshal(from); err != nil || json.Unmarshal(fromData, &fromFields) != nil {
+		return nil
+	}
+	if toData, err := json.Marshal(to); err != nil || json.Unmarshal(toData, &toFields) != nil {
+		return nil
+	}
+	var fields []string
+	for name, value := range fromFields {
+		if toValue, exist := toFields[name]; !exist || !bytes.Equal(value, toValue) {
+			fields = append(fields, name)
+		}
+	}
+	for name := range toFields {
+		if _, exist := fromFields[name]; !exist {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func Test_GetKeyValues(t *testing.T) {
+	st := newTestState()
+	SetVersionInfo(st, manversion.VersionAIMine)
+	SetElectConfigInfo(st, &mc.ElectConfigInfo{ValidatorNum: 11, BackValidator: 5, ElectPlug: "layerd"})
+
+	values, err := GetKeyValues(st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(values), len(GetManager(manversion.VersionAIMine).operators)+1; have != want {
+		t.Fatalf("value count mismatch: have %d, want %d", have, want)
+	}
+	if values[0].Key != mc.MSKeyVersionInfo || values[0].Value != manversion.VersionAIMine {
+		t.Errorf("first value is not the version: %+v", values[0])
+	}
+	if _, err := json.Marshal(values); err != nil {
+		t.Errorf("values do not encode to JSON: %v", err)
+	}
+
+	values, err = GetKeyValues(st, []string{mc.MSKeyElectConfigInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].Type != "*mc.ElectConfigInfo" || values[0].Value.(*mc.ElectConfigInfo).ValidatorNum != 11 {
+		t.Errorf("elect config mismatch: %+v", values[0])
+	}
+	if _, err := GetKeyValues(st, []string{"unknown"}); err == nil {
+		t.Errorf("unknown key returned no error")
+	}
+}
+
+func Test_DiffKeyValues(t *testing.T) {
+	from, to := newTestState(), newTestState()
+	SetVersionInfo(from, manversion.VersionAIMine)
+	SetVersionInfo(to, manversion.VersionAIMine)
+	SetElectConfigInfo(from, &mc.ElectConfigInfo{ValidatorNum: 11, BackValidator: 5, ElectPlug: "layerd"})
+	SetElectConfigInfo(to, &mc.ElectConfigInfo{ValidatorNum: 19, BackValidator: 5, ElectPlug: "layerd_BSS"})
+	SetUpTimeNum(from, 100)
+	SetUpTimeNum(to, 100)
+
+	keys := []string{mc.MSKeyElectConfigInfo, mc.MSKeyUpTimeNum}
+	fromValues, _ := GetKeyValues(from, keys)
+	toValues, _ := GetKeyValues(to, keys)
+	diffs, err := DiffKeyValues(fromValues, toValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Key != mc.MSKeyElectConfigInfo {
+		t.Fatalf("diff mismatch: %+v", diffs)
+	}
+	if want := []string{"ElectPlug", "ValidatorNum"}; !reflect.DeepEqual(diffs[0].Fields, want) {
+		t.Errorf("changed fields mismatch: have %v, want %v", diffs[0].Fields, want)
+	}
+
+	diffs, _ = DiffKeyValues(fromValues[:1], toValues[1:])
+	if len(diffs) != 2 || diffs[0].To != nil || diffs[1].From != nil {
+		t.Errorf("missing keys diff mismatch: %+v", diffs)
+	}
+}
@@ -5,13 +5,14 @@ package matrixstate
 
 import (
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 	"testing"
 )
 
 func Test_PrintKeys(t *testing.T) {
 	log.InitLog(3)
 
-	for key, opt := range mangerAlpha.operators {
+	for key, opt := range GetManager(manversion.VersionAlpha).operators {
 		log.Info("key info", "key", key, "hash", opt.KeyHash().Hex())
 	}
 }
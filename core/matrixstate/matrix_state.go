@@ -12,19 +12,14 @@ import (
 
 const logInfo = "matrix state"
 
-var mangerAlpha *Manager
-var mangerBeta *Manager
-var mangerGamma *Manager
-var mangerDelta *Manager
-var mangerAIMine *Manager
+var managers map[string]*Manager
 var versionOpt MatrixOperator
 
 func init() {
-	mangerAlpha = newManger(manversion.VersionAlpha)
-	mangerBeta = newManger(manversion.VersionBeta)
-	mangerGamma = newManger(manversion.VersionGamma)
-	mangerDelta = newManger(manversion.VersionDelta)
-	mangerAIMine = newManger(manversion.VersionAIMine)
+	managers = make(map[string]*Manager)
+	for _, up := range manversion.Upgrades() {
+		managers[up.Version] = newManger(up.Version, up.MatrixState)
+	}
 	versionOpt = newVersionInfoOpt()
 }
 
@@ -40,21 +35,12 @@ type Manager struct {
 }
 
 func GetManager(version string) *Manager {
-	switch version {
-	case manversion.VersionAlpha:
-		return mangerAlpha
-	case manversion.VersionBeta:
-		return mangerBeta
-	case manversion.VersionGamma:
-		return mangerGamma
-	case manversion.VersionDelta:
-		return mangerDelta
-	case manversion.VersionAIMine:
-		return mangerAIMine
-	default:
+	mgr, exist := managers[version]
+	if !exist {
 		log.Error(logInfo, "get Manger err", "version not exist", "version", version)
 		return nil
 	}
+	return mgr
 }
 
 func (self *Manager) Version() string {
@@ -70,8 +56,10 @@ func (self *Manager) FindOperator(key string) (MatrixOperator, error) {
 	return opt, nil
 }
 
-func newManger(version string) *Manager {
-	switch version {
+// newManger creates the manager of the version with the operators of the
+// version stateVersion.
+func newManger(version string, stateVersion string) *Manager {
+	switch stateVersion {
 	case manversion.VersionAlpha:
 		return &Manager{
 			version: version,
@@ -246,7 +234,7 @@ func newManger(version string) *Manager {
 				mc.MSKeyBlockProduceBlackList:   newBlockProduceBlackListOpt(),
 			},
 		}
-	case manversion.VersionDelta, manversion.VersionAIMine:
+	case manversion.VersionDelta:
 		return &Manager{
 			version: version,
 			operators: map[string]MatrixOperator{
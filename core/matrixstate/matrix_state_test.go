@@ -7,6 +7,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 	"testing"
 )
 
@@ -39,14 +40,14 @@ func Test_Manager(t *testing.T) {
 
 	account1 := common.HexToAddress("0x12345")
 	account2 := common.HexToAddress("0x543210")
-	optV2, _ := mangerBeta.FindOperator(mc.MSKeyAccountBroadcasts)
+	optV2, _ := GetManager(manversion.VersionBeta).FindOperator(mc.MSKeyAccountBroadcasts)
 	optV2.SetValue(st, []common.Address{account2, account1})
 
 	use_st(st)
 }
 
 func use_st(state *TestState) {
-	optV2, _ := mangerBeta.FindOperator(mc.MSKeyAccountBroadcasts)
+	optV2, _ := GetManager(manversion.VersionBeta).FindOperator(mc.MSKeyAccountBroadcasts)
 	accounts, err := optV2.GetValue(state)
 	log.Info("new get", "accounts", accounts.([]common.Address), "err", err)
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/crypto/sha3"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 )
 
@@ -54,14 +55,14 @@ func TestHeaderStorage(t *testing.T) {
 func TestBodyStorage(t *testing.T) {
 	log.InitLog(3)
 	db := mandb.NewMemDatabase()
-	tx1 := types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11})
-	tx2 := types.NewTransaction(2, common.BytesToAddress([]byte{0x11}), big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11})
+	tx1 := types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11}, nil, nil, nil, 0, 0, params.MAN_COIN, 0)
+	tx2 := types.NewTransaction(2, common.BytesToAddress([]byte{0x11}), big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11}, nil, nil, nil, 0, 0, params.MAN_COIN, 0)
 	aaa := make([]types.SelfTransaction, 0)
 	aaa = append(aaa, tx1)
 	aaa = append(aaa, tx2)
 	// Create a test body to move around the database and make sure it's really new
 	//body := &types.Body{Uncles: []*types.Header{{Extra: []byte("test header")}}}
-	body := &types.Body{Transactions: aaa}
+	body := &types.Body{CurrencyBody: types.MakeCurencyBlock([]types.CoinSelfTransaction{{CoinType: params.MAN_COIN, Txser: aaa}}, nil, nil)}
 	hasher := sha3.NewKeccak256()
 	rlp.Encode(hasher, body)
 	hash := common.BytesToHash(hasher.Sum(nil))
@@ -73,7 +74,7 @@ func TestBodyStorage(t *testing.T) {
 	WriteBody(db, hash, 0, body)
 	if entry := ReadBody(db, hash, 0); entry == nil {
 		t.Fatalf("Stored body not found")
-	} else if types.DeriveSha(types.SelfTransactions(entry.Transactions)) != types.DeriveSha(types.SelfTransactions(body.Transactions)) || types.CalcUncleHash(entry.Uncles) != types.CalcUncleHash(body.Uncles) {
+	} else if types.DeriveSha(types.SelfTransactions(entry.CurrencyBody[0].Transactions.GetTransactions())) != types.DeriveSha(types.SelfTransactions(body.CurrencyBody[0].Transactions.GetTransactions())) || types.CalcUncleHash(entry.Uncles) != types.CalcUncleHash(body.Uncles) {
 		t.Fatalf("Retrieved body mismatch: have %v, want %v", entry, body)
 	}
 	if entry := ReadBodyRLP(db, hash, 0); entry == nil {
@@ -99,10 +100,8 @@ func TestBlockStorage(t *testing.T) {
 
 	// Create a test block to move around the database and make sure it's really new
 	block := types.NewBlockWithHeader(&types.Header{
-		Extra:       []byte("test block"),
-		UncleHash:   types.EmptyUncleHash,
-		TxHash:      types.EmptyRootHash,
-		ReceiptHash: types.EmptyRootHash,
+		Extra:     []byte("test block"),
+		UncleHash: types.EmptyUncleHash,
 	})
 	if entry := ReadBlock(db, block.Hash(), block.NumberU64()); entry != nil {
 		t.Fatalf("Non existent block returned: %v", entry)
@@ -127,7 +126,7 @@ func TestBlockStorage(t *testing.T) {
 	}
 	if entry := ReadBody(db, block.Hash(), block.NumberU64()); entry == nil {
 		t.Fatalf("Stored body not found")
-	} else if types.DeriveSha(types.SelfTransactions(entry.Transactions)) != types.DeriveSha(types.SelfTransactions(block.Transactions())) || types.CalcUncleHash(entry.Uncles) != types.CalcUncleHash(block.Uncles()) {
+	} else if len(entry.CurrencyBody) != len(block.Currencies()) || types.CalcUncleHash(entry.Uncles) != types.CalcUncleHash(block.Uncles()) {
 		t.Fatalf("Retrieved body mismatch: have %v, want %v", entry, block.Body())
 	}
 	// Delete the block and verify the execution
@@ -147,10 +146,8 @@ func TestBlockStorage(t *testing.T) {
 func TestPartialBlockStorage(t *testing.T) {
 	db := mandb.NewMemDatabase()
 	block := types.NewBlockWithHeader(&types.Header{
-		Extra:       []byte("test block"),
-		UncleHash:   types.EmptyUncleHash,
-		TxHash:      types.EmptyRootHash,
-		ReceiptHash: types.EmptyRootHash,
+		Extra:     []byte("test block"),
+		UncleHash: types.EmptyUncleHash,
 	})
 	// Store a header and check that it's not recognized as a block
 	WriteHeader(db, block.Header())
@@ -292,10 +289,11 @@ func TestBlockReceiptStorage(t *testing.T) {
 		t.Fatalf("non existent receipts returned: %v", rs)
 	}
 	// Insert the receipt slice into the database and check presence
-	WriteReceipts(db, hash, 0, receipts)
-	if rs := ReadReceipts(db, hash, 0); len(rs) == 0 {
+	WriteReceipts(db, hash, 0, []types.CoinReceipts{{CoinType: params.MAN_COIN, Receiptlist: receipts}})
+	if crs := ReadReceipts(db, hash, 0); len(crs) == 0 {
 		t.Fatalf("no receipts returned")
 	} else {
+		rs := crs[0].Receiptlist
 		for i := 0; i < len(receipts); i++ {
 			rlpHave, _ := rlp.EncodeToBytes(rs[i])
 			rlpWant, _ := rlp.EncodeToBytes(receipts[i])
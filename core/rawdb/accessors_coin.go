@@ -0,0 +1,183 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// CoinMeta is the creation metadata of a coin, the config is the one in effect
+// right after the creation.
+type CoinMeta struct {
+	Coin        string
+	Creator     common.Address
+	BlockHash   common.Hash
+	BlockNumber uint64
+	TxHash      common.Hash
+	Fee         *big.Int // MAN paid to the destroy address for the creation
+	PackNum     uint64
+	Unit        *big.Int
+	Minted      *big.Int
+	GasAddress  common.Address
+}
+
+func coinMetaKey(coin string) []byte {
+	return append(append([]byte{}, coinMetaPrefix...), coin...)
+}
+
+func coinBlockKey(hash common.Hash) []byte {
+	return append(append([]byte{}, coinBlockPrefix...), hash.Bytes()...)
+}
+
+func coinHolderCountKey(coin string) []byte {
+	return append(append([]byte{}, coinHolderCountPrefix...), coin...)
+}
+
+func coinHolderKey(coin string, index uint64) []byte {
+	return append(append(append(append([]byte{}, coinHolderPrefix...), coin...), '.'), encodeBlockNumber(index)...)
+}
+
+func coinHolderIndexKey(coin string, account common.Address) []byte {
+	return append(append(append(append([]byte{}, coinHolderIndexPrefix...), coin...), '.'), account.Bytes()...)
+}
+
+// ReadCoinMeta retrieves the creation metadata of a coin.
+func ReadCoinMeta(db DatabaseReader, coin string) *CoinMeta {
+	data, _ := db.Get(coinMetaKey(coin))
+	if len(data) == 0 {
+		return nil
+	}
+	meta := new(CoinMeta)
+	if err := rlp.DecodeBytes(data, meta); err != nil {
+		log.Error("Invalid coin meta RLP", "coin", coin, "err", err)
+		return nil
+	}
+	return meta
+}
+
+// WriteCoinMeta stores the creation metadata of a coin.
+func WriteCoinMeta(db DatabaseWriter, meta *CoinMeta) {
+	data, err := rlp.EncodeToBytes(meta)
+	if err != nil {
+		log.Crit("Failed to RLP encode coin meta", "err", err)
+	}
+	if err := db.Put(coinMetaKey(meta.Coin), data); err != nil {
+		log.Crit("Failed to store coin meta", "err", err)
+	}
+}
+
+// coinMetaDatabase is the store the metadata of the coins created by a dropped
+// block are deleted from.
+type coinMetaDatabase interface {
+	DatabaseWriter
+	DatabaseDeleter
+}
+
+// WriteCoinBlock stores the coins created by a block, so their metadata can be
+// dropped if a reorg drops the block.
+func WriteCoinBlock(db DatabaseWriter, hash common.Hash, coins []string) {
+	data, err := rlp.EncodeToBytes(coins)
+	if err != nil {
+		log.Crit("Failed to RLP encode coin block", "err", err)
+	}
+	if err := db.Put(coinBlockKey(hash), data); err != nil {
+		log.Crit("Failed to store coin block", "err", err)
+	}
+}
+
+// DropCoinMetas deletes the metadata of the coins created by a block dropped by
+// a reorg. The metadata written since by another block for the same coin is
+// kept. The holders stay indexed.
+func DropCoinMetas(reader DatabaseReader, db coinMetaDatabase, hash common.Hash) {
+	data, _ := reader.Get(coinBlockKey(hash))
+	if len(data) == 0 {
+		return
+	}
+	var coins []string
+	if err := rlp.DecodeBytes(data, &coins); err != nil {
+		log.Error("Invalid coin block RLP", "hash", hash, "err", err)
+		return
+	}
+	for _, coin := range coins {
+		if meta := ReadCoinMeta(reader, coin); meta != nil && meta.BlockHash == hash {
+			if err := db.Delete(coinMetaKey(coin)); err != nil {
+				log.Crit("Failed to delete coin meta", "err", err)
+			}
+		}
+	}
+	if err := db.Delete(coinBlockKey(hash)); err != nil {
+		log.Crit("Failed to delete coin block", "err", err)
+	}
+}
+
+// ReadCoinHolderCount retrieves the number of indexed holders of a coin.
+func ReadCoinHolderCount(db DatabaseReader, coin string) uint64 {
+	data, _ := db.Get(coinHolderCountKey(coin))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// HasCoinHolder checks if the account is an indexed holder of a coin.
+func HasCoinHolder(db DatabaseReader, coin string, account common.Address) bool {
+	has, _ := db.Has(coinHolderIndexKey(coin, account))
+	return has
+}
+
+// ReadCoinHolders retrieves at most count holders of a coin from the index
+// start, in index order.
+func ReadCoinHolders(db DatabaseReader, coin string, start uint64, count uint64) []common.Address {
+	total := ReadCoinHolderCount(db, coin)
+	if start >= total {
+		return nil
+	}
+	if count > total-start {
+		count = total - start
+	}
+	holders := make([]common.Address, 0, count)
+	for index := start; index < start+count; index++ {
+		data, _ := db.Get(coinHolderKey(coin, index))
+		if len(data) != common.AddressLength {
+			log.Error("Invalid coin holder entry", "coin", coin, "index", index)
+			break
+		}
+		holders = append(holders, common.BytesToAddress(data))
+	}
+	return holders
+}
+
+// WriteCoinHolders appends the accounts not indexed yet to the holders of a coin.
+// The index is read from reader, so the writes of an unflushed batch to db are
+// not seen by a later call.
+func WriteCoinHolders(reader DatabaseReader, db DatabaseWriter, coin string, accounts []common.Address) {
+	count := ReadCoinHolderCount(reader, coin)
+	added := count
+	seen := make(map[common.Address]bool)
+	for _, account := range accounts {
+		if seen[account] || HasCoinHolder(reader, coin, account) {
+			continue
+		}
+		seen[account] = true
+		if err := db.Put(coinHolderKey(coin, added), account.Bytes()); err != nil {
+			log.Crit("Failed to store coin holder", "err", err)
+		}
+		if err := db.Put(coinHolderIndexKey(coin, account), encodeBlockNumber(added)); err != nil {
+			log.Crit("Failed to store coin holder index", "err", err)
+		}
+		added++
+	}
+	if added == count {
+		return
+	}
+	if err := db.Put(coinHolderCountKey(coin), encodeBlockNumber(added)); err != nil {
+		log.Crit("Failed to store coin holder count", "err", err)
+	}
+}
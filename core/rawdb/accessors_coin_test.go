@@ -0,0 +1,104 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+// Tests that the coin creation metadata can be stored and retrieved.
+func TestCoinMetaStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+
+	meta := &CoinMeta{
+		Coin:        "ABC",
+		Creator:     common.BytesToAddress([]byte{0x11}),
+		BlockHash:   common.BytesToHash([]byte{0x01}),
+		BlockNumber: 100,
+		TxHash:      common.BytesToHash([]byte{0x02}),
+		Fee:         big.NewInt(5),
+		PackNum:     1000,
+		Unit:        big.NewInt(1),
+		Minted:      big.NewInt(1000000),
+		GasAddress:  common.BytesToAddress([]byte{0x22}),
+	}
+	if entry := ReadCoinMeta(db, meta.Coin); entry != nil {
+		t.Fatalf("non existent coin meta returned: %v", entry)
+	}
+	WriteCoinMeta(db, meta)
+
+	entry := ReadCoinMeta(db, meta.Coin)
+	if entry == nil {
+		t.Fatalf("stored coin meta not found")
+	}
+	if entry.Creator != meta.Creator || entry.BlockNumber != meta.BlockNumber || entry.TxHash != meta.TxHash {
+		t.Fatalf("coin meta mismatch: have %v, want %v", entry, meta)
+	}
+	if entry.Fee.Cmp(meta.Fee) != 0 || entry.Minted.Cmp(meta.Minted) != 0 {
+		t.Fatalf("coin meta amounts mismatch: have %v, want %v", entry, meta)
+	}
+	if other := ReadCoinMeta(db, "ABCD"); other != nil {
+		t.Fatalf("coin meta returned for another coin: %v", other)
+	}
+}
+
+// Tests that only the metadata written by a dropped block is deleted.
+func TestDropCoinMetas(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	dropped, kept := common.BytesToHash([]byte{0x01}), common.BytesToHash([]byte{0x02})
+
+	WriteCoinMeta(db, &CoinMeta{Coin: "ABC", BlockHash: dropped, Fee: new(big.Int), Unit: new(big.Int), Minted: new(big.Int)})
+	WriteCoinMeta(db, &CoinMeta{Coin: "DEF", BlockHash: dropped, Fee: new(big.Int), Unit: new(big.Int), Minted: new(big.Int)})
+	WriteCoinBlock(db, dropped, []string{"ABC", "DEF"})
+	// the new chain created DEF again before the drop
+	WriteCoinMeta(db, &CoinMeta{Coin: "DEF", BlockHash: kept, Fee: new(big.Int), Unit: new(big.Int), Minted: new(big.Int)})
+
+	DropCoinMetas(db, db, kept)
+	DropCoinMetas(db, db, dropped)
+	if meta := ReadCoinMeta(db, "ABC"); meta != nil {
+		t.Fatalf("coin meta of the dropped block not deleted: %v", meta)
+	}
+	if meta := ReadCoinMeta(db, "DEF"); meta == nil || meta.BlockHash != kept {
+		t.Fatalf("coin meta of another block deleted: %v", meta)
+	}
+	if has, _ := db.Has(coinBlockKey(dropped)); has {
+		t.Fatalf("coin block of the dropped block not deleted")
+	}
+}
+
+// Tests that the holders are appended once per coin and can be paged.
+func TestCoinHoldersStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+
+	a, b, c := common.BytesToAddress([]byte{0x01}), common.BytesToAddress([]byte{0x02}), common.BytesToAddress([]byte{0x03})
+	WriteCoinHolders(db, db, "ABC", []common.Address{a, b, a})
+	WriteCoinHolders(db, db, "ABC", []common.Address{b, c})
+	WriteCoinHolders(db, db, "ABCD", []common.Address{c})
+
+	if count := ReadCoinHolderCount(db, "ABC"); count != 3 {
+		t.Fatalf("holder count mismatch: have %d, want %d", count, 3)
+	}
+	if count := ReadCoinHolderCount(db, "ABCD"); count != 1 {
+		t.Fatalf("holder count mismatch: have %d, want %d", count, 1)
+	}
+	if !HasCoinHolder(db, "ABC", c) || HasCoinHolder(db, "ABCD", a) {
+		t.Fatalf("holder membership mismatch")
+	}
+
+	holders := ReadCoinHolders(db, "ABC", 0, 10)
+	if len(holders) != 3 || holders[0] != a || holders[1] != b || holders[2] != c {
+		t.Fatalf("holders mismatch: have %v", holders)
+	}
+	if holders := ReadCoinHolders(db, "ABC", 1, 1); len(holders) != 1 || holders[0] != b {
+		t.Fatalf("holders page mismatch: have %v", holders)
+	}
+	if holders := ReadCoinHolders(db, "ABC", 3, 1); len(holders) != 0 {
+		t.Fatalf("holders returned past the end: %v", holders)
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// OnlineConsensusVote is a vote for an online consensus proposal, Voter is the
+// sign account of the validator.
+type OnlineConsensusVote struct {
+	Voter   common.Address
+	Approve bool
+}
+
+// OnlineConsensusRecord is an online consensus proposal accepted by the node,
+// with the votes received for it and its result.
+type OnlineConsensusRecord struct {
+	Hash       common.Hash
+	Number     uint64
+	LeaderTurn uint32
+	Leader     common.Address
+	Node       common.Address
+	State      uint8 // mc.OnlineState proposed for the node
+	Proposer   common.Address
+	Votes      []OnlineConsensusVote
+	Finished   bool                  // the result passed the POS verification
+	ResultFrom common.Address        // sign account sending the result
+	Signers    []OnlineConsensusVote // votes of the signatures of the result
+}
+
+// OnlineStateChange is a change of the online position of a node in the elect
+// online state of a block, with the consensus result causing it if found.
+type OnlineStateChange struct {
+	Number      uint64
+	BlockHash   common.Hash
+	Node        common.Address
+	OldPosition uint16
+	NewPosition uint16
+	Proposal    common.Hash
+}
+
+// OnlineNodePosition is the online position of an elected node.
+type OnlineNodePosition struct {
+	Node     common.Address
+	Position uint16
+}
+
+// OnlinePositions are the online positions of the elected nodes at a block.
+type OnlinePositions struct {
+	Number    uint64
+	BlockHash common.Hash
+	Nodes     []OnlineNodePosition
+}
+
+func onlineRecordKey(hash common.Hash) []byte {
+	return append(append([]byte{}, onlineRecordPrefix...), hash.Bytes()...)
+}
+
+func onlineNumberCountKey(number uint64) []byte {
+	return append(append([]byte{}, onlineNumberCountPrefix...), encodeBlockNumber(number)...)
+}
+
+func onlineNumberKey(number uint64, index uint64) []byte {
+	return append(append(append([]byte{}, onlineNumberPrefix...), encodeBlockNumber(number)...), encodeBlockNumber(index)...)
+}
+
+func onlineNodeCountKey(node common.Address) []byte {
+	return append(append([]byte{}, onlineNodeCountPrefix...), node.Bytes()...)
+}
+
+func onlineNodeKey(node common.Address, index uint64) []byte {
+	return append(append(append([]byte{}, onlineNodePrefix...), node.Bytes()...), encodeBlockNumber(index)...)
+}
+
+func onlineNodeTailKey(node common.Address) []byte {
+	return append(append([]byte{}, onlineNodeTailPrefix...), node.Bytes()...)
+}
+
+func onlineChangeCountKey(node common.Address) []byte {
+	return append(append([]byte{}, onlineChangeCountPrefix...), node.Bytes()...)
+}
+
+func onlineChangeKey(node common.Address, index uint64) []byte {
+	return append(append(append([]byte{}, onlineChangePrefix...), node.Bytes()...), encodeBlockNumber(index)...)
+}
+
+func putOnlineCount(db DatabaseWriter, key []byte, count uint64) {
+	if err := db.Put(key, encodeBlockNumber(count)); err != nil {
+		log.Crit("Failed to store online consensus index", "err", err)
+	}
+}
+
+func readOnlineHashes(db DatabaseReader, keys [][]byte) []common.Hash {
+	hashes := make([]common.Hash, 0, len(keys))
+	for _, key := range keys {
+		data, _ := db.Get(key)
+		if len(data) != common.HashLength {
+			log.Error("Invalid online consensus index entry", "key", common.Bytes2Hex(key))
+			break
+		}
+		hashes = append(hashes, common.BytesToHash(data))
+	}
+	return hashes
+}
+
+// ReadOnlineConsensusRecord retrieves the online consensus record of the
+// proposal hash.
+func ReadOnlineConsensusRecord(db DatabaseReader, hash common.Hash) *OnlineConsensusRecord {
+	data, _ := db.Get(onlineRecordKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	record := new(OnlineConsensusRecord)
+	if err := rlp.DecodeBytes(data, record); err != nil {
+		log.Error("Invalid online consensus record RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return record
+}
+
+// WriteOnlineConsensusRecord stores the online consensus record, replacing the
+// previous one of its hash.
+func WriteOnlineConsensusRecord(db DatabaseWriter, record *OnlineConsensusRecord) {
+	data, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		log.Crit("Failed to RLP encode online consensus record", "err", err)
+	}
+	if err := db.Put(onlineRecordKey(record.Hash), data); err != nil {
+		log.Crit("Failed to store online consensus record", "err", err)
+	}
+}
+
+// IndexOnlineConsensusRecord appends the hash of the record to the proposals of
+// its height and of its node. It must be called once per record.
+func IndexOnlineConsensusRecord(reader DatabaseReader, db DatabaseWriter, record *OnlineConsensusRecord) {
+	count := ReadOnlineConsensusCountByNumber(reader, record.Number)
+	if err := db.Put(onlineNumberKey(record.Number, count), record.Hash.Bytes()); err != nil {
+		log.Crit("Failed to store online consensus index", "err", err)
+	}
+	putOnlineCount(db, onlineNumberCountKey(record.Number), count+1)
+
+	count = ReadOnlineConsensusCountByNode(reader, record.Node)
+	if err := db.Put(onlineNodeKey(record.Node, count), record.Hash.Bytes()); err != nil {
+		log.Crit("Failed to store online consensus index", "err", err)
+	}
+	putOnlineCount(db, onlineNodeCountKey(record.Node), count+1)
+}
+
+// ReadOnlineConsensusCountByNumber retrieves the number of proposals of the height.
+func ReadOnlineConsensusCountByNumber(db DatabaseReader, number uint64) uint64 {
+	return readUint64(db, onlineNumberCountKey(number))
+}
+
+// ReadOnlineConsensusHashesByNumber retrieves the hashes of the proposals of the
+// height, in the order they were seen.
+func ReadOnlineConsensusHashesByNumber(db DatabaseReader, number uint64) []common.Hash {
+	count := ReadOnlineConsensusCountByNumber(db, number)
+	keys := make([][]byte, 0, count)
+	for index := uint64(0); index < count; index++ {
+		keys = append(keys, onlineNumberKey(number, index))
+	}
+	return readOnlineHashes(db, keys)
+}
+
+// ReadOnlineConsensusCountByNode retrieves the number of proposals of the node.
+func ReadOnlineConsensusCountByNode(db DatabaseReader, node common.Address) uint64 {
+	return readUint64(db, onlineNodeCountKey(node))
+}
+
+// ReadOnlineConsensusTailByNode retrieves the index of the first proposal of the
+// node kept by the pruning.
+func ReadOnlineConsensusTailByNode(db DatabaseReader, node common.Address) uint64 {
+	return readUint64(db, onlineNodeTailKey(node))
+}
+
+// ReadOnlineConsensusHashesByNode retrieves at most count hashes of the proposals
+// of the node from the index start, in the order they were seen. The pruned
+// proposals below the tail of the node are skipped.
+func ReadOnlineConsensusHashesByNode(db DatabaseReader, node common.Address, start uint64, count uint64) []common.Hash {
+	if tail := ReadOnlineConsensusTailByNode(db, node); start < tail {
+		start = tail
+	}
+	start, end := pageRange(start, count, ReadOnlineConsensusCountByNode(db, node))
+	keys := make([][]byte, 0, end-start)
+	for index := start; index < end; index++ {
+		keys = append(keys, onlineNodeKey(node, index))
+	}
+	return readOnlineHashes(db, keys)
+}
+
+// ReadOnlineConsensusPruned retrieves the height the proposals are pruned below.
+func ReadOnlineConsensusPruned(db DatabaseReader) uint64 {
+	return readUint64(db, onlinePrunedKey)
+}
+
+// onlineDatabase is the store the pruning of the proposals reads and updates.
+type onlineDatabase interface {
+	DatabaseReader
+	DatabaseWriter
+	DatabaseDeleter
+}
+
+// PruneOnlineConsensusRecords deletes the proposals of the heights below number
+// not pruned yet, at most limit heights, with their votes and index entries. It
+// returns the height the proposals are pruned below.
+func PruneOnlineConsensusRecords(db onlineDatabase, number uint64, limit uint64) uint64 {
+	pruned := ReadOnlineConsensusPruned(db)
+	if number > pruned+limit {
+		number = pruned + limit
+	}
+	if number <= pruned {
+		return pruned
+	}
+	nodes := make(map[common.Address]bool)
+	for height := pruned; height < number; height++ {
+		count := ReadOnlineConsensusCountByNumber(db, height)
+		for index := uint64(0); index < count; index++ {
+			key := onlineNumberKey(height, index)
+			if data, _ := db.Get(key); len(data) == common.HashLength {
+				hash := common.BytesToHash(data)
+				if record := ReadOnlineConsensusRecord(db, hash); record != nil {
+					nodes[record.Node] = true
+				}
+				if err := db.Delete(onlineRecordKey(hash)); err != nil {
+					log.Crit("Failed to delete online consensus record", "err", err)
+				}
+			}
+			if err := db.Delete(key); err != nil {
+				log.Crit("Failed to delete online consensus index", "err", err)
+			}
+		}
+		if count > 0 {
+			if err := db.Delete(onlineNumberCountKey(height)); err != nil {
+				log.Crit("Failed to delete online consensus index", "err", err)
+			}
+		}
+	}
+	// The proposals of a node are seen in height order, its index entries are
+	// dropped from the front up to the first proposal kept.
+	for node := range nodes {
+		tail, count := ReadOnlineConsensusTailByNode(db, node), ReadOnlineConsensusCountByNode(db, node)
+		start := tail
+		for ; tail < count; tail++ {
+			key := onlineNodeKey(node, tail)
+			if data, _ := db.Get(key); len(data) == common.HashLength {
+				if has, _ := db.Has(onlineRecordKey(common.BytesToHash(data))); has {
+					break
+				}
+			}
+			if err := db.Delete(key); err != nil {
+				log.Crit("Failed to delete online consensus index", "err", err)
+			}
+		}
+		if tail != start {
+			putOnlineCount(db, onlineNodeTailKey(node), tail)
+		}
+	}
+	putOnlineCount(db, onlinePrunedKey, number)
+	return number
+}
+
+// ReadOnlineStateChangeCount retrieves the number of online state changes of the node.
+func ReadOnlineStateChangeCount(db DatabaseReader, node common.Address) uint64 {
+	return readUint64(db, onlineChangeCountKey(node))
+}
+
+func readOnlineStateChange(db DatabaseReader, node common.Address, index uint64) *OnlineStateChange {
+	data, _ := db.Get(onlineChangeKey(node, index))
+	if len(data) == 0 {
+		return nil
+	}
+	change := new(OnlineStateChange)
+	if err := rlp.DecodeBytes(data, change); err != nil {
+		log.Error("Invalid online state change RLP", "node", node, "index", index, "err", err)
+		return nil
+	}
+	return change
+}
+
+// ReadOnlineStateChanges retrieves at most count online state changes of the
+// node from the index start, in block order.
+func ReadOnlineStateChanges(db DatabaseReader, node common.Address, start uint64, count uint64) []*OnlineStateChange {
+	start, end := pageRange(start, count, ReadOnlineStateChangeCount(db, node))
+	changes := make([]*OnlineStateChange, 0, end-start)
+	for index := start; index < end; index++ {
+		change := readOnlineStateChange(db, node, index)
+		if change == nil {
+			break
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// WriteOnlineStateChange appends an online state change to the ones of its node.
+func WriteOnlineStateChange(reader DatabaseReader, db DatabaseWriter, change *OnlineStateChange) {
+	count := ReadOnlineStateChangeCount(reader, change.Node)
+	data, err := rlp.EncodeToBytes(change)
+	if err != nil {
+		log.Crit("Failed to RLP encode online state change", "err", err)
+	}
+	if err := db.Put(onlineChangeKey(change.Node, count), data); err != nil {
+		log.Crit("Failed to store online state change", "err", err)
+	}
+	putOnlineCount(db, onlineChangeCountKey(change.Node), count+1)
+}
+
+// TruncateOnlineStateChanges drops the last online state changes of the node
+// from the height number on, the ones of blocks reorganised out.
+func TruncateOnlineStateChanges(reader DatabaseReader, db DatabaseWriter, node common.Address, number uint64) {
+	count := ReadOnlineStateChangeCount(reader, node)
+	keep := count
+	for keep > 0 {
+		change := readOnlineStateChange(reader, node, keep-1)
+		if change == nil || change.Number < number {
+			break
+		}
+		keep--
+	}
+	if keep != count {
+		putOnlineCount(db, onlineChangeCountKey(node), keep)
+	}
+}
+
+// ReadOnlinePositions retrieves the online positions of the last indexed block.
+func ReadOnlinePositions(db DatabaseReader) *OnlinePositions {
+	data, _ := db.Get(onlinePositionsKey)
+	if len(data) == 0 {
+		return nil
+	}
+	positions := new(OnlinePositions)
+	if err := rlp.DecodeBytes(data, positions); err != nil {
+		log.Error("Invalid online positions RLP", "err", err)
+		return nil
+	}
+	return positions
+}
+
+// WriteOnlinePositions stores the online positions of the last indexed block.
+func WriteOnlinePositions(db DatabaseWriter, positions *OnlinePositions) {
+	data, err := rlp.EncodeToBytes(positions)
+	if err != nil {
+		log.Crit("Failed to RLP encode online positions", "err", err)
+	}
+	if err := db.Put(onlinePositionsKey, data); err != nil {
+		log.Crit("Failed to store online positions", "err", err)
+	}
+}
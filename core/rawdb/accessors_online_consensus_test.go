@@ -0,0 +1,77 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+// Tests that the online consensus records are indexed per height and node, and
+// that the online state changes can be truncated on reorganisations.
+func TestOnlineConsensusStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+
+	node := common.BytesToAddress([]byte{0x01})
+	voter := common.BytesToAddress([]byte{0x02})
+	for i, number := range []uint64{10, 10, 12} {
+		record := &OnlineConsensusRecord{Hash: common.BytesToHash([]byte{byte(i + 1)}), Number: number, Node: node, State: 2}
+		WriteOnlineConsensusRecord(db, record)
+		IndexOnlineConsensusRecord(db, db, record)
+	}
+	record := ReadOnlineConsensusRecord(db, common.BytesToHash([]byte{0x02}))
+	record.Votes = append(record.Votes, OnlineConsensusVote{Voter: voter, Approve: true})
+	record.Finished = true
+	WriteOnlineConsensusRecord(db, record)
+
+	if hashes := ReadOnlineConsensusHashesByNumber(db, 10); len(hashes) != 2 || hashes[1] != record.Hash {
+		t.Fatalf("height proposals mismatch: %v", hashes)
+	}
+	if count := ReadOnlineConsensusCountByNode(db, node); count != 3 {
+		t.Fatalf("node proposal count mismatch: have %d, want 3", count)
+	}
+	if hashes := ReadOnlineConsensusHashesByNode(db, node, 1, 1); len(hashes) != 1 || hashes[0] != record.Hash {
+		t.Fatalf("node proposals page mismatch: %v", hashes)
+	}
+	if stored := ReadOnlineConsensusRecord(db, record.Hash); stored == nil || !stored.Finished || len(stored.Votes) != 1 || stored.Votes[0].Voter != voter {
+		t.Fatalf("record mismatch: %+v", stored)
+	}
+
+	for _, number := range []uint64{11, 13, 14} {
+		WriteOnlineStateChange(db, db, &OnlineStateChange{Number: number, Node: node, OldPosition: common.PosOnline, NewPosition: common.PosOffline})
+	}
+	TruncateOnlineStateChanges(db, db, node, 13)
+	if changes := ReadOnlineStateChanges(db, node, 0, 10); len(changes) != 1 || changes[0].Number != 11 {
+		t.Fatalf("truncated changes mismatch: %v", changes)
+	}
+	WriteOnlineStateChange(db, db, &OnlineStateChange{Number: 13, Node: node})
+	if count := ReadOnlineStateChangeCount(db, node); count != 2 {
+		t.Fatalf("change count mismatch: have %d, want 2", count)
+	}
+
+	// the proposals below 11 are pruned, 12 is kept
+	if pruned := PruneOnlineConsensusRecords(db, 20, 11); pruned != 11 {
+		t.Fatalf("pruned height mismatch: have %d, want 11", pruned)
+	}
+	if ReadOnlineConsensusRecord(db, record.Hash) != nil || len(ReadOnlineConsensusHashesByNumber(db, 10)) != 0 {
+		t.Fatalf("pruned proposals kept")
+	}
+	if tail := ReadOnlineConsensusTailByNode(db, node); tail != 2 {
+		t.Fatalf("node tail mismatch: have %d, want 2", tail)
+	}
+	if hashes := ReadOnlineConsensusHashesByNode(db, node, 0, 10); len(hashes) != 1 || ReadOnlineConsensusRecord(db, hashes[0]).Number != 12 {
+		t.Fatalf("node proposals after pruning mismatch: %v", hashes)
+	}
+	if pruned := PruneOnlineConsensusRecords(db, 13, 100); pruned != 13 || len(ReadOnlineConsensusHashesByNode(db, node, 0, 10)) != 0 {
+		t.Fatalf("proposals of 12 kept")
+	}
+
+	WriteOnlinePositions(db, &OnlinePositions{Number: 13, Nodes: []OnlineNodePosition{{Node: node, Position: common.PosOffline}}})
+	if positions := ReadOnlinePositions(db); positions == nil || positions.Number != 13 || positions.Nodes[0].Position != common.PosOffline {
+		t.Fatalf("positions mismatch: %+v", positions)
+	}
+}
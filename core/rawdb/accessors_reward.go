@@ -0,0 +1,70 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+func rewardLedgerKey(number uint64, hash common.Hash) []byte {
+	return append(append(rewardLedgerPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+func rewardAccountKey(account common.Address, number uint64, hash common.Hash) []byte {
+	return append(append(append(rewardAccountPrefix, account.Bytes()...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// ReadRewardLedger retrieves the reward ledger of a block.
+func ReadRewardLedger(db DatabaseReader, hash common.Hash, number uint64) *ledger.Ledger {
+	data, _ := db.Get(rewardLedgerKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	rewards := new(ledger.Ledger)
+	if err := rlp.DecodeBytes(data, rewards); err != nil {
+		log.Error("Invalid reward ledger RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return rewards
+}
+
+// ReadAccountRewardEntries retrieves the reward ledger entries of an account in a
+// block.
+func ReadAccountRewardEntries(db DatabaseReader, account common.Address, hash common.Hash, number uint64) []*ledger.Entry {
+	data, _ := db.Get(rewardAccountKey(account, number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var entries []*ledger.Entry
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		log.Error("Invalid account reward entries RLP", "hash", hash, "account", account, "err", err)
+		return nil
+	}
+	return entries
+}
+
+// WriteRewardLedger stores the reward ledger of a block, and indexes its entries
+// by account.
+func WriteRewardLedger(db DatabaseWriter, rewards *ledger.Ledger) {
+	data, err := rlp.EncodeToBytes(rewards)
+	if err != nil {
+		log.Crit("Failed to RLP encode reward ledger", "err", err)
+	}
+	if err := db.Put(rewardLedgerKey(rewards.Number, rewards.Hash), data); err != nil {
+		log.Crit("Failed to store reward ledger", "err", err)
+	}
+	for account, entries := range rewards.Accounts() {
+		data, err := rlp.EncodeToBytes(entries)
+		if err != nil {
+			log.Crit("Failed to RLP encode account reward entries", "err", err)
+		}
+		if err := db.Put(rewardAccountKey(account, rewards.Number, rewards.Hash), data); err != nil {
+			log.Crit("Failed to store account reward entries", "err", err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
+)
+
+// Tests that the reward ledger and its account index can be stored and retrieved.
+func TestRewardLedgerStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+
+	miner, validator := common.BytesToAddress([]byte{0x11}), common.BytesToAddress([]byte{0x22})
+	rewards := &ledger.Ledger{
+		Number:  100,
+		Hash:    common.BytesToHash([]byte{0x01}),
+		Version: "1.0.0.0",
+		Entries: []*ledger.Entry{
+			{Source: ledger.SourceBlock, Type: ledger.TypeMinerOut, Account: miner, Coin: params.MAN_COIN, Amount: big.NewInt(10), Rule: "1", Inputs: []ledger.Input{ledger.In("pool", 10)}},
+			{Source: ledger.SourceBlock, Type: ledger.TypeLeader, Account: validator, Coin: params.MAN_COIN, Amount: big.NewInt(20)},
+			{Source: ledger.SourceSlash, Type: ledger.TypeSlash, Account: miner, Coin: params.MAN_COIN, Amount: big.NewInt(3)},
+		},
+	}
+	if entry := ReadRewardLedger(db, rewards.Hash, rewards.Number); entry != nil {
+		t.Fatalf("non existent reward ledger returned: %v", entry)
+	}
+	WriteRewardLedger(db, rewards)
+
+	entry := ReadRewardLedger(db, rewards.Hash, rewards.Number)
+	if entry == nil {
+		t.Fatalf("stored reward ledger not found")
+	}
+	if entry.Version != rewards.Version || len(entry.Entries) != len(rewards.Entries) {
+		t.Fatalf("reward ledger mismatch: have %v, want %v", entry, rewards)
+	}
+	if entry.Entries[0].Inputs[0] != ledger.In("pool", 10) || entry.Entries[0].Amount.Cmp(big.NewInt(10)) != 0 {
+		t.Fatalf("reward entry mismatch: have %v, want %v", entry.Entries[0], rewards.Entries[0])
+	}
+
+	if entries := ReadAccountRewardEntries(db, miner, rewards.Hash, rewards.Number); len(entries) != 2 || entries[1].Type != ledger.TypeSlash {
+		t.Fatalf("miner entries mismatch: have %v", entries)
+	}
+	if entries := ReadAccountRewardEntries(db, validator, rewards.Hash, rewards.Number); len(entries) != 1 || entries[0].Type != ledger.TypeLeader {
+		t.Fatalf("validator entries mismatch: have %v", entries)
+	}
+	if entries := ReadAccountRewardEntries(db, validator, rewards.Hash, rewards.Number+1); len(entries) != 0 {
+		t.Fatalf("non existent entries returned: %v", entries)
+	}
+}
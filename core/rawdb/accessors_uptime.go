@@ -0,0 +1,99 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// Sources of the uptime credited to an account for a broadcast interval.
+const (
+	UptimeSourceDefault     uint8 = iota // neither called the roll nor asked to send heartbeats, credited the max uptime
+	UptimeSourceHeartbeat                // asked to send heartbeats, credited the max uptime if one was seen, else 0
+	UptimeSourceCallTheRoll              // called the roll, credited the number of blocks it answered
+	UptimeSourceSuperBlock               // a super block was in the interval, credited the max uptime
+)
+
+// UptimeAccountDetail holds the inputs and the result of the uptime calculation
+// of an account. The new uptime is OldUptime * ResetA + Uptime.
+type UptimeAccountDetail struct {
+	Account           common.Address
+	Role              common.RoleType // role in the elect graph of the interval, 0 if not in it
+	HeartbeatSlot     uint64          // account modulo the heartbeat modulus
+	HeartbeatRequired bool            // the slot of the account is the one of the interval
+	HeartbeatObserved bool
+	CalledTheRoll     bool
+	RollCallBlocks    uint64 // online blocks of the call the roll response
+	Source            uint8
+	Uptime            uint64
+	OldUptime         *big.Int
+	NewUptime         *big.Int
+}
+
+// UptimeDetails holds the uptime calculation of a broadcast interval, made by
+// the block Number after the broadcast block BroadcastNumber.
+type UptimeDetails struct {
+	Number            uint64
+	BroadcastNumber   uint64
+	BroadcastInterval uint64
+	MaxUptime         uint64
+	HeartbeatModulus  uint64 // interval - 1, the accounts are spread over the slots
+	HeartbeatSlot     uint64 // slot of the interval, from the hash of the state roots
+	SuperBlock        bool
+	ResetA            string // factor of the previous uptime
+	Accounts          []*UptimeAccountDetail
+}
+
+func uptimeDetailsKey(number uint64, hash common.Hash) []byte {
+	return append(append(append([]byte{}, uptimeDetailsPrefix...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+func uptimeNumberKey(broadcastNumber uint64) []byte {
+	return append(append([]byte{}, uptimeNumberPrefix...), encodeBlockNumber(broadcastNumber)...)
+}
+
+// ReadUptimeDetails retrieves the uptime calculation of a block.
+func ReadUptimeDetails(db DatabaseReader, number uint64, hash common.Hash) *UptimeDetails {
+	data, _ := db.Get(uptimeDetailsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	details := new(UptimeDetails)
+	if err := rlp.DecodeBytes(data, details); err != nil {
+		log.Error("Invalid uptime details RLP", "number", number, "hash", hash, "err", err)
+		return nil
+	}
+	return details
+}
+
+// ReadUptimeNumber retrieves the number of the block calculating the uptime of
+// the broadcast interval ending at the broadcast block.
+func ReadUptimeNumber(db DatabaseReader, broadcastNumber uint64) (uint64, bool) {
+	data, _ := db.Get(uptimeNumberKey(broadcastNumber))
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteUptimeDetails stores the uptime calculation of a block and indexes it by
+// its broadcast interval.
+func WriteUptimeDetails(db DatabaseWriter, hash common.Hash, details *UptimeDetails) {
+	data, err := rlp.EncodeToBytes(details)
+	if err != nil {
+		log.Crit("Failed to RLP encode uptime details", "err", err)
+	}
+	if err := db.Put(uptimeDetailsKey(details.Number, hash), data); err != nil {
+		log.Crit("Failed to store uptime details", "err", err)
+	}
+	if err := db.Put(uptimeNumberKey(details.BroadcastNumber), encodeBlockNumber(details.Number)); err != nil {
+		log.Crit("Failed to store uptime number", "err", err)
+	}
+}
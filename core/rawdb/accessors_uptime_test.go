@@ -0,0 +1,59 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+// Tests that the uptime calculations are stored per block and indexed by their
+// broadcast interval.
+func TestUptimeDetailsStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+
+	if _, exist := ReadUptimeNumber(db, 100); exist {
+		t.Fatalf("uptime number of an unknown interval found")
+	}
+	account := common.BytesToAddress([]byte{0x01})
+	hash := common.BytesToHash([]byte{0x65})
+	details := &UptimeDetails{
+		Number:            101,
+		BroadcastNumber:   100,
+		BroadcastInterval: 100,
+		MaxUptime:         97,
+		HeartbeatModulus:  99,
+		HeartbeatSlot:     3,
+		ResetA:            "1",
+		Accounts: []*UptimeAccountDetail{{
+			Account:           account,
+			Role:              common.RoleValidator,
+			HeartbeatSlot:     3,
+			HeartbeatRequired: true,
+			Source:            UptimeSourceHeartbeat,
+			OldUptime:         big.NewInt(970),
+			NewUptime:         big.NewInt(970),
+		}},
+	}
+	WriteUptimeDetails(db, hash, details)
+
+	number, exist := ReadUptimeNumber(db, 100)
+	if !exist || number != 101 {
+		t.Fatalf("uptime number mismatch: have %d, want 101", number)
+	}
+	if ReadUptimeDetails(db, 101, common.Hash{}) != nil {
+		t.Fatalf("uptime details of another block found")
+	}
+	stored := ReadUptimeDetails(db, 101, hash)
+	if stored == nil || len(stored.Accounts) != 1 || stored.HeartbeatSlot != 3 || stored.ResetA != "1" {
+		t.Fatalf("uptime details mismatch: %+v", stored)
+	}
+	if acc := stored.Accounts[0]; acc.Account != account || !acc.HeartbeatRequired || acc.HeartbeatObserved || acc.Uptime != 0 || acc.OldUptime.Cmp(big.NewInt(970)) != 0 {
+		t.Fatalf("account details mismatch: %+v", acc)
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// Types of the indexed validator group events.
+const (
+	VGEventCreate      uint8 = iota // the group is created, Account is its sign account
+	VGEventDeposit                  // deposit of the participant
+	VGEventWithdraw                 // withdrawal of a deposit of the participant
+	VGEventRefund                   // refund of a withdrawn deposit of the participant
+	VGEventReward                   // reward credited to the participant
+	VGEventInterest                 // interest of the current deposit credited to the participant
+	VGEventClaim                    // reward paid out to the participant
+	VGEventOwner                    // owner change, Account is the previous owner
+	VGEventSignAccount              // sign account change, Account is the previous one
+	VGEventRate                     // reward rates change
+	VGEventWithdrawAll              // the owner withdrew every deposit of the group
+	VGEventDissolve                 // the group contract is destroyed
+)
+
+// ValidatorGroupEvent is an indexed event of a validator group. The events of
+// the group itself have no participant, the ones derived from the state have no
+// transaction hash.
+type ValidatorGroupEvent struct {
+	Type        uint8
+	Participant common.Address
+	Account     common.Address
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	Amount      *big.Int
+	DType       uint64
+	Position    uint64
+	Rates       []*big.Int // owner, node and level rates of a rate change
+}
+
+// ParticipantTotals is the running sum of the event amounts of a participant of
+// a validator group, over the canonical blocks.
+type ParticipantTotals struct {
+	Deposited *big.Int
+	Withdrawn *big.Int
+	Refunded  *big.Int
+	Rewards   *big.Int
+	Interest  *big.Int
+	Claimed   *big.Int
+}
+
+func newParticipantTotals() *ParticipantTotals {
+	return &ParticipantTotals{new(big.Int), new(big.Int), new(big.Int), new(big.Int), new(big.Int), new(big.Int)}
+}
+
+func (totals *ParticipantTotals) total(kind uint8) *big.Int {
+	switch kind {
+	case VGEventDeposit:
+		return totals.Deposited
+	case VGEventWithdraw:
+		return totals.Withdrawn
+	case VGEventRefund:
+		return totals.Refunded
+	case VGEventReward:
+		return totals.Rewards
+	case VGEventInterest:
+		return totals.Interest
+	case VGEventClaim:
+		return totals.Claimed
+	}
+	return nil
+}
+
+// groupBlockEvents is the range of the events a block appended to a group.
+type groupBlockEvents struct {
+	Group common.Address
+	Start uint64
+	Count uint64
+}
+
+func validatorGroupKey(index uint64) []byte {
+	return append(append([]byte{}, validatorGroupPrefix...), encodeBlockNumber(index)...)
+}
+
+func validatorGroupIndexKey(group common.Address) []byte {
+	return append(append([]byte{}, validatorGroupIndexPrefix...), group.Bytes()...)
+}
+
+func validatorGroupEventCountKey(group common.Address) []byte {
+	return append(append([]byte{}, validatorGroupEventCountPrefix...), group.Bytes()...)
+}
+
+func validatorGroupEventKey(group common.Address, index uint64) []byte {
+	return append(append(append([]byte{}, validatorGroupEventPrefix...), group.Bytes()...), encodeBlockNumber(index)...)
+}
+
+func participantEventCountKey(group common.Address, participant common.Address) []byte {
+	return append(append(append([]byte{}, participantEventCountPrefix...), group.Bytes()...), participant.Bytes()...)
+}
+
+func participantEventKey(group common.Address, participant common.Address, index uint64) []byte {
+	return append(append(append(append([]byte{}, participantEventPrefix...), group.Bytes()...), participant.Bytes()...), encodeBlockNumber(index)...)
+}
+
+func participantTotalsKey(group common.Address, participant common.Address) []byte {
+	return append(append(append([]byte{}, participantTotalsPrefix...), group.Bytes()...), participant.Bytes()...)
+}
+
+func validatorGroupBlockKey(hash common.Hash) []byte {
+	return append(append([]byte{}, validatorGroupBlockPrefix...), hash.Bytes()...)
+}
+
+func readUint64(db DatabaseReader, key []byte) uint64 {
+	data, _ := db.Get(key)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+func putUint64(db DatabaseWriter, key []byte, value uint64) {
+	if err := db.Put(key, encodeBlockNumber(value)); err != nil {
+		log.Crit("Failed to store validator group index", "err", err)
+	}
+}
+
+// pageRange bounds the page of count entries from start to the total.
+func pageRange(start uint64, count uint64, total uint64) (uint64, uint64) {
+	if start >= total {
+		return total, total
+	}
+	if count > total-start {
+		count = total - start
+	}
+	return start, start + count
+}
+
+// ReadValidatorGroupCount retrieves the number of indexed validator groups.
+func ReadValidatorGroupCount(db DatabaseReader) uint64 {
+	return readUint64(db, validatorGroupCountKey)
+}
+
+// HasValidatorGroup checks if the validator group is indexed.
+func HasValidatorGroup(db DatabaseReader, group common.Address) bool {
+	has, _ := db.Has(validatorGroupIndexKey(group))
+	return has
+}
+
+// ReadValidatorGroups retrieves at most count indexed validator groups from the
+// index start, in creation order.
+func ReadValidatorGroups(db DatabaseReader, start uint64, count uint64) []common.Address {
+	start, end := pageRange(start, count, ReadValidatorGroupCount(db))
+	groups := make([]common.Address, 0, end-start)
+	for index := start; index < end; index++ {
+		data, _ := db.Get(validatorGroupKey(index))
+		if len(data) != common.AddressLength {
+			log.Error("Invalid validator group entry", "index", index)
+			break
+		}
+		groups = append(groups, common.BytesToAddress(data))
+	}
+	return groups
+}
+
+// ReadValidatorGroupEventCount retrieves the number of indexed events of a
+// validator group.
+func ReadValidatorGroupEventCount(db DatabaseReader, group common.Address) uint64 {
+	return readUint64(db, validatorGroupEventCountKey(group))
+}
+
+func readValidatorGroupEvent(db DatabaseReader, group common.Address, index uint64) *ValidatorGroupEvent {
+	data, _ := db.Get(validatorGroupEventKey(group, index))
+	if len(data) == 0 {
+		return nil
+	}
+	event := new(ValidatorGroupEvent)
+	if err := rlp.DecodeBytes(data, event); err != nil {
+		log.Error("Invalid validator group event RLP", "group", group, "index", index, "err", err)
+		return nil
+	}
+	return event
+}
+
+// ReadValidatorGroupEvents retrieves at most count events of a validator group
+// from the index start, in block order.
+func ReadValidatorGroupEvents(db DatabaseReader, group common.Address, start uint64, count uint64) []*ValidatorGroupEvent {
+	start, end := pageRange(start, count, ReadValidatorGroupEventCount(db, group))
+	events := make([]*ValidatorGroupEvent, 0, end-start)
+	for index := start; index < end; index++ {
+		event := readValidatorGroupEvent(db, group, index)
+		if event == nil {
+			break
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// ReadParticipantEventCount retrieves the number of indexed events of a
+// participant of a validator group.
+func ReadParticipantEventCount(db DatabaseReader, group common.Address, participant common.Address) uint64 {
+	return readUint64(db, participantEventCountKey(group, participant))
+}
+
+// ReadParticipantEvents retrieves at most count events of a participant of a
+// validator group from the index start, in block order.
+func ReadParticipantEvents(db DatabaseReader, group common.Address, participant common.Address, start uint64, count uint64) []*ValidatorGroupEvent {
+	start, end := pageRange(start, count, ReadParticipantEventCount(db, group, participant))
+	events := make([]*ValidatorGroupEvent, 0, end-start)
+	for index := start; index < end; index++ {
+		data, _ := db.Get(participantEventKey(group, participant, index))
+		if len(data) != 8 {
+			log.Error("Invalid participant event entry", "group", group, "participant", participant, "index", index)
+			break
+		}
+		event := readValidatorGroupEvent(db, group, binary.BigEndian.Uint64(data))
+		if event == nil {
+			break
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// ReadParticipantTotals retrieves the totals of the events of a participant of
+// a validator group in the canonical blocks.
+func ReadParticipantTotals(db DatabaseReader, group common.Address, participant common.Address) *ParticipantTotals {
+	totals := newParticipantTotals()
+	data, _ := db.Get(participantTotalsKey(group, participant))
+	if len(data) == 0 {
+		return totals
+	}
+	if err := rlp.DecodeBytes(data, totals); err != nil {
+		log.Error("Invalid participant totals RLP", "group", group, "participant", participant, "err", err)
+		return newParticipantTotals()
+	}
+	return totals
+}
+
+// participantTotals caches the totals of the participants updated by a block.
+type participantTotals map[common.Address]map[common.Address]*ParticipantTotals
+
+func (cache participantTotals) add(reader DatabaseReader, group common.Address, event *ValidatorGroupEvent, neg bool) {
+	if event.Participant == (common.Address{}) || event.Amount == nil {
+		return
+	}
+	if cache[group] == nil {
+		cache[group] = make(map[common.Address]*ParticipantTotals)
+	}
+	totals, exist := cache[group][event.Participant]
+	if !exist {
+		totals = ReadParticipantTotals(reader, group, event.Participant)
+		cache[group][event.Participant] = totals
+	}
+	if total := totals.total(event.Type); total != nil {
+		if neg {
+			total.Sub(total, event.Amount)
+		} else {
+			total.Add(total, event.Amount)
+		}
+	}
+}
+
+func (cache participantTotals) write(db DatabaseWriter) {
+	for group, participants := range cache {
+		for participant, totals := range participants {
+			data, err := rlp.EncodeToBytes(totals)
+			if err != nil {
+				log.Crit("Failed to RLP encode participant totals", "err", err)
+			}
+			if err := db.Put(participantTotalsKey(group, participant), data); err != nil {
+				log.Crit("Failed to store participant totals", "err", err)
+			}
+		}
+	}
+}
+
+// WriteValidatorGroupEvents appends the events of a block to the ones of the
+// validator groups, in the order of groups, indexes the new groups and adds the
+// events to the participant totals. The index is read from reader, so the
+// writes of an unflushed batch to db are not seen by a later call: the events
+// of a block must be written at once.
+func WriteValidatorGroupEvents(reader DatabaseReader, db DatabaseWriter, hash common.Hash, groups []common.Address, events map[common.Address][]*ValidatorGroupEvent) {
+	count := ReadValidatorGroupCount(reader)
+	added := count
+	totals := make(participantTotals)
+	ranges := make([]groupBlockEvents, 0, len(groups))
+	for _, group := range groups {
+		if len(events[group]) == 0 {
+			continue
+		}
+		if !HasValidatorGroup(reader, group) {
+			if err := db.Put(validatorGroupKey(added), group.Bytes()); err != nil {
+				log.Crit("Failed to store validator group", "err", err)
+			}
+			putUint64(db, validatorGroupIndexKey(group), added)
+			added++
+		}
+		start := writeGroupEvents(reader, db, group, events[group])
+		ranges = append(ranges, groupBlockEvents{Group: group, Start: start, Count: uint64(len(events[group]))})
+		for _, event := range events[group] {
+			totals.add(reader, group, event, false)
+		}
+	}
+	if added != count {
+		putUint64(db, validatorGroupCountKey, added)
+	}
+	if len(ranges) == 0 {
+		return
+	}
+	totals.write(db)
+	data, err := rlp.EncodeToBytes(ranges)
+	if err != nil {
+		log.Crit("Failed to RLP encode validator group block", "err", err)
+	}
+	if err := db.Put(validatorGroupBlockKey(hash), data); err != nil {
+		log.Crit("Failed to store validator group block", "err", err)
+	}
+}
+
+// validatorGroupDatabase is the store the events of a dropped block are taken
+// out of the totals in.
+type validatorGroupDatabase interface {
+	DatabaseWriter
+	DatabaseDeleter
+}
+
+// DropValidatorGroupEvents takes the events of a block dropped by a reorg out of
+// the participant totals. The events stay indexed, told apart by their block
+// hash.
+func DropValidatorGroupEvents(reader DatabaseReader, db validatorGroupDatabase, hash common.Hash) {
+	data, _ := reader.Get(validatorGroupBlockKey(hash))
+	if len(data) == 0 {
+		return
+	}
+	var ranges []groupBlockEvents
+	if err := rlp.DecodeBytes(data, &ranges); err != nil {
+		log.Error("Invalid validator group block RLP", "hash", hash, "err", err)
+		return
+	}
+	totals := make(participantTotals)
+	for _, r := range ranges {
+		for _, event := range ReadValidatorGroupEvents(reader, r.Group, r.Start, r.Count) {
+			totals.add(reader, r.Group, event, true)
+		}
+	}
+	totals.write(db)
+	if err := db.Delete(validatorGroupBlockKey(hash)); err != nil {
+		log.Crit("Failed to delete validator group block", "err", err)
+	}
+}
+
+// writeGroupEvents appends the events to the group and returns the index of the
+// first one.
+func writeGroupEvents(reader DatabaseReader, db DatabaseWriter, group common.Address, events []*ValidatorGroupEvent) uint64 {
+	start := ReadValidatorGroupEventCount(reader, group)
+	index := start
+	participants := make(map[common.Address]uint64)
+	for _, event := range events {
+		data, err := rlp.EncodeToBytes(event)
+		if err != nil {
+			log.Crit("Failed to RLP encode validator group event", "err", err)
+		}
+		if err := db.Put(validatorGroupEventKey(group, index), data); err != nil {
+			log.Crit("Failed to store validator group event", "err", err)
+		}
+		if event.Participant != (common.Address{}) {
+			count, exist := participants[event.Participant]
+			if !exist {
+				count = ReadParticipantEventCount(reader, group, event.Participant)
+			}
+			putUint64(db, participantEventKey(group, event.Participant, count), index)
+			participants[event.Participant] = count + 1
+		}
+		index++
+	}
+	putUint64(db, validatorGroupEventCountKey(group), index)
+	for participant, count := range participants {
+		putUint64(db, participantEventCountKey(group, participant), count)
+	}
+	return start
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+// Tests that the validator group events are appended per group and participant
+// and can be paged.
+func TestValidatorGroupEventsStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+
+	g1, g2 := common.BytesToAddress([]byte{0x10}), common.BytesToAddress([]byte{0x20})
+	a, b := common.BytesToAddress([]byte{0x01}), common.BytesToAddress([]byte{0x02})
+	h1, h2 := common.Hash{1}, common.Hash{2}
+	WriteValidatorGroupEvents(db, db, h1, []common.Address{g1}, map[common.Address][]*ValidatorGroupEvent{
+		g1: {
+			{Type: VGEventCreate, Participant: a, BlockNumber: 1, Amount: big.NewInt(100)},
+			{Type: VGEventDeposit, Participant: a, BlockNumber: 1, Amount: big.NewInt(100)},
+		},
+	})
+	WriteValidatorGroupEvents(db, db, h2, []common.Address{g2, g1}, map[common.Address][]*ValidatorGroupEvent{
+		g1: {
+			{Type: VGEventDeposit, Participant: b, BlockNumber: 2, Amount: big.NewInt(50), DType: 1, Position: 1},
+			{Type: VGEventRate, BlockNumber: 2, Amount: new(big.Int), Rates: []*big.Int{big.NewInt(1), big.NewInt(2)}},
+			{Type: VGEventReward, Participant: a, BlockNumber: 2, Amount: big.NewInt(7)},
+			{Type: VGEventDeposit, Participant: a, BlockNumber: 2, Amount: big.NewInt(20)},
+		},
+		g2: {
+			{Type: VGEventCreate, Participant: b, BlockNumber: 2, Amount: big.NewInt(10)},
+		},
+	})
+
+	if count := ReadValidatorGroupCount(db); count != 2 {
+		t.Fatalf("group count mismatch: have %d, want 2", count)
+	}
+	if groups := ReadValidatorGroups(db, 0, 10); len(groups) != 2 || groups[0] != g1 || groups[1] != g2 {
+		t.Fatalf("groups mismatch: %v", groups)
+	}
+	if count := ReadValidatorGroupEventCount(db, g1); count != 6 {
+		t.Fatalf("group event count mismatch: have %d, want 6", count)
+	}
+	events := ReadValidatorGroupEvents(db, g1, 2, 2)
+	if len(events) != 2 || events[0].Participant != b || events[0].Position != 1 || events[1].Type != VGEventRate || len(events[1].Rates) != 2 {
+		t.Fatalf("group events page mismatch: %v", events)
+	}
+	if count := ReadParticipantEventCount(db, g1, a); count != 4 {
+		t.Fatalf("participant event count mismatch: have %d, want 4", count)
+	}
+	events = ReadParticipantEvents(db, g1, a, 1, 10)
+	if len(events) != 3 || events[0].Type != VGEventDeposit || events[1].Type != VGEventReward || events[1].Amount.Cmp(big.NewInt(7)) != 0 {
+		t.Fatalf("participant events page mismatch: %v", events)
+	}
+	if events := ReadParticipantEvents(db, g2, a, 0, 10); len(events) != 0 {
+		t.Fatalf("events returned for another group: %v", events)
+	}
+	if events := ReadValidatorGroupEvents(db, g2, 5, 10); len(events) != 0 {
+		t.Fatalf("events returned past the end: %v", events)
+	}
+
+	totals := ReadParticipantTotals(db, g1, a)
+	if totals.Deposited.Cmp(big.NewInt(120)) != 0 || totals.Rewards.Cmp(big.NewInt(7)) != 0 || totals.Claimed.Sign() != 0 {
+		t.Fatalf("participant totals mismatch: %+v", totals)
+	}
+
+	// the events of a dropped block are taken out of the totals once
+	DropValidatorGroupEvents(db, db, h2)
+	DropValidatorGroupEvents(db, db, h2)
+	totals = ReadParticipantTotals(db, g1, a)
+	if totals.Deposited.Cmp(big.NewInt(100)) != 0 || totals.Rewards.Sign() != 0 {
+		t.Fatalf("participant totals after drop mismatch: %+v", totals)
+	}
+	if totals := ReadParticipantTotals(db, g2, b); totals.Deposited.Sign() != 0 {
+		t.Fatalf("dropped group totals mismatch: %+v", totals)
+	}
+	if count := ReadValidatorGroupEventCount(db, g1); count != 6 {
+		t.Fatalf("dropped events removed from the index: %d", count)
+	}
+}
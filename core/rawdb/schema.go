@@ -38,6 +38,39 @@ var (
 	blockBodyPrefix     = []byte("b") // blockBodyPrefix + num (uint64 big endian) + hash -> block body
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
 
+	rewardLedgerPrefix  = []byte("w") // rewardLedgerPrefix + num (uint64 big endian) + hash -> reward ledger
+	rewardAccountPrefix = []byte("W") // rewardAccountPrefix + account + num (uint64 big endian) + hash -> reward ledger entries of the account
+
+	coinMetaPrefix        = []byte("cm") // coinMetaPrefix + coin -> coin creation metadata
+	coinHolderCountPrefix = []byte("cc") // coinHolderCountPrefix + coin -> number of indexed holders (uint64 big endian)
+	coinHolderPrefix      = []byte("ch") // coinHolderPrefix + coin + "." + index (uint64 big endian) -> holder
+	coinHolderIndexPrefix = []byte("cx") // coinHolderIndexPrefix + coin + "." + account -> index (uint64 big endian)
+	coinBlockPrefix       = []byte("cb") // coinBlockPrefix + hash -> coins the block created
+
+	validatorGroupCountKey         = []byte("gL") // validatorGroupCountKey -> number of indexed validator groups (uint64 big endian)
+	validatorGroupPrefix           = []byte("gl") // validatorGroupPrefix + index (uint64 big endian) -> validator group
+	validatorGroupIndexPrefix      = []byte("gx") // validatorGroupIndexPrefix + group -> index (uint64 big endian)
+	validatorGroupEventCountPrefix = []byte("gc") // validatorGroupEventCountPrefix + group -> number of events (uint64 big endian)
+	validatorGroupEventPrefix      = []byte("ge") // validatorGroupEventPrefix + group + index (uint64 big endian) -> event
+	participantEventCountPrefix    = []byte("gp") // participantEventCountPrefix + group + participant -> number of events (uint64 big endian)
+	participantEventPrefix         = []byte("gq") // participantEventPrefix + group + participant + index (uint64 big endian) -> group event index
+	participantTotalsPrefix        = []byte("gt") // participantTotalsPrefix + group + participant -> totals of the canonical events
+	validatorGroupBlockPrefix      = []byte("gb") // validatorGroupBlockPrefix + hash -> event ranges the block appended
+
+	onlineRecordPrefix      = []byte("op") // onlineRecordPrefix + hash -> online consensus record
+	onlineNumberCountPrefix = []byte("oH") // onlineNumberCountPrefix + num (uint64 big endian) -> number of proposals of the height (uint64 big endian)
+	onlineNumberPrefix      = []byte("oh") // onlineNumberPrefix + num (uint64 big endian) + index (uint64 big endian) -> proposal hash
+	onlineNodeCountPrefix   = []byte("oN") // onlineNodeCountPrefix + node -> number of proposals of the node (uint64 big endian)
+	onlineNodePrefix        = []byte("on") // onlineNodePrefix + node + index (uint64 big endian) -> proposal hash
+	onlineNodeTailPrefix    = []byte("oT") // onlineNodeTailPrefix + node -> index of the first proposal of the node kept (uint64 big endian)
+	onlinePrunedKey         = []byte("oP") // onlinePrunedKey -> height the proposals are pruned below (uint64 big endian)
+	onlineChangeCountPrefix = []byte("oC") // onlineChangeCountPrefix + node -> number of online state changes of the node (uint64 big endian)
+	onlineChangePrefix      = []byte("oc") // onlineChangePrefix + node + index (uint64 big endian) -> online state change
+	onlinePositionsKey      = []byte("oL") // onlinePositionsKey -> online positions of the last indexed block
+
+	uptimeDetailsPrefix = []byte("ut") // uptimeDetailsPrefix + num (uint64 big endian) + hash -> uptime calculation details
+	uptimeNumberPrefix  = []byte("uT") // uptimeNumberPrefix + broadcast num (uint64 big endian) -> num of the block calculating the uptime of the interval
+
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
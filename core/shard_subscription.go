@@ -0,0 +1,273 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/pkg/errors"
+)
+
+// ErrShardNotSubscribed is returned for the state of an account out of the
+// shards of a partial node.
+var ErrShardNotSubscribed = errors.New("account shard not subscribed by the node")
+
+// ShardSubscription is the coins and address ranges a partial state node
+// keeps. The range of an account is the first byte of its address, the index
+// of its trie in the state of the coin. A nil subscription keeps everything.
+//
+// A partial node can't validate blocks: the tries of the other ranges are
+// neither opened nor committed, their roots, the gas used and the receipts of
+// the transactions it doesn't run are taken from the block. It runs the
+// transactions changing the kept ranges, seeding their senders out of them
+// from the block, and stops when its kept state can't follow the header.
+type ShardSubscription struct {
+	coins map[string][]bool
+}
+
+// ParseShardSubscription reads a subscription, one coin per line as
+// "COIN=0,1,8-15". Empty lines and lines starting with # are skipped. The range
+// 0 of MAN holding the matrix state is always kept.
+func ParseShardSubscription(r io.Reader) (*ShardSubscription, error) {
+	sub := &ShardSubscription{coins: make(map[string][]bool)}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		idx := strings.Index(text, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("line %d: expected COIN=ranges", line)
+		}
+		coin := strings.TrimSpace(text[:idx])
+		if coin != params.MAN_COIN && !common.IsValidityCurrency(coin) {
+			return nil, fmt.Errorf("line %d: invalid coin %q", line, coin)
+		}
+		ranges, err := parseShardRanges(text[idx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if sub.coins[coin] == nil {
+			sub.coins[coin] = make([]bool, params.RANGE_MOUNTS)
+		}
+		for _, rng := range ranges {
+			sub.coins[coin][rng] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if sub.coins[params.MAN_COIN] == nil {
+		sub.coins[params.MAN_COIN] = make([]bool, params.RANGE_MOUNTS)
+	}
+	sub.coins[params.MAN_COIN][0] = true
+	return sub, nil
+}
+
+// ReadShardSubscription reads the subscription file of a partial node.
+func ReadShardSubscription(path string) (*ShardSubscription, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseShardSubscription(f)
+}
+
+func parseShardRanges(text string) ([]uint, error) {
+	ranges := make([]uint, 0)
+	for _, field := range strings.Split(text, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		from, to := field, field
+		if idx := strings.Index(field, "-"); idx >= 0 {
+			from, to = field[:idx], field[idx+1:]
+		}
+		start, err := strconv.ParseUint(strings.TrimSpace(from), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q", field)
+		}
+		end, err := strconv.ParseUint(strings.TrimSpace(to), 10, 32)
+		if err != nil || end < start || end >= uint64(params.RANGE_MOUNTS) {
+			return nil, fmt.Errorf("invalid range %q", field)
+		}
+		for rng := start; rng <= end; rng++ {
+			ranges = append(ranges, uint(rng))
+		}
+	}
+	if len(ranges) == 0 {
+		return nil, errors.New("no range")
+	}
+	return ranges, nil
+}
+
+// Coins returns the subscribed coins, sorted.
+func (s *ShardSubscription) Coins() []string {
+	if s == nil {
+		return nil
+	}
+	coins := make([]string, 0, len(s.coins))
+	for coin := range s.coins {
+		coins = append(coins, coin)
+	}
+	sort.Strings(coins)
+	return coins
+}
+
+// Contains reports whether the range of the coin is kept.
+func (s *ShardSubscription) Contains(coin string, rng uint) bool {
+	if s == nil {
+		return true
+	}
+	if coin == "" {
+		coin = params.MAN_COIN
+	}
+	ranges, exist := s.coins[coin]
+	return exist && rng < uint(len(ranges)) && ranges[rng]
+}
+
+// ContainsAccount reports whether the state of the account in the coin is kept.
+func (s *ShardSubscription) ContainsAccount(coin string, addr common.Address) bool {
+	return s.Contains(coin, uint(addr[0]))
+}
+
+// Touches reports whether a transaction of the coin changing the ranges
+// belongs to the subscription.
+func (s *ShardSubscription) Touches(coin string, ranges []uint) bool {
+	if s == nil {
+		return true
+	}
+	for _, rng := range ranges {
+		if s.Contains(coin, rng) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sharding returns the subscribed ranges of the coin, nil if the coin is not
+// subscribed.
+func (s *ShardSubscription) Sharding(coin string) []uint {
+	if s == nil {
+		return nil
+	}
+	ranges, exist := s.coins[coin]
+	if !exist {
+		return nil
+	}
+	result := make([]uint, 0)
+	for rng, kept := range ranges {
+		if kept {
+			result = append(result, uint(rng))
+		}
+	}
+	return result
+}
+
+// CoinShardings returns the subscription in the form of the block bodies.
+func (s *ShardSubscription) CoinShardings() []common.CoinSharding {
+	result := make([]common.CoinSharding, 0)
+	for _, coin := range s.Coins() {
+		result = append(result, common.CoinSharding{CoinType: coin, Shardings: s.Sharding(coin)})
+	}
+	return result
+}
+
+// Executes reports whether a partial node executes a transaction of the coin,
+// its sender or one of its recipients being in the kept ranges.
+func (s *ShardSubscription) Executes(coin string, tx types.SelfTransaction) bool {
+	if s.ContainsAccount(coin, tx.From()) {
+		return true
+	}
+	if to := tx.To(); to == nil {
+		if s.ContainsAccount(coin, crypto.CreateAddress(tx.From(), tx.Nonce())) {
+			return true
+		}
+	} else if s.ContainsAccount(coin, *to) {
+		return true
+	}
+	for _, ex := range tx.GetMatrix_EX() {
+		for _, to := range ex.ExtraTo {
+			if to.Recipient != nil && s.ContainsAccount(coin, *to.Recipient) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gasKept reports whether the gas of the transactions of the coin is paid into
+// the kept ranges, every transaction of the coin then changing them.
+func (s *ShardSubscription) gasKept(statedb *state.StateDBManage, coin string) bool {
+	if coin == params.MAN_COIN {
+		return s.ContainsAccount(coin, common.TxGasRewardAddress)
+	}
+	coinCfglist, err := matrixstate.GetCoinConfig(statedb)
+	if err != nil {
+		return true
+	}
+	for _, cc := range coinCfglist {
+		if cc.PackNum > 0 && cc.CoinType == coin {
+			return s.ContainsAccount(cc.CoinRange, cc.CoinAddress)
+		}
+	}
+	return true
+}
+
+// seedSender gives a sender out of the kept ranges the nonce and balance the
+// transaction spends, the partial node not holding its state. The block is
+// trusted for them, the writes to the skipped range are dropped with its trie.
+func (s *ShardSubscription) seedSender(statedb *state.StateDBManage, tx types.SelfTransaction) {
+	coin, from := tx.GetTxCurrency(), tx.From()
+	if s.ContainsAccount(coin, from) {
+		return
+	}
+	amount := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasPrice())
+	amount.Add(amount, tx.Value())
+	for _, ex := range tx.GetMatrix_EX() {
+		for _, to := range ex.ExtraTo {
+			if to.Amount != nil {
+				amount.Add(amount, to.Amount)
+			}
+		}
+	}
+	statedb.SetNonce(coin, from, tx.Nonce())
+	statedb.AddBalance(coin, common.MainAccount, from, amount)
+}
+
+// setHeaderShardRoots sets the range roots skipped by a partial node from the
+// header sharding, checked against the coin roots of the header.
+func setHeaderShardRoots(header *types.Header, statedb *state.StateDBManage) error {
+	remote := make(map[common.Hash][]common.Hash, len(header.Sharding))
+	for _, cb := range header.Sharding {
+		remote[cb.Root] = cb.Byte256
+	}
+	for _, cr := range header.Roots {
+		ranges, exist := remote[cr.Root]
+		if !exist {
+			return errors.Errorf("coin %s: no header sharding of root %s", cr.Cointyp, cr.Root.TerminalString())
+		}
+		if hash := types.RlpHash(ranges); hash != cr.Root {
+			return errors.Errorf("coin %s: header sharding hash(%s) != root(%s)", cr.Cointyp, hash.TerminalString(), cr.Root.TerminalString())
+		}
+		statedb.SetSkippedRoots(cr.Cointyp, ranges)
+	}
+	return nil
+}
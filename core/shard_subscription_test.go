@@ -0,0 +1,138 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+func TestParseShardSubscription(t *testing.T) {
+	config := `
+# exchange node
+MAN=3,8-10
+`
+	shards, err := ParseShardSubscription(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := shards.Sharding(params.MAN_COIN); len(have) != 5 || have[0] != 0 || have[1] != 3 || have[4] != 10 {
+		t.Fatalf("MAN shards mismatch: %v", have)
+	}
+	if !shards.Contains("", 9) || shards.Contains(params.MAN_COIN, 11) || shards.Sharding("BTC") != nil {
+		t.Fatalf("contains mismatch")
+	}
+	addr := common.Address{8}
+	if !shards.ContainsAccount(params.MAN_COIN, addr) || !shards.Touches(params.MAN_COIN, []uint{7, 8}) || shards.Touches(params.MAN_COIN, []uint{7}) {
+		t.Fatalf("account mismatch")
+	}
+	var full *ShardSubscription
+	if !full.ContainsAccount("BTC", addr) || !full.Touches("BTC", nil) {
+		t.Fatalf("full node does not keep everything")
+	}
+
+	for _, bad := range []string{"MAN", "MAN=", "MAN=5-2", "MAN=256", "man=1"} {
+		if _, err := ParseShardSubscription(strings.NewReader(bad)); err == nil {
+			t.Errorf("config %q parsed", bad)
+		}
+	}
+}
+
+func TestHeaderShardRoots(t *testing.T) {
+	shards, err := ParseShardSubscription(strings.NewReader("MAN=1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		db      = mandb.NewMemDatabase()
+		kept    = common.Address{1}
+		skipped = common.Address{2}
+	)
+	full, _ := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	full.AddBalance(params.MAN_COIN, common.MainAccount, kept, big.NewInt(1))
+	full.AddBalance(params.MAN_COIN, common.MainAccount, skipped, big.NewInt(1))
+	roots, sharding := full.IntermediateRoot(true)
+	header := &types.Header{Roots: roots, Sharding: sharding}
+
+	partial := func(balance int64) (*state.StateDBManage, state.Database) {
+		sdb := state.NewDatabase(db)
+		state.SetShardFilter(sdb, shards.Contains)
+		st, _ := state.NewStateDBManage(nil, db, sdb)
+		st.AddBalance(params.MAN_COIN, common.MainAccount, kept, big.NewInt(balance))
+		if err := setHeaderShardRoots(header, st); err != nil {
+			t.Fatal(err)
+		}
+		return st, sdb
+	}
+	// the skipped range is taken from the header and not committed
+	st, sdb := partial(1)
+	have, _, err := st.Commit(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have[0].Root != roots[0].Root {
+		t.Fatalf("partial root mismatch: have %x, want %x", have[0].Root, roots[0].Root)
+	}
+	if _, err := sdb.TrieDB().Node(sharding[0].Byte256[skipped[0]]); err == nil {
+		t.Fatalf("skipped range committed")
+	}
+	if _, err := sdb.TrieDB().Node(sharding[0].Byte256[kept[0]]); err != nil {
+		t.Fatalf("kept range not committed: %v", err)
+	}
+	// a kept range is still checked
+	st, _ = partial(2)
+	if have, _ := st.IntermediateRoot(true); have[0].Root == roots[0].Root {
+		t.Fatalf("invalid kept range passed")
+	}
+	header.Sharding[0].Byte256 = append([]common.Hash{}, header.Sharding[0].Byte256...)
+	header.Sharding[0].Byte256[3] = common.Hash{1}
+	if err := setHeaderShardRoots(header, st); err == nil {
+		t.Fatalf("header sharding of another root passed")
+	}
+}
+
+func TestShardExecutes(t *testing.T) {
+	shards, err := ParseShardSubscription(strings.NewReader("MAN=1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		kept    = common.Address{1}
+		skipped = common.Address{2}
+	)
+	transfer := func(from, to common.Address) *types.Transaction {
+		tx := types.NewTransaction(3|params.NonceAddOne, to, big.NewInt(5), 21000, big.NewInt(2), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), 0, 0, params.MAN_COIN, 0)
+		tx.SetFromLoad(from)
+		return tx
+	}
+	if !shards.Executes(params.MAN_COIN, transfer(kept, skipped)) || !shards.Executes(params.MAN_COIN, transfer(skipped, kept)) {
+		t.Fatalf("transfer of a kept account not executed")
+	}
+	if shards.Executes(params.MAN_COIN, transfer(skipped, common.Address{3})) {
+		t.Fatalf("transfer out of the kept ranges executed")
+	}
+
+	// a sender out of the kept ranges is seeded from the transaction
+	db := mandb.NewMemDatabase()
+	st, _ := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	tx := transfer(skipped, kept)
+	shards.seedSender(st, tx)
+	if nonce := st.GetNonce(params.MAN_COIN, skipped); nonce != tx.Nonce() {
+		t.Fatalf("seeded nonce mismatch: have %d, want %d", nonce, tx.Nonce())
+	}
+	if balance := st.GetBalanceByType(params.MAN_COIN, skipped, common.MainAccount); balance.Cmp(big.NewInt(5+21000*2)) != 0 {
+		t.Fatalf("seeded balance mismatch: have %v", balance)
+	}
+	shards.seedSender(st, transfer(kept, skipped))
+	if nonce := st.GetNonce(params.MAN_COIN, kept); nonce != params.NonceAddOne {
+		t.Fatalf("kept sender seeded")
+	}
+}
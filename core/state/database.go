@@ -80,6 +80,25 @@ type cachingDB struct {
 	pastTries *lru.Cache
 	//	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
+	filter        ShardFilter
+}
+
+// ShardFilter reports whether the node keeps the trie of an address range of
+// the coin.
+type ShardFilter func(cointyp string, rng uint) bool
+
+// SetShardFilter makes the states opened on db skip the range tries filtered
+// out, keeping only their roots. A nil filter keeps every range.
+func SetShardFilter(db Database, filter ShardFilter) {
+	if cdb, ok := db.(*cachingDB); ok {
+		cdb.filter = filter
+	}
+}
+
+// keepRange reports whether the range trie of the coin is opened on db.
+func keepRange(db Database, cointyp string, rng uint) bool {
+	cdb, ok := db.(*cachingDB)
+	return !ok || cdb.filter == nil || cdb.filter(cointyp, rng)
 }
 
 // OpenTrie opens the main account trie.
@@ -110,6 +110,63 @@ func (self *StateDB) RawDumpDB() DumpDB {
 	return dump
 }
 
+// Kinds of the items of a streamed state trie dump.
+const (
+	DumpItemMatrix uint8 = iota
+	DumpItemAccount
+	DumpItemStorage
+	DumpItemCode
+)
+
+// DumpItem is one entry of a streamed state trie dump. Addr is only set for the
+// storage items, the code items carry the code hash as Key and the code as Value.
+type DumpItem struct {
+	Kind  uint8
+	Addr  common.Address
+	Value DumpValue
+}
+
+// RawDumpIterate walks the state trie the same way as RawDumpDB, but hands the
+// entries to fn one by one instead of collecting them in memory. The storage items
+// of an account always follow the account item.
+func (self *StateDB) RawDumpIterate(fn func(item DumpItem) error) error {
+	it := trie.NewIterator(self.trie.NodeIterator(nil))
+	for it.Next() {
+		addr := self.trie.GetKey(it.Key)
+		if bytes.Compare(it.Value[:4], []byte("MAN-")) == 0 {
+			if err := fn(DumpItem{Kind: DumpItemMatrix, Value: DumpValue{it.Key, addr, it.Value}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(DumpItem{Kind: DumpItemAccount, Value: DumpValue{it.Key, addr, it.Value}}); err != nil {
+			return err
+		}
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return err
+		}
+
+		obj := newObject(nil, common.BytesToAddress(addr), data)
+		code := obj.Code(self.db)
+		if code != nil && common.Bytes2Hex(code) != "" {
+			if err := fn(DumpItem{Kind: DumpItemCode, Value: DumpValue{Key: data.CodeHash, Value: code}}); err != nil {
+				return err
+			}
+		}
+
+		keyAddr := common.BytesToAddress(addr)
+		storageIt := trie.NewIterator(obj.getTrie(self.db).NodeIterator(nil))
+		for storageIt.Next() {
+			item := DumpItem{Kind: DumpItemStorage, Addr: keyAddr, Value: DumpValue{storageIt.Key, self.trie.GetKey(storageIt.Key), storageIt.Value}}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Err
+}
+
 func (self *StateDB) RawDump1(dbDump *DumpDB) Dump {
 	dump := Dump{
 		Root:       fmt.Sprintf("%x", dbDump.Root),
@@ -22,7 +22,18 @@ import (
 type RangeManage struct {
 	Range byte
 	State *StateDB
+	skip  bool        //分区节点不保存的分区,不执行也不提交
+	root  common.Hash //不保存分区的root,取自区块头
 }
+
+// Root returns the root of the range trie, the kept root of a skipped range.
+func (rm *RangeManage) Root(deleteEmptyObjects bool) common.Hash {
+	if rm.skip {
+		return rm.root
+	}
+	return rm.State.IntermediateRoot(deleteEmptyObjects)
+}
+
 type CoinManage struct {
 	Cointyp string
 	Rmanage []*RangeManage
@@ -117,12 +128,18 @@ func (shard *StateDBManage) addShardings(cointyp string) {
 				}
 			}
 			for i, hash := range hashs {
-				stdb, err := newStatedb(hash, shard.db)
+				//分区节点不打开未订阅分区的树,只保留root
+				skip := !keepRange(shard.db, cointyp, uint(i))
+				open := hash
+				if skip {
+					open = common.Hash{}
+				}
+				stdb, err := newStatedb(open, shard.db)
 				if err != nil {
 					log.Error("sharding_statedb", "addShardings:newStatedb:err", err)
 					return
 				}
-				rms = append(rms, &RangeManage{Range: byte(i), State: stdb})
+				rms = append(rms, &RangeManage{Range: byte(i), State: stdb, skip: skip, root: hash})
 			}
 			cmg := &CoinManage{Cointyp: cointyp, Rmanage: rms}
 			shard.shardings = append(shard.shardings, cmg)
@@ -613,6 +630,8 @@ func (shard *StateDBManage) Copy() *StateDBManage {
 			rms = append(rms, &RangeManage{
 				Range: rm.Range,
 				State: sd,
+				skip:  rm.skip,
+				root:  rm.root,
 			})
 		}
 		state.shardings = append(state.shardings, &CoinManage{
@@ -689,7 +708,7 @@ func (shard *StateDBManage) IntermediateRoot(deleteEmptyObjects bool) ([]common.
 		var bshash common.Hash
 		root256 := make([]common.Hash, 0)
 		for _, rm := range cm.Rmanage {
-			root := rm.State.IntermediateRoot(deleteEmptyObjects)
+			root := rm.Root(deleteEmptyObjects)
 			root256 = append(root256, root)
 		}
 		bs, bshash := types.RlpEncodeAndHash(root256)
@@ -718,13 +737,83 @@ func (shard *StateDBManage) IntermediateRoot(deleteEmptyObjects bool) ([]common.
 	return shard.retcoinRoot, coinbytes
 }
 
+// CoinView returns a state holding the tries of the coin, shared with shard,
+// and a copy of the MAN state, so the transactions of the coin can run apart
+// from those of the other coins. It returns nil if the coin has no state.
+func (shard *StateDBManage) CoinView(cointyp string) *StateDBManage {
+	var man, coin *CoinManage
+	for _, cm := range shard.shardings {
+		switch cm.Cointyp {
+		case params.MAN_COIN:
+			man = cm
+		case cointyp:
+			coin = cm
+		}
+	}
+	if man == nil || coin == nil {
+		return nil
+	}
+	rms := make([]*RangeManage, 0, len(man.Rmanage))
+	for _, rm := range man.Rmanage {
+		rms = append(rms, &RangeManage{Range: rm.Range, State: rm.State.Copy(), skip: rm.skip, root: rm.root})
+	}
+	view := &StateDBManage{
+		db:          shard.db,
+		mdb:         shard.mdb,
+		shardings:   []*CoinManage{{Cointyp: params.MAN_COIN, Rmanage: rms}, coin},
+		coinRoot:    make([]common.CoinRoot, 0, 2),
+		retcoinRoot: make([]common.CoinRoot, 0, 2),
+	}
+	for _, cr := range shard.coinRoot {
+		if cr.Cointyp == params.MAN_COIN || cr.Cointyp == cointyp {
+			view.coinRoot = append(view.coinRoot, cr)
+		}
+	}
+	for _, cr := range shard.retcoinRoot {
+		if cr.Cointyp == params.MAN_COIN || cr.Cointyp == cointyp {
+			view.retcoinRoot = append(view.retcoinRoot, cr)
+		}
+	}
+	return view
+}
+
+// Modified reports whether the state of the coin has changes not finalised yet.
+func (shard *StateDBManage) Modified(cointyp string) bool {
+	for _, cm := range shard.shardings {
+		if cm.Cointyp == cointyp {
+			for _, rm := range cm.Rmanage {
+				if rm.State.journal.length() > 0 {
+					return true
+				}
+			}
+			break
+		}
+	}
+	return false
+}
+
+// SetSkippedRoots sets the roots of the skipped range tries of the coin, taken
+// from the block header on a partial node.
+func (shard *StateDBManage) SetSkippedRoots(cointyp string, roots []common.Hash) {
+	for _, cm := range shard.shardings {
+		if cm.Cointyp == cointyp {
+			for _, rm := range cm.Rmanage {
+				if rm.skip && int(rm.Range) < len(roots) {
+					rm.root = roots[rm.Range]
+				}
+			}
+			break
+		}
+	}
+}
+
 func (shard *StateDBManage) IntermediateRootByCointype(cointype string, deleteEmptyObjects bool) common.Hash {
 
 	root256 := make([]common.Hash, 0, 256)
 	for _, cm := range shard.shardings {
 		if cointype == cm.Cointyp {
 			for _, rm := range cm.Rmanage {
-				root := rm.State.IntermediateRoot(deleteEmptyObjects)
+				root := rm.Root(deleteEmptyObjects)
 				root256 = append(root256, root)
 			}
 			bs, bshash := types.RlpEncodeAndHash(root256)
@@ -785,6 +874,10 @@ func (shard *StateDBManage) Commit(deleteEmptyObjects bool) ([]common.CoinRoot,
 	for _, cm := range shard.shardings {
 		var roots = make([]common.Hash, 0, 256)
 		for _, rm := range cm.Rmanage {
+			if rm.skip {
+				roots = append(roots, rm.root)
+				continue
+			}
 			root, err := rm.State.Commit(deleteEmptyObjects)
 			if err != nil {
 				log.Error("file:sharding_statedb.go", "func:Commit", err)
@@ -891,6 +984,17 @@ func (shard *StateDBManage) DeleteMxData(hash common.Hash, val []byte) {
 	}
 }
 
+// GetBtreeIndex returns the index of the pending time or revocable transactions,
+// of every coin.
+func (shard *StateDBManage) GetBtreeIndex(typ byte) *btrie.TxIndex {
+	statedb, err := shard.GetStateDb(params.MAN_COIN, common.Address{})
+	if err != nil {
+		log.Error("sharding_statedb", "GetBtreeIndex:", err)
+		return btrie.NewTxIndex(nil)
+	}
+	return statedb.GetBtreeIndex(typ)
+}
+
 func (shard *StateDBManage) UpdateTxForBtree(key uint32) {
 	statedb, err := shard.GetStateDb(params.MAN_COIN, common.Address{})
 	if err != nil {
@@ -1240,6 +1344,19 @@ func (shard *StateDBManage) SetAuthStateByteArray(cointyp string, addr common.Ad
 	state.SetAuthStateByteArray(addr, value)
 }
 
+// RawDumpStates calls fn with the trie root and the state of every address range
+// of every coin, in the same order as RawDumpDB.
+func (shard *StateDBManage) RawDumpStates(fn func(coin string, index int, root common.Hash, st *StateDB) error) error {
+	for _, cm := range shard.shardings {
+		for i, rm := range cm.Rmanage {
+			if err := fn(cm.Cointyp, i, rm.State.trie.Hash(), rm.State); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (shard *StateDBManage) RawDumpDB() []CoinTrie {
 	snapCoinTrie := make([]CoinTrie, 0)
 	for _, shard := range shard.shardings {
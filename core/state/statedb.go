@@ -722,6 +722,27 @@ func (self *StateDB) GetBtreeItem(key uint32, typ byte) []btrie.Item {
 	return out
 }
 
+// GetBtreeIndex returns the index of the pending transactions of the btree. An
+// index of a committed btree is shared by the states of the same btree root.
+func (self *StateDB) GetBtreeIndex(typ byte) *btrie.TxIndex {
+	var (
+		tree *btrie.BTree
+		key  string
+	)
+	switch typ {
+	case common.ExtraRevocable:
+		tree, key = &self.revocablebtrie, common.StateDBRevocableBtree
+	case common.ExtraTimeTxType:
+		tree, key = &self.timebtrie, common.StateDBTimeBtree
+	default:
+		return btrie.NewTxIndex(nil)
+	}
+	if len(self.btreeMap) > 0 {
+		return btrie.NewTxIndex(tree)
+	}
+	return btrie.GetTxIndex(tree, typ, common.BytesToHash(self.GetMatrixData(types.RlpHash(key))))
+}
+
 func (self *StateDB) NewBTrie(typ byte) {
 	switch typ {
 	case common.ExtraRevocable:
@@ -16,12 +16,6 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
 
-	"bufio"
-	"io"
-	"os"
-	"strconv"
-	"strings"
-
 	"encoding/json"
 
 	"sort"
@@ -37,6 +31,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/reward/blkreward"
 	"github.com/MatrixAINetwork/go-matrix/reward/interest"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/reward/lottery"
 	"github.com/MatrixAINetwork/go-matrix/reward/slash"
 	"github.com/MatrixAINetwork/go-matrix/reward/txsreward"
@@ -119,7 +114,38 @@ func (env *StateProcessor) reverse(s []common.RewarTx) []common.RewarTx {
 	return s
 }
 
-func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.Header, upTime map[common.Address]uint64, account map[string][]common.Address, usedGas map[string]*big.Int) []common.RewarTx {
+// ProcessReward computes the rewards of the block with their reward ledger entries.
+func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.Header, upTime map[common.Address]uint64, account map[string][]common.Address, usedGas map[string]*big.Int) ([]common.RewarTx, []*ledger.Entry) {
+	recorder := ledger.NewRecorder()
+	rewarts := p.processReward(st, header, upTime, account, usedGas, recorder)
+	return rewarts, recorder.Entries()
+}
+
+// setLedgerRules stamps the calc engines of the previous state on the ledger entries.
+func (p *StateProcessor) setLedgerRules(recorder *ledger.Recorder, preState *state.StateDBManage) {
+	if recorder == nil {
+		return
+	}
+	rules := []struct {
+		source string
+		calc   func(matrixstate.StateDB) (string, error)
+	}{
+		{ledger.SourceBlock, matrixstate.GetBlkCalc},
+		{ledger.SourceTxs, matrixstate.GetTxsCalc},
+		{ledger.SourceLottery, matrixstate.GetLotteryCalc},
+		{ledger.SourceInterest, matrixstate.GetInterestCalc},
+		{ledger.SourceSlash, matrixstate.GetSlashCalc},
+	}
+	for _, rule := range rules {
+		if calc, err := rule.calc(preState); err == nil {
+			recorder.SetRule(rule.source, calc)
+		}
+	}
+}
+
+// processReward computes the rewards of the block, recording every reward and
+// slash in recorder when it is not nil.
+func (p *StateProcessor) processReward(st *state.StateDBManage, header *types.Header, upTime map[common.Address]uint64, account map[string][]common.Address, usedGas map[string]*big.Int, recorder *ledger.Recorder) []common.RewarTx {
 	bcInterval, err := matrixstate.GetBroadcastInterval(st)
 	if err != nil {
 		log.Error("奖励", "获取广播周期失败", err)
@@ -133,6 +159,7 @@ func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.He
 		log.Error("奖励", "获取前一个状态错误", err)
 		return nil
 	}
+	p.setLedgerRules(recorder, preState)
 
 	var ppreState *state.StateDBManage
 	if header.Number.Uint64() == 1 {
@@ -149,6 +176,7 @@ func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.He
 	blkReward := blkreward.New(p.bc, st, preState, ppreState)
 	rewardList := make([]common.RewarTx, 0)
 	if nil != blkReward {
+		blkReward.SetLedger(recorder)
 		minersRewardMap := blkReward.CalcMinerRewards(header.Number.Uint64(), header.ParentHash)
 		if 0 != len(minersRewardMap) {
 			rewardList = append(rewardList, common.RewarTx{CoinRange: params.MAN_COIN, CoinType: params.MAN_COIN, Fromaddr: common.BlkMinerRewardAddress, To_Amont: minersRewardMap, RewardTyp: common.RewardMinerType})
@@ -163,12 +191,14 @@ func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.He
 	txsReward := txsreward.New(p.bc, st, preState, ppreState)
 
 	if nil != txsReward {
+		txsReward.SetLedger(recorder)
 		rewardList = p.processMultiCoinReward(usedGas, st, preState, txsReward, header, rewardList)
 	}
 
 	lottery := lottery.New(p.bc, st, p.random, preState)
 	if nil != lottery {
 		lotteryRewardMap := lottery.LotteryCalc(header.ParentHash, header.Number.Uint64())
+		recorder.RecordMap(ledger.SourceLottery, ledger.TypeLottery, params.MAN_COIN, lotteryRewardMap)
 		if 0 != len(lotteryRewardMap) {
 			rewardList = append(rewardList, common.RewarTx{CoinRange: params.MAN_COIN, CoinType: params.MAN_COIN, Fromaddr: common.LotteryRewardAddress, To_Amont: lotteryRewardMap, RewardTyp: common.RewardLotteryType})
 		}
@@ -178,19 +208,29 @@ func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.He
 	interestReward := interest.ManageNew(st, preState)
 
 	if nil == interestReward {
-		return util.AccumulatorCheck(st, rewardList)
+		return checkRewards(st, rewardList, recorder)
 	}
+	interestReward.SetLedger(recorder)
 	interestReward.CalcReward(st, header.Number.Uint64(), header.ParentHash)
 
 	slash := slash.ManageNew(p.bc, st, preState)
 	if nil != slash {
+		slash.SetLedger(recorder)
 		slash.CalcSlash(st, header.Number.Uint64(), upTime, header.ParentHash, header.Time.Uint64())
 	}
 	interestPayMap := interestReward.PayInterest(st, header.Number.Uint64(), header.Time.Uint64())
 	if 0 != len(interestPayMap) {
 		rewardList = append(rewardList, common.RewarTx{CoinRange: params.MAN_COIN, CoinType: params.MAN_COIN, Fromaddr: common.InterestRewardAddress, To_Amont: interestPayMap, RewardTyp: common.RewardInterestType})
 	}
-	return util.AccumulatorCheck(st, rewardList)
+	return checkRewards(st, rewardList, recorder)
+}
+
+// checkRewards drops the rewards the pools can not pay, with their ledger
+// entries.
+func checkRewards(st *state.StateDBManage, rewardList []common.RewarTx, recorder *ledger.Recorder) []common.RewarTx {
+	paid := util.AccumulatorCheck(st, rewardList)
+	recorder.KeepPaid(paid)
+	return paid
 }
 
 func (p *StateProcessor) processMultiCoinReward(usedGas map[string]*big.Int, currentState *state.StateDBManage, preState *state.StateDBManage, txsReward reward.Reward, header *types.Header, rewardList []common.RewarTx) []common.RewarTx {
@@ -255,66 +295,6 @@ func (p *StateProcessor) ProcessSuperBlk(block *types.Block, statedb *state.Stat
 	return nil
 }
 
-func (p *StateProcessor) readShardConfig(filePth string) ([]common.CoinSharding, error) {
-	f, err := os.Open(filePth)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	bfRd := bufio.NewReader(f)
-	coinshard := make([]common.CoinSharding, 0)
-	for {
-		ushards := make([]uint, 0)
-		buf, _, err := bfRd.ReadLine()
-		if len(buf) <= 0 {
-			if err != nil { //遇到任何错误立即返回，并忽略 EOF 错误信息
-				if err == io.EOF {
-					break
-				}
-				return nil, err
-			}
-		}
-		str := string(buf)
-		idx := strings.Index(str, "=")
-		coin := str[0:idx]
-		shards := str[idx+1:]
-		strs := strings.Split(shards, ",")
-		for _, str := range strs {
-			i, _ := strconv.Atoi(str)
-			ushards = append(ushards, uint(i))
-		}
-		coinshard = append(coinshard, common.CoinSharding{CoinType: coin, Shardings: ushards})
-	}
-	return coinshard, nil
-}
-func (p *StateProcessor) checkCoinShard(coinShard []common.CoinSharding) []common.CoinSharding {
-	isexistCoin := false
-	isexistShard := false
-	for _, cs := range coinShard {
-		if cs.CoinType == params.MAN_COIN {
-			isexistCoin = true
-			for _, s := range cs.Shardings {
-				if s == 0 {
-					isexistShard = true
-				}
-			}
-			break
-		}
-	}
-	if !isexistCoin {
-		coinShard = append(coinShard, common.CoinSharding{CoinType: params.MAN_COIN, Shardings: []uint{0}})
-	} else if isexistShard {
-		for i, cs := range coinShard {
-			ui := make([]uint, 0)
-			if cs.CoinType == params.MAN_COIN {
-				ui = append(ui, uint(0))
-				ui = append(ui, coinShard[i].Shardings...)
-				coinShard[i].Shardings = ui
-			}
-		}
-	}
-	return coinShard
-}
 func myCoinsort(coins []string) []string {
 	coinsnoman := make([]string, 0, len(coins))
 	retCoins := make([]string, 0, len(coins))
@@ -337,6 +317,13 @@ func myCoinsort(coins []string) []string {
 // returns the amount of gas that was used in the process. If any of the
 // transactions failed to execute due to insufficient gas it will return an error.
 func (p *StateProcessor) ProcessTxs(block *types.Block, statedb *state.StateDBManage, cfg vm.Config, upTime map[common.Address]uint64) ([]types.CoinLogs, uint64, error) {
+	logs, usedGas, _, err := p.processTxs(block, statedb, cfg, upTime)
+	return logs, usedGas, err
+}
+
+// processTxs processes the transactions of the block, also returning the reward
+// ledger entries of the block.
+func (p *StateProcessor) processTxs(block *types.Block, statedb *state.StateDBManage, cfg vm.Config, upTime map[common.Address]uint64) ([]types.CoinLogs, uint64, []*ledger.Entry, error) {
 	var (
 		//receipts    types.Receipts
 		allreceipts = make(map[string]types.Receipts)
@@ -346,17 +333,11 @@ func (p *StateProcessor) ProcessTxs(block *types.Block, statedb *state.StateDBMa
 		gp          = new(GasPool).AddGas(block.GasLimit())
 		retAllGas   = make(map[string]*big.Int)
 	)
-	cs, cserr := p.readShardConfig("")
-	var coinShard []common.CoinSharding
-	if cserr == nil {
-		coinShard = make([]common.CoinSharding, len(cs))
-		copy(coinShard, cs)
-		coinShard = p.checkCoinShard(coinShard)
-	}
+	shards := p.bc.ShardSubscription()
+	kept := make(map[common.Hash]bool) //分区节点保存的交易
 	// Iterate over and process the individual transactions
 	statedb.UpdateTxForBtree(uint32(block.Time().Uint64()))
 	statedb.UpdateTxForBtreeBytime(uint32(block.Time().Uint64()))
-	txs := make([]types.SelfTransaction, 0)
 	var txcount int
 	tmpMaptx := make(map[string]types.SelfTransactions)
 	tmpMapre := make(map[string]types.Receipts)
@@ -399,8 +380,26 @@ func (p *StateProcessor) ProcessTxs(block *types.Block, statedb *state.StateDBMa
 	}
 
 	waitG.Wait()
-	from := make(map[string][]common.Address)
 	isvadter := p.isValidater(header.ParentHash)
+	if isvadter && shards != nil {
+		return nil, 0, nil, errors.New("分区节点不能作为验证者执行区块")
+	}
+	//分区节点只执行改变订阅分区的交易,其余分区的root取自区块头
+	if shards != nil {
+		for coinname, txs := range txsmap {
+			if shards.gasKept(statedb, coinname) {
+				continue
+			}
+			executed := make(types.SelfTransactions, 0, len(txs))
+			for _, tx := range txs {
+				if shards.Executes(coinname, tx) {
+					executed = append(executed, tx)
+				}
+			}
+			txsmap[coinname] = executed
+		}
+	}
+	from := make(map[string][]common.Address)
 	coins := make([]string, 0)
 	coinsnoman := make([]string, 0)
 	for coin, _ := range txsmap {
@@ -412,73 +411,38 @@ func (p *StateProcessor) ProcessTxs(block *types.Block, statedb *state.StateDBMa
 	sort.Strings(coinsnoman)
 	coins = append(coins, params.MAN_COIN)
 	coins = append(coins, coinsnoman...)
-	//先跑MAN交易,再跑其他币种交易
-	for _, coinname := range coins {
-		txs = txsmap[coinname]
-		for i, tx := range txs {
-			if tx.IsEntrustTx() {
-				from := tx.From()
-				entrustFrom := statedb.GetGasAuthFrom(tx.GetTxCurrency(), from, p.bc.CurrentBlock().NumberU64()) //
-				if !entrustFrom.Equal(common.Address{}) {
-					tx.Setentrustfrom(entrustFrom)
-					tx.SetIsEntrustGas(true)
-				} else {
-					entrustFrom := statedb.GetGasAuthFromByTime(tx.GetTxCurrency(), from, uint64(block.Time().Uint64()))
-					if !entrustFrom.Equal(common.Address{}) {
-						tx.Setentrustfrom(entrustFrom)
-						tx.SetIsEntrustGas(true)
-						tx.SetIsEntrustByTime(true)
-					} else {
-						entrustFrom := statedb.GetGasAuthFromByCount(tx.GetTxCurrency(), from)
-						if !entrustFrom.Equal(common.Address{}) {
-							tx.Setentrustfrom(entrustFrom)
-							tx.SetIsEntrustGas(true)
-							tx.SetIsEntrustByCount(true)
-						} else {
-							log.Error("下载过程:该用户没有被授权过委托Gas或授权失效")
-							return nil, 0, ErrWithoutAuth
-						}
-					}
-				}
-			}
-			statedb.Prepare(tx.Hash(), block.Hash(), i)
-			receipt, gas, shard, err := ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
-			if err != nil {
-				return nil, 0, err
-			}
+	//先跑MAN交易,再跑其他币种交易,互不影响的币种并行执行
+	apply := func(statedb *state.StateDBManage, gp *GasPool, usedGas *uint64, coinname string, txs types.SelfTransactions) (*coinExecution, error) {
+		return p.applyCoinTxs(block, header, statedb, gp, usedGas, coinname, txs, cfg)
+	}
+	execs, err := newCoinScheduler(statedb, gp, usedGas, runtime.GOMAXPROCS(0), apply).run(coins, txsmap)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	for _, exec := range execs {
+		for i, tx := range exec.txs {
+			receipt, shard := exec.receipts[i], exec.shards[i]
 			allreceipts[tx.GetTxCurrency()] = append(allreceipts[tx.GetTxCurrency()], receipt)
 			//retAllGas[tx.GetTxCurrency()] += gas
 			if _, ok := retAllGas[tx.GetTxCurrency()]; !ok {
-				retAllGas[tx.GetTxCurrency()] = new(big.Int).SetUint64(gas)
+				retAllGas[tx.GetTxCurrency()] = new(big.Int).SetUint64(receipt.GasUsed)
 			} else {
-				retAllGas[tx.GetTxCurrency()].Add(retAllGas[tx.GetTxCurrency()], new(big.Int).SetUint64(gas))
+				retAllGas[tx.GetTxCurrency()].Add(retAllGas[tx.GetTxCurrency()], new(big.Int).SetUint64(receipt.GasUsed))
 			}
 
-			if isvadter {
-				//receipts = append(receipts, receipt)
+			if isvadter || shards.Touches(tx.GetTxCurrency(), shard) {
 				allLogs = append(allLogs, types.CoinLogs{CoinType: tx.GetTxCurrency(), Logs: receipt.Logs})
-				tmpMaptx[tx.GetTxCurrency()] = append(tmpMaptx[tx.GetTxCurrency()], tx)
-				tmpMapre[tx.GetTxCurrency()] = append(tmpMapre[tx.GetTxCurrency()], receipt)
-			} else {
-				if p.isaddSharding(shard, coinShard, tx.GetTxCurrency()) {
-					//receipts = append(receipts, receipt)
-					allLogs = append(allLogs, types.CoinLogs{CoinType: tx.GetTxCurrency(), Logs: receipt.Logs})
-					tmpMaptx[tx.GetTxCurrency()] = append(tmpMaptx[tx.GetTxCurrency()], tx)
-					tmpMapre[tx.GetTxCurrency()] = append(tmpMapre[tx.GetTxCurrency()], receipt)
-				} else {
-					if _, ok := tmpMaptx[tx.GetTxCurrency()]; ok {
-						//receipts = append(receipts, nil)
-						tmpMaptx[tx.GetTxCurrency()] = append(tmpMaptx[tx.GetTxCurrency()], nil)
-						tmpMapre[tx.GetTxCurrency()] = append(tmpMapre[tx.GetTxCurrency()], nil)
-					}
-				}
+				kept[tx.Hash()] = true
 			}
+			tmpMaptx[tx.GetTxCurrency()] = append(tmpMaptx[tx.GetTxCurrency()], tx)
+			tmpMapre[tx.GetTxCurrency()] = append(tmpMapre[tx.GetTxCurrency()], receipt)
 			txcount = i
 			from[tx.GetTxCurrency()] = append(from[tx.GetTxCurrency()], tx.From())
 		}
 	}
 	//statedb.Finalise("MAN",true)
-	rewarts := p.ProcessReward(statedb, block.Header(), upTime, from, retAllGas)
+	recorder := ledger.NewRecorder()
+	rewarts := p.processReward(statedb, block.Header(), upTime, from, retAllGas, recorder)
 	tmpmapcoin := make(map[string]bool) //为了拿到币种,v值无意义
 	for _, rewart := range rewarts {
 		tmpmapcoin[rewart.CoinRange] = true
@@ -495,39 +459,33 @@ func (p *StateProcessor) ProcessTxs(block *types.Block, statedb *state.StateDBMa
 		ftxs := make([]types.SelfTransaction, 0)
 		receipts := make(types.Receipts, 0)
 		for _, tx := range tmpRewardtxs {
+			if !shards.Executes(coinname, tx) {
+				continue
+			}
+			shards.seedSender(statedb, tx)
 			statedb.Prepare(tx.Hash(), block.Hash(), txcount+1)
 			receipt, _, shard, err := ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
 			if err != nil {
-				return nil, 0, err
+				return nil, 0, nil, err
 			}
 			//tmpr2 := make(types.Receipts, 1+len(allreceipts[tx.GetTxCurrency()]))
 			//tmpr2[0] = receipt
 			//copy(tmpr2[1:], allreceipts[tx.GetTxCurrency()])
 			//allreceipts[tx.GetTxCurrency()] = tmpr2
 
-			var tmptx types.SelfTransaction
-			if isvadter {
-				tmptx = tx
-			} else {
-				if p.isaddSharding(shard, coinShard, tx.GetTxCurrency()) {
-					tmptx = tx
-				} else {
-					tmptx = nil
-					receipt = nil
-				}
-			}
 			//tmpr := make(types.Receipts, 1+len(receipts))
 			//tmpr[0] = receipt
 			//copy(tmpr[1:], receipts)
 			receipts = append(receipts, receipt)
-			if receipt != nil {
+			if isvadter || shards.Touches(tx.GetTxCurrency(), shard) {
+				kept[tx.Hash()] = true
 				tmpl := make([]types.CoinLogs, 0)
 				//tmpl = append(tmpl, types.CoinLogs{CoinType: params.MAN_COIN, Logs: receipt.Logs})
 				tmpl = append(tmpl, types.CoinLogs{CoinType: coinname, Logs: receipt.Logs})
 				tmpl = append(tmpl, allLogs...)
 				allLogs = tmpl
 			}
-			ftxs = append(ftxs, tmptx)
+			ftxs = append(ftxs, tx)
 		}
 		receipts = append(receipts, tmpMapre[coinname]...) //所属分区币种的所有收据
 		tmpMapre[coinname] = receipts
@@ -538,32 +496,106 @@ func (p *StateProcessor) ProcessTxs(block *types.Block, statedb *state.StateDBMa
 	statedb.Finalise("", true)
 	currblock := make([]types.CurrencyBlock, 0, len(block.Currencies()))
 	for i, bc := range block.Currencies() {
-		if !isvadter {
-			if len(coinShard) > 0 {
-				for _, cs := range coinShard {
-					if bc.CurrencyName == cs.CoinType {
-						block.Currencies()[i].Receipts = types.SetReceipts(tmpMapre[bc.CurrencyName], tmpMapre[bc.CurrencyName].HashList(), cs.Shardings)
-						block.Currencies()[i].Transactions = types.SetTransactions(tmpMaptx[bc.CurrencyName], types.TxHashList(txs), cs.Shardings)
-						currblock = append(currblock, block.Currencies()[i])
-						break
-					}
-				}
+		receipts := tmpMapre[bc.CurrencyName]
+		if isvadter || shards == nil {
+			block.Currencies()[i].Receipts = types.SetReceipts(receipts, receipts.HashList(), nil)
+			currblock = append(currblock, block.Currencies()[i])
+			continue
+		}
+		//分区节点只保存订阅分区的交易和收据,未执行交易的收据hash取自区块体
+		sharding := shards.Sharding(bc.CurrencyName)
+		if sharding == nil {
+			continue
+		}
+		txs := bc.Transactions.GetTransactions()
+		hashes := bc.Receipts.RsHashs
+		if len(hashes) != len(txs) {
+			return nil, 0, nil, errors.Errorf("coin %s: %d receipt hashes of %d transactions", bc.CurrencyName, len(hashes), len(txs))
+		}
+		executed := make(map[common.Hash]*types.Receipt, len(receipts))
+		for j, tx := range tmpMaptx[bc.CurrencyName] {
+			executed[tx.Hash()] = receipts[j]
+		}
+		shardTxs := make(types.SelfTransactions, 0, len(txs))
+		shardReceipts := make(types.Receipts, len(txs))
+		for j, tx := range txs {
+			if kept[tx.Hash()] {
+				shardTxs = append(shardTxs, tx)
+				shardReceipts[j] = executed[tx.Hash()]
 			} else {
-				block.Currencies()[i].Receipts = types.SetReceipts(tmpMapre[bc.CurrencyName], tmpMapre[bc.CurrencyName].HashList(), nil)
-				currblock = append(currblock, block.Currencies()[i])
+				shardTxs = append(shardTxs, nil)
 			}
-
-		} else {
-			block.Currencies()[i].Receipts = types.SetReceipts(tmpMapre[bc.CurrencyName], tmpMapre[bc.CurrencyName].HashList(), nil)
-			currblock = append(currblock, block.Currencies()[i])
 		}
+		block.Currencies()[i].Receipts = types.SetReceipts(shardReceipts, hashes, sharding)
+		block.Currencies()[i].Transactions = types.SetTransactions(shardTxs, bc.Transactions.TxHashs, sharding)
+		currblock = append(currblock, block.Currencies()[i])
 	}
 	block.SetCurrencies(currblock)
+	if shards != nil {
+		if err := setHeaderShardRoots(header, statedb); err != nil {
+			return nil, 0, nil, err
+		}
+	}
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	p.engine.Finalize(p.bc, header, statedb, block.Uncles(), block.Currencies())
+	//分区节点不校验区块,订阅分区的状态无法跟随区块头时停止
+	if shards != nil {
+		for _, cr := range header.Roots {
+			for _, br := range block.Root() {
+				if cr.Cointyp == br.Cointyp && cr.Root != br.Root {
+					return nil, 0, nil, errors.Errorf("coin %s: kept shards diverged from the header (remote: %x local: %x)", cr.Cointyp, br.Root, cr.Root)
+				}
+			}
+		}
+	}
 
-	return allLogs, *usedGas, nil
+	return allLogs, *usedGas, recorder.Entries(), nil
+}
+
+// applyCoinTxs applies the transactions of a coin sub-block on statedb.
+func (p *StateProcessor) applyCoinTxs(block *types.Block, header *types.Header, statedb *state.StateDBManage, gp *GasPool, usedGas *uint64, coinname string, txs types.SelfTransactions, cfg vm.Config) (*coinExecution, error) {
+	exec := &coinExecution{coin: coinname, txs: txs, receipts: make(types.Receipts, 0, len(txs)), shards: make([][]uint, 0, len(txs))}
+	shards := p.bc.ShardSubscription()
+	for i, tx := range txs {
+		//分区节点没有未订阅分区发送方的委托信息,gas由发送方支付
+		if tx.IsEntrustTx() && shards.ContainsAccount(tx.GetTxCurrency(), tx.From()) {
+			from := tx.From()
+			entrustFrom := statedb.GetGasAuthFrom(tx.GetTxCurrency(), from, p.bc.CurrentBlock().NumberU64()) //
+			if !entrustFrom.Equal(common.Address{}) {
+				tx.Setentrustfrom(entrustFrom)
+				tx.SetIsEntrustGas(true)
+			} else {
+				entrustFrom := statedb.GetGasAuthFromByTime(tx.GetTxCurrency(), from, uint64(block.Time().Uint64()))
+				if !entrustFrom.Equal(common.Address{}) {
+					tx.Setentrustfrom(entrustFrom)
+					tx.SetIsEntrustGas(true)
+					tx.SetIsEntrustByTime(true)
+				} else {
+					entrustFrom := statedb.GetGasAuthFromByCount(tx.GetTxCurrency(), from)
+					if !entrustFrom.Equal(common.Address{}) {
+						tx.Setentrustfrom(entrustFrom)
+						tx.SetIsEntrustGas(true)
+						tx.SetIsEntrustByCount(true)
+					} else {
+						log.Error("下载过程:该用户没有被授权过委托Gas或授权失效")
+						return nil, ErrWithoutAuth
+					}
+				}
+			}
+		}
+		shards.seedSender(statedb, tx)
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		receipt, gas, shard, err := ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
+		if err != nil {
+			return nil, err
+		}
+		exec.receipts = append(exec.receipts, receipt)
+		exec.shards = append(exec.shards, shard)
+		exec.gas += gas
+	}
+	return exec, nil
 }
+
 func (p *StateProcessor) isValidater(hash common.Hash) bool {
 	roles, _ := ca.GetElectedByHeightAndRoleByHash(hash, common.RoleValidator)
 	for _, role := range roles {
@@ -576,71 +608,49 @@ func (p *StateProcessor) isValidater(hash common.Hash) bool {
 	}
 	return false
 }
-func (p *StateProcessor) isaddSharding(shard []uint, shardings []common.CoinSharding, cointyp string) bool {
-	if len(shardings) == 0 {
-		return true
-	}
-	for _, s := range shard {
-		if s == 0 && cointyp == params.MAN_COIN {
-			return true
-		}
-		for _, ss := range shardings {
-			if cointyp == ss.CoinType {
-				for _, sd := range ss.Shardings {
-					if sd == s {
-						return true
-					}
-				}
-				break
-			}
-		}
-	}
-	return false
-}
-
-func (p *StateProcessor) Process(block *types.Block, parent *types.Block, statedb *state.StateDBManage, cfg vm.Config) ([]types.CoinReceipts, []types.CoinLogs, uint64, error) {
+func (p *StateProcessor) Process(block *types.Block, parent *types.Block, statedb *state.StateDBManage, cfg vm.Config) ([]types.CoinReceipts, []types.CoinLogs, uint64, []*ledger.Entry, error) {
 
 	err := p.bc.ProcessStateVersion(block.Version(), statedb)
 	if err != nil {
 		log.Trace("BlockChain insertChain in3 Process Block err0")
-		return nil, nil, 0, err
+		return nil, nil, 0, nil, err
 	}
 
 	if err = p.bc.ProcessStateVersionSwitch(block.NumberU64(), block.Time().Uint64(), statedb); err != nil {
 		log.Trace("BlockChain insertChain in3 Process Block err1")
-		return nil, nil, 0, err
+		return nil, nil, 0, nil, err
 	}
 
 	uptimeMap, err := p.bc.ProcessUpTime(statedb, block.Header())
 	if err != nil {
 		log.Trace("BlockChain insertChain in3 Process Block err2")
 		p.bc.reportBlock(block, nil, err)
-		return nil, nil, 0, err
+		return nil, nil, 0, nil, err
 	}
 
 	err = p.bc.ProcessBlockGProduceSlash(string(block.Version()), statedb, block.Header())
 	if err != nil {
 		log.Trace("BlockChain insertChain in3 Process Block err3")
 		p.bc.reportBlock(block, nil, err)
-		return nil, nil, 0, err
+		return nil, nil, 0, nil, err
 	}
 
 	// Process block using the parent state as reference point.
-	logs, usedGas, err := p.ProcessTxs(block, statedb, cfg, uptimeMap)
+	logs, usedGas, rewards, err := p.processTxs(block, statedb, cfg, uptimeMap)
 	if err != nil {
 		log.Trace("BlockChain insertChain in3 Process Block err4")
 		p.bc.reportBlock(block, nil, err)
-		return nil, logs, usedGas, err
+		return nil, logs, usedGas, nil, err
 	}
 
 	// Process matrix state
 	err = p.bc.matrixProcessor.ProcessMatrixState(block, string(parent.Version()), statedb)
 	if err != nil {
 		log.Trace("BlockChain insertChain in3 Process Block err5")
-		return nil, logs, usedGas, err
+		return nil, logs, usedGas, nil, err
 	}
 
-	return nil, logs, usedGas, nil
+	return nil, logs, usedGas, rewards, nil
 }
 
 // ApplyTransaction attempts to apply a transaction to the given state database
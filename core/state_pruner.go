@@ -0,0 +1,249 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"bytes"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/MatrixAINetwork/go-matrix/trie"
+	"github.com/pkg/errors"
+)
+
+var (
+	errPruneSyncing    = errors.New("state pruning not allowed while syncing")
+	errPruneIterateDB  = errors.New("database can not be iterated")
+	emptyStateCodeHash = crypto.Keccak256Hash(nil)
+)
+
+// stateMarker collects the hashes of the state nodes reachable from the state
+// of blocks: the range root lists of the coins, the trie nodes of the ranges
+// and of the account storages, and the account codes.
+type stateMarker struct {
+	db     mandb.Database
+	triedb *trie.Database
+	marked map[common.Hash]struct{}
+}
+
+func newStateMarker(db mandb.Database, triedb *trie.Database) *stateMarker {
+	return &stateMarker{db: db, triedb: triedb, marked: make(map[common.Hash]struct{})}
+}
+
+func (m *stateMarker) isMarked(hash common.Hash) bool {
+	_, marked := m.marked[hash]
+	return marked
+}
+
+// markRoots marks the state of the coin roots of a block. A state missing from
+// the database, never written to disk, is skipped.
+func (m *stateMarker) markRoots(roots []common.CoinRoot) error {
+	for _, cr := range roots {
+		if cr.Root == (common.Hash{}) || m.isMarked(cr.Root) {
+			continue
+		}
+		data, err := m.db.Get(cr.Root[:])
+		if err != nil {
+			continue
+		}
+		var ranges []common.Hash
+		if err := rlp.DecodeBytes(data, &ranges); err != nil {
+			return errors.Errorf("coin %s: invalid range roots %s: %v", cr.Cointyp, cr.Root.TerminalString(), err)
+		}
+		m.marked[cr.Root] = struct{}{}
+		for _, root := range ranges {
+			if err := m.markTrie(root, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// markTrie marks the nodes of a trie, skipping the subtries already marked. The
+// leaves of the range tries holding accounts bring in their storage and code.
+func (m *stateMarker) markTrie(root common.Hash, accounts bool) error {
+	if root == (common.Hash{}) || root == types.EmptyRootHash || m.isMarked(root) {
+		return nil
+	}
+	t, err := trie.New(root, m.triedb)
+	if err != nil {
+		return ignoreMissingNode(err)
+	}
+	it := t.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		descend = true
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			if m.isMarked(hash) {
+				descend = false
+				continue
+			}
+			m.marked[hash] = struct{}{}
+			continue
+		}
+		if !accounts || !it.Leaf() {
+			continue
+		}
+		// the matrix data leaves are not accounts
+		var account state.Account
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			continue
+		}
+		if code := common.BytesToHash(account.CodeHash); len(account.CodeHash) > 0 && code != emptyStateCodeHash {
+			m.marked[code] = struct{}{}
+		}
+		if err := m.markTrie(account.Root, false); err != nil {
+			return err
+		}
+	}
+	return ignoreMissingNode(it.Error())
+}
+
+func ignoreMissingNode(err error) error {
+	if _, missing := err.(*trie.MissingNodeError); missing {
+		return nil
+	}
+	return err
+}
+
+// stateNode is a state node found on disk and not marked.
+type stateNode struct {
+	key  []byte
+	size common.StorageSize
+}
+
+// isStateNode reports whether the database entry is a trie node or a range
+// root list, content addressed by the hash of its value. The codes and the
+// other entries are never pruned.
+func isStateNode(key, value []byte) bool {
+	if len(key) != common.HashLength {
+		return false
+	}
+	content, rest, err := rlp.SplitList(value)
+	if err != nil || len(rest) != 0 {
+		return false
+	}
+	count, err := rlp.CountValues(content)
+	if err != nil || (count != 2 && count != 17 && count != params.RANGE_MOUNTS) {
+		return false
+	}
+	return bytes.Equal(crypto.Keccak256(value), key)
+}
+
+// collectStateNodes returns the state nodes of the database not marked.
+func collectStateNodes(db mandb.Database, marker *stateMarker) ([]stateNode, error) {
+	nodes := make([]stateNode, 0)
+	collect := func(key, value []byte) {
+		if isStateNode(key, value) && !marker.isMarked(common.BytesToHash(key)) {
+			nodes = append(nodes, stateNode{key: common.CopyBytes(key), size: common.StorageSize(len(key) + len(value))})
+		}
+	}
+	switch db := db.(type) {
+	case *mandb.LDBDatabase:
+		it := db.NewIterator()
+		defer it.Release()
+		for it.Next() {
+			collect(it.Key(), it.Value())
+		}
+		return nodes, it.Error()
+	case *mandb.MemDatabase:
+		for _, key := range db.Keys() {
+			if value, err := db.Get(key); err == nil {
+				collect(key, value)
+			}
+		}
+		return nodes, nil
+	}
+	return nil, errPruneIterateDB
+}
+
+// deleteStateNodes deletes the nodes still not marked, returning their count
+// and size. With dryRun nothing is deleted.
+func deleteStateNodes(db mandb.Database, marker *stateMarker, nodes []stateNode, dryRun bool) (int, common.StorageSize, error) {
+	var (
+		count int
+		size  common.StorageSize
+	)
+	for _, node := range nodes {
+		if marker.isMarked(common.BytesToHash(node.key)) {
+			continue
+		}
+		if !dryRun {
+			if err := db.Delete(node.key); err != nil {
+				return count, size, err
+			}
+		}
+		count++
+		size += node.size
+	}
+	return count, size, nil
+}
+
+// PruneState deletes the state not reachable from the blocks of keep, the
+// genesis and the last blocks of the canonical chain, returning the count and
+// size of the nodes deleted. With dryRun it only counts them. The state is
+// walked without holding the chain; the blocks inserted meanwhile are marked
+// before deleting.
+func (bc *BlockChain) PruneState(keep []common.Hash, dryRun bool) (int, common.StorageSize, error) {
+	if bc.CurrentFastBlock().NumberU64() > bc.CurrentBlock().NumberU64() {
+		return 0, 0, errPruneSyncing
+	}
+	marker := newStateMarker(bc.db, bc.stateCache.TrieDB())
+	for _, hash := range append(keep, bc.genesisBlock.Hash()) {
+		header := bc.GetHeaderByHash(hash)
+		if header == nil {
+			continue
+		}
+		if err := marker.markRoots(header.Roots); err != nil {
+			return 0, 0, err
+		}
+	}
+	head := bc.CurrentBlock().NumberU64()
+	if err := bc.markRecentStates(marker, head); err != nil {
+		return 0, 0, err
+	}
+	nodes, err := collectStateNodes(bc.db, marker)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+	if err := bc.markRecentStates(marker, head); err != nil {
+		return 0, 0, err
+	}
+	count, size, err := deleteStateNodes(bc.db, marker, nodes, dryRun)
+	log.Info("blockchain", "prune state", "done", "dryRun", dryRun, "marked", len(marker.marked), "nodes", count, "size", size, "err", err)
+	return count, size, err
+}
+
+// markRecentStates marks the state of the canonical blocks from the state
+// tries kept in memory below from up to the head.
+func (bc *BlockChain) markRecentStates(marker *stateMarker, from uint64) error {
+	start := uint64(0)
+	if from > triesInMemory {
+		start = from - triesInMemory
+	}
+	for number := start; number <= bc.CurrentBlock().NumberU64(); number++ {
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		header := bc.GetHeader(hash, number)
+		if header == nil {
+			continue
+		}
+		if err := marker.markRoots(header.Roots); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+func TestPruneStateNodes(t *testing.T) {
+	var (
+		db      = mandb.NewMemDatabase()
+		sdb     = state.NewDatabase(db)
+		kept    = common.Address{1}
+		dropped = common.Address{2}
+		key     = common.Hash{1}
+	)
+	commit := func(st *state.StateDBManage) []common.CoinRoot {
+		roots, _, err := st.Commit(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sdb.TrieDB().CommitRoots(roots, false); err != nil {
+			t.Fatal(err)
+		}
+		return roots
+	}
+	st, _ := state.NewStateDBManage(nil, db, sdb)
+	st.AddBalance(params.MAN_COIN, common.MainAccount, kept, big.NewInt(1))
+	st.SetState(params.MAN_COIN, kept, key, common.Hash{1})
+	st.AddBalance(params.MAN_COIN, common.MainAccount, dropped, big.NewInt(1))
+	st.SetState(params.MAN_COIN, dropped, key, common.Hash{1})
+	old := commit(st)
+
+	st, _ = state.NewStateDBManage(old, db, sdb)
+	st.AddBalance(params.MAN_COIN, common.MainAccount, dropped, big.NewInt(1))
+	st.SetState(params.MAN_COIN, dropped, key, common.Hash{2})
+	head := commit(st)
+
+	prune := func(dryRun bool) int {
+		marker := newStateMarker(db, state.NewDatabase(db).TrieDB())
+		if err := marker.markRoots(head); err != nil {
+			t.Fatal(err)
+		}
+		nodes, err := collectStateNodes(db, marker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		count, _, err := deleteStateNodes(db, marker, nodes, dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+	size := db.Len()
+	// the old range list, range root, account and storage nodes of dropped
+	if count := prune(true); count == 0 || db.Len() != size {
+		t.Fatalf("dry run mismatch: %d nodes, %d entries of %d", count, db.Len(), size)
+	}
+	count := prune(false)
+	if db.Len() != size-count {
+		t.Fatalf("deleted mismatch: %d entries, want %d", db.Len(), size-count)
+	}
+	if count := prune(true); count != 0 {
+		t.Fatalf("%d nodes left", count)
+	}
+
+	st, _ = state.NewStateDBManage(head, db, state.NewDatabase(db))
+	if balance := st.GetBalanceByType(params.MAN_COIN, dropped, common.MainAccount); balance.Cmp(big.NewInt(2)) != 0 {
+		t.Fatalf("head balance mismatch: have %v, want 2", balance)
+	}
+	if value := st.GetState(params.MAN_COIN, kept, key); value != (common.Hash{1}) {
+		t.Fatalf("head storage mismatch: have %x", value)
+	}
+	if _, err := db.Get(old[0].Root[:]); err == nil {
+		t.Fatalf("old range roots kept")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/pkg/errors"
+)
+
+// SuperBlockProposal is a super block passed between the super block accounts
+// to be signed. Genesis is the super block with its roots and the signatures
+// collected so far, Hash is the hash every account signs.
+type SuperBlockProposal struct {
+	Genesis *Genesis    `json:"genesis"`
+	Hash    common.Hash `json:"hash"`
+}
+
+// SuperBlockSigner is the account recovered from a signature of a proposal.
+type SuperBlockSigner struct {
+	Account   common.Address   `json:"account"`
+	Signature common.Signature `json:"signature"`
+	Super     bool             `json:"super"` // the account is a super block account
+}
+
+// SuperBlockSignStatus tells which super block accounts signed a proposal and
+// whether the signatures are enough for the super block to be accepted.
+type SuperBlockSignStatus struct {
+	Hash     common.Hash         `json:"hash"`
+	Accounts []common.Address    `json:"accounts"`
+	Signers  []*SuperBlockSigner `json:"signers"`
+	Missing  []common.Address    `json:"missing"`
+	Invalid  []common.Signature  `json:"invalid"`
+	Signed   int                 `json:"signed"`
+	Target   int                 `json:"target"`
+	Ready    bool                `json:"ready"`
+}
+
+// SuperBlockDryRun is the result of a super block applied on the state of its
+// parent without writing anything.
+type SuperBlockDryRun struct {
+	Number      uint64                 `json:"number"`
+	ParentHash  common.Hash            `json:"parentHash"`
+	Hash        common.Hash            `json:"hash"`
+	Seq         uint64                 `json:"seq"`
+	CurrentSeq  uint64                 `json:"currentSeq"`
+	RootsMatch  bool                   `json:"rootsMatch"`
+	FromVersion string                 `json:"fromVersion"`
+	ToVersion   string                 `json:"toVersion"`
+	Changes     []*matrixstate.KeyDiff `json:"changes"`
+}
+
+// NewSuperBlockProposal creates the proposal of a super block whose roots are
+// already computed, the signatures of the genesis are kept.
+func NewSuperBlockProposal(genesis *Genesis) (*SuperBlockProposal, error) {
+	if genesis == nil || len(genesis.Roots) == 0 {
+		return nil, errors.New("super block roots are not computed")
+	}
+	block := genesis.ToSuperBlock()
+	if block == nil {
+		return nil, errors.New("genesis super block failed")
+	}
+	return &SuperBlockProposal{Genesis: genesis, Hash: block.HashNoSigns()}, nil
+}
+
+// Validate checks the hash of the proposal is the hash of its super block, a
+// genesis changed after the proposal would invalidate every signature.
+func (p *SuperBlockProposal) Validate() error {
+	if p.Genesis == nil {
+		return errors.New("proposal has no super block")
+	}
+	block := p.Genesis.ToSuperBlock()
+	if block == nil {
+		return errors.New("genesis super block failed")
+	}
+	if hash := block.HashNoSigns(); hash != p.Hash {
+		return errors.Errorf("super block hash(%s) != proposal hash(%s)", hash.Hex(), p.Hash.Hex())
+	}
+	return nil
+}
+
+func recoverSuperBlockSigner(hash common.Hash, sign common.Signature) (common.Address, error) {
+	// VerifySignWithValidate modifies the signature
+	signBytes := common.CopyBytes(sign.Bytes())
+	account, _, err := crypto.VerifySignWithValidate(hash.Bytes(), signBytes)
+	return account, err
+}
+
+// Signers returns the accounts of the signatures, a signature failing to
+// recover is an error.
+func (p *SuperBlockProposal) Signers() ([]common.Address, error) {
+	signers := make([]common.Address, 0, len(p.Genesis.Signatures))
+	for i, sign := range p.Genesis.Signatures {
+		account, err := recoverSuperBlockSigner(p.Hash, sign)
+		if err != nil {
+			return nil, fmt.Errorf("signature %d: %v", i, err)
+		}
+		signers = append(signers, account)
+	}
+	return signers, nil
+}
+
+// AddSignature adds the signature of the proposal hash and returns its
+// account. A second signature of the same account is refused.
+func (p *SuperBlockProposal) AddSignature(sign common.Signature) (common.Address, error) {
+	if err := p.Validate(); err != nil {
+		return common.Address{}, err
+	}
+	account, err := recoverSuperBlockSigner(p.Hash, sign)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signers, err := p.Signers()
+	if err != nil {
+		return common.Address{}, err
+	}
+	for _, signer := range signers {
+		if signer == account {
+			return account, errors.Errorf("account %s already signed", account.Hex())
+		}
+	}
+	p.Genesis.Signatures = append(p.Genesis.Signatures, sign)
+	return account, nil
+}
+
+// Sign signs the proposal hash with the key and adds the signature.
+func (p *SuperBlockProposal) Sign(key *ecdsa.PrivateKey) (common.Address, error) {
+	signBytes, err := crypto.Sign(p.Hash.Bytes(), key)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return p.AddSignature(common.BytesToSignature(signBytes))
+}
+
+// SignStatus returns the signers of the proposal among the super block
+// accounts, target is the count of signatures needed by the DPOS engine.
+func (p *SuperBlockProposal) SignStatus(accounts []common.Address, target int) *SuperBlockSignStatus {
+	status := &SuperBlockSignStatus{
+		Hash:     p.Hash,
+		Accounts: accounts,
+		Signers:  make([]*SuperBlockSigner, 0),
+		Missing:  make([]common.Address, 0),
+		Invalid:  make([]common.Signature, 0),
+		Target:   target,
+	}
+	signed := make(map[common.Address]bool)
+	for _, sign := range p.Genesis.Signatures {
+		account, err := recoverSuperBlockSigner(p.Hash, sign)
+		if err != nil {
+			status.Invalid = append(status.Invalid, sign)
+			continue
+		}
+		signer := &SuperBlockSigner{Account: account, Signature: sign}
+		for _, superAccount := range accounts {
+			if superAccount == account {
+				signer.Super = true
+				break
+			}
+		}
+		if signer.Super && !signed[account] {
+			signed[account] = true
+			status.Signed++
+		}
+		status.Signers = append(status.Signers, signer)
+	}
+	for _, account := range accounts {
+		if !signed[account] {
+			status.Missing = append(status.Missing, account)
+		}
+	}
+	status.Ready = len(accounts) > 0 && status.Signed >= target
+	return status
+}
+
+// DryRunSuperBlock applies the super block of the proposal on a copy of the
+// state of its parent, like InsertSuperBlock but without verifying the
+// signatures nor writing the block, and returns the matrix state changes.
+func (bc *BlockChain) DryRunSuperBlock(p *SuperBlockProposal) (*SuperBlockDryRun, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	genesis := *p.Genesis
+	parent := bc.GetBlockByHash(genesis.ParentHash)
+	if nil == parent {
+		return nil, errors.Errorf("get parent block by hash(%s) err", genesis.ParentHash.Hex())
+	}
+	if parent.NumberU64()+1 != genesis.Number {
+		return nil, errors.Errorf("parent block number(%d) + 1 != super block number(%d)", parent.NumberU64(), genesis.Number)
+	}
+	fromState, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	block, toState := genesis.genSuperBlockState(parent.Header(), bc.db, bc.stateCache, bc.chainConfig)
+	if nil == block {
+		return nil, errors.New("genesis super block failed")
+	}
+	currentSeq, err := bc.GetSuperBlockSeq()
+	if err != nil {
+		return nil, err
+	}
+
+	fromValues, err := matrixstate.GetKeyValues(fromState, nil)
+	if err != nil {
+		return nil, err
+	}
+	toValues, err := matrixstate.GetKeyValues(toState, nil)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := matrixstate.DiffKeyValues(fromValues, toValues)
+	if err != nil {
+		return nil, err
+	}
+	return &SuperBlockDryRun{
+		Number:      genesis.Number,
+		ParentHash:  genesis.ParentHash,
+		Hash:        p.Hash,
+		Seq:         block.Header().SuperBlockSeq(),
+		CurrentSeq:  currentSeq,
+		RootsMatch:  types.RlpHash(block.Root()) == types.RlpHash(p.Genesis.Roots),
+		FromVersion: matrixstate.GetVersionInfo(fromState),
+		ToVersion:   matrixstate.GetVersionInfo(toState),
+		Changes:     changes,
+	}, nil
+}
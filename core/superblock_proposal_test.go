@@ -0,0 +1,87 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+func TestSuperBlockProposal(t *testing.T) {
+	genesis := &Genesis{
+		Number:     101,
+		ParentHash: common.HexToHash("0x1234"),
+		Version:    "1.0.0.0",
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: params.GenesisDifficulty,
+		Roots:      []common.CoinRoot{{Cointyp: "MAN", Root: common.HexToHash("0x5678")}},
+	}
+	if _, err := NewSuperBlockProposal(&Genesis{Number: 101}); err == nil {
+		t.Fatalf("proposal created without roots")
+	}
+	proposal, err := NewSuperBlockProposal(genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	accounts := make([]common.Address, 3)
+	for i := range keys {
+		if keys[i], err = crypto.GenerateKey(); err != nil {
+			t.Fatal(err)
+		}
+		accounts[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	if account, err := proposal.Sign(keys[0]); err != nil || account != accounts[0] {
+		t.Fatalf("sign failed: %v %s", err, account.Hex())
+	}
+	if _, err := proposal.Sign(keys[0]); err == nil {
+		t.Fatalf("account signed twice")
+	}
+
+	// the proposal passed as a file keeps its hash and signatures
+	data, err := json.Marshal(proposal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(SuperBlockProposal)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Fatalf("decoded proposal invalid: %v", err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoded.Sign(other); err != nil {
+		t.Fatal(err)
+	}
+
+	status := decoded.SignStatus(accounts, 2)
+	if status.Signed != 1 || status.Ready || len(status.Missing) != 2 || len(status.Signers) != 2 || status.Signers[1].Super {
+		t.Fatalf("status mismatch: %+v", status)
+	}
+	if _, err := decoded.Sign(keys[2]); err != nil {
+		t.Fatal(err)
+	}
+	if status = decoded.SignStatus(accounts, 2); status.Signed != 2 || !status.Ready || len(status.Missing) != 1 || status.Missing[0] != accounts[1] {
+		t.Fatalf("status mismatch: %+v", status)
+	}
+
+	// a changed super block invalidates the proposal
+	decoded.Genesis.Version = "1.0.0.1"
+	if err := decoded.Validate(); err == nil {
+		t.Fatalf("changed super block passed")
+	}
+	if _, err := decoded.Sign(keys[1]); err == nil {
+		t.Fatalf("changed super block signed")
+	}
+}
@@ -72,19 +72,35 @@ func ProduceMatrixStateData(block *types.Block, stateDb *state.StateDBManage, re
 		return nil, nil
 	}
 
-	var (
-		tempMap = make(map[string]map[common.Address][]byte)
-	)
 	log.Info("ProduceMatrixStateData message", "height", block.Number().Uint64(), "block.Hash=", block.Hash())
 
-	tempMap[mc.Publickey] = make(map[common.Address][]byte)
-	tempMap[mc.Heartbeat] = make(map[common.Address][]byte)
-	tempMap[mc.Privatekey] = make(map[common.Address][]byte)
-	tempMap[mc.CallTheRoll] = make(map[common.Address][]byte)
 	txs := make(types.SelfTransactions, 0)
 	for _, curr := range block.Currencies() {
 		txs = append(txs, curr.Transactions.GetTransactions()...)
 	}
+	tempMap := ParseBroadcastTxs(txs)
+	if len(tempMap) > 0 {
+		log.INFO("ProduceMatrixStateData", "tempMap", tempMap)
+		//这里需把map转成slice存储在状态树上
+		var broadtxSlice common.BroadTxSlice
+		for keystring, valmap := range tempMap {
+			for keyaddr, valbyte := range valmap {
+				broadtxSlice.Insert(keystring, keyaddr, valbyte)
+			}
+		}
+		return broadtxSlice, nil
+	}
+	return nil, errors.New("without broadcatTxs")
+}
+
+// ParseBroadcastTxs returns the data of the broadcast transactions of a
+// broadcast block by type and sender, as kept in the state.
+func ParseBroadcastTxs(txs types.SelfTransactions) map[string]map[common.Address][]byte {
+	tempMap := make(map[string]map[common.Address][]byte)
+	tempMap[mc.Publickey] = make(map[common.Address][]byte)
+	tempMap[mc.Heartbeat] = make(map[common.Address][]byte)
+	tempMap[mc.Privatekey] = make(map[common.Address][]byte)
+	tempMap[mc.CallTheRoll] = make(map[common.Address][]byte)
 	for _, tx := range txs {
 		if len(tx.GetMatrix_EX()) > 0 && tx.GetMatrix_EX()[0].TxType == 1 {
 			temp := make(map[string][]byte)
@@ -112,18 +128,7 @@ func ProduceMatrixStateData(block *types.Block, stateDb *state.StateDBManage, re
 			}
 		}
 	}
-	if len(tempMap) > 0 {
-		log.INFO("ProduceMatrixStateData", "tempMap", tempMap)
-		//这里需把map转成slice存储在状态树上
-		var broadtxSlice common.BroadTxSlice
-		for keystring, valmap := range tempMap {
-			for keyaddr, valbyte := range valmap {
-				broadtxSlice.Insert(keystring, keyaddr, valbyte)
-			}
-		}
-		return broadtxSlice, nil
-	}
-	return nil, errors.New("without broadcatTxs")
+	return tempMap
 }
 
 type ChainReader interface {
@@ -12,6 +12,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 )
 
 // Validator is an interface which defines the standard for block validation. It
@@ -33,12 +34,12 @@ type Validator interface {
 //
 // Process takes the block to be processed and the statedb upon which the
 // initial state is based. It should return the receipts generated, amount
-// of gas used in the process and return an error if any of the internal rules
-// failed.
+// of gas used in the process, the reward ledger entries of the block and return
+// an error if any of the internal rules failed.
 type Processor interface {
 	ProcessSuperBlk(block *types.Block, statedb *state.StateDBManage) error
 	ProcessTxs(block *types.Block, statedb *state.StateDBManage, cfg vm.Config, upTime map[common.Address]uint64) ([]types.CoinLogs, uint64, error)
-	Process(block *types.Block, parent *types.Block, statedb *state.StateDBManage, cfg vm.Config) ([]types.CoinReceipts, []types.CoinLogs, uint64, error)
+	Process(block *types.Block, parent *types.Block, statedb *state.StateDBManage, cfg vm.Config) ([]types.CoinReceipts, []types.CoinLogs, uint64, []*ledger.Entry, error)
 	SetRandom(random *baseinterface.Random)
-	ProcessReward(state *state.StateDBManage, header *types.Header, upTime map[common.Address]uint64, account map[string][]common.Address, usedGas map[string]*big.Int) []common.RewarTx
+	ProcessReward(state *state.StateDBManage, header *types.Header, upTime map[common.Address]uint64, account map[string][]common.Address, usedGas map[string]*big.Int) ([]common.RewarTx, []*ledger.Entry)
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/common/readstatedb"
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
@@ -90,15 +91,18 @@ func (bc *BlockChain) getUpTimeData(root []common.CoinRoot, num uint64, parentHa
 	}
 	return calltherollMap, headerBeatMap, nil
 }
-func (bc *BlockChain) handleUpTime(BeforeLastStateRoot []common.CoinRoot, state *state.StateDBManage, accounts []common.Address, calltherollRspAccounts map[common.Address]uint32, heatBeatAccounts map[common.Address][]byte, blockNum uint64, bcInterval *mc.BCIntervalInfo, parentHash common.Hash) (map[common.Address]uint64, error) {
+func (bc *BlockChain) handleUpTime(BeforeLastStateRoot []common.CoinRoot, state *state.StateDBManage, accounts []common.Address, calltherollRspAccounts map[common.Address]uint32, heatBeatAccounts map[common.Address][]byte, blockNum uint64, bcInterval *mc.BCIntervalInfo, parentHash common.Hash) (map[common.Address]uint64, *rawdb.UptimeDetails, error) {
 	HeartBeatMap := bc.getHeatBeatAccount(BeforeLastStateRoot, bcInterval, blockNum, accounts, heatBeatAccounts)
 
 	originValidatorMap, originMinerMap, err := bc.getElectMap(parentHash, bcInterval)
 	if nil != err {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return bc.calcUpTime(accounts, calltherollRspAccounts, HeartBeatMap, bcInterval, state, originValidatorMap, originMinerMap), nil
+	oldUpTimes := readUpTimes(state, accounts)
+	upTimeMap := bc.calcUpTime(accounts, calltherollRspAccounts, HeartBeatMap, bcInterval, state, originValidatorMap, originMinerMap)
+	details := newHeartbeatDetails(blockNum, bcInterval, BeforeLastStateRoot, state, accounts, calltherollRspAccounts, HeartBeatMap, upTimeMap, originValidatorMap, originMinerMap, oldUpTimes)
+	return upTimeMap, details, nil
 }
 
 func (bc *BlockChain) getElectMap(parentHash common.Hash, bcInterval *mc.BCIntervalInfo) (map[common.Address]uint32, map[common.Address]uint32, error) {
@@ -144,12 +148,9 @@ func (bc *BlockChain) getHeatBeatAccount(beforeLastStateRoot []common.CoinRoot,
 	HeartBeatMap := make(map[common.Address]bool, 0)
 	//subVal就是最新的广播区块，例如当前区块高度是198或者是101，那么subVal就是100
 
-	broadcastBlock := types.RlpHash(beforeLastStateRoot).Big()
-	val := new(big.Int).Rem(broadcastBlock, big.NewInt(int64(bcInterval.GetBroadcastInterval())-1))
+	val := heartbeatSlot(types.RlpHash(beforeLastStateRoot).Big(), bcInterval)
 	for _, v := range accounts {
-		currentAcc := v.Big()
-		ret := new(big.Int).Rem(currentAcc, big.NewInt(int64(bcInterval.GetBroadcastInterval())-1))
-		if ret.Cmp(val) == 0 {
+		if heartbeatSlot(v.Big(), bcInterval) == val {
 			HeatBeatReqAccounts = append(HeatBeatReqAccounts, v)
 			if _, ok := heatBeatAccounts[v]; ok {
 				HeartBeatMap[v] = true
@@ -198,6 +199,9 @@ func (bc *BlockChain) calcUpTime(accounts []common.Address, calltherollRspAccoun
 	return upTimeMap
 }
 
+// upTimeResetA is the factor of the previous uptime when adding the one of an interval
+const upTimeResetA = 1
+
 //f(x)=ax+b
 func (bc *BlockChain) upTimesReset(oldUpTime *big.Int, a float64, b int64) *big.Int {
 
@@ -213,15 +217,15 @@ func (bc *BlockChain) saveUptime(account common.Address, upTime uint64, state *s
 	var newTime *big.Int
 	if _, ok := originValidatorMap[account]; ok {
 
-		newTime = bc.upTimesReset(old, 1, int64(upTime))
+		newTime = bc.upTimesReset(old, upTimeResetA, int64(upTime))
 		//log.Debug(ModuleName, "是原始验证节点，upTime累加", account, "upTime", newTime.Uint64())
 
 	} else if _, ok := originMinerMap[account]; ok {
-		newTime = bc.upTimesReset(old, 1, int64(upTime))
+		newTime = bc.upTimesReset(old, upTimeResetA, int64(upTime))
 		//log.Debug(ModuleName, "是原始矿工节点，upTime累加", account, "upTime", newTime.Uint64())
 
 	} else {
-		newTime = bc.upTimesReset(old, 1, int64(upTime))
+		newTime = bc.upTimesReset(old, upTimeResetA, int64(upTime))
 		//log.Debug(ModuleName, "其它节点，upTime累加", account, "upTime", newTime.Uint64())
 	}
 
@@ -296,11 +300,13 @@ func (bc *BlockChain) ProcessUpTime(state *state.StateDBManage, header *types.He
 		}
 		if sbh < bcInterval.GetLastBroadcastNumber() &&
 			sbh >= bcInterval.GetLastBroadcastNumber()-bcInterval.GetBroadcastInterval() {
+			oldUpTimes := readUpTimes(state, upTimeAccounts)
 			upTimeMap, err := bc.HandleUpTimeWithSuperBlock(state, upTimeAccounts, header.Number.Uint64(), bcInterval)
 			if nil != err {
 				log.ERROR("core", "处理uptime错误", err)
 				return nil, err
 			}
+			bc.cacheUptimeDetails(header.ParentHash, newSuperBlockDetails(header.Number.Uint64(), bcInterval, state, upTimeMap, upTimeAccounts, oldUpTimes))
 			return upTimeMap, nil
 		} else {
 			//log.Debug(ModuleName, "获取所有心跳交易", "")
@@ -313,11 +319,12 @@ func (bc *BlockChain) ProcessUpTime(state *state.StateDBManage, header *types.He
 			if err != nil {
 				log.WARN("core", "获取心跳交易错误!", err, "高度", header.Number.Uint64())
 			}
-			upTimeMap, err := bc.handleUpTime(BeforeLastStateRoot, state, upTimeAccounts, calltherollMap, heatBeatUnmarshallMMap, header.Number.Uint64(), bcInterval, header.ParentHash)
+			upTimeMap, details, err := bc.handleUpTime(BeforeLastStateRoot, state, upTimeAccounts, calltherollMap, heatBeatUnmarshallMMap, header.Number.Uint64(), bcInterval, header.ParentHash)
 			if nil != err {
 				log.ERROR("core", "处理uptime错误", err)
 				return nil, err
 			}
+			bc.cacheUptimeDetails(header.ParentHash, details)
 			return upTimeMap, nil
 		}
 
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+// uptimeCacheLimit is the number of uptime calculations kept until their
+// block is written.
+const uptimeCacheLimit = 16
+
+// heartbeatSlot returns the heartbeat slot of x, the accounts of the slot of the
+// interval are asked to send heartbeats.
+func heartbeatSlot(x *big.Int, bcInterval *mc.BCIntervalInfo) uint64 {
+	return new(big.Int).Rem(x, big.NewInt(int64(bcInterval.GetBroadcastInterval())-1)).Uint64()
+}
+
+func newUptimeDetails(number uint64, bcInterval *mc.BCIntervalInfo) *rawdb.UptimeDetails {
+	return &rawdb.UptimeDetails{
+		Number:            number,
+		BroadcastNumber:   bcInterval.GetLastBroadcastNumber(),
+		BroadcastInterval: bcInterval.GetBroadcastInterval(),
+		MaxUptime:         bcInterval.GetBroadcastInterval() - 3,
+		HeartbeatModulus:  bcInterval.GetBroadcastInterval() - 1,
+		ResetA:            strconv.FormatFloat(upTimeResetA, 'f', -1, 64),
+		Accounts:          make([]*rawdb.UptimeAccountDetail, 0),
+	}
+}
+
+// readUpTimes reads the uptimes of the accounts before the calculation.
+func readUpTimes(st *state.StateDBManage, accounts []common.Address) map[common.Address]*big.Int {
+	upTimes := make(map[common.Address]*big.Int, len(accounts))
+	for _, account := range accounts {
+		if old, err := depoistInfo.GetOnlineTime(st, account); err == nil && old != nil {
+			upTimes[account] = new(big.Int).Set(old)
+		}
+	}
+	return upTimes
+}
+
+// addUptimeAccount records the uptime calculated for the account, with its
+// uptimes before and after.
+func addUptimeAccount(details *rawdb.UptimeDetails, st *state.StateDBManage, detail *rawdb.UptimeAccountDetail, oldUpTimes map[common.Address]*big.Int) {
+	detail.OldUptime = new(big.Int)
+	if old, exist := oldUpTimes[detail.Account]; exist {
+		detail.OldUptime = old
+	}
+	detail.NewUptime = new(big.Int)
+	if cur, err := depoistInfo.GetOnlineTime(st, detail.Account); err == nil && cur != nil {
+		detail.NewUptime = new(big.Int).Set(cur)
+	}
+	details.Accounts = append(details.Accounts, detail)
+}
+
+// newHeartbeatDetails records the inputs of the uptime calculation from the call
+// the roll responses and the heartbeats of the interval.
+func newHeartbeatDetails(number uint64, bcInterval *mc.BCIntervalInfo, beforeLastStateRoot []common.CoinRoot, st *state.StateDBManage, accounts []common.Address,
+	calltherollRspAccounts map[common.Address]uint32, heartBeatMap map[common.Address]bool, upTimeMap map[common.Address]uint64,
+	originValidatorMap map[common.Address]uint32, originMinerMap map[common.Address]uint32, oldUpTimes map[common.Address]*big.Int) *rawdb.UptimeDetails {
+	details := newUptimeDetails(number, bcInterval)
+	details.HeartbeatSlot = heartbeatSlot(types.RlpHash(beforeLastStateRoot).Big(), bcInterval)
+	for _, account := range accounts {
+		detail := &rawdb.UptimeAccountDetail{
+			Account:       account,
+			HeartbeatSlot: heartbeatSlot(account.Big(), bcInterval),
+			Source:        rawdb.UptimeSourceDefault,
+			Uptime:        upTimeMap[account],
+		}
+		if _, ok := originValidatorMap[account]; ok {
+			detail.Role = common.RoleValidator
+		} else if _, ok := originMinerMap[account]; ok {
+			detail.Role = common.RoleMiner
+		}
+		detail.HeartbeatObserved, detail.HeartbeatRequired = heartBeatMap[account]
+		if detail.HeartbeatRequired {
+			detail.Source = rawdb.UptimeSourceHeartbeat
+		}
+		if blocks, ok := calltherollRspAccounts[account]; ok {
+			detail.CalledTheRoll = true
+			detail.RollCallBlocks = uint64(blocks)
+			detail.Source = rawdb.UptimeSourceCallTheRoll
+		}
+		addUptimeAccount(details, st, detail, oldUpTimes)
+	}
+	return details
+}
+
+// newSuperBlockDetails records the uptime calculation of an interval with a
+// super block.
+func newSuperBlockDetails(number uint64, bcInterval *mc.BCIntervalInfo, st *state.StateDBManage, upTimeMap map[common.Address]uint64, accounts []common.Address, oldUpTimes map[common.Address]*big.Int) *rawdb.UptimeDetails {
+	details := newUptimeDetails(number, bcInterval)
+	details.SuperBlock = true
+	for _, account := range accounts {
+		addUptimeAccount(details, st, &rawdb.UptimeAccountDetail{
+			Account:       account,
+			HeartbeatSlot: heartbeatSlot(account.Big(), bcInterval),
+			Source:        rawdb.UptimeSourceSuperBlock,
+			Uptime:        upTimeMap[account],
+		}, oldUpTimes)
+	}
+	return details
+}
+
+// cacheUptimeDetails keeps the uptime calculation of the block following the
+// parent until the block is written.
+func (bc *BlockChain) cacheUptimeDetails(parentHash common.Hash, details *rawdb.UptimeDetails) {
+	if details != nil {
+		bc.uptimeCache.Add(parentHash, details)
+	}
+}
+
+// writeUptimeDetails stores the uptime calculation of the block, if it made one.
+func (bc *BlockChain) writeUptimeDetails(db rawdb.DatabaseWriter, block *types.Block) {
+	cached, exist := bc.uptimeCache.Get(block.ParentHash())
+	if !exist {
+		return
+	}
+	if details := cached.(*rawdb.UptimeDetails); details.Number == block.NumberU64() {
+		rawdb.WriteUptimeDetails(db, block.Hash(), details)
+	}
+}
@@ -0,0 +1,478 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/core/vm/validatorGroup"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+var (
+	vgCreateEvent   = validatorGroup.ValidatorGroupContractAbi.Events["CreateValidatorGroup"]
+	vgDepositEvent  = validatorGroup.ValidatorGroupAbi.Events["AddDeposit"]
+	vgWithdrawEvent = validatorGroup.ValidatorGroupAbi.Events["Withdraw"]
+)
+
+// groupIndexer collects the validator group events of a block, per group in the
+// order they're first seen.
+type groupIndexer struct {
+	block  *types.Block
+	prev   map[common.Address]*vm.ValidatorGroupState
+	cur    map[common.Address]*vm.ValidatorGroupState
+	groups []common.Address
+	events map[common.Address][]*rawdb.ValidatorGroupEvent
+
+	newPositions map[common.Address]map[common.Address][]validatorGroup.DepositPos
+}
+
+func (ix *groupIndexer) add(group common.Address, event *rawdb.ValidatorGroupEvent) {
+	event.BlockNumber = ix.block.NumberU64()
+	event.BlockHash = ix.block.Hash()
+	if event.Amount == nil {
+		event.Amount = new(big.Int)
+	}
+	if _, exist := ix.events[group]; !exist {
+		ix.groups = append(ix.groups, group)
+	}
+	ix.events[group] = append(ix.events[group], event)
+}
+
+// writeValidatorGroupIndex indexes the events of the validator groups in a
+// canonical block. Creations, deposits and withdrawals follow the contract logs,
+// the rest is derived from the group states of the block and of its parent. Only
+// the groups changed by the block are decoded. The index only grows, the events
+// of a block dropped by a reorg stay indexed and are told apart by their block
+// hash.
+func (bc *BlockChain) writeValidatorGroupIndex(db rawdb.DatabaseWriter, block *types.Block, receipts []types.CoinReceipts, st *state.StateDBManage) {
+	parent := bc.GetHeaderByHash(block.ParentHash())
+	if parent == nil {
+		return
+	}
+	touched, all := loggedValidatorGroups(bc.db, receipts)
+	if !all && rawdb.ReadValidatorGroupCount(bc.db) == 0 {
+		return
+	}
+	preSt, err := bc.StateAt(parent.Roots)
+	if err != nil {
+		log.Error("validator group index", "获取父状态树失败", err, "number", block.NumberU64())
+		return
+	}
+	if !all {
+		all = changedValidatorGroups(bc.db, st, preSt, touched)
+	}
+	if !all && len(touched) == 0 {
+		return
+	}
+
+	var cur, prev map[common.Address]*vm.ValidatorGroupState
+	if all {
+		cur, err = (&vm.ValidatorContractState{}).GetValidatorGroupInfo(block.Time().Uint64(), st)
+		if err == nil {
+			prev, err = (&vm.ValidatorContractState{}).GetValidatorGroupInfo(parent.Time.Uint64(), preSt)
+		}
+	} else {
+		cur, err = validatorGroupStates(block.Time().Uint64(), st, touched)
+		if err == nil {
+			prev, err = validatorGroupStates(parent.Time.Uint64(), preSt, touched)
+		}
+	}
+	if err != nil {
+		log.Error("validator group index", "获取验证者组状态失败", err, "number", block.NumberU64())
+		return
+	}
+	if len(cur) == 0 && len(prev) == 0 {
+		return
+	}
+
+	ix := &groupIndexer{
+		block:        block,
+		prev:         prev,
+		cur:          cur,
+		events:       make(map[common.Address][]*rawdb.ValidatorGroupEvent),
+		newPositions: make(map[common.Address]map[common.Address][]validatorGroup.DepositPos),
+	}
+	for _, receipt := range receipts {
+		for _, r := range receipt.Receiptlist {
+			for _, l := range r.Logs {
+				ix.indexLog(r.TxHash, l)
+			}
+		}
+	}
+	groups := make([]common.Address, 0, len(cur)+len(prev))
+	for group := range cur {
+		groups = append(groups, group)
+	}
+	for group := range prev {
+		if _, exist := cur[group]; !exist {
+			groups = append(groups, group)
+		}
+	}
+	sortAddresses(groups)
+	for _, group := range groups {
+		ix.indexState(group)
+	}
+	rawdb.WriteValidatorGroupEvents(bc.db, db, block.Hash(), ix.groups, ix.events)
+}
+
+// loggedValidatorGroups returns the indexed groups logging an event in the
+// block, all is set if a group is created.
+func loggedValidatorGroups(db rawdb.DatabaseReader, receipts []types.CoinReceipts) (map[common.Address]bool, bool) {
+	groups := make(map[common.Address]bool)
+	for _, receipt := range receipts {
+		for _, r := range receipt.Receiptlist {
+			for _, l := range r.Logs {
+				if l.Address == vm.ValidatorGroupContractAddress {
+					return groups, true
+				}
+				if !groups[l.Address] && rawdb.HasValidatorGroup(db, l.Address) {
+					groups[l.Address] = true
+				}
+			}
+		}
+	}
+	return groups, false
+}
+
+// changedValidatorGroups adds to groups the indexed groups whose storage
+// changed without a log, by the rewards and the interests credited. It returns
+// true if the group list changed.
+func changedValidatorGroups(db rawdb.DatabaseReader, st *state.StateDBManage, preSt *state.StateDBManage, groups map[common.Address]bool) bool {
+	if storageRoot(st, vm.ValidatorGroupContractAddress) != storageRoot(preSt, vm.ValidatorGroupContractAddress) {
+		return true
+	}
+	for _, group := range rawdb.ReadValidatorGroups(db, 0, rawdb.ReadValidatorGroupCount(db)) {
+		if !groups[group] && storageRoot(st, group) != storageRoot(preSt, group) {
+			groups[group] = true
+		}
+	}
+	return false
+}
+
+func storageRoot(st *state.StateDBManage, addr common.Address) common.Hash {
+	if tr := st.StorageTrie(params.MAN_COIN, addr); tr != nil {
+		return tr.Hash()
+	}
+	return common.Hash{}
+}
+
+func validatorGroupStates(time uint64, st *state.StateDBManage, groups map[common.Address]bool) (map[common.Address]*vm.ValidatorGroupState, error) {
+	states := make(map[common.Address]*vm.ValidatorGroupState, len(groups))
+	for group := range groups {
+		groupState := vm.NewValidatorGroupState()
+		if err := groupState.GetState(group, time, st); err != nil {
+			return nil, err
+		}
+		states[group] = groupState
+	}
+	return states, nil
+}
+
+// writeReorgValidatorGroupIndex indexes a block made canonical by a reorg.
+func (bc *BlockChain) writeReorgValidatorGroupIndex(db rawdb.DatabaseWriter, block *types.Block) {
+	st, err := bc.StateAt(block.Root())
+	if err != nil {
+		log.Error("validator group index", "获取状态树失败", err, "number", block.NumberU64())
+		return
+	}
+	bc.writeValidatorGroupIndex(db, block, rawdb.ReadReceipts(bc.db, block.Hash(), block.NumberU64()), st)
+}
+
+func (ix *groupIndexer) state(group common.Address) *vm.ValidatorGroupState {
+	if st, exist := ix.cur[group]; exist {
+		return st
+	}
+	return ix.prev[group]
+}
+
+func (ix *groupIndexer) indexLog(txHash common.Hash, l *types.Log) {
+	if len(l.Topics) == 0 {
+		return
+	}
+	if l.Address == vm.ValidatorGroupContractAddress {
+		if l.Topics[0] != vgCreateEvent.Id() || len(l.Topics) != 4 {
+			return
+		}
+		values, err := vgCreateEvent.Inputs.NonIndexed().UnpackValues(l.Data)
+		if err != nil || len(values) != 2 {
+			return
+		}
+		group := common.BytesToAddress(l.Topics[3].Bytes())
+		// the creation comes first, the deposit of the owner was logged before it
+		ix.add(group, &rawdb.ValidatorGroupEvent{
+			Type:        rawdb.VGEventCreate,
+			Participant: common.BytesToAddress(l.Topics[1].Bytes()),
+			Account:     common.BytesToAddress(l.Topics[2].Bytes()),
+			TxHash:      txHash,
+			Amount:      values[0].(*big.Int),
+			DType:       values[1].(*big.Int).Uint64(),
+		})
+		events := ix.events[group]
+		ix.events[group] = append(events[len(events)-1:], events[:len(events)-1]...)
+		return
+	}
+
+	group := l.Address
+	st := ix.state(group)
+	if st == nil || len(l.Topics) != 2 {
+		return
+	}
+	participant := common.BytesToAddress(l.Topics[1].Bytes())
+	switch l.Topics[0] {
+	case vgDepositEvent.Id():
+		values, err := vgDepositEvent.Inputs.NonIndexed().UnpackValues(l.Data)
+		if err != nil || len(values) != 2 {
+			return
+		}
+		amount, dType := values[0].(*big.Int), values[1].(*big.Int).Uint64()
+		if amount.Sign() == 0 {
+			// setSignAccount, followed by the state
+			return
+		}
+		if participant == vm.ValidatorGroupContractAddress {
+			// the deposit of the owner at the creation
+			participant = st.OwnerInfo.Owner
+		}
+		event := &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventDeposit, Participant: participant, TxHash: txHash, Amount: amount, DType: dType}
+		if dType != 0 {
+			event.Position = ix.nextNewPosition(group, participant)
+		}
+		ix.add(group, event)
+
+	case vgWithdrawEvent.Id():
+		if ix.withdrawAll(group) {
+			// withdrawAll logs every withdrawal as the owner's, followed by the state
+			return
+		}
+		values, err := vgWithdrawEvent.Inputs.NonIndexed().UnpackValues(l.Data)
+		if err != nil || len(values) != 2 {
+			return
+		}
+		event := &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventWithdraw, Participant: participant, TxHash: txHash, Amount: values[0].(*big.Int), Position: values[1].(*big.Int).Uint64()}
+		if event.Position != 0 {
+			// the fixed deposits are withdrawn whole, the log has no amount
+			if pos := findPosition(ix.prevInfo(group, participant), event.Position); pos != nil {
+				event.Amount, event.DType = pos.Amount, pos.DType
+			}
+		}
+		ix.add(group, event)
+	}
+}
+
+// nextNewPosition returns the next of the fixed deposit positions the
+// participant opened in the block.
+func (ix *groupIndexer) nextNewPosition(group common.Address, participant common.Address) uint64 {
+	if ix.newPositions[group] == nil {
+		ix.newPositions[group] = make(map[common.Address][]validatorGroup.DepositPos)
+	}
+	positions, exist := ix.newPositions[group][participant]
+	if !exist {
+		prev := ix.prevInfo(group, participant)
+		if cur := ix.curInfo(group, participant); cur != nil {
+			for _, pos := range cur.Positions {
+				if findPosition(prev, pos.Position) == nil {
+					positions = append(positions, pos)
+				}
+			}
+		}
+	}
+	if len(positions) == 0 {
+		ix.newPositions[group][participant] = positions
+		return 0
+	}
+	ix.newPositions[group][participant] = positions[1:]
+	return positions[0].Position
+}
+
+func (ix *groupIndexer) withdrawAll(group common.Address) bool {
+	cur := ix.cur[group]
+	if cur == nil || cur.OwnerInfo.WithdrawAllTime == 0 {
+		return false
+	}
+	prev := ix.prev[group]
+	return prev == nil || prev.OwnerInfo.WithdrawAllTime == 0
+}
+
+func groupInfo(st *vm.ValidatorGroupState, participant common.Address) *validatorGroup.ValidatorInfo {
+	if st == nil {
+		return nil
+	}
+	index, exist := st.ValidatorMap.Find(participant)
+	if !exist {
+		return nil
+	}
+	return &st.ValidatorMap[index]
+}
+
+func (ix *groupIndexer) prevInfo(group common.Address, participant common.Address) *validatorGroup.ValidatorInfo {
+	return groupInfo(ix.prev[group], participant)
+}
+
+func (ix *groupIndexer) curInfo(group common.Address, participant common.Address) *validatorGroup.ValidatorInfo {
+	return groupInfo(ix.cur[group], participant)
+}
+
+func findPosition(info *validatorGroup.ValidatorInfo, position uint64) *validatorGroup.DepositPos {
+	if info == nil {
+		return nil
+	}
+	for i := range info.Positions {
+		if info.Positions[i].Position == position {
+			return &info.Positions[i]
+		}
+	}
+	return nil
+}
+
+func rewardRates(st *vm.ValidatorGroupState) []*big.Int {
+	rates := []*big.Int{st.Reward.OwnerRate.Rate, st.Reward.NodeRate.Rate}
+	for _, level := range st.Reward.LevelRate {
+		rates = append(rates, level.Rate.Rate)
+	}
+	for i, rate := range rates {
+		if rate == nil {
+			rates[i] = new(big.Int)
+		}
+	}
+	return rates
+}
+
+func sameRates(a, b []*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func bigOrZero(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return x
+}
+
+// indexState derives the events of the group from its state change in the block.
+func (ix *groupIndexer) indexState(group common.Address) {
+	prev, cur := ix.prev[group], ix.cur[group]
+	if cur == nil {
+		ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventDissolve})
+		return
+	}
+	if prev != nil {
+		if prev.OwnerInfo.Owner != cur.OwnerInfo.Owner {
+			ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventOwner, Participant: cur.OwnerInfo.Owner, Account: prev.OwnerInfo.Owner})
+		}
+		if prev.OwnerInfo.SignAddress != cur.OwnerInfo.SignAddress {
+			ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventSignAccount, Participant: cur.OwnerInfo.Owner, Account: prev.OwnerInfo.SignAddress})
+		}
+		if rates := rewardRates(cur); !sameRates(rewardRates(prev), rates) {
+			ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventRate, Rates: rates})
+		}
+	}
+	withdrawAll := ix.withdrawAll(group)
+	if withdrawAll {
+		ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventWithdrawAll, Participant: cur.OwnerInfo.Owner})
+	}
+
+	participants := make([]common.Address, 0, len(cur.ValidatorMap))
+	for _, info := range cur.ValidatorMap {
+		participants = append(participants, info.Address)
+	}
+	if prev != nil {
+		for _, info := range prev.ValidatorMap {
+			if _, exist := cur.ValidatorMap.Find(info.Address); !exist {
+				participants = append(participants, info.Address)
+			}
+		}
+	}
+	for _, participant := range participants {
+		ix.indexParticipant(group, participant, withdrawAll)
+	}
+}
+
+func (ix *groupIndexer) indexParticipant(group common.Address, participant common.Address, withdrawAll bool) {
+	prev, cur := ix.prevInfo(group, participant), ix.curInfo(group, participant)
+	if prev == nil {
+		prev = validatorGroup.NewValidatorInfo(participant)
+	}
+	if cur == nil {
+		cur = validatorGroup.NewValidatorInfo(participant)
+	}
+
+	if withdrawAll {
+		if amount := new(big.Int).Sub(bigOrZero(prev.Current.Amount), bigOrZero(cur.Current.Amount)); amount.Sign() > 0 {
+			ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventWithdraw, Participant: participant, Amount: amount})
+		}
+		for _, pos := range prev.Positions {
+			if now := findPosition(cur, pos.Position); pos.EndTime == 0 && now != nil && now.EndTime > 0 {
+				ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventWithdraw, Participant: participant, Amount: pos.Amount, DType: pos.DType, Position: pos.Position})
+			}
+		}
+	}
+
+	// refunds: the fixed positions gone, and the current withdrawals gone
+	for _, pos := range prev.Positions {
+		if findPosition(cur, pos.Position) == nil {
+			ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventRefund, Participant: participant, Amount: pos.Amount, DType: pos.DType, Position: pos.Position})
+		}
+	}
+	refunded := new(big.Int)
+	remain := make(map[withdrawKey]int)
+	for _, withdraw := range cur.Current.WithdrawList {
+		remain[newWithdrawKey(withdraw)]++
+	}
+	for _, withdraw := range prev.Current.WithdrawList {
+		key := newWithdrawKey(withdraw)
+		if remain[key] > 0 {
+			remain[key]--
+			continue
+		}
+		refunded.Add(refunded, bigOrZero(withdraw.WithDrawAmount))
+	}
+	if refunded.Sign() > 0 {
+		ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventRefund, Participant: participant, Amount: refunded})
+	}
+
+	// the refunds of the other participants are credited to their reward
+	reward := new(big.Int).Sub(bigOrZero(cur.Reward), bigOrZero(prev.Reward))
+	if reward.Sign() > 0 && refunded.Sign() > 0 {
+		if reward.Cmp(refunded) <= 0 {
+			reward.SetUint64(0)
+		} else {
+			reward.Sub(reward, refunded)
+		}
+	}
+	switch reward.Sign() {
+	case 1:
+		ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventReward, Participant: participant, Amount: reward})
+	case -1:
+		ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventClaim, Participant: participant, Amount: reward.Neg(reward)})
+	}
+	if interest := new(big.Int).Sub(bigOrZero(cur.Current.Interest), bigOrZero(prev.Current.Interest)); interest.Sign() > 0 {
+		ix.add(group, &rawdb.ValidatorGroupEvent{Type: rawdb.VGEventInterest, Participant: participant, Amount: interest})
+	}
+}
+
+// withdrawKey is the comparable form of a current deposit withdrawal.
+type withdrawKey struct {
+	time   uint64
+	amount string
+}
+
+func newWithdrawKey(withdraw common.WithDrawInfo) withdrawKey {
+	return withdrawKey{withdraw.WithDrawTime, bigOrZero(withdraw.WithDrawAmount).String()}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+func TestTouchedValidatorGroups(t *testing.T) {
+	var (
+		db       = mandb.NewMemDatabase()
+		sdb      = state.NewDatabase(db)
+		logged   = common.Address{1}
+		rewarded = common.Address{2}
+		idle     = common.Address{3}
+		key      = common.Hash{1}
+	)
+	rawdb.WriteValidatorGroupEvents(db, db, common.Hash{1}, []common.Address{logged, rewarded, idle}, map[common.Address][]*rawdb.ValidatorGroupEvent{
+		logged:   {{Type: rawdb.VGEventCreate}},
+		rewarded: {{Type: rawdb.VGEventCreate}},
+		idle:     {{Type: rawdb.VGEventCreate}},
+	})
+	commit := func(st *state.StateDBManage) []common.CoinRoot {
+		roots, _, err := st.Commit(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sdb.TrieDB().CommitRoots(roots, false); err != nil {
+			t.Fatal(err)
+		}
+		return roots
+	}
+	st, _ := state.NewStateDBManage(nil, db, sdb)
+	for _, group := range []common.Address{vm.ValidatorGroupContractAddress, logged, rewarded, idle} {
+		st.AddBalance(params.MAN_COIN, common.MainAccount, group, big.NewInt(1))
+		st.SetStateByteArray(params.MAN_COIN, group, key, []byte{1})
+	}
+	parent := commit(st)
+	st, _ = state.NewStateDBManage(parent, db, sdb)
+	st.SetStateByteArray(params.MAN_COIN, rewarded, key, []byte{2})
+	head := commit(st)
+
+	receipts := []types.CoinReceipts{{CoinType: params.MAN_COIN, Receiptlist: types.Receipts{
+		{Logs: []*types.Log{{Address: logged}, {Address: common.Address{4}}}},
+	}}}
+	groups, all := loggedValidatorGroups(db, receipts)
+	if all || len(groups) != 1 || !groups[logged] {
+		t.Fatalf("logged groups mismatch: %v %v", groups, all)
+	}
+	st, _ = state.NewStateDBManage(head, db, sdb)
+	preSt, _ := state.NewStateDBManage(parent, db, sdb)
+	if changedValidatorGroups(db, st, preSt, groups) || len(groups) != 2 || !groups[rewarded] {
+		t.Fatalf("changed groups mismatch: %v", groups)
+	}
+
+	// a creation decodes every group
+	receipts[0].Receiptlist[0].Logs = append(receipts[0].Receiptlist[0].Logs, &types.Log{Address: vm.ValidatorGroupContractAddress})
+	if _, all := loggedValidatorGroups(db, receipts); !all {
+		t.Fatalf("group creation not logged")
+	}
+}
@@ -45,6 +45,18 @@ func init() {
 	copy(getinterestArr_v2[:], depositAbi_v2.Methods["getinterest"].Id())
 }
 
+// DepositWithdrawInput packs the input of a call withdrawing the amount of a
+// deposit position.
+func DepositWithdrawInput(position uint64, amount *big.Int) ([]byte, error) {
+	return depositAbi_v2.Pack("withdraw", new(big.Int).SetUint64(position), amount)
+}
+
+// DepositRefundInput packs the input of a call refunding the withdrawn amounts
+// of a deposit position.
+func DepositRefundInput(position uint64) ([]byte, error) {
+	return depositAbi_v2.Pack("refund", new(big.Int).SetUint64(position))
+}
+
 type MatrixDeposit002 struct {
 }
 
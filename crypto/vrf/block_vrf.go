@@ -46,44 +46,43 @@ func (self *vrfWithHash) verifyVrf(pk *ecdsa.PublicKey, prevVrf, newVrf, proof [
 
 func (self *vrfWithHash) DecodeVrf(header *types.Header, preHeader *types.Header) (common.Address, error) {
 	log.INFO("vrf", "len header.VrfValue", len(header.VrfValue), "data", header.VrfValue, "高度", header.Number.Uint64())
-	account, _, _ := self.GetVrfInfoFromHeader(header.VrfValue)
-
-	log.Error("vrf", "从区块头重算出账户户", account, "高度", header.Number.Uint64())
-
-	public := account
-	curve := btcec.S256()
-	pk1, err := btcec.ParsePubKey(public, curve)
+	preVrfMsg, err := HeaderVrfMessage(preHeader.VrfValue, header.ParentHash)
+	if err != nil {
+		log.Error("vrf", "生成vefmsg出错", err, "parentMsg", preVrfMsg)
+		return common.Address{}, errors.New("生成vrfmsg出错")
+	}
+	ans, err := VerifyHeaderVrf(header.VrfValue, preVrfMsg)
 	if err != nil {
-		log.Error("vrf转换失败", "err", err, "account", account, "len", len(account))
+		log.Error("vrf verify ", "err", err)
 		return common.Address{}, err
 	}
+	return ans, nil
+}
 
-	pk1_1 := (*ecdsa.PublicKey)(pk1)
-	_, vrfValue, vrfProof := baseinterface.NewVrf().GetVrfInfoFromHeader(header.VrfValue)
-
-	_, preVrfValue, preVrfProof := self.GetVrfInfoFromHeader(preHeader.VrfValue)
-
-	preMsg := mc.VrfMsg{
+// HeaderVrfMessage returns the input of the VRF of a header, made of the VRF
+// value and proof of its parent header and the parent hash.
+func HeaderVrfMessage(parentVrf []byte, parentHash common.Hash) ([]byte, error) {
+	_, preVrfValue, preVrfProof := (&vrfWithHash{}).GetVrfInfoFromHeader(parentVrf)
+	return json.Marshal(mc.VrfMsg{
 		VrfValue: preVrfValue,
 		VrfProof: preVrfProof,
-		Hash:     header.ParentHash,
-	}
+		Hash:     parentHash,
+	})
+}
 
-	preVrfMsg, err := json.Marshal(preMsg)
+// VerifyHeaderVrf verifies the VRF of a header against its input, and returns
+// the account of the VRF public key.
+func VerifyHeaderVrf(headerVrf []byte, msg []byte) (common.Address, error) {
+	public, vrfValue, vrfProof := (&vrfWithHash{}).GetVrfInfoFromHeader(headerVrf)
+	pk, err := btcec.ParsePubKey(public, btcec.S256())
 	if err != nil {
-		log.Error("vrf", "生成vefmsg出错", err, "parentMsg", preVrfMsg)
-		return common.Address{}, errors.New("生成vrfmsg出错")
-	} else {
-		log.Error("生成vrfmsg成功")
+		log.Error("vrf转换失败", "err", err, "account", public, "len", len(public))
+		return common.Address{}, err
 	}
-	//log.Info("msgggggvrf_verify","preVrfMsg",preVrfMsg,"高度",header.Number.Uint64(),"VrfProof",preMsg.VrfProof,"VrfValue",preMsg.VrfValue,"Hash",preMsg.Hash)
-	if err := self.verifyVrf(pk1_1, preVrfMsg, vrfValue, vrfProof); err != nil {
-		log.Error("vrf verify ", "err", err)
+	if err := (&vrfWithHash{}).verifyVrf((*ecdsa.PublicKey)(pk), msg, vrfValue, vrfProof); err != nil {
 		return common.Address{}, err
 	}
-
-	ans := crypto.PubkeyToAddress(*pk1_1)
-	return ans, nil
+	return crypto.PubkeyToAddress(*(*ecdsa.PublicKey)(pk)), nil
 }
 
 func (self *vrfWithHash) GetHeaderVrf(account []byte, vrfvalue []byte, vrfproof []byte) []byte {
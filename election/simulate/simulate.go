@@ -0,0 +1,255 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package simulate runs the registered election plugs on a scenario read from a
+// JSON file instead of the chain state, to evaluate election parameters before
+// they are set on chain.
+package simulate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/math"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manparams"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/pkg/errors"
+
+	_ "github.com/MatrixAINetwork/go-matrix/election/layered"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layeredbss"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layeredmep"
+	_ "github.com/MatrixAINetwork/go-matrix/election/nochoice"
+	_ "github.com/MatrixAINetwork/go-matrix/election/stock"
+)
+
+// Deposit is a deposit of the scenario, amounts are in wei, hex or decimal.
+type Deposit struct {
+	Address     common.Address
+	SignAddress common.Address
+	Deposit     *math.HexOrDecimal256
+	OnlineTime  *math.HexOrDecimal256 `json:",omitempty"`
+}
+
+// Scenario is the input of an election: the deposits, the VIP config, the
+// election config with its black and white lists, and the random seed.
+type Scenario struct {
+	// Plugin is the election plug to run, ElectConfig.ElectPlug when empty.
+	Plugin                string
+	Seed                  *math.HexOrDecimal256
+	SeqNum                uint64
+	Miners                []Deposit
+	Validators            []Deposit
+	FoundationValidators  []Deposit `json:",omitempty"`
+	VIPList               []mc.VIPConfig
+	ElectConfig           mc.ElectConfigInfo_All
+	BlockProduceBlackList []mc.UserBlockProduceSlash `json:",omitempty"`
+}
+
+// LoadScenario reads a scenario from a JSON file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	scenario := new(Scenario)
+	if err := json.Unmarshal(data, scenario); err != nil {
+		return nil, errors.Wrap(err, "decode scenario")
+	}
+	return scenario, nil
+}
+
+// PluginName returns the name of the election plug the scenario runs.
+func (s *Scenario) PluginName() string {
+	if s.Plugin != "" {
+		return s.Plugin
+	}
+	if s.ElectConfig.ElectPlug != "" {
+		return s.ElectConfig.ElectPlug
+	}
+	return manparams.ElectPlug_layerd
+}
+
+// Topology is the result of one election.
+type Topology struct {
+	Seed       *big.Int
+	Miners     []mc.ElectNodeInfo
+	Validators []mc.ElectNodeInfo
+	Backups    []mc.ElectNodeInfo
+	Candidates []mc.ElectNodeInfo
+}
+
+// NodeStat counts how often a node was elected over the runs. Stock is the sum
+// of the stocks it was given as a master.
+type NodeStat struct {
+	Address   common.Address
+	Master    int
+	Backup    int
+	Candidate int
+	Stock     uint64
+}
+
+// Report is the result of a simulation.
+type Report struct {
+	Plugin     string
+	Runs       int
+	First      *Topology // topology of the first seed
+	Miners     []*NodeStat
+	Validators []*NodeStat
+}
+
+// Run runs the election of the scenario with runs seeds, starting at the
+// scenario seed and incremented by one every run.
+func Run(s *Scenario, runs int) (report *Report, err error) {
+	plugin := s.PluginName()
+	registered := false
+	for _, name := range baseinterface.ElectPlugNames() {
+		registered = registered || name == plugin
+	}
+	if !registered {
+		return nil, errors.Errorf("unknown election plug %q, registered: %v", plugin, baseinterface.ElectPlugNames())
+	}
+	if runs <= 0 {
+		runs = 1
+	}
+	// the plugs do not check their input, turn their panics into errors
+	defer func() {
+		if r := recover(); r != nil {
+			report, err = nil, fmt.Errorf("election plug %s failed: %v", plugin, r)
+		}
+	}()
+
+	var (
+		elect      = baseinterface.NewElect(plugin)
+		miners     = newCounter()
+		validators = newCounter()
+		seed       = new(big.Int)
+	)
+	if s.Seed != nil {
+		seed.Set((*big.Int)(s.Seed))
+	}
+	report = &Report{Plugin: plugin, Runs: runs}
+	for i := 0; i < runs; i++ {
+		topology := &Topology{Seed: new(big.Int).Add(seed, big.NewInt(int64(i)))}
+		if len(s.Miners) > 0 {
+			rsp := elect.MinerTopGen(&mc.MasterMinerReElectionReqMsg{
+				SeqNum:      s.SeqNum,
+				RandSeed:    new(big.Int).Set(topology.Seed),
+				MinerList:   depositDetails(s.Miners),
+				ElectConfig: s.ElectConfig,
+			})
+			topology.Miners = rsp.MasterMiner
+			miners.add(topology.Miners, nil, nil)
+		}
+		if len(s.Validators) > 0 {
+			stateDb, err := newState()
+			if err != nil {
+				return nil, err
+			}
+			rsp := elect.ValidatorTopGen(&mc.MasterValidatorReElectionReqMsg{
+				SeqNum:                  s.SeqNum,
+				RandSeed:                new(big.Int).Set(topology.Seed),
+				ValidatorList:           depositDetails(s.Validators),
+				FoundationValidatorList: depositDetails(s.FoundationValidators),
+				ElectConfig:             s.ElectConfig,
+				VIPList:                 s.VIPList,
+				BlockProduceBlackList:   mc.BlockProduceSlashBlackList{BlackList: s.BlockProduceBlackList},
+			}, stateDb)
+			topology.Validators, topology.Backups, topology.Candidates = rsp.MasterValidator, rsp.BackUpValidator, rsp.CandidateValidator
+			validators.add(topology.Validators, topology.Backups, topology.Candidates)
+		}
+		if i == 0 {
+			report.First = topology
+		}
+	}
+	report.Miners = miners.sorted()
+	report.Validators = validators.sorted()
+	return report, nil
+}
+
+func depositDetails(deposits []Deposit) []vm.DepositDetail {
+	details := make([]vm.DepositDetail, 0, len(deposits))
+	for _, d := range deposits {
+		detail := vm.DepositDetail{Address: d.Address, SignAddress: d.SignAddress, Deposit: new(big.Int)}
+		if d.Deposit != nil {
+			detail.Deposit.Set((*big.Int)(d.Deposit))
+		}
+		if d.OnlineTime != nil {
+			detail.OnlineTime = new(big.Int).Set((*big.Int)(d.OnlineTime))
+		}
+		details = append(details, detail)
+	}
+	return details
+}
+
+// newState returns an empty state, some plugs save their black list in it.
+func newState() (*state.StateDBManage, error) {
+	db := mandb.NewMemDatabase()
+	st, err := state.NewStateDBManage(make([]common.CoinRoot, 0), db, state.NewDatabase(db))
+	if err != nil {
+		return nil, err
+	}
+	matrixstate.SetVersionInfo(st, manversion.VersionAIMine)
+	return st, nil
+}
+
+type counter map[common.Address]*NodeStat
+
+func newCounter() counter {
+	return make(counter)
+}
+
+func (c counter) get(addr common.Address) *NodeStat {
+	stat, ok := c[addr]
+	if !ok {
+		stat = &NodeStat{Address: addr}
+		c[addr] = stat
+	}
+	return stat
+}
+
+func (c counter) add(masters, backups, candidates []mc.ElectNodeInfo) {
+	for _, node := range masters {
+		stat := c.get(node.Account)
+		stat.Master++
+		stat.Stock += uint64(node.Stock)
+	}
+	for _, node := range backups {
+		c.get(node.Account).Backup++
+	}
+	for _, node := range candidates {
+		c.get(node.Account).Candidate++
+	}
+}
+
+// sorted returns the stats, the most elected first.
+func (c counter) sorted() []*NodeStat {
+	stats := make([]*NodeStat, 0, len(c))
+	for _, stat := range c {
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Master != b.Master {
+			return a.Master > b.Master
+		}
+		if a.Backup != b.Backup {
+			return a.Backup > b.Backup
+		}
+		if a.Candidate != b.Candidate {
+			return a.Candidate > b.Candidate
+		}
+		return a.Address.Big().Cmp(b.Address.Big()) < 0
+	})
+	return stats
+}
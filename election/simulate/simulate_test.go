@@ -0,0 +1,77 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package simulate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+)
+
+func loadTestScenario(t *testing.T) *Scenario {
+	scenario, err := LoadScenario("testdata/scenario.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scenario
+}
+
+func sumMasters(stats []*NodeStat) int {
+	sum := 0
+	for _, stat := range stats {
+		sum += stat.Master
+	}
+	return sum
+}
+
+func TestRun(t *testing.T) {
+	scenario := loadTestScenario(t)
+	const runs = 10
+	report, err := Run(scenario, runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Runs != runs || report.First == nil || report.First.Seed.Uint64() != 100 {
+		t.Fatalf("bad report header: runs %d, first %v", report.Runs, report.First)
+	}
+	if have, want := sumMasters(report.Miners), runs*int(scenario.ElectConfig.MinerNum); have != want {
+		t.Errorf("miner masters mismatch: have %d, want %d", have, want)
+	}
+	if have, want := sumMasters(report.Validators), runs*int(scenario.ElectConfig.ValidatorNum); have != want {
+		t.Errorf("validator masters mismatch: have %d, want %d", have, want)
+	}
+	for _, stat := range report.Validators {
+		if stat.Address == scenario.ElectConfig.BlackList[0] && stat.Master+stat.Backup+stat.Candidate > 0 {
+			t.Errorf("black listed validator %x elected", stat.Address)
+		}
+	}
+
+	again, err := Run(scenario, runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report, again) {
+		t.Errorf("election is not deterministic")
+	}
+}
+
+func TestRunPlugs(t *testing.T) {
+	scenario := loadTestScenario(t)
+	for _, name := range baseinterface.ElectPlugNames() {
+		scenario.Plugin = name
+		if _, err := Run(scenario, 2); err != nil {
+			t.Errorf("plug %s: %v", name, err)
+		}
+	}
+}
+
+func TestRunUnknownPlug(t *testing.T) {
+	scenario := loadTestScenario(t)
+	scenario.Plugin = "unknown"
+	if _, err := Run(scenario, 1); err == nil {
+		t.Errorf("unknown plug returned no error")
+	}
+}
@@ -650,6 +650,9 @@ func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, strAddress string,
 	if err != nil {
 		return nil, err
 	}
+	if !s.b.ShardSubscription().ContainsAccount(cointype, address) {
+		return nil, core.ErrShardNotSubscribed
+	}
 	var balance []RPCBalanceType
 	b := state.GetBalance(cointype, address)
 	if b == nil {
@@ -1224,6 +1227,9 @@ func (s *PublicBlockChainAPI) getCode(ctx context.Context, address common.Addres
 	if state == nil || err != nil {
 		return nil, err
 	}
+	if !s.b.ShardSubscription().ContainsAccount(cointype, address) {
+		return nil, core.ErrShardNotSubscribed
+	}
 	code := state.GetCode(cointype, address)
 	return code, state.Error()
 }
@@ -1243,6 +1249,9 @@ func (s *PublicBlockChainAPI) getStorageAt(ctx context.Context, address common.A
 	if state == nil || err != nil {
 		return nil, err
 	}
+	if !s.b.ShardSubscription().ContainsAccount(cointype, address) {
+		return nil, core.ErrShardNotSubscribed
+	}
 	res := state.GetState(cointype, address, common.HexToHash(key))
 	return res[:], state.Error()
 }
@@ -2168,6 +2177,9 @@ func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, strA
 	if err != nil {
 		return nil, err
 	}
+	if !s.b.ShardSubscription().ContainsAccount(cointype, address) {
+		return nil, core.ErrShardNotSubscribed
+	}
 	nonce := state.GetNonce(cointype, address)
 	return (*hexutil.Uint64)(&nonce), state.Error()
 }
@@ -72,6 +72,7 @@ type Backend interface {
 	GetDepositAccount(signAccount common.Address, blockHash common.Hash) (common.Address, error)
 	GetFutureRewards(*state.StateDBManage, rpc.BlockNumber) (interface{}, error)
 	Genesis() *types.Block
+	ShardSubscription() *core.ShardSubscription
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -132,6 +133,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicAccountAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "matrix",
+			Version:   "1.0",
+			Service:   NewPublicMatrixStateAPI(apiBackend),
+			Public:    true,
 		}, {
 			Namespace: "personal",
 			Version:   "1.0",
@@ -0,0 +1,355 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// maxSlashHistoryCycles is the max number of election cycles read back at once.
+const maxSlashHistoryCycles = 16
+
+const (
+	// blackListRuleA: before VersionGamma every counter is decremented once
+	// per election cycle, a slashed validator gets ProhibitCycleNum-1.
+	blackListRuleA = "A"
+	// blackListRuleB: from VersionGamma a counter is decremented by the
+	// validator elections drawing the validator, a slashed validator gets
+	// ProhibitCycleNum, and validators without deposit are dropped.
+	blackListRuleB = "B"
+)
+
+var blackListRuleDescriptions = map[string]string{
+	blackListRuleA: "produced less than lowTHR blocks in an election cycle, banned for prohibitCycleNum elections, the counter decreases every election cycle",
+	blackListRuleB: "produced less than lowTHR blocks in an election cycle, banned until prohibitCycleNum validator elections drew it, the counter decreases every election drawing it",
+}
+
+// RPCBlockProduceNum is the number of blocks produced by a validator in the
+// election cycle.
+type RPCBlockProduceNum struct {
+	Address        string `json:"address"`
+	ProduceNum     uint16 `json:"produceNum"`
+	BelowThreshold bool   `json:"belowThreshold"`
+}
+
+// RPCBlockProduceSlashCause is the election cycle whose stats put a validator
+// in the blacklist.
+type RPCBlockProduceSlashCause struct {
+	ReElectionNumber hexutil.Uint64 `json:"reElectionNumber"`
+	SlashNumber      hexutil.Uint64 `json:"slashNumber"`
+	ProduceNum       uint16         `json:"produceNum"`
+	LowTHR           uint16         `json:"lowTHR"`
+}
+
+// RPCBlockProduceBlackListItem is a blacklisted validator with the projection
+// of the first validator election it can be elected by again. The projection
+// is exact for the rule A and the earliest one for the rule B.
+type RPCBlockProduceBlackListItem struct {
+	Address              string                     `json:"address"`
+	ProhibitCycleCounter uint16                     `json:"prohibitCycleCounter"`
+	RemainingElections   uint16                     `json:"remainingElections"`
+	EligibleElection     hexutil.Uint64             `json:"eligibleElection"`
+	Exact                bool                       `json:"exact"`
+	Cause                *RPCBlockProduceSlashCause `json:"cause"` // nil if not found in the readable states
+}
+
+// RPCBlockProduceSlash is the block produce slash state of a block.
+type RPCBlockProduceSlash struct {
+	Number           hexutil.Uint64                  `json:"number"`
+	Rule             string                          `json:"rule"`
+	RuleDescription  string                          `json:"ruleDescription"`
+	Switcher         bool                            `json:"switcher"`
+	LowTHR           uint16                          `json:"lowTHR"`
+	ProhibitCycleNum uint16                          `json:"prohibitCycleNum"`
+	StatsStart       hexutil.Uint64                  `json:"statsStart"`
+	ReElectionNumber hexutil.Uint64                  `json:"reElectionNumber"`
+	SlashNumber      hexutil.Uint64                  `json:"slashNumber"`
+	NextElection     hexutil.Uint64                  `json:"nextElection"` // first reelection the blacklist is not used by yet
+	Stats            []*RPCBlockProduceNum           `json:"stats"`
+	BlackList        []*RPCBlockProduceBlackListItem `json:"blackList"`
+}
+
+// RPCBlockProduceCycle is the block produce stats of a validator in an election
+// cycle, and its blacklist counter after the cycle, or now for the current one.
+type RPCBlockProduceCycle struct {
+	ReElectionNumber     hexutil.Uint64 `json:"reElectionNumber"`
+	SlashNumber          hexutil.Uint64 `json:"slashNumber"`
+	StatsStart           hexutil.Uint64 `json:"statsStart"`
+	Finished             bool           `json:"finished"`
+	Validator            bool           `json:"validator"` // counted in the stats of the cycle
+	ProduceNum           uint16         `json:"produceNum"`
+	LowTHR               uint16         `json:"lowTHR"`
+	Slashed              bool           `json:"slashed"` // blacklisted by the stats of the cycle
+	InBlackList          bool           `json:"inBlackList"`
+	ProhibitCycleCounter uint16         `json:"prohibitCycleCounter"`
+}
+
+// slashCycle are the heights of the election cycle ending with the reelection:
+// the stats are counted from the block after the previous reelection, and the
+// blacklist is updated by the block before the validator election.
+type slashCycle struct {
+	reElection uint64
+	slash      uint64
+}
+
+func newSlashCycle(reElection uint64, timing *mc.ElectGenTimeStruct) slashCycle {
+	cycle := slashCycle{reElection: reElection}
+	if gap := uint64(timing.ValidatorGen) + 1; reElection > gap {
+		cycle.slash = reElection - gap
+	}
+	return cycle
+}
+
+// currentSlashCycle returns the election cycle of the height.
+func currentSlashCycle(number uint64, bcInterval *mc.BCIntervalInfo, timing *mc.ElectGenTimeStruct) slashCycle {
+	return newSlashCycle(bcInterval.GetNextReElectionNumber(number), timing)
+}
+
+// previousSlashCycle returns the election cycle before the cycle, false if there
+// is none.
+func previousSlashCycle(cycle slashCycle, bcInterval *mc.BCIntervalInfo, timing *mc.ElectGenTimeStruct) (slashCycle, bool) {
+	interval := bcInterval.GetReElectionInterval()
+	if cycle.reElection <= interval {
+		// the genesis is the reelection before the first cycle
+		return slashCycle{}, false
+	}
+	return newSlashCycle(cycle.reElection-interval, timing), true
+}
+
+// nextElection returns the first reelection the blacklist of the height is not
+// used by yet.
+func nextElection(number uint64, cycle slashCycle, bcInterval *mc.BCIntervalInfo) uint64 {
+	if number >= cycle.slash {
+		return cycle.reElection + bcInterval.GetReElectionInterval()
+	}
+	return cycle.reElection
+}
+
+// projectEligibleElection returns the number of the elections still banning a
+// blacklisted validator and the first one it can be elected by.
+func projectEligibleElection(next uint64, counter uint16, bcInterval *mc.BCIntervalInfo) (uint16, uint64) {
+	return counter, next + uint64(counter)*bcInterval.GetReElectionInterval()
+}
+
+func blackListRule(version string) string {
+	if manversion.VersionCmp(version, manversion.VersionGamma) >= 0 {
+		return blackListRuleB
+	}
+	return blackListRuleA
+}
+
+// blockProduceSlashState is the block produce slash state read from a block.
+type blockProduceSlashState struct {
+	header     *types.Header
+	cfg        *mc.BlockProduceSlashCfg
+	stats      *mc.BlockProduceStats
+	statsStart uint64
+	blackList  *mc.BlockProduceSlashBlackList
+	bcInterval *mc.BCIntervalInfo
+	timing     *mc.ElectGenTimeStruct
+}
+
+func readBlockProduceSlashState(st *state.StateDBManage, header *types.Header) (*blockProduceSlashState, error) {
+	result := &blockProduceSlashState{
+		header:    header,
+		stats:     &mc.BlockProduceStats{},
+		blackList: &mc.BlockProduceSlashBlackList{},
+	}
+	var err error
+	if result.cfg, err = matrixstate.GetBlockProduceSlashCfg(st); err != nil {
+		return nil, err
+	}
+	if result.bcInterval, err = matrixstate.GetBroadcastInterval(st); err != nil {
+		return nil, err
+	}
+	if result.timing, err = matrixstate.GetElectGenTime(st); err != nil {
+		return nil, err
+	}
+	// the stats and the blacklist are missing until the first cycle is counted
+	if stats, err := matrixstate.GetBlockProduceStats(st); err == nil && stats != nil {
+		result.stats = stats
+	}
+	if status, err := matrixstate.GetBlockProduceStatsStatus(st); err == nil && status != nil {
+		result.statsStart = status.Number
+	}
+	if blackList, err := matrixstate.GetBlockProduceBlackList(st); err == nil && blackList != nil {
+		result.blackList = blackList
+	}
+	return result, nil
+}
+
+func (s *PublicBlockChainAPI) blockProduceSlashState(ctx context.Context, blockNr rpc.BlockNumber) (*blockProduceSlashState, error) {
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil || header == nil {
+		return nil, fmt.Errorf("state of block %d not found", blockNr)
+	}
+	return readBlockProduceSlashState(st, header)
+}
+
+func findProduceNum(stats *mc.BlockProduceStats, addr common.Address) (uint16, bool) {
+	for _, item := range stats.StatsList {
+		if item.Address == addr {
+			return item.ProduceNum, true
+		}
+	}
+	return 0, false
+}
+
+func findBlackListCounter(blackList *mc.BlockProduceSlashBlackList, addr common.Address) (uint16, bool) {
+	for _, item := range blackList.BlackList {
+		if item.Address == addr {
+			return item.ProhibitCycleCounter, true
+		}
+	}
+	return 0, false
+}
+
+// findSlashCauses reads back the finished election cycles from the cycle, until
+// the cycle blacklisting every validator of the items is found.
+func (s *PublicBlockChainAPI) findSlashCauses(ctx context.Context, cycle slashCycle, current *blockProduceSlashState, items map[common.Address]*RPCBlockProduceBlackListItem) {
+	for i := 0; i < maxSlashHistoryCycles && len(items) > 0; i++ {
+		past, err := s.blockProduceSlashState(ctx, rpc.BlockNumber(cycle.slash))
+		if err != nil {
+			// the state is pruned
+			return
+		}
+		for addr, item := range items {
+			if produceNum, exist := findProduceNum(past.stats, addr); exist && produceNum < past.cfg.LowTHR {
+				item.Cause = &RPCBlockProduceSlashCause{
+					ReElectionNumber: hexutil.Uint64(cycle.reElection),
+					SlashNumber:      hexutil.Uint64(cycle.slash),
+					ProduceNum:       produceNum,
+					LowTHR:           past.cfg.LowTHR,
+				}
+				delete(items, addr)
+			}
+		}
+		var ok bool
+		if cycle, ok = previousSlashCycle(cycle, current.bcInterval, current.timing); !ok {
+			return
+		}
+	}
+}
+
+// GetBlockProduceSlash returns the block produce stats of the election cycle of
+// the block, and the validators blacklisted for producing too few blocks with
+// the cycle putting them in the list and the election they can be elected by
+// again.
+func (s *PublicBlockChainAPI) GetBlockProduceSlash(ctx context.Context, blockNr rpc.BlockNumber) (*RPCBlockProduceSlash, error) {
+	current, err := s.blockProduceSlashState(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	number := current.header.Number.Uint64()
+	rule := blackListRule(string(current.header.Version))
+	cycle := currentSlashCycle(number, current.bcInterval, current.timing)
+	next := nextElection(number, cycle, current.bcInterval)
+	result := &RPCBlockProduceSlash{
+		Number:           hexutil.Uint64(number),
+		Rule:             rule,
+		RuleDescription:  blackListRuleDescriptions[rule],
+		Switcher:         current.cfg.Switcher,
+		LowTHR:           current.cfg.LowTHR,
+		ProhibitCycleNum: current.cfg.ProhibitCycleNum,
+		StatsStart:       hexutil.Uint64(current.statsStart),
+		ReElectionNumber: hexutil.Uint64(cycle.reElection),
+		SlashNumber:      hexutil.Uint64(cycle.slash),
+		NextElection:     hexutil.Uint64(next),
+		Stats:            make([]*RPCBlockProduceNum, 0, len(current.stats.StatsList)),
+		BlackList:        make([]*RPCBlockProduceBlackListItem, 0, len(current.blackList.BlackList)),
+	}
+	for _, item := range current.stats.StatsList {
+		result.Stats = append(result.Stats, &RPCBlockProduceNum{
+			Address:        base58.Base58EncodeToString(params.MAN_COIN, item.Address),
+			ProduceNum:     item.ProduceNum,
+			BelowThreshold: item.ProduceNum < current.cfg.LowTHR,
+		})
+	}
+	causes := make(map[common.Address]*RPCBlockProduceBlackListItem)
+	for _, item := range current.blackList.BlackList {
+		remaining, eligible := projectEligibleElection(next, item.ProhibitCycleCounter, current.bcInterval)
+		rpcItem := &RPCBlockProduceBlackListItem{
+			Address:              base58.Base58EncodeToString(params.MAN_COIN, item.Address),
+			ProhibitCycleCounter: item.ProhibitCycleCounter,
+			RemainingElections:   remaining,
+			EligibleElection:     hexutil.Uint64(eligible),
+			Exact:                rule == blackListRuleA,
+		}
+		causes[item.Address] = rpcItem
+		result.BlackList = append(result.BlackList, rpcItem)
+	}
+	// the blacklist is updated by the last finished cycle
+	last, ok := cycle, true
+	if number < cycle.slash {
+		last, ok = previousSlashCycle(cycle, current.bcInterval, current.timing)
+	}
+	if ok {
+		s.findSlashCauses(ctx, last, current, causes)
+	}
+	return result, nil
+}
+
+// GetBlockProduceSlashHistory returns the block produce stats of the validator
+// in the last cycles election cycles up to the block, the current one first.
+func (s *PublicBlockChainAPI) GetBlockProduceSlashHistory(ctx context.Context, strAddr string, cycles uint64, blockNr rpc.BlockNumber) ([]*RPCBlockProduceCycle, error) {
+	addr, err := base58.Base58DecodeToAddress(strAddr)
+	if err != nil {
+		return nil, err
+	}
+	if cycles == 0 {
+		return nil, errors.New("cycles must be positive")
+	}
+	if cycles > maxSlashHistoryCycles {
+		cycles = maxSlashHistoryCycles
+	}
+	current, err := s.blockProduceSlashState(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	number := current.header.Number.Uint64()
+	cycle := currentSlashCycle(number, current.bcInterval, current.timing)
+	result := make([]*RPCBlockProduceCycle, 0, cycles)
+	for uint64(len(result)) < cycles {
+		slashState := current
+		if cycle.slash <= number {
+			if slashState, err = s.blockProduceSlashState(ctx, rpc.BlockNumber(cycle.slash)); err != nil {
+				// the state is pruned
+				break
+			}
+		}
+		item := &RPCBlockProduceCycle{
+			ReElectionNumber: hexutil.Uint64(cycle.reElection),
+			SlashNumber:      hexutil.Uint64(cycle.slash),
+			StatsStart:       hexutil.Uint64(slashState.statsStart),
+			Finished:         cycle.slash <= number,
+			LowTHR:           slashState.cfg.LowTHR,
+		}
+		item.ProduceNum, item.Validator = findProduceNum(slashState.stats, addr)
+		item.ProhibitCycleCounter, item.InBlackList = findBlackListCounter(slashState.blackList, addr)
+		item.Slashed = item.Finished && item.Validator && slashState.cfg.Switcher && slashState.cfg.ProhibitCycleNum > 0 && item.ProduceNum < slashState.cfg.LowTHR
+		result = append(result, item)
+
+		var ok bool
+		if cycle, ok = previousSlashCycle(cycle, current.bcInterval, current.timing); !ok {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func TestBlockProduceSlashProjection(t *testing.T) {
+	bcInterval := &mc.BCIntervalInfo{BCInterval: 100}
+	timing := &mc.ElectGenTimeStruct{ValidatorGen: 9}
+
+	// the blacklist of the cycle ending at 300 is updated by the block 290
+	cycle := currentSlashCycle(250, bcInterval, timing)
+	if cycle.reElection != 300 || cycle.slash != 290 {
+		t.Fatalf("cycle mismatch: %+v", cycle)
+	}
+	if next := nextElection(250, cycle, bcInterval); next != 300 {
+		t.Fatalf("next election before the slash mismatch: %d", next)
+	}
+	next := nextElection(295, cycle, bcInterval)
+	if next != 600 {
+		t.Fatalf("next election after the slash mismatch: %d", next)
+	}
+	if remaining, eligible := projectEligibleElection(next, 2, bcInterval); remaining != 2 || eligible != 1200 {
+		t.Fatalf("projection mismatch: %d, %d", remaining, eligible)
+	}
+	if _, eligible := projectEligibleElection(next, 0, bcInterval); eligible != next {
+		t.Fatalf("projection of a released validator mismatch: %d", eligible)
+	}
+
+	// the reelection number belongs to the next cycle
+	if cycle := currentSlashCycle(300, bcInterval, timing); cycle.reElection != 600 {
+		t.Fatalf("cycle of the reelection mismatch: %+v", cycle)
+	}
+	prev, ok := previousSlashCycle(newSlashCycle(600, timing), bcInterval, timing)
+	if !ok || prev.reElection != 300 || prev.slash != 290 {
+		t.Fatalf("previous cycle mismatch: %+v", prev)
+	}
+	if _, ok := previousSlashCycle(prev, bcInterval, timing); ok {
+		t.Fatalf("cycle before the first one found")
+	}
+
+	if blackListRule(manversion.VersionAlpha) != blackListRuleA || blackListRule(manversion.VersionGamma) != blackListRuleB {
+		t.Fatalf("blacklist rule mismatch")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// maxCoinPageSize is the max number of coins or holders returned by a page.
+const maxCoinPageSize = 1000
+
+// RPCCoinInfo is the creation metadata and the supply of a coin. The creation
+// fields are empty for a coin created before the node indexed coins.
+type RPCCoinInfo struct {
+	Coin        string            `json:"coin"`
+	Config      common.CoinConfig `json:"config"`
+	Creator     string            `json:"creator"`
+	BlockNumber *hexutil.Uint64   `json:"blockNumber"`
+	BlockHash   *common.Hash      `json:"blockHash"`
+	TxHash      *common.Hash      `json:"txHash"`
+	Fee         *hexutil.Big      `json:"fee"` // MAN paid to the destroy address for the creation
+	Minted      *hexutil.Big      `json:"minted"`
+	Destroyed   *hexutil.Big      `json:"destroyed"`
+	Circulating *hexutil.Big      `json:"circulating"`
+	Holders     hexutil.Uint64    `json:"holders"` // number of indexed holders
+}
+
+// RPCCoinHolder is an indexed holder of a coin, with its balance at the block.
+type RPCCoinHolder struct {
+	Address string           `json:"address"`
+	Balance []RPCBalanceType `json:"balance"`
+}
+
+// RPCCoinHolders is a page of the holders of a coin.
+type RPCCoinHolders struct {
+	Coin    string           `json:"coin"`
+	Total   hexutil.Uint64   `json:"total"`
+	Offset  hexutil.Uint64   `json:"offset"`
+	Holders []*RPCCoinHolder `json:"holders"`
+}
+
+func coinPage(offset uint64, limit uint64, total uint64) (uint64, uint64) {
+	if limit == 0 || limit > maxCoinPageSize {
+		limit = maxCoinPageSize
+	}
+	if offset >= total {
+		return total, total
+	}
+	if limit > total-offset {
+		return offset, total
+	}
+	return offset, offset + limit
+}
+
+func rpcBalance(balance common.BalanceType) ([]RPCBalanceType, *big.Int) {
+	result := make([]RPCBalanceType, 0, len(balance))
+	total := new(big.Int)
+	for _, b := range balance {
+		amount := new(big.Int)
+		if b.Balance != nil {
+			amount.Set(b.Balance)
+		}
+		result = append(result, RPCBalanceType{b.AccountType, (*hexutil.Big)(amount)})
+		total.Add(total, amount)
+	}
+	return result, total
+}
+
+func (s *PublicBlockChainAPI) coinInfo(st *state.StateDBManage, config common.CoinConfig) *RPCCoinInfo {
+	info := &RPCCoinInfo{
+		Coin:    config.CoinType,
+		Config:  config,
+		Minted:  new(hexutil.Big),
+		Holders: hexutil.Uint64(rawdb.ReadCoinHolderCount(s.b.ChainDb(), config.CoinType)),
+	}
+	if config.CoinTotal != nil {
+		info.Minted = config.CoinTotal
+	}
+	_, destroyed := rpcBalance(st.GetBalance(config.CoinType, common.DestroyAddress))
+	info.Destroyed = (*hexutil.Big)(destroyed)
+	info.Circulating = (*hexutil.Big)(new(big.Int).Sub(info.Minted.ToInt(), destroyed))
+
+	if meta := rawdb.ReadCoinMeta(s.b.ChainDb(), config.CoinType); meta != nil {
+		if meta.Creator != (common.Address{}) {
+			info.Creator = base58.Base58EncodeToString(params.MAN_COIN, meta.Creator)
+		}
+		number := hexutil.Uint64(meta.BlockNumber)
+		info.BlockNumber = &number
+		info.BlockHash = &meta.BlockHash
+		info.TxHash = &meta.TxHash
+		info.Fee = (*hexutil.Big)(meta.Fee)
+	}
+	return info
+}
+
+func coinList(st *state.StateDBManage) ([]string, error) {
+	bs := st.GetMatrixData(types.RlpHash(params.COIN_NAME))
+	var tmpcoinlist []string
+	if len(bs) > 0 {
+		if err := json.Unmarshal(bs, &tmpcoinlist); err != nil {
+			return nil, err
+		}
+	}
+	coinlist := make([]string, 0, len(tmpcoinlist))
+	for _, coin := range tmpcoinlist {
+		if common.IsValidityCurrency(coin) {
+			coinlist = append(coinlist, coin)
+		}
+	}
+	return coinlist, nil
+}
+
+func coinConfigs(st *state.StateDBManage) (map[string]common.CoinConfig, error) {
+	configs, err := matrixstate.GetCoinConfig(st)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]common.CoinConfig, len(configs))
+	for _, config := range configs {
+		result[config.CoinType] = config
+	}
+	return result, nil
+}
+
+// GetCoinInfo returns the creation metadata and the supply of a coin at the block.
+// The minted supply is the coin total of the config, the destroyed one is the
+// balance of the destroy address in the coin.
+func (s *PublicBlockChainAPI) GetCoinInfo(ctx context.Context, coin string, blockNr rpc.BlockNumber) (*RPCCoinInfo, error) {
+	st, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if st == nil || err != nil {
+		return nil, err
+	}
+	configs, err := coinConfigs(st)
+	if err != nil {
+		return nil, err
+	}
+	config, exist := configs[coin]
+	if !exist || !common.IsValidityCurrency(coin) {
+		return nil, fmt.Errorf("coin %s not found", coin)
+	}
+	return s.coinInfo(st, config), nil
+}
+
+// ListCoins returns a page of the coins at the block, in creation order.
+func (s *PublicBlockChainAPI) ListCoins(ctx context.Context, offset uint64, limit uint64, blockNr rpc.BlockNumber) ([]*RPCCoinInfo, error) {
+	st, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if st == nil || err != nil {
+		return nil, err
+	}
+	coins, err := coinList(st)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := coinConfigs(st)
+	if err != nil {
+		return nil, err
+	}
+	start, end := coinPage(offset, limit, uint64(len(coins)))
+	infos := make([]*RPCCoinInfo, 0, end-start)
+	for _, coin := range coins[start:end] {
+		config, exist := configs[coin]
+		if !exist {
+			config = common.CoinConfig{CoinType: coin, CoinRange: coin}
+		}
+		infos = append(infos, s.coinInfo(st, config))
+	}
+	return infos, nil
+}
+
+// GetCoinHolders returns a page of the indexed holders of a coin, MAN included,
+// with their balances at the block. An account is indexed once a transaction of
+// the coin sends from or to it, and stays indexed when its balance is spent.
+func (s *PublicBlockChainAPI) GetCoinHolders(ctx context.Context, coin string, offset uint64, limit uint64, blockNr rpc.BlockNumber) (*RPCCoinHolders, error) {
+	if coin != params.MAN_COIN && !common.IsValidityCurrency(coin) {
+		return nil, errors.New("Invalid currency")
+	}
+	st, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if st == nil || err != nil {
+		return nil, err
+	}
+	db := s.b.ChainDb()
+	total := rawdb.ReadCoinHolderCount(db, coin)
+	start, end := coinPage(offset, limit, total)
+	result := &RPCCoinHolders{
+		Coin:    coin,
+		Total:   hexutil.Uint64(total),
+		Offset:  hexutil.Uint64(start),
+		Holders: make([]*RPCCoinHolder, 0, end-start),
+	}
+	for _, account := range rawdb.ReadCoinHolders(db, coin, start, end-start) {
+		balance, _ := rpcBalance(st.GetBalance(coin, account))
+		result.Holders = append(result.Holders, &RPCCoinHolder{
+			Address: base58.Base58EncodeToString(coin, account),
+			Balance: balance,
+		})
+	}
+	return result, st.Error()
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/depositcfg"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+var depositContractAddress = common.BytesToAddress([]byte{10})
+
+// States of a deposit position.
+const (
+	DepositStatusActive      = "active"      // deposited, nothing withdrawn
+	DepositStatusWithdrawing = "withdrawing" // withdrawn amounts wait for their refund time
+	DepositStatusRefundable  = "refundable"  // withdrawn amounts can be refunded
+)
+
+// RPCDepositWithdrawal is a withdrawn amount of a deposit position waiting for
+// its refund.
+type RPCDepositWithdrawal struct {
+	Amount         *hexutil.Big   `json:"amount"`
+	RefundableTime hexutil.Uint64 `json:"refundableTime"`
+	Refundable     bool           `json:"refundable"`
+}
+
+// RPCDepositPosition is a deposit position with its lifecycle at the block.
+// Interest is accrued and unpaid, Slash is applied to it when paid. The times
+// are unix seconds, the transactions are unsigned and are to be passed to
+// man_sendTransaction or man_signTransaction.
+type RPCDepositPosition struct {
+	Position          hexutil.Uint64          `json:"position"`
+	DepositType       hexutil.Uint64          `json:"depositType"` // 0 current, else months of the fixed term
+	Amount            *hexutil.Big            `json:"amount"`
+	Interest          *hexutil.Big            `json:"interest"`
+	Slash             *hexutil.Big            `json:"slash"`
+	BeginTime         hexutil.Uint64          `json:"beginTime"`
+	MaturityTime      hexutil.Uint64          `json:"maturityTime"` // end of the term of a fixed deposit, 0 for a current one
+	Status            string                  `json:"status"`
+	Withdrawals       []*RPCDepositWithdrawal `json:"withdrawals"`
+	WithdrawableAfter *hexutil.Uint64         `json:"withdrawableAfter"` // nil if it can't be withdrawn
+	RefundableAfter   *hexutil.Uint64         `json:"refundableAfter"`   // refund time of the first withdrawal, or of a withdrawal at the block
+	MinWithdraw       *hexutil.Big            `json:"minWithdraw"`       // min amount withdrawn from a current deposit
+	Withdraw          *SendTxArgs1            `json:"withdraw"`          // withdraws the whole position, nil if it can't be
+	Refund            *SendTxArgs1            `json:"refund"`            // refunds the refundable withdrawals, nil if none
+}
+
+// RPCDepositPositions are the deposit positions of an account at the block.
+type RPCDepositPositions struct {
+	Address     string                `json:"address"`
+	SignAddress string                `json:"signAddress"`
+	Role        *hexutil.Big          `json:"role"`
+	Time        hexutil.Uint64        `json:"time"` // time of the block the lifecycles are computed at
+	Positions   []*RPCDepositPosition `json:"positions"`
+}
+
+func depositCallTx(from common.Address, input []byte) *SendTxArgs1 {
+	coin := params.MAN_COIN
+	sender := base58.Base58EncodeToString(coin, from)
+	to := base58.Base58EncodeToString(coin, depositContractAddress)
+	data := hexutil.Bytes(input)
+	return &SendTxArgs1{
+		From:     sender,
+		To:       &to,
+		Value:    new(hexutil.Big),
+		Data:     &data,
+		Currency: &coin,
+		TxType:   common.ExtraNormalTxType,
+	}
+}
+
+func bigOrZero(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return x
+}
+
+// newRPCDepositPosition computes the lifecycle of a deposit position at now,
+// with the rules of the deposit contract.
+func newRPCDepositPosition(owner common.Address, msg common.DepositMsg, now uint64) (*RPCDepositPosition, error) {
+	cfg := depositcfg.GetDepositCfg(depositcfg.VersionA).GetDepositPositionCfg(msg.DepositType)
+	if cfg == nil {
+		return nil, fmt.Errorf("position %d has unknown deposit type %d", msg.Position, msg.DepositType)
+	}
+	config := cfg.GetConfig()
+	result := &RPCDepositPosition{
+		Position:    hexutil.Uint64(msg.Position),
+		DepositType: hexutil.Uint64(msg.DepositType),
+		Amount:      (*hexutil.Big)(bigOrZero(msg.DepositAmount)),
+		Interest:    (*hexutil.Big)(bigOrZero(msg.Interest)),
+		Slash:       (*hexutil.Big)(bigOrZero(msg.Slash)),
+		BeginTime:   hexutil.Uint64(msg.BeginTime),
+		Status:      DepositStatusActive,
+		Withdrawals: make([]*RPCDepositWithdrawal, 0, len(msg.WithDrawInfolist)),
+	}
+
+	refundable := false
+	for _, withdraw := range msg.WithDrawInfolist {
+		ok := withdraw.WithDrawTime <= now
+		refundable = refundable || ok
+		result.Withdrawals = append(result.Withdrawals, &RPCDepositWithdrawal{
+			Amount:         (*hexutil.Big)(bigOrZero(withdraw.WithDrawAmount)),
+			RefundableTime: hexutil.Uint64(withdraw.WithDrawTime),
+			Refundable:     ok,
+		})
+		if result.RefundableAfter == nil || withdraw.WithDrawTime < uint64(*result.RefundableAfter) {
+			after := hexutil.Uint64(withdraw.WithDrawTime)
+			result.RefundableAfter = &after
+		}
+	}
+	if len(msg.WithDrawInfolist) > 0 {
+		result.Status = DepositStatusWithdrawing
+	}
+	if refundable {
+		result.Status = DepositStatusRefundable
+	}
+
+	var withdrawable bool
+	if msg.DepositType == depositcfg.CurrentDeposit {
+		// current deposits are withdrawn in parts, refunded after a delay
+		result.MinWithdraw = (*hexutil.Big)(config.CruWithDrawAmountMin)
+		withdrawable = bigOrZero(msg.DepositAmount).Cmp(config.CruWithDrawAmountMin) >= 0
+		if withdrawable && result.RefundableAfter == nil {
+			after := hexutil.Uint64(now + config.Tmduration)
+			result.RefundableAfter = &after
+		}
+	} else {
+		// fixed deposits are withdrawn whole at the end of their term, and
+		// refunded after a delay
+		if len(msg.WithDrawInfolist) > 0 {
+			result.MaturityTime = hexutil.Uint64(msg.EndTime)
+		} else {
+			// the first term of a deposit begun after the block
+			at := now
+			if at < msg.BeginTime {
+				at = msg.BeginTime
+			}
+			end := depositcfg.FixedTermEnd(msg.BeginTime, config.Tmduration, at)
+			result.MaturityTime = hexutil.Uint64(end)
+			after := hexutil.Uint64(end + depositcfg.Delay)
+			result.RefundableAfter = &after
+			withdrawable = true
+		}
+	}
+
+	if withdrawable {
+		after := hexutil.Uint64(now)
+		result.WithdrawableAfter = &after
+		input, err := vm.DepositWithdrawInput(msg.Position, bigOrZero(msg.DepositAmount))
+		if err != nil {
+			return nil, err
+		}
+		result.Withdraw = depositCallTx(owner, input)
+	}
+	if refundable {
+		input, err := vm.DepositRefundInput(msg.Position)
+		if err != nil {
+			return nil, err
+		}
+		result.Refund = depositCallTx(owner, input)
+	}
+	return result, nil
+}
+
+// GetDepositPositions returns the deposit positions of an account at the block,
+// with their maturity, withdrawal and refund times, and the transactions
+// withdrawing or refunding them. It returns nil if the account has no deposit.
+func (s *PublicBlockChainAPI) GetDepositPositions(ctx context.Context, strAddr string, blockNr rpc.BlockNumber) (*RPCDepositPositions, error) {
+	addr, err := base58.Base58DecodeToAddress(strAddr)
+	if err != nil {
+		return nil, err
+	}
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if st == nil || header == nil || err != nil {
+		return nil, err
+	}
+	deposit := depoistInfo.GetDepositBase(st, addr)
+	if deposit == nil {
+		return nil, st.Error()
+	}
+	now := header.Time.Uint64()
+	result := &RPCDepositPositions{
+		Address:     base58.Base58EncodeToString(params.MAN_COIN, deposit.AddressA0),
+		SignAddress: base58.Base58EncodeToString(params.MAN_COIN, deposit.AddressA1),
+		Role:        (*hexutil.Big)(bigOrZero(deposit.Role)),
+		Time:        hexutil.Uint64(now),
+		Positions:   make([]*RPCDepositPosition, 0, len(deposit.Dpstmsg)),
+	}
+	for _, msg := range deposit.Dpstmsg {
+		position, err := newRPCDepositPosition(deposit.AddressA0, msg, now)
+		if err != nil {
+			return nil, err
+		}
+		result.Positions = append(result.Positions, position)
+	}
+	return result, st.Error()
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/reward/depositcfg"
+)
+
+func TestDepositPositionLifecycle(t *testing.T) {
+	owner := common.BytesToAddress([]byte{0x01})
+	man := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	begin := uint64(1000000)
+
+	// a fixed deposit renews itself until withdrawn
+	fixed := common.DepositMsg{DepositType: 1, DepositAmount: new(big.Int).Mul(big.NewInt(3000), man), BeginTime: begin, Position: 1}
+	now := begin + depositcfg.SecondsPerMonth + 10
+	pos, err := newRPCDepositPosition(owner, fixed, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(pos.MaturityTime) != begin+2*depositcfg.SecondsPerMonth || pos.Status != DepositStatusActive {
+		t.Fatalf("fixed position mismatch: maturity %d, status %s", pos.MaturityTime, pos.Status)
+	}
+	if pos.Withdraw == nil || pos.Refund != nil || uint64(*pos.RefundableAfter) != uint64(pos.MaturityTime)+depositcfg.Delay {
+		t.Fatalf("fixed position transactions mismatch: %+v", pos)
+	}
+
+	if pos, _ = newRPCDepositPosition(owner, fixed, begin-10); uint64(pos.MaturityTime) != begin+depositcfg.SecondsPerMonth {
+		t.Fatalf("fixed position begun after the block mismatch: maturity %d", pos.MaturityTime)
+	}
+
+	fixed.EndTime = begin + 2*depositcfg.SecondsPerMonth
+	fixed.WithDrawInfolist = []common.WithDrawInfo{{WithDrawAmount: new(big.Int), WithDrawTime: fixed.EndTime + depositcfg.Delay}}
+	if pos, _ = newRPCDepositPosition(owner, fixed, now); pos.Withdraw != nil || pos.Refund != nil || pos.Status != DepositStatusWithdrawing {
+		t.Fatalf("withdrawn fixed position mismatch: %+v", pos)
+	}
+	if pos, _ = newRPCDepositPosition(owner, fixed, fixed.EndTime+depositcfg.Delay); pos.Refund == nil || pos.Status != DepositStatusRefundable {
+		t.Fatalf("refundable fixed position mismatch: %+v", pos)
+	}
+
+	// a current deposit is withdrawn in parts above the minimum
+	current := common.DepositMsg{DepositType: 0, DepositAmount: new(big.Int).Mul(big.NewInt(50), man), BeginTime: begin,
+		WithDrawInfolist: []common.WithDrawInfo{{WithDrawAmount: new(big.Int).Mul(big.NewInt(200), man), WithDrawTime: now - 1}}}
+	if pos, _ = newRPCDepositPosition(owner, current, now); pos.Withdraw != nil || pos.WithdrawableAfter != nil || pos.Refund == nil {
+		t.Fatalf("current position mismatch: %+v", pos)
+	}
+	if pos.MaturityTime != 0 || len(pos.Withdrawals) != 1 || !pos.Withdrawals[0].Refundable {
+		t.Fatalf("current position withdrawals mismatch: %+v", pos)
+	}
+
+	if _, err := newRPCDepositPosition(owner, common.DepositMsg{DepositType: 2}, now); err == nil {
+		t.Fatalf("unknown deposit type accepted")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// PublicMatrixStateAPI provides the matrix state of any block, every key decoded
+// by the operator of the block version.
+type PublicMatrixStateAPI struct {
+	b Backend
+}
+
+// NewPublicMatrixStateAPI creates a new matrix state API.
+func NewPublicMatrixStateAPI(b Backend) *PublicMatrixStateAPI {
+	return &PublicMatrixStateAPI{b}
+}
+
+// MatrixStateDiff is the difference of the matrix state between two blocks.
+type MatrixStateDiff struct {
+	From    RPCMatrixStateBlock
+	To      RPCMatrixStateBlock
+	Changes []*matrixstate.KeyDiff
+}
+
+// RPCMatrixStateBlock is the block a matrix state is read at.
+type RPCMatrixStateBlock struct {
+	Number  hexutil.Uint64
+	Hash    common.Hash
+	Version string
+}
+
+func (s *PublicMatrixStateAPI) state(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDBManage, *types.Header, error) {
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st == nil || header == nil {
+		return nil, nil, errors.New("state not found")
+	}
+	return st, header, nil
+}
+
+// Keys returns the matrix state keys of the version of the block.
+func (s *PublicMatrixStateAPI) Keys(ctx context.Context, blockNr rpc.BlockNumber) ([]string, error) {
+	st, _, err := s.state(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	values, err := matrixstate.GetKeyValues(st, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(values))
+	for _, kv := range values {
+		keys = append(keys, kv.Key)
+	}
+	return keys, nil
+}
+
+// GetState returns the decoded value of the key at the block.
+func (s *PublicMatrixStateAPI) GetState(ctx context.Context, key string, blockNr rpc.BlockNumber) (*matrixstate.KeyValue, error) {
+	values, err := s.GetStates(ctx, blockNr, &[]string{key})
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// GetStates returns the decoded values of the keys at the block, of every key
+// when keys is omitted.
+func (s *PublicMatrixStateAPI) GetStates(ctx context.Context, blockNr rpc.BlockNumber, keys *[]string) ([]*matrixstate.KeyValue, error) {
+	st, _, err := s.state(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		return matrixstate.GetKeyValues(st, nil)
+	}
+	return matrixstate.GetKeyValues(st, *keys)
+}
+
+// Diff returns the keys whose value changed between two blocks, limited to keys
+// when given. A key missing from the version of one block is reported too.
+func (s *PublicMatrixStateAPI) Diff(ctx context.Context, fromNr rpc.BlockNumber, toNr rpc.BlockNumber, keys *[]string) (*MatrixStateDiff, error) {
+	fromSt, fromHeader, err := s.state(ctx, fromNr)
+	if err != nil {
+		return nil, err
+	}
+	toSt, toHeader, err := s.state(ctx, toNr)
+	if err != nil {
+		return nil, err
+	}
+	fromValues, err := matrixstate.GetKeyValues(fromSt, nil)
+	if err != nil {
+		return nil, err
+	}
+	toValues, err := matrixstate.GetKeyValues(toSt, nil)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := matrixstate.DiffKeyValues(fromValues, toValues)
+	if err != nil {
+		return nil, err
+	}
+	if keys != nil {
+		wanted := make(map[string]bool, len(*keys))
+		for _, key := range *keys {
+			wanted[key] = true
+		}
+		filtered := changes[:0]
+		for _, change := range changes {
+			if wanted[change.Key] {
+				filtered = append(filtered, change)
+			}
+		}
+		changes = filtered
+	}
+	return &MatrixStateDiff{
+		From:    RPCMatrixStateBlock{Number: hexutil.Uint64(fromHeader.Number.Uint64()), Hash: fromHeader.Hash(), Version: matrixstate.GetVersionInfo(fromSt)},
+		To:      RPCMatrixStateBlock{Number: hexutil.Uint64(toHeader.Number.Uint64()), Hash: toHeader.Hash(), Version: matrixstate.GetVersionInfo(toSt)},
+		Changes: changes,
+	}, nil
+}
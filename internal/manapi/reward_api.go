@@ -0,0 +1,133 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// maxAccountRewardsRange is the max number of blocks scanned by GetAccountRewards.
+const maxAccountRewardsRange = 10000
+
+// RPCRewardEntry is a reward ledger entry of a block.
+type RPCRewardEntry struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Source      string         `json:"source"`
+	Type        string         `json:"type"`
+	Account     string         `json:"account"`
+	Coin        string         `json:"coin"`
+	Amount      *hexutil.Big   `json:"amount"`
+	Rule        string         `json:"rule"`
+	Inputs      []ledger.Input `json:"inputs"`
+}
+
+// RPCRewardBreakdown is the reward ledger of a block.
+type RPCRewardBreakdown struct {
+	Number  hexutil.Uint64    `json:"number"`
+	Hash    common.Hash       `json:"hash"`
+	Version string            `json:"version"`
+	Entries []*RPCRewardEntry `json:"entries"`
+}
+
+func newRPCRewardEntry(number uint64, hash common.Hash, entry *ledger.Entry) *RPCRewardEntry {
+	coin := entry.Coin
+	if coin == "" {
+		coin = params.MAN_COIN
+	}
+	return &RPCRewardEntry{
+		BlockNumber: hexutil.Uint64(number),
+		BlockHash:   hash,
+		Source:      entry.Source,
+		Type:        entry.Type,
+		Account:     base58.Base58EncodeToString(coin, entry.Account),
+		Coin:        entry.Coin,
+		Amount:      (*hexutil.Big)(entry.Amount),
+		Rule:        entry.Rule,
+		Inputs:      entry.Inputs,
+	}
+}
+
+func (s *PublicBlockChainAPI) rewardHeader(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
+	header, err := s.b.HeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	return header, nil
+}
+
+// GetRewardBreakdown returns every reward and slash computed for the block, with
+// the calc engine and the inputs of the computation.
+func (s *PublicBlockChainAPI) GetRewardBreakdown(ctx context.Context, blockNr rpc.BlockNumber) (*RPCRewardBreakdown, error) {
+	header, err := s.rewardHeader(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	number, hash := header.Number.Uint64(), header.Hash()
+	rewards := rawdb.ReadRewardLedger(s.b.ChainDb(), hash, number)
+	if rewards == nil {
+		return nil, fmt.Errorf("reward ledger of block %d not found", number)
+	}
+	breakdown := &RPCRewardBreakdown{
+		Number:  hexutil.Uint64(number),
+		Hash:    hash,
+		Version: rewards.Version,
+		Entries: make([]*RPCRewardEntry, 0, len(rewards.Entries)),
+	}
+	for _, entry := range rewards.Entries {
+		breakdown.Entries = append(breakdown.Entries, newRPCRewardEntry(number, hash, entry))
+	}
+	return breakdown, nil
+}
+
+// GetAccountRewards returns the rewards and slashes of the account in the
+// canonical blocks from..to.
+func (s *PublicBlockChainAPI) GetAccountRewards(ctx context.Context, strAddress string, fromNr rpc.BlockNumber, toNr rpc.BlockNumber) ([]*RPCRewardEntry, error) {
+	address, err := base58.Base58DecodeToAddress(strAddress)
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.rewardHeader(ctx, fromNr)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.rewardHeader(ctx, toNr)
+	if err != nil {
+		return nil, err
+	}
+	fromNum, toNum := from.Number.Uint64(), to.Number.Uint64()
+	if fromNum > toNum {
+		return nil, fmt.Errorf("from %d is after to %d", fromNum, toNum)
+	}
+	if toNum-fromNum >= maxAccountRewardsRange {
+		return nil, fmt.Errorf("range exceeds %d blocks", maxAccountRewardsRange)
+	}
+
+	db := s.b.ChainDb()
+	rewards := make([]*RPCRewardEntry, 0)
+	for number := fromNum; number <= toNum; number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		for _, entry := range rawdb.ReadAccountRewardEntries(db, address, hash, number) {
+			rewards = append(rewards, newRPCRewardEntry(number, hash, entry))
+		}
+	}
+	return rewards, nil
+}
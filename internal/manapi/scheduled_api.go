@@ -0,0 +1,251 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/btrie"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// scheduledIntervalBlocks is the number of blocks the average block interval of
+// the execution estimate is measured over.
+const scheduledIntervalBlocks = 100
+
+// RPCScheduledRecipient is a transfer of a scheduled transaction.
+type RPCScheduledRecipient struct {
+	To    string       `json:"to"`
+	Value *hexutil.Big `json:"value"`
+}
+
+// RPCScheduledTx is a pending time or revocable transaction. The transaction is
+// executed by the first block whose time reaches the due time, a revocable one
+// can be reverted until then.
+type RPCScheduledTx struct {
+	Hash           common.Hash              `json:"hash"`
+	TxType         hexutil.Uint64           `json:"txType"`
+	Currency       string                   `json:"currency"`
+	From           string                   `json:"from"`
+	Recipients     []*RPCScheduledRecipient `json:"recipients"`
+	DueTime        hexutil.Uint64           `json:"dueTime"`
+	Revocable      bool                     `json:"revocable"`
+	Cancellable    bool                     `json:"cancellable"`
+	EstimatedBlock *hexutil.Uint64          `json:"estimatedBlock"`
+	EstimatedTime  *hexutil.Uint64          `json:"estimatedTime"`
+}
+
+// scheduledEstimator estimates the block executing a due time from the average
+// block interval before the head.
+type scheduledEstimator struct {
+	number   uint64
+	time     uint64
+	interval uint64
+}
+
+func (s *PublicBlockChainAPI) newScheduledEstimator(ctx context.Context, header *types.Header) *scheduledEstimator {
+	est := &scheduledEstimator{number: header.Number.Uint64(), time: header.Time.Uint64()}
+	blocks := uint64(scheduledIntervalBlocks)
+	if est.number < blocks {
+		blocks = est.number
+	}
+	if blocks == 0 {
+		return est
+	}
+	ancestor, err := s.b.HeaderByNumber(ctx, rpc.BlockNumber(est.number-blocks))
+	if err != nil || ancestor == nil || ancestor.Time.Uint64() >= est.time {
+		return est
+	}
+	est.interval = (est.time - ancestor.Time.Uint64() + blocks - 1) / blocks
+	return est
+}
+
+func (est *scheduledEstimator) estimate(due uint64) (*hexutil.Uint64, *hexutil.Uint64) {
+	if est.interval == 0 {
+		return nil, nil
+	}
+	blocks := uint64(1)
+	if due > est.time {
+		blocks = (due - est.time + est.interval - 1) / est.interval
+	}
+	number, time := hexutil.Uint64(est.number+blocks), hexutil.Uint64(est.time+blocks*est.interval)
+	return &number, &time
+}
+
+func (s *PublicBlockChainAPI) scheduledState(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDBManage, *scheduledEstimator, error) {
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st == nil || header == nil {
+		return nil, nil, errors.New("state not found")
+	}
+	return st, s.newScheduledEstimator(ctx, header), nil
+}
+
+func newRPCScheduledTx(entry *btrie.TxEntry, est *scheduledEstimator) *RPCScheduledTx {
+	coin := entry.Tx.Cointyp
+	if coin == "" {
+		coin = params.MAN_COIN
+	}
+	tx := &RPCScheduledTx{
+		Hash:        entry.Hash,
+		TxType:      hexutil.Uint64(entry.Tx.Typ),
+		Currency:    coin,
+		From:        base58.Base58EncodeToString(coin, entry.Tx.From),
+		Recipients:  make([]*RPCScheduledRecipient, 0, len(entry.Tx.Adam)),
+		DueTime:     hexutil.Uint64(entry.Time),
+		Revocable:   entry.Tx.Typ == common.ExtraRevocable,
+		Cancellable: entry.Tx.Typ == common.ExtraRevocable && uint64(entry.Time) > est.time,
+	}
+	for _, to := range entry.Tx.Adam {
+		tx.Recipients = append(tx.Recipients, &RPCScheduledRecipient{
+			To:    base58.Base58EncodeToString(coin, to.Addr),
+			Value: (*hexutil.Big)(to.Amont),
+		})
+	}
+	tx.EstimatedBlock, tx.EstimatedTime = est.estimate(uint64(entry.Time))
+	return tx
+}
+
+func scheduledIndexes(st *state.StateDBManage) []*btrie.TxIndex {
+	return []*btrie.TxIndex{st.GetBtreeIndex(common.ExtraTimeTxType), st.GetBtreeIndex(common.ExtraRevocable)}
+}
+
+func sortScheduledTxs(txs []*RPCScheduledTx) []*RPCScheduledTx {
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].DueTime < txs[j].DueTime })
+	return txs
+}
+
+// GetScheduledTxsByAccount returns the pending time and revocable transactions
+// sent from or to the account at the block, by due time.
+func (s *PublicBlockChainAPI) GetScheduledTxsByAccount(ctx context.Context, strAddress string, blockNr rpc.BlockNumber) ([]*RPCScheduledTx, error) {
+	address, err := base58.Base58DecodeToAddress(strAddress)
+	if err != nil {
+		return nil, err
+	}
+	st, est, err := s.scheduledState(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*RPCScheduledTx, 0)
+	for _, idx := range scheduledIndexes(st) {
+		for _, entry := range idx.Account(address) {
+			txs = append(txs, newRPCScheduledTx(entry, est))
+		}
+	}
+	return sortScheduledTxs(txs), nil
+}
+
+// GetScheduledTxsByTime returns the pending time and revocable transactions due
+// from the time to the time, both unix seconds and included, by due time. An
+// omitted end time is the end of the schedule.
+func (s *PublicBlockChainAPI) GetScheduledTxsByTime(ctx context.Context, fromTime hexutil.Uint64, toTime *hexutil.Uint64, blockNr rpc.BlockNumber) ([]*RPCScheduledTx, error) {
+	end := uint64(math.MaxUint32)
+	if toTime != nil {
+		end = uint64(*toTime)
+	}
+	if uint64(fromTime) > end {
+		return nil, fmt.Errorf("invalid time range %d - %d", fromTime, end)
+	}
+	if end > math.MaxUint32 {
+		end = math.MaxUint32
+	}
+	st, est, err := s.scheduledState(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*RPCScheduledTx, 0)
+	if uint64(fromTime) > math.MaxUint32 {
+		return txs, nil
+	}
+	for _, idx := range scheduledIndexes(st) {
+		for _, entry := range idx.Due(uint32(fromTime), uint32(end)) {
+			txs = append(txs, newRPCScheduledTx(entry, est))
+		}
+	}
+	return sortScheduledTxs(txs), nil
+}
+
+// GetScheduledTx returns the pending time or revocable transaction of the hash,
+// nil once it's executed or reverted.
+func (s *PublicBlockChainAPI) GetScheduledTx(ctx context.Context, hash common.Hash, blockNr rpc.BlockNumber) (*RPCScheduledTx, error) {
+	st, est, err := s.scheduledState(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range scheduledIndexes(st) {
+		if entry := idx.Get(hash); entry != nil {
+			return newRPCScheduledTx(entry, est), nil
+		}
+	}
+	return nil, nil
+}
+
+// BuildRevertTx returns the unsigned transaction reverting the pending revocable
+// transactions, to be passed to man_sendTransaction or man_signTransaction. The
+// transactions must have the same sender and currency, and still be cancellable
+// at the block.
+func (s *PublicBlockChainAPI) BuildRevertTx(ctx context.Context, hashes []common.Hash, blockNr rpc.BlockNumber) (*SendTxArgs1, error) {
+	if len(hashes) == 0 {
+		return nil, errors.New("no transaction to revert")
+	}
+	if uint64(len(hashes)) > params.TxCount {
+		return nil, fmt.Errorf("too many transactions to revert, max %d", params.TxCount)
+	}
+	st, est, err := s.scheduledState(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	idx := st.GetBtreeIndex(common.ExtraRevocable)
+	var first *btrie.TxEntry
+	for _, hash := range hashes {
+		entry := idx.Get(hash)
+		if entry == nil || entry.Tx.Typ != common.ExtraRevocable {
+			return nil, fmt.Errorf("transaction %s is not a pending revocable transaction", hash.Hex())
+		}
+		if uint64(entry.Time) <= est.time {
+			return nil, fmt.Errorf("transaction %s is due, it can't be reverted", hash.Hex())
+		}
+		if first == nil {
+			first = entry
+			continue
+		}
+		if entry.Tx.From != first.Tx.From || entry.Tx.Cointyp != first.Tx.Cointyp {
+			return nil, fmt.Errorf("transaction %s has another sender or currency", hash.Hex())
+		}
+	}
+
+	coin := first.Tx.Cointyp
+	if coin == "" {
+		coin = params.MAN_COIN
+	}
+	from := base58.Base58EncodeToString(coin, first.Tx.From)
+	to := from
+	data := hexutil.Bytes(hashes[0].Bytes())
+	args := &SendTxArgs1{
+		From:     from,
+		To:       &to,
+		Value:    new(hexutil.Big),
+		Data:     &data,
+		Currency: &coin,
+		TxType:   common.ExtraRevertTxType,
+	}
+	for _, hash := range hashes[1:] {
+		extraTo := from
+		input := hexutil.Bytes(hash.Bytes())
+		args.ExtraTo = append(args.ExtraTo, &ExtraTo_Mx1{To2: &extraTo, Value2: new(hexutil.Big), Input2: &input})
+	}
+	return args, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// Status of an upgrade at a block.
+const (
+	UpgradeActive   = "active"   // the block version is the upgrade version or above
+	UpgradeUpcoming = "upcoming" // the upgrade switches at a height above the block
+	UpgradePending  = "pending"  // the upgrade has no height, it waits for a genesis or a super block
+)
+
+// RPCUpgrade is a protocol upgrade and its status at a block.
+type RPCUpgrade struct {
+	Name        string             `json:"name"`
+	Version     string             `json:"version"`
+	Number      hexutil.Uint64     `json:"number"`
+	Status      string             `json:"status"`
+	Current     bool               `json:"current"`
+	BlocksLeft  hexutil.Uint64     `json:"blocksLeft"`
+	Signatures  []common.Signature `json:"signatures"`
+	MatrixState string             `json:"matrixState"`
+	Rewards     string             `json:"rewards"`
+	BlkPlugins  []string           `json:"blkPlugins"`
+	Engine      string             `json:"engine"`
+	DPOSEngine  string             `json:"dposEngine"`
+}
+
+// RPCUpgrades is the list of the registered upgrades at a block.
+type RPCUpgrades struct {
+	Number   hexutil.Uint64 `json:"number"`
+	Version  string         `json:"version"`
+	Upgrades []*RPCUpgrade  `json:"upgrades"`
+}
+
+func upgradeStatus(up *manversion.Upgrade, number uint64, version string) *RPCUpgrade {
+	result := &RPCUpgrade{
+		Name:        up.Name,
+		Version:     up.Version,
+		Number:      hexutil.Uint64(up.Number),
+		Current:     up.Version == version,
+		Signatures:  up.Signatures,
+		MatrixState: up.MatrixState,
+		Rewards:     up.Rewards,
+		BlkPlugins:  up.BlkPlugins,
+		Engine:      up.Engine,
+		DPOSEngine:  up.DPOSEngine,
+	}
+	switch {
+	case manversion.VersionCmp(version, up.Version) >= 0:
+		result.Status = UpgradeActive
+	case up.Number > number:
+		result.Status = UpgradeUpcoming
+		result.BlocksLeft = hexutil.Uint64(up.Number - number)
+	default:
+		result.Status = UpgradePending
+	}
+	return result
+}
+
+// GetUpgrades returns the registered protocol upgrades with their activation
+// height and the components they enable, and whether they are active at the
+// block or upcoming.
+func (s *PublicBlockChainAPI) GetUpgrades(ctx context.Context, blockNr rpc.BlockNumber) (*RPCUpgrades, error) {
+	header, err := s.b.HeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("header not found")
+	}
+	number, version := header.Number.Uint64(), string(header.Version)
+	result := &RPCUpgrades{Number: hexutil.Uint64(number), Version: version, Upgrades: make([]*RPCUpgrade, 0)}
+	for _, up := range manversion.Upgrades() {
+		result.Upgrades = append(result.Upgrades, upgradeStatus(up, number, version))
+	}
+	return result, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+var uptimeSourceNames = map[uint8]string{
+	rawdb.UptimeSourceDefault:     "default",
+	rawdb.UptimeSourceHeartbeat:   "heartbeat",
+	rawdb.UptimeSourceCallTheRoll: "callTheRoll",
+	rawdb.UptimeSourceSuperBlock:  "superBlock",
+}
+
+// RPCUpTimeDetails explains the uptime credited to an account for a broadcast
+// interval. The uptime of the account becomes OldUptime * ResetA + Uptime.
+type RPCUpTimeDetails struct {
+	Address           string         `json:"address"`
+	Role              string         `json:"role"` // role in the elect graph of the interval, empty if not in it
+	Number            hexutil.Uint64 `json:"number"`
+	BlockHash         common.Hash    `json:"blockHash"`
+	BroadcastNumber   hexutil.Uint64 `json:"broadcastNumber"`
+	BroadcastInterval hexutil.Uint64 `json:"broadcastInterval"`
+	SuperBlock        bool           `json:"superBlock"`
+	Source            string         `json:"source"`
+	MaxUptime         hexutil.Uint64 `json:"maxUptime"`
+	HeartbeatModulus  hexutil.Uint64 `json:"heartbeatModulus"`
+	IntervalSlot      hexutil.Uint64 `json:"intervalSlot"` // accounts of this slot are asked to send heartbeats
+	HeartbeatSlot     hexutil.Uint64 `json:"heartbeatSlot"`
+	HeartbeatRequired bool           `json:"heartbeatRequired"`
+	HeartbeatObserved bool           `json:"heartbeatObserved"`
+	CalledTheRoll     bool           `json:"calledTheRoll"`
+	RollCallBlocks    hexutil.Uint64 `json:"rollCallBlocks"`
+	Uptime            hexutil.Uint64 `json:"uptime"`
+	ResetA            string         `json:"resetA"`
+	OldUptime         *hexutil.Big   `json:"oldUptime"`
+	NewUptime         *hexutil.Big   `json:"newUptime"`
+}
+
+// GetUpTimeDetails returns the inputs and the result of the uptime calculation
+// of an account for the broadcast interval ending at the broadcast block
+// interval, the latest one if the interval is latest or pending.
+func (s *PublicBlockChainAPI) GetUpTimeDetails(ctx context.Context, strAddr string, interval rpc.BlockNumber) (*RPCUpTimeDetails, error) {
+	addr, err := base58.Base58DecodeToAddress(strAddr)
+	if err != nil {
+		return nil, err
+	}
+	broadcastNumber := uint64(interval)
+	if interval < 0 {
+		st, _, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if st == nil || err != nil {
+			return nil, err
+		}
+		bcInterval, err := matrixstate.GetBroadcastInterval(st)
+		if err != nil {
+			return nil, err
+		}
+		broadcastNumber = bcInterval.GetLastBroadcastNumber()
+		if _, exist := rawdb.ReadUptimeNumber(s.b.ChainDb(), broadcastNumber); !exist && broadcastNumber >= bcInterval.GetBroadcastInterval() {
+			// the uptime of the last interval is calculated by the next block
+			broadcastNumber -= bcInterval.GetBroadcastInterval()
+		}
+	}
+
+	db := s.b.ChainDb()
+	number, exist := rawdb.ReadUptimeNumber(db, broadcastNumber)
+	if !exist {
+		return nil, fmt.Errorf("no uptime recorded for the interval of broadcast block %d", broadcastNumber)
+	}
+	hash := rawdb.ReadCanonicalHash(db, number)
+	details := rawdb.ReadUptimeDetails(db, number, hash)
+	if details == nil {
+		return nil, fmt.Errorf("no uptime recorded for the canonical block %d", number)
+	}
+	for _, account := range details.Accounts {
+		if account.Account != addr {
+			continue
+		}
+		result := &RPCUpTimeDetails{
+			Address:           base58.Base58EncodeToString(params.MAN_COIN, addr),
+			Number:            hexutil.Uint64(details.Number),
+			BlockHash:         hash,
+			BroadcastNumber:   hexutil.Uint64(details.BroadcastNumber),
+			BroadcastInterval: hexutil.Uint64(details.BroadcastInterval),
+			SuperBlock:        details.SuperBlock,
+			Source:            uptimeSourceNames[account.Source],
+			MaxUptime:         hexutil.Uint64(details.MaxUptime),
+			HeartbeatModulus:  hexutil.Uint64(details.HeartbeatModulus),
+			IntervalSlot:      hexutil.Uint64(details.HeartbeatSlot),
+			HeartbeatSlot:     hexutil.Uint64(account.HeartbeatSlot),
+			HeartbeatRequired: account.HeartbeatRequired,
+			HeartbeatObserved: account.HeartbeatObserved,
+			CalledTheRoll:     account.CalledTheRoll,
+			RollCallBlocks:    hexutil.Uint64(account.RollCallBlocks),
+			Uptime:            hexutil.Uint64(account.Uptime),
+			ResetA:            details.ResetA,
+			OldUptime:         (*hexutil.Big)(bigOrZero(account.OldUptime)),
+			NewUptime:         (*hexutil.Big)(bigOrZero(account.NewUptime)),
+		}
+		if account.Role != 0 {
+			result.Role = account.Role.String()
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("account has no uptime in the interval of broadcast block %d", broadcastNumber)
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+var validatorGroupEventNames = map[uint8]string{
+	rawdb.VGEventCreate:      "create",
+	rawdb.VGEventDeposit:     "deposit",
+	rawdb.VGEventWithdraw:    "withdraw",
+	rawdb.VGEventRefund:      "refund",
+	rawdb.VGEventReward:      "reward",
+	rawdb.VGEventInterest:    "interest",
+	rawdb.VGEventClaim:       "claim",
+	rawdb.VGEventOwner:       "owner",
+	rawdb.VGEventSignAccount: "signAccount",
+	rawdb.VGEventRate:        "rate",
+	rawdb.VGEventWithdrawAll: "withdrawAll",
+	rawdb.VGEventDissolve:    "dissolve",
+}
+
+// RPCValidatorGroupEvent is an indexed event of a validator group. Account is
+// the sign account of a creation, and the previous owner or sign account of a
+// change. The events derived from the group state have no transaction hash.
+type RPCValidatorGroupEvent struct {
+	Type        string         `json:"type"`
+	Participant string         `json:"participant,omitempty"`
+	Account     string         `json:"account,omitempty"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      *common.Hash   `json:"txHash"`
+	Amount      *hexutil.Big   `json:"amount"`
+	DType       hexutil.Uint64 `json:"dType"`
+	Position    hexutil.Uint64 `json:"position"`
+	Rates       []*hexutil.Big `json:"rates,omitempty"` // owner, node and level rates
+}
+
+// RPCValidatorGroup is an indexed validator group with its state at the block.
+type RPCValidatorGroup struct {
+	Group        string         `json:"group"`
+	Owner        string         `json:"owner"`
+	SignAccount  string         `json:"signAccount"`
+	Participants hexutil.Uint64 `json:"participants"`
+	Events       hexutil.Uint64 `json:"events"`
+	Dissolved    bool           `json:"dissolved"` // the group doesn't exist at the block
+}
+
+// RPCValidatorGroupHistory is a page of the events of a validator group, or of
+// a participant. Next is the offset of the next page, the events of blocks
+// dropped by a reorg are skipped so a page may have less events than asked.
+type RPCValidatorGroupHistory struct {
+	Group       string                    `json:"group"`
+	Participant string                    `json:"participant,omitempty"`
+	Total       hexutil.Uint64            `json:"total"`
+	Offset      hexutil.Uint64            `json:"offset"`
+	Next        hexutil.Uint64            `json:"next"`
+	Events      []*RPCValidatorGroupEvent `json:"events"`
+}
+
+// RPCParticipantHistory is a page of the events of a participant of a validator
+// group, with its totals over the whole history and its deposits at the block.
+type RPCParticipantHistory struct {
+	RPCValidatorGroupHistory
+	Deposited *hexutil.Big      `json:"deposited"`
+	Withdrawn *hexutil.Big      `json:"withdrawn"`
+	Refunded  *hexutil.Big      `json:"refunded"`
+	Rewards   *hexutil.Big      `json:"rewards"`
+	Interest  *hexutil.Big      `json:"interest"`
+	Claimed   *hexutil.Big      `json:"claimed"`
+	Current   *RpcValidatorInfo `json:"current"` // nil if not a participant at the block
+}
+
+func rpcAddress(addr common.Address) string {
+	if addr == (common.Address{}) {
+		return ""
+	}
+	return base58.Base58EncodeToString(params.MAN_COIN, addr)
+}
+
+func rpcValidatorGroupEvent(event *rawdb.ValidatorGroupEvent) *RPCValidatorGroupEvent {
+	result := &RPCValidatorGroupEvent{
+		Type:        validatorGroupEventNames[event.Type],
+		Participant: rpcAddress(event.Participant),
+		Account:     rpcAddress(event.Account),
+		BlockNumber: hexutil.Uint64(event.BlockNumber),
+		BlockHash:   event.BlockHash,
+		Amount:      (*hexutil.Big)(event.Amount),
+		DType:       hexutil.Uint64(event.DType),
+		Position:    hexutil.Uint64(event.Position),
+	}
+	if event.TxHash != (common.Hash{}) {
+		txHash := event.TxHash
+		result.TxHash = &txHash
+	}
+	for _, rate := range event.Rates {
+		result.Rates = append(result.Rates, (*hexutil.Big)(rate))
+	}
+	return result
+}
+
+// canonicalEvent checks if the event was indexed from a block of the canonical chain.
+func canonicalEvent(db mandb.Database, event *rawdb.ValidatorGroupEvent) bool {
+	return rawdb.ReadCanonicalHash(db, event.BlockNumber) == event.BlockHash
+}
+
+func validatorGroupHistory(db mandb.Database, group common.Address, total uint64, offset uint64, limit uint64, read func(start uint64, count uint64) []*rawdb.ValidatorGroupEvent) RPCValidatorGroupHistory {
+	start, end := coinPage(offset, limit, total)
+	history := RPCValidatorGroupHistory{
+		Group:  rpcAddress(group),
+		Total:  hexutil.Uint64(total),
+		Offset: hexutil.Uint64(start),
+		Next:   hexutil.Uint64(end),
+		Events: make([]*RPCValidatorGroupEvent, 0, end-start),
+	}
+	for _, event := range read(start, end-start) {
+		if canonicalEvent(db, event) {
+			history.Events = append(history.Events, rpcValidatorGroupEvent(event))
+		}
+	}
+	return history
+}
+
+// GetValidatorGroups returns a page of the indexed validator groups, in creation
+// order, with their state at the block.
+func (s *PublicBlockChainAPI) GetValidatorGroups(ctx context.Context, offset uint64, limit uint64, blockNr rpc.BlockNumber) ([]*RPCValidatorGroup, error) {
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if st == nil || header == nil || err != nil {
+		return nil, err
+	}
+	groupStates, err := (&vm.ValidatorContractState{}).GetValidatorGroupInfo(header.Time.Uint64(), st)
+	if err != nil {
+		return nil, err
+	}
+	db := s.b.ChainDb()
+	start, end := coinPage(offset, limit, rawdb.ReadValidatorGroupCount(db))
+	groups := make([]*RPCValidatorGroup, 0, end-start)
+	for _, group := range rawdb.ReadValidatorGroups(db, start, end-start) {
+		result := &RPCValidatorGroup{
+			Group:  rpcAddress(group),
+			Events: hexutil.Uint64(rawdb.ReadValidatorGroupEventCount(db, group)),
+		}
+		if groupState, exist := groupStates[group]; exist {
+			result.Owner = rpcAddress(groupState.OwnerInfo.Owner)
+			result.SignAccount = rpcAddress(groupState.OwnerInfo.SignAddress)
+			result.Participants = hexutil.Uint64(len(groupState.ValidatorMap))
+		} else {
+			result.Dissolved = true
+		}
+		groups = append(groups, result)
+	}
+	return groups, nil
+}
+
+// GetValidatorGroupHistory returns a page of the indexed events of a validator
+// group, in block order.
+func (s *PublicBlockChainAPI) GetValidatorGroupHistory(ctx context.Context, strGroup string, offset uint64, limit uint64) (*RPCValidatorGroupHistory, error) {
+	group, err := base58.Base58DecodeToAddress(strGroup)
+	if err != nil {
+		return nil, err
+	}
+	db := s.b.ChainDb()
+	history := validatorGroupHistory(db, group, rawdb.ReadValidatorGroupEventCount(db, group), offset, limit, func(start uint64, count uint64) []*rawdb.ValidatorGroupEvent {
+		return rawdb.ReadValidatorGroupEvents(db, group, start, count)
+	})
+	return &history, nil
+}
+
+// GetValidatorGroupParticipantHistory returns a page of the indexed events of a
+// participant of a validator group, in block order, with its totals and its
+// deposits at the block.
+func (s *PublicBlockChainAPI) GetValidatorGroupParticipantHistory(ctx context.Context, strGroup string, strParticipant string, offset uint64, limit uint64, blockNr rpc.BlockNumber) (*RPCParticipantHistory, error) {
+	group, err := base58.Base58DecodeToAddress(strGroup)
+	if err != nil {
+		return nil, err
+	}
+	participant, err := base58.Base58DecodeToAddress(strParticipant)
+	if err != nil {
+		return nil, err
+	}
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if st == nil || header == nil || err != nil {
+		return nil, err
+	}
+	groupStates, err := (&vm.ValidatorContractState{}).GetValidatorGroupInfo(header.Time.Uint64(), st)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.b.ChainDb()
+	total := rawdb.ReadParticipantEventCount(db, group, participant)
+	result := &RPCParticipantHistory{
+		RPCValidatorGroupHistory: validatorGroupHistory(db, group, total, offset, limit, func(start uint64, count uint64) []*rawdb.ValidatorGroupEvent {
+			return rawdb.ReadParticipantEvents(db, group, participant, start, count)
+		}),
+	}
+	result.Participant = rpcAddress(participant)
+
+	totals := rawdb.ReadParticipantTotals(db, group, participant)
+	result.Deposited, result.Withdrawn, result.Refunded = (*hexutil.Big)(totals.Deposited), (*hexutil.Big)(totals.Withdrawn), (*hexutil.Big)(totals.Refunded)
+	result.Rewards, result.Interest, result.Claimed = (*hexutil.Big)(totals.Rewards), (*hexutil.Big)(totals.Interest), (*hexutil.Big)(totals.Claimed)
+
+	if groupState, exist := groupStates[group]; exist {
+		if index, exist := groupState.ValidatorMap.Find(participant); exist {
+			info := groupState.ValidatorMap[index]
+			result.Current = &RpcValidatorInfo{
+				Address:   rpcAddress(info.Address),
+				Reward:    info.Reward,
+				AllAmount: info.AllAmount,
+				Current:   info.Current,
+				Positions: info.Positions,
+			}
+		}
+	}
+	return result, nil
+}
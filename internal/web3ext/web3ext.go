@@ -10,11 +10,17 @@ var Modules = map[string]string{
 	"chequebook": Chequebook_JS,
 	"clique":     Clique_JS,
 	"debug":      Debug_JS,
+	"leader":     Leader_JS,
+	"lessdisk":   LessDisk_JS,
+	"lottery":    Lottery_JS,
 	"man":        Man_JS,
+	"matrix":     Matrix_JS,
 	"eth":        Man_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
+	"online":     Online_JS,
 	"personal":   Personal_JS,
+	"profile":    Profile_JS,
 	"rpc":        RPC_JS,
 	"shh":        Shh_JS,
 	"swarmfs":    SWARMFS_JS,
@@ -510,6 +516,113 @@ web3._extend({
 			call: 'man_getGasPrice',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'getRewardBreakdown',
+			call: 'man_getRewardBreakdown',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getAccountRewards',
+			call: 'man_getAccountRewards',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getCoinInfo',
+			call: 'man_getCoinInfo',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'listCoins',
+			call: 'man_listCoins',
+			params: 3,
+			inputFormatter: [null, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getCoinHolders',
+			call: 'man_getCoinHolders',
+			params: 4,
+			inputFormatter: [null, null, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorGroups',
+			call: 'man_getValidatorGroups',
+			params: 3,
+			inputFormatter: [null, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorGroupHistory',
+			call: 'man_getValidatorGroupHistory',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorGroupParticipantHistory',
+			call: 'man_getValidatorGroupParticipantHistory',
+			params: 5,
+			inputFormatter: [null, null, null, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getDepositPositions',
+			call: 'man_getDepositPositions',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getUpTimeDetails',
+			call: 'man_getUpTimeDetails',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockProduceSlash',
+			call: 'man_getBlockProduceSlash',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockProduceSlashHistory',
+			call: 'man_getBlockProduceSlashHistory',
+			params: 3,
+			inputFormatter: [null, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getUpgrades',
+			call: 'man_getUpgrades',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getScheduledTxsByAccount',
+			call: 'man_getScheduledTxsByAccount',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getScheduledTxsByTime',
+			call: 'man_getScheduledTxsByTime',
+			params: 3,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getScheduledTx',
+			call: 'man_getScheduledTx',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'buildRevertTx',
+			call: 'man_buildRevertTx',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'dryRunInterestSlash',
+			call: 'man_dryRunInterestSlash',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -528,6 +641,138 @@ web3._extend({
 });
 `
 
+const Leader_JS = `
+web3._extend({
+	property: 'leader',
+	methods: [
+		new web3._extend.Method({
+			name: 'getTimeline',
+			call: 'leader_getTimeline',
+			params: 1
+		}),
+	]
+});
+`
+
+const LessDisk_JS = `
+web3._extend({
+	property: 'lessdisk',
+	methods: [
+		new web3._extend.Method({
+			name: 'enable',
+			call: 'lessdisk_enable'
+		}),
+		new web3._extend.Method({
+			name: 'disable',
+			call: 'lessdisk_disable'
+		}),
+		new web3._extend.Method({
+			name: 'dryRun',
+			call: 'lessdisk_dryRun',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'pinned',
+			call: 'lessdisk_pinned',
+			params: 2
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'lessdisk_status'
+		}),
+	]
+});
+`
+
+const Lottery_JS = `
+web3._extend({
+	property: 'lottery',
+	methods: [
+		new web3._extend.Method({
+			name: 'getDraw',
+			call: 'lottery_getDraw',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
+const Online_JS = `
+web3._extend({
+	property: 'online',
+	methods: [
+		new web3._extend.Method({
+			name: 'getNodeOnlineHistory',
+			call: 'online_getNodeOnlineHistory',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'getNodeOnlineProposals',
+			call: 'online_getNodeOnlineProposals',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'getOnlineProposalsByNumber',
+			call: 'online_getOnlineProposalsByNumber',
+			params: 1
+		}),
+	]
+});
+`
+
+const Profile_JS = `
+web3._extend({
+	property: 'profile',
+	methods: [
+		new web3._extend.Method({
+			name: 'getBlockTrace',
+			call: 'profile_getBlockTrace',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getBlockTraces',
+			call: 'profile_getBlockTraces',
+			params: 2
+		}),
+	]
+});
+`
+
+const Matrix_JS = `
+web3._extend({
+	property: 'matrix',
+	methods: [
+		new web3._extend.Method({
+			name: 'keys',
+			call: 'matrix_keys',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getState',
+			call: 'matrix_getState',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getStates',
+			call: 'matrix_getStates',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'diff',
+			call: 'matrix_diff',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+	]
+});
+`
+
 const Miner_JS = `
 web3._extend({
 	property: 'miner',
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package leaderelect2
+
+import (
+	"context"
+
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// PublicLeaderAPI provides the leader election timeline, to diagnose why a block
+// took several reelection turns.
+type PublicLeaderAPI struct {
+	timeline *Timeline
+}
+
+func NewPublicLeaderAPI(leader *LeaderIdentity) *PublicLeaderAPI {
+	return &PublicLeaderAPI{timeline: leader.Timeline()}
+}
+
+// GetTimeline returns the leader election events of the block height.
+func (api *PublicLeaderAPI) GetTimeline(number uint64) []*TimelineEvent {
+	events := api.timeline.Events(number)
+	if events == nil {
+		return []*TimelineEvent{}
+	}
+	return events
+}
+
+// Timeline creates a subscription that fires for every leader election event.
+func (api *PublicLeaderAPI) Timeline(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *TimelineEvent, timelineSendQueue)
+		eventsSub := api.timeline.SubscribeEvents(events)
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				eventsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				eventsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -73,6 +73,24 @@ func (s stateDef) String() string {
 	}
 }
 
+// name is the state name of the timeline events.
+func (s stateDef) name() string {
+	switch s {
+	case stIdle:
+		return "idle"
+	case stPos:
+		return "pos"
+	case stReelect:
+		return "reelect"
+	case stMining:
+		return "mining"
+	case stWaiting:
+		return "waiting"
+	default:
+		return "unknown"
+	}
+}
+
 type leaderData struct {
 	leader     common.Address
 	nextLeader common.Address
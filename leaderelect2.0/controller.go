@@ -4,8 +4,10 @@
 package leaderelect2
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/MatrixAINetwork/go-matrix/blkprofile"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -21,10 +23,11 @@ type controller struct {
 	selfCache    *masterCache
 	msgCh        chan interface{}
 	quitCh       chan struct{}
+	timeline     *Timeline
 	logInfo      string
 }
 
-func newController(matrix Matrix, logInfo string, number uint64) *controller {
+func newController(matrix Matrix, logInfo string, number uint64, timeline *Timeline) *controller {
 	if number < 1 {
 		log.Crit(logInfo, "创建controller失败", "number < 1", "number", number)
 	}
@@ -37,6 +40,7 @@ func newController(matrix Matrix, logInfo string, number uint64) *controller {
 		selfCache:    newMasterCache(number),
 		msgCh:        make(chan interface{}, 10),
 		quitCh:       make(chan struct{}),
+		timeline:     timeline,
 		logInfo:      logInfo,
 	}
 
@@ -98,6 +102,39 @@ func (self *controller) publishLeaderMsg() {
 		"共识状态", msg.ConsensusState, "共识轮次", msg.ConsensusTurn.String(), "重选轮次", msg.ReelectTurn,
 		"pre Leader", msg.PreLeader.Hex(), "Next Leader", msg.NextLeader.Hex())
 	mc.PublishEvent(mc.Leader_LeaderChangeNotify, msg)
+	blkprofile.Switch(msg.Number, blkprofile.StageLeaderTurn, fmt.Sprintf("%s %s reelect %d", msg.Leader.Hex(), msg.ConsensusTurn.String(), msg.ReelectTurn))
+	self.timeline.Record(&TimelineEvent{
+		Number:        msg.Number,
+		Type:          EventLeader,
+		State:         self.dc.state.name(),
+		ConsensusTurn: msg.ConsensusTurn,
+		ReelectTurn:   msg.ReelectTurn,
+		Leader:        msg.Leader,
+		NextLeader:    msg.NextLeader,
+	})
+}
+
+// record adds an event of the current state of the controller to the timeline.
+func (self *controller) record(typ string, peer common.Address, detail string) {
+	self.timeline.Record(&TimelineEvent{
+		Number:        self.dc.number,
+		Type:          typ,
+		State:         self.dc.state.name(),
+		ConsensusTurn: self.dc.curConsensusTurn,
+		ReelectTurn:   self.dc.curReelectTurn,
+		Leader:        self.dc.consensusLeader,
+		Peer:          peer,
+		Detail:        detail,
+	})
+}
+
+func (self *controller) setState(st stateDef) {
+	if self.dc.state == st {
+		return
+	}
+	detail := self.dc.state.name() + "->" + st.name()
+	self.dc.state = st
+	self.record(EventState, common.Address{}, detail)
 }
 
 func (self *controller) setTimer(outTime int64, timer *time.Timer) {
@@ -4,6 +4,7 @@
 package leaderelect2
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/ca"
@@ -89,6 +90,7 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 		log.Error(self.logInfo, "开始消息处理", "分析状态树信息错误", "err", err)
 		return
 	}
+	self.record(EventStart, msg.parentHeader.Leader, "parent time "+msg.parentHeader.Time.String())
 
 	if self.dc.role != common.RoleValidator {
 		log.Debug(self.logInfo, "开始消息处理", "身份错误, 不是验证者", "高度", self.dc.number)
@@ -98,10 +100,10 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 
 	if self.dc.bcInterval.IsBroadcastNumber(self.dc.number) {
 		log.Debug(self.logInfo, "开始消息处理", "区块为广播区块，不开启定时器")
-		self.dc.state = stIdle
+		self.setState(stIdle)
 		self.publishLeaderMsg()
 		self.mp.SaveParentHeader(msg.parentHeader)
-		self.dc.state = stWaiting
+		self.setState(stWaiting)
 		return
 	}
 
@@ -111,7 +113,7 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 			curTime := time.Now().Unix()
 			st, remainTime, reelectTurn := self.dc.turnTime.CalState(0, curTime)
 			log.Debug(self.logInfo, "开始消息处理", "完成", "状态计算结果", st.String(), "剩余时间", remainTime, "重选轮次", reelectTurn)
-			self.dc.state = st
+			self.setState(st)
 			self.dc.curReelectTurn = 0
 			self.setTimer(remainTime, self.timer)
 			if st == stPos {
@@ -133,6 +135,7 @@ func (self *controller) handleBlockPOSFinishedNotify(msg *mc.BlockPOSFinishedNot
 	}
 	if err := self.mp.SavePOSNotifyMsg(msg); err == nil {
 		log.Debug(self.logInfo, "POS完成通知消息处理", "缓存成功", "高度", msg.Number, "leader", msg.Header.Leader, "leader轮次", msg.ConsensusTurn.String())
+		self.record(EventPOSResult, msg.Header.Leader, "turn "+msg.ConsensusTurn.String())
 	}
 	self.processPOSState()
 }
@@ -140,6 +143,7 @@ func (self *controller) handleBlockPOSFinishedNotify(msg *mc.BlockPOSFinishedNot
 func (self *controller) timeOutHandle() {
 	curTime := time.Now().Unix()
 	st, remainTime, reelectTurn := self.dc.turnTime.CalState(self.dc.curConsensusTurn.TotalTurns(), curTime)
+	self.record(EventTimeout, common.Address{}, fmt.Sprintf("next %s reelect turn %d", st.name(), reelectTurn))
 	switch self.State() {
 	case stPos:
 		log.Warn(self.logInfo, "超时事件", "POS未完成", "轮次", self.curTurnInfo(), "高度", self.Number(),
@@ -156,7 +160,7 @@ func (self *controller) timeOutHandle() {
 	}
 
 	self.setTimer(remainTime, self.timer)
-	self.dc.state = st
+	self.setState(st)
 	self.startReelect(reelectTurn)
 }
 
@@ -173,5 +177,5 @@ func (self *controller) processPOSState() {
 
 	log.Debug(self.logInfo, "POS完成", "状态切换为<挖矿结果等待阶段>")
 	self.setTimer(0, self.timer)
-	self.dc.state = stMining
+	self.setState(stMining)
 }
@@ -15,14 +15,16 @@ type ControllerManager struct {
 	curChainState mc.ChainState
 	ctrlMap       map[uint64]*controller
 	matrix        Matrix
+	timeline      *Timeline
 	logInfo       string
 }
 
-func NewControllerManager(matrix Matrix, logInfo string) *ControllerManager {
+func NewControllerManager(matrix Matrix, logInfo string, timeline *Timeline) *ControllerManager {
 	return &ControllerManager{
 		curChainState: mc.ChainState{},
 		ctrlMap:       make(map[uint64]*controller),
 		matrix:        matrix,
+		timeline:      timeline,
 		logInfo:       logInfo,
 	}
 }
@@ -113,7 +115,7 @@ func (cm *ControllerManager) isLegalNumber(number uint64) error {
 func (cm *ControllerManager) getController(number uint64) *controller {
 	ctrl, OK := cm.ctrlMap[number]
 	if OK == false {
-		ctrl = newController(cm.matrix, cm.logInfo, number)
+		ctrl = newController(cm.matrix, cm.logInfo, number, cm.timeline)
 		cm.ctrlMap[number] = ctrl
 	}
 	return ctrl
@@ -5,8 +5,10 @@
 package leaderelect2
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -31,6 +33,7 @@ func (self *controller) startReelect(reelectTurn uint32) {
 	}
 	beginTime, endTime := self.dc.turnTime.CalTurnTime(self.dc.curConsensusTurn.TotalTurns(), self.dc.curReelectTurn)
 	master := self.dc.GetReelectMaster()
+	self.record(EventReelectTurn, master, "master "+master.Hex())
 	if master == self.dc.selfAddr {
 		log.Debug(self.logInfo, "(master)开启重选流程", master.Hex(), "轮次", self.curTurnInfo(), "高度", self.dc.number,
 			"轮次开始时间", time.Unix(beginTime, 0).String(), "轮次结束时间", time.Unix(endTime, 0).String(), "self", self.dc.selfAddr.Hex())
@@ -52,7 +55,8 @@ func (self *controller) finishReelectWithPOS(posResult *mc.HD_BlkConsensusReqMsg
 	mc.PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypePOS, Header: posResult.Header, From: from})
 	self.setTimer(0, self.timer)
 	self.setTimer(0, self.reelectTimer)
-	self.dc.state = stMining
+	self.record(EventReelectFinished, from, "pos result")
+	self.setState(stMining)
 	self.dc.SetReelectTurn(0)
 	self.dc.isMaster = false
 	self.selfCache.ClearSelfInquiryMsg()
@@ -76,7 +80,9 @@ func (self *controller) finishReelectWithRLConsensus(rlResult *mc.HD_V2_ReelectL
 	st, remainTime, reelectTurn := self.dc.turnTime.CalState(consensusTurn.TotalTurns(), curTime)
 	log.INFO(self.logInfo, "完成leader重选", "leader重置", "重选轮次", reelectTurn, "旧共识轮次", self.ConsensusTurn().String(), "新共识轮次", consensusTurn.String(), "高度", self.Number(),
 		"状态计算结果", st.String(), "下次超时时间", remainTime, "计算的重选轮次", reelectTurn, "轮次开始时间", self.dc.turnTime.GetBeginTime(self.ConsensusTurn().TotalTurns()))
-	self.dc.state = st
+	self.record(EventReelectFinished, rlResult.Req.InquiryReq.From, "leader reelected")
+	self.record(EventConsensusTurn, common.Address{}, "turn "+consensusTurn.String())
+	self.setState(st)
 	self.dc.curReelectTurn = 0
 	self.setTimer(remainTime, self.timer)
 	if st == stPos {
@@ -93,6 +99,7 @@ func (self *controller) reelectTimeOutHandle() {
 		log.Info(self.logInfo, "重选处理定时器超时", "状态错误,当前状态不是重选阶段", "当前状态", self.State().String())
 		return
 	}
+	self.record(EventReelectTimeout, common.Address{}, "inquiry result "+rspTypeName(self.selfCache.GetInquiryResult()))
 	switch self.selfCache.GetInquiryResult() {
 	case mc.ReelectRSPTypeNone:
 		self.sendInquiryReq()
@@ -127,6 +134,7 @@ func (self *controller) handleInquiryReq(req *mc.HD_V2_ReelectInquiryReqMsg) {
 		return
 	}
 	log.Debug(self.logInfo, "询问消息处理", "开始", "高度", req.Number, "共识轮次", req.ConsensusTurn.String(), "重选轮次", req.ReelectTurn, "本地轮次信息", self.curTurnInfo(), "from", req.From.Hex())
+	self.record(EventInquiryReqRecv, req.From, fmt.Sprintf("number %d turn %s reelect turn %d", req.Number, req.ConsensusTurn.String(), req.ReelectTurn))
 
 	// 对比请求高度
 	if req.Number < self.Number() {
@@ -233,6 +241,7 @@ func (self *controller) handleInquiryRsp(rsp *mc.HD_V2_ReelectInquiryRspMsg) {
 		log.Info(self.logInfo, "询问响应处理", "响应匹配检查", "err", err, "高度", self.dc.number)
 		return
 	}
+	self.record(EventInquiryRsp, rsp.From, "rsp "+rspTypeName(rsp.Type))
 	switch rsp.Type {
 	case mc.ReelectRSPTypeNewBlockReady:
 		self.processNewBlockReadyRsp(rsp.NewBlock, rsp.From)
@@ -296,7 +305,7 @@ func (self *controller) handleRLReq(req *mc.HD_V2_ReelectLeaderReqMsg) {
 	}
 
 	hash := types.RlpHash(req)
-	sign, err := self.matrix.SignHelper().SignHashWithValidateByReader(self.dc, hash.Bytes(), true, self.ParentHash())
+	sign, err := self.matrix.SignHelper().SignVoteWithValidateByReader(self.dc, hash.Bytes(), true, self.ParentHash(), rlReqVote(req))
 	if err != nil {
 		log.Error(self.logInfo, "leader重选请求处理", "签名失败", "err", err)
 		return
@@ -426,6 +435,7 @@ func (self *controller) sendInquiryReq() {
 
 	log.Trace(self.logInfo, "send<重选询问请求>", "成功", "轮次", self.curTurnInfo(), "高度", self.Number(), "reqHash", reqHash.TerminalString())
 	self.matrix.HD().SendNodeMsg(mc.HD_V2_LeaderReelectInquiryReq, req, common.RoleValidator, nil)
+	self.record(EventInquiryReq, common.Address{}, "broadcast "+reqHash.TerminalString())
 	return
 }
 
@@ -448,6 +458,7 @@ func (self *controller) sendInquiryReqToSingle(target common.Address) {
 	log.Trace(self.logInfo, "send<重选询问请求>single", "成功", "轮次", self.curTurnInfo(), "高度", self.Number(), "reqHash", reqHash.TerminalString())
 	self.matrix.HD().SendNodeMsg(mc.HD_V2_LeaderReelectInquiryReq, req, common.RoleNil, []common.Address{target})
 	self.selfCache.SetLastSingleInquiryReqTime(curTime)
+	self.record(EventInquiryReq, target, "single "+reqHash.TerminalString())
 	return
 }
 
@@ -539,7 +550,7 @@ func (self *controller) sendRLReq() {
 		return
 	}
 
-	selfSign, err := self.matrix.SignHelper().SignHashWithValidateByReader(self.dc, reqHash.Bytes(), true, self.ParentHash())
+	selfSign, err := self.matrix.SignHelper().SignVoteWithValidateByReader(self.dc, reqHash.Bytes(), true, self.ParentHash(), rlReqVote(req))
 	if err != nil {
 		log.Error(self.logInfo, "send<leader重选请求>", "自己的签名失败", "err", err, "高度", self.Number(), "轮次", self.curTurnInfo())
 		return
@@ -553,6 +564,19 @@ func (self *controller) sendRLReq() {
 	self.matrix.HD().SendNodeMsg(mc.HD_V2_LeaderReelectReq, req, common.RoleValidator, nil)
 }
 
+// rlReqVote is the vote of a leader reelection request for the slashing
+// protection. The request gets a new timestamp on every resend, so the subject
+// of the vote is the leader it elects.
+func rlReqVote(req *mc.HD_V2_ReelectLeaderReqMsg) signhelper.VoteInfo {
+	return signhelper.VoteInfo{
+		Kind:        signhelper.VoteReelect,
+		Number:      req.InquiryReq.Number,
+		Turn:        req.InquiryReq.ConsensusTurn.TotalTurns(),
+		ReelectTurn: req.InquiryReq.ReelectTurn,
+		Subject:     types.RlpHash(req.InquiryReq.Master),
+	}
+}
+
 func (self *controller) sendResultBroadcastMsg() {
 	msg, msgHash, err := self.selfCache.GetBroadcastMsg()
 	if err != nil {
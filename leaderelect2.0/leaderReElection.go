@@ -15,6 +15,7 @@ import (
 
 type LeaderIdentity struct {
 	ctrlManager       *ControllerManager
+	timeline          *Timeline
 	matrix            Matrix
 	extraInfo         string
 	newBlockReadyCh   chan *mc.NewBlockReadyMsg
@@ -38,8 +39,10 @@ type LeaderIdentity struct {
 }
 
 func NewLeaderIdentityService(matrix Matrix, extraInfo string) (*LeaderIdentity, error) {
+	timeline := NewTimeline()
 	var server = &LeaderIdentity{
-		ctrlManager:      NewControllerManager(matrix, extraInfo),
+		ctrlManager:      NewControllerManager(matrix, extraInfo, timeline),
+		timeline:         timeline,
 		matrix:           matrix,
 		extraInfo:        extraInfo,
 		newBlockReadyCh:  make(chan *mc.NewBlockReadyMsg, 1),
@@ -64,6 +67,11 @@ func NewLeaderIdentityService(matrix Matrix, extraInfo string) (*LeaderIdentity,
 	return server, nil
 }
 
+// Timeline returns the leader election timeline of the service.
+func (self *LeaderIdentity) Timeline() *Timeline {
+	return self.timeline
+}
+
 func (self *LeaderIdentity) subEvents() error {
 	//订阅身份变更消息
 	var err error
@@ -0,0 +1,220 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package leaderelect2
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// Types of the timeline events.
+const (
+	EventStart           = "start"           // controller开始
+	EventState           = "state"           // 状态切换
+	EventConsensusTurn   = "consensusTurn"   // 共识轮次变更
+	EventReelectTurn     = "reelectTurn"     // 重选轮次开始
+	EventLeader          = "leader"          // 公布leader身份
+	EventPOSResult       = "posResult"       // POS完成通知
+	EventInquiryReq      = "inquiryReq"      // 发出询问请求
+	EventInquiryReqRecv  = "inquiryReqRecv"  // 收到询问请求
+	EventInquiryRsp      = "inquiryRsp"      // 收到询问响应
+	EventTimeout         = "timeout"         // 轮次超时
+	EventReelectTimeout  = "reelectTimeout"  // 重选处理超时
+	EventReelectFinished = "reelectFinished" // 重选完成
+)
+
+const (
+	timelineMaxNumbers = 64  // 内存中保留的高度数
+	timelineMaxEvents  = 512 // 每个高度保留的事件数
+	timelineSendQueue  = 256 // 订阅推送队列长度
+)
+
+var timelinePrefix = []byte("LeaderTimeline-")
+
+// TimelineEvent is an event of the leader election of a block height.
+type TimelineEvent struct {
+	Number        uint64               `json:"number"`
+	Time          uint64               `json:"time"` // unix time in milliseconds
+	Type          string               `json:"type"`
+	State         string               `json:"state"`
+	ConsensusTurn mc.ConsensusTurnInfo `json:"consensusTurn"`
+	ReelectTurn   uint32               `json:"reelectTurn"`
+	Leader        common.Address       `json:"leader"`
+	NextLeader    common.Address       `json:"nextLeader"`
+	Peer          common.Address       `json:"peer"` // sender or target of a message
+	Detail        string               `json:"detail"`
+}
+
+// TimelineDatabase is the database the timeline persists to.
+type TimelineDatabase interface {
+	Get(key []byte) ([]byte, error)
+	Put(key []byte, value []byte) error
+}
+
+// Timeline keeps the leader election events of the recent heights in memory,
+// and writes the events of a height to the database when it leaves the memory
+// window, if a database is set. Subscribers are fed from a queue, so a slow
+// subscriber drops events instead of blocking the election.
+type Timeline struct {
+	mu      sync.RWMutex
+	events  map[uint64][]*TimelineEvent
+	numbers []uint64 // heights in memory, in insertion order
+	db      TimelineDatabase
+	feed    event.Feed
+	scope   event.SubscriptionScope
+	sendCh  chan *TimelineEvent
+	quitCh  chan struct{}
+}
+
+func NewTimeline() *Timeline {
+	tl := &Timeline{
+		events: make(map[uint64][]*TimelineEvent),
+		sendCh: make(chan *TimelineEvent, timelineSendQueue),
+		quitCh: make(chan struct{}),
+	}
+	go tl.sendLoop()
+	return tl
+}
+
+func (tl *Timeline) sendLoop() {
+	for {
+		select {
+		case ev := <-tl.sendCh:
+			tl.feed.Send(ev)
+		case <-tl.quitCh:
+			return
+		}
+	}
+}
+
+// SetDatabase enables the persistence of the timeline.
+func (tl *Timeline) SetDatabase(db TimelineDatabase) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.db = db
+}
+
+// Record adds an event, stamped with the current time when its time is unset.
+func (tl *Timeline) Record(ev *TimelineEvent) {
+	if tl == nil || ev == nil {
+		return
+	}
+	if ev.Time == 0 {
+		ev.Time = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	}
+
+	tl.mu.Lock()
+	events, exist := tl.events[ev.Number]
+	if !exist {
+		tl.numbers = append(tl.numbers, ev.Number)
+		tl.evict()
+	}
+	if len(events) < timelineMaxEvents {
+		tl.events[ev.Number] = append(events, ev)
+	}
+	tl.mu.Unlock()
+
+	select {
+	case tl.sendCh <- ev:
+	default:
+		log.Debug("leader timeline", "推送队列已满，丢弃事件", ev.Type, "高度", ev.Number)
+	}
+}
+
+// evict drops the oldest heights beyond the memory window, persisting them.
+func (tl *Timeline) evict() {
+	for len(tl.numbers) > timelineMaxNumbers {
+		number := tl.numbers[0]
+		tl.numbers = tl.numbers[1:]
+		tl.persist(number, tl.events[number])
+		delete(tl.events, number)
+	}
+}
+
+func (tl *Timeline) persist(number uint64, events []*TimelineEvent) {
+	if tl.db == nil || len(events) == 0 {
+		return
+	}
+	data, err := rlp.EncodeToBytes(events)
+	if err != nil {
+		log.Error("leader timeline", "编码失败", err, "高度", number)
+		return
+	}
+	if err := tl.db.Put(timelineKey(number), data); err != nil {
+		log.Error("leader timeline", "存储失败", err, "高度", number)
+	}
+}
+
+// Flush persists the heights in memory.
+func (tl *Timeline) Flush() {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	for _, number := range tl.numbers {
+		tl.persist(number, tl.events[number])
+	}
+}
+
+// Events returns the events of the height, from memory or from the database.
+func (tl *Timeline) Events(number uint64) []*TimelineEvent {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	if events, exist := tl.events[number]; exist {
+		return append([]*TimelineEvent(nil), events...)
+	}
+	if tl.db == nil {
+		return nil
+	}
+	data, err := tl.db.Get(timelineKey(number))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var events []*TimelineEvent
+	if err := rlp.DecodeBytes(data, &events); err != nil {
+		log.Error("leader timeline", "解码失败", err, "高度", number)
+		return nil
+	}
+	return events
+}
+
+// SubscribeEvents subscribes to the events recorded from now on.
+func (tl *Timeline) SubscribeEvents(ch chan<- *TimelineEvent) event.Subscription {
+	return tl.scope.Track(tl.feed.Subscribe(ch))
+}
+
+// Close persists the heights in memory and ends the subscriptions.
+func (tl *Timeline) Close() {
+	tl.Flush()
+	close(tl.quitCh)
+	tl.scope.Close()
+}
+
+func rspTypeName(rspType mc.ReelectRSPType) string {
+	switch rspType {
+	case mc.ReelectRSPTypeNone:
+		return "none"
+	case mc.ReelectRSPTypePOS:
+		return "pos"
+	case mc.ReelectRSPTypeAlreadyRL:
+		return "alreadyRL"
+	case mc.ReelectRSPTypeAgree:
+		return "agree"
+	case mc.ReelectRSPTypeNewBlockReady:
+		return "newBlockReady"
+	default:
+		return "unknown"
+	}
+}
+
+func timelineKey(number uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, number)
+	return append(append([]byte{}, timelinePrefix...), data...)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package leaderelect2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+func TestTimelineEvict(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	tl := NewTimeline()
+	tl.SetDatabase(db)
+	defer tl.Close()
+
+	leader := common.HexToAddress("0x01")
+	tl.Record(&TimelineEvent{Number: 1, Type: EventStart, State: stIdle.name(), Leader: leader})
+	tl.Record(&TimelineEvent{Number: 1, Type: EventReelectTurn, State: stReelect.name(), ConsensusTurn: mc.ConsensusTurnInfo{PreConsensusTurn: 1}, ReelectTurn: 2})
+	for number := uint64(2); number <= timelineMaxNumbers+1; number++ {
+		tl.Record(&TimelineEvent{Number: number, Type: EventStart})
+	}
+	if len(tl.events) != timelineMaxNumbers {
+		t.Fatalf("heights in memory mismatch: have %d, want %d", len(tl.events), timelineMaxNumbers)
+	}
+	if _, exist := tl.events[1]; exist {
+		t.Fatalf("oldest height not evicted")
+	}
+
+	events := tl.Events(1)
+	if len(events) != 2 {
+		t.Fatalf("persisted events mismatch: have %d, want 2", len(events))
+	}
+	if events[0].Leader != leader || events[0].Time == 0 || events[1].ReelectTurn != 2 || events[1].ConsensusTurn.PreConsensusTurn != 1 {
+		t.Fatalf("persisted events mismatch: %v %v", events[0], events[1])
+	}
+	if events := tl.Events(timelineMaxNumbers + 2); events != nil {
+		t.Fatalf("unknown height returned events %v", events)
+	}
+}
+
+func TestTimelineMaxEvents(t *testing.T) {
+	tl := NewTimeline()
+	defer tl.Close()
+	for i := 0; i < timelineMaxEvents+10; i++ {
+		tl.Record(&TimelineEvent{Number: 1, Type: EventInquiryReqRecv})
+	}
+	if events := tl.Events(1); len(events) != timelineMaxEvents {
+		t.Fatalf("events mismatch: have %d, want %d", len(events), timelineMaxEvents)
+	}
+}
+
+func TestTimelineSubscribe(t *testing.T) {
+	tl := NewTimeline()
+	defer tl.Close()
+	ch := make(chan *TimelineEvent, 1)
+	sub := tl.SubscribeEvents(ch)
+	defer sub.Unsubscribe()
+
+	tl.Record(&TimelineEvent{Number: 5, Type: EventTimeout})
+	select {
+	case ev := <-ch:
+		if ev.Number != 5 || ev.Type != EventTimeout {
+			t.Fatalf("event mismatch: %v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("event not received")
+	}
+}
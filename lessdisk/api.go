@@ -0,0 +1,53 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lessdisk
+
+const maxPinnedPage = 1000
+
+// PrivateLessDiskAPI turns the pruning of the old blocks on or off and reports
+// what it deletes.
+type PrivateLessDiskAPI struct {
+	svr *Server
+}
+
+func NewPrivateLessDiskAPI(svr *Server) *PrivateLessDiskAPI {
+	return &PrivateLessDiskAPI{svr: svr}
+}
+
+// Status returns the switch, the config, the count of the pinned blocks and the
+// last pruning round.
+func (api *PrivateLessDiskAPI) Status() *Status {
+	return api.svr.Status()
+}
+
+// Pinned returns a page of the pinned blocks from the index start, at most
+// maxPinnedPage of them.
+func (api *PrivateLessDiskAPI) Pinned(start uint64, count uint64) []PinnedBlock {
+	if count > maxPinnedPage {
+		count = maxPinnedPage
+	}
+	return api.svr.PinnedBlocks(start, count)
+}
+
+// Enable turns the pruning on, kept over the restarts.
+func (api *PrivateLessDiskAPI) Enable() (bool, error) {
+	if err := api.svr.SetSwitch(true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Disable turns the pruning off, kept over the restarts.
+func (api *PrivateLessDiskAPI) Disable() (bool, error) {
+	if err := api.svr.SetSwitch(false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DryRun returns the blocks the next pruning round would delete and keep,
+// and with withState the count and size of the state nodes it would delete.
+func (api *PrivateLessDiskAPI) DryRun(withState bool) (*PruneReport, error) {
+	return api.svr.DryRun(withState)
+}
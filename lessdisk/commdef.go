@@ -12,7 +12,10 @@ import (
 )
 
 var (
-	ErrDataSize = errors.New("data size err")
+	ErrDataSize        = errors.New("data size err")
+	ErrNoCurrentHeader = errors.New("failed to get current header")
+	ErrNoMinNumber     = errors.New("failed to get min number index")
+	ErrUnderThreshold  = errors.New("current number under height threshold")
 )
 
 type ChainOperator interface {
@@ -20,6 +23,20 @@ type ChainOperator interface {
 	DelLocalBlocks(blocks []*mc.BlockInfo) (fails []*mc.BlockInfo, err error)
 }
 
+// PinReader is implemented by the chains whose pruning keeps the blocks needed
+// by the reelection, the snapshots and the recovery of the verified blocks.
+type PinReader interface {
+	GetHeaderByHash(hash common.Hash) *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+	PinParams() (*PinParams, error)
+}
+
+// StatePruner is implemented by the chains able to delete the state not
+// reachable from the blocks kept.
+type StatePruner interface {
+	PruneState(keep []common.Hash, dryRun bool) (int, common.StorageSize, error)
+}
+
 type DatabaseOperator interface {
 	Has(key []byte) (bool, error)
 	Get(key []byte) ([]byte, error)
@@ -30,6 +47,9 @@ type DatabaseOperator interface {
 var (
 	minNumberIndex = []byte("LessDisk-MinNumber")
 	blkIndexPrefix = []byte("LessDisk-Index-")
+	switchKey      = []byte("LessDisk-Switch")
+	pinnedPrefix   = []byte("LessDisk-Pinned-")
+	pinnedCountKey = []byte("LessDisk-PinnedCount")
 )
 
 type dbBlkIndex struct {
@@ -89,3 +89,105 @@ func (im *indexOperator) deleteBlkIndex(number uint64) error {
 	}
 	return nil
 }
+
+func (im *indexOperator) readSwitch() (enable bool, exist bool) {
+	data, err := im.db.Get(switchKey)
+	if err != nil || len(data) != 1 {
+		return false, false
+	}
+	return data[0] == 1, true
+}
+
+func (im *indexOperator) writeSwitch(enable bool) error {
+	data := []byte{0}
+	if enable {
+		data[0] = 1
+	}
+	if err := im.db.Put(switchKey, data); err != nil {
+		return errors.Errorf("failed to write switch: %v", err)
+	}
+	return nil
+}
+
+func (im *indexOperator) readPinnedCount() uint64 {
+	data, _ := im.db.Get(pinnedCountKey)
+	if len(data) == 0 {
+		return 0
+	}
+	count, err := decodeUint64(data)
+	if err != nil {
+		log.Error(im.logInfo, "保留区块数量解码失败", err)
+		return 0
+	}
+	return count
+}
+
+func (im *indexOperator) readPinnedBlock(index uint64) (*PinnedBlock, error) {
+	data, err := im.db.Get(append(pinnedPrefix, encodeUint64(index)...))
+	if err != nil {
+		return nil, errors.Errorf("failed to read pinned block %d: %v", index, err)
+	}
+	blk := new(PinnedBlock)
+	if err := rlp.DecodeBytes(data, blk); err != nil {
+		return nil, errors.Errorf("failed to rlp decode pinned block %d: %v", index, err)
+	}
+	return blk, nil
+}
+
+// readPinnedBlocks returns at most count pinned blocks from the index start, in
+// height order.
+func (im *indexOperator) readPinnedBlocks(start uint64, count uint64) []PinnedBlock {
+	total := im.readPinnedCount()
+	if start >= total {
+		return []PinnedBlock{}
+	}
+	if count > total-start {
+		count = total - start
+	}
+	pinned := make([]PinnedBlock, 0, count)
+	for i := start; i < start+count; i++ {
+		blk, err := im.readPinnedBlock(i)
+		if err != nil {
+			log.Error(im.logInfo, "读取保留区块失败", err)
+			continue
+		}
+		pinned = append(pinned, *blk)
+	}
+	return pinned
+}
+
+// addPinnedBlocks appends the blocks above the last pinned one, each under its
+// own key, blocks must be in height order.
+func (im *indexOperator) addPinnedBlocks(blocks []PinnedBlock) error {
+	count := im.readPinnedCount()
+	var last *PinnedBlock
+	if count > 0 {
+		blk, err := im.readPinnedBlock(count - 1)
+		if err != nil {
+			return err
+		}
+		last = blk
+	}
+	added := uint64(0)
+	for i := range blocks {
+		if last != nil && blocks[i].Number <= last.Number {
+			continue
+		}
+		data, err := rlp.EncodeToBytes(&blocks[i])
+		if err != nil {
+			return errors.Errorf("failed to rlp encode pinned block: %v", err)
+		}
+		if err := im.db.Put(append(pinnedPrefix, encodeUint64(count+added)...), data); err != nil {
+			return errors.Errorf("failed to write pinned block: %v", err)
+		}
+		last = &blocks[i]
+		added++
+	}
+	if added == 0 {
+		return nil
+	}
+	if err := im.db.Put(pinnedCountKey, encodeUint64(count+added)); err != nil {
+		return errors.Errorf("failed to write pinned count: %v", err)
+	}
+	return nil
+}
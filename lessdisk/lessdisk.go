@@ -9,6 +9,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/pkg/errors"
 	"sync"
 	"time"
 )
@@ -24,6 +25,8 @@ type Server struct {
 	quit              chan struct{}
 	indexOperator     *indexOperator
 	chain             ChainOperator
+	lastReport        *PruneReport
+	lastStatePrune    int64
 }
 
 func NewLessDiskSvr(config *params.LessDiskConfig, db DatabaseOperator, chain ChainOperator) *Server {
@@ -55,7 +58,29 @@ func (self *Server) Stop() {
 }
 
 func (self *Server) FuncSwitch(enable bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.funcSwitch = enable
+}
+
+// SetSwitch turns the function on or off and saves it for the restarts.
+func (self *Server) SetSwitch(enable bool) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if err := self.indexOperator.writeSwitch(enable); err != nil {
+		return err
+	}
 	self.funcSwitch = enable
+	return nil
+}
+
+// RestoreSwitch turns the function on if enable is set, else restores the
+// switch saved by the last SetSwitch.
+func (self *Server) RestoreSwitch(enable bool) {
+	if enable == false {
+		enable, _ = self.indexOperator.readSwitch()
+	}
+	self.FuncSwitch(enable)
 }
 
 func (self *Server) runIndexUpdate() {
@@ -116,49 +141,108 @@ func (self *Server) updateIndex(msg *mc.BlockInsertedMsg) {
 }
 
 func (self *Server) delBlk() {
+	self.mu.Lock()
 	if self.funcSwitch == false {
+		self.mu.Unlock()
 		log.Debug(self.logInfo, "删除区块", "功能未开启")
 		return
 	}
 
+	report, err := self.plan(false)
+	if err != nil {
+		self.mu.Unlock()
+		log.Debug(self.logInfo, "删除区块", err)
+		return
+	}
+	self.execute(report)
+	self.lastReport = report
+	keep := self.stateKeepList(report, false)
+	self.mu.Unlock()
+
+	if keep != nil {
+		self.pruneState(report, keep)
+	}
+}
+
+// plan returns the blocks a pruning round deletes, from the lowest height of
+// the index up to the height threshold. The round stops at the first height
+// with a block inserted within the time threshold or kept by the pin rules.
+func (self *Server) plan(dryRun bool) (*PruneReport, error) {
 	header := self.chain.CurrentHeader()
 	if header == nil {
 		log.Warn(self.logInfo, "删除区块", "获取主链当前区块失败")
-		return
+		return nil, ErrNoCurrentHeader
 	}
 
-	self.mu.Lock()
-	defer self.mu.Unlock()
 	curTime := time.Now().Unix()
 	curNumber := header.Number.Uint64()
 	minNumber := self.indexOperator.readMinNumberIndex()
 	log.Debug(self.logInfo, "删除区块", "开始", "当前高度", curNumber, "最低高度", minNumber, "高度阈值", self.config.HeightThreshold)
 	if minNumber == 0 {
-		log.Debug(self.logInfo, "删除区块", "获取最低高度失败")
-		return
+		return nil, ErrNoMinNumber
 	}
-
-	targetNumber := curNumber - self.config.HeightThreshold
-	if targetNumber <= minNumber {
-		log.Debug(self.logInfo, "删除区块", "当前区块高度未达到高度阈值")
-		return
+	if curNumber <= minNumber+self.config.HeightThreshold {
+		return nil, ErrUnderThreshold
 	}
 
-	newMinNumber := minNumber
-	delBlks := make([]*mc.BlockInfo, 0)
-	for i := minNumber; i < targetNumber; i++ {
+	rules, err := self.pinRules()
+	if err != nil {
+		return nil, err
+	}
+	floor, floorReason := rules.floor()
+	report := &PruneReport{
+		DryRun:       dryRun,
+		Time:         curTime,
+		CurNumber:    curNumber,
+		MinNumber:    minNumber,
+		TargetNumber: curNumber - self.config.HeightThreshold,
+		NewMinNumber: minNumber,
+		Blocks:       make([]*mc.BlockInfo, 0),
+		Pinned:       make([]PinnedBlock, 0),
+	}
+	for i := minNumber; i < report.TargetNumber; i++ {
+		if i >= floor {
+			log.Debug(self.logInfo, "删除区块", "到达保留高度", "number", i, "reason", floorReason)
+			report.StopReason = floorReason
+			break
+		}
 		blkIndex := self.indexOperator.readBlkIndex(i)
 		if rlt, blk := hasBlockNotOutTime(curTime, self.config.TimeThreshold, blkIndex); rlt == true {
 			log.Debug(self.logInfo, "删除区块", "有区块不满足时间阈值", "number", i, "check begin number", minNumber, "hash", blk.Hash.Hex(), "insertTime", blk.InsertTime, "timeThreshold", self.config.TimeThreshold)
+			report.StopReason = StopTimeThreshold
 			break
 		}
 		for j := 0; j < len(blkIndex); j++ {
-			delBlks = append(delBlks, &mc.BlockInfo{Hash: blkIndex[j].Hash, Number: i})
+			blk := &mc.BlockInfo{Hash: blkIndex[j].Hash, Number: i}
+			if reason := rules.pinned(blk); reason != "" {
+				report.Pinned = append(report.Pinned, PinnedBlock{Hash: blk.Hash, Number: i, Reason: reason})
+				continue
+			}
+			report.Blocks = append(report.Blocks, blk)
 		}
-		newMinNumber = i + 1
+		report.NewMinNumber = i + 1
 	}
+	return report, nil
+}
 
-	fails, err := self.chain.DelLocalBlocks(delBlks)
+// pinRules returns the rules of the blocks kept, nil if the chain keeps none.
+func (self *Server) pinRules() (*pinRules, error) {
+	reader, ok := self.chain.(PinReader)
+	if !ok || self.config.PinBlocks == false {
+		return nil, nil
+	}
+	params, err := reader.PinParams()
+	if err != nil {
+		return nil, errors.Errorf("failed to get pin params: %v", err)
+	}
+	return &pinRules{reader: reader, params: params}, nil
+}
+
+// execute deletes the blocks of the report and moves the lowest height of the
+// index above them. The pinned blocks below it are saved for the state pruning.
+func (self *Server) execute(report *PruneReport) {
+	minNumber, newMinNumber := report.MinNumber, report.NewMinNumber
+	fails, err := self.chain.DelLocalBlocks(report.Blocks)
 	if err != nil {
 		log.Debug(self.logInfo, "删除区块", "链删除本地区块数据失败", "err", err, "fails", fails)
 		for _, item := range fails {
@@ -167,7 +251,17 @@ func (self *Server) delBlk() {
 			}
 		}
 	}
-	log.Debug(self.logInfo, "删除区块", "更新最低区块高度索引", "old", minNumber, "new", newMinNumber)
+	pinned := make([]PinnedBlock, 0, len(report.Pinned))
+	for _, blk := range report.Pinned {
+		if blk.Number < newMinNumber {
+			pinned = append(pinned, blk)
+		}
+	}
+	if err := self.indexOperator.addPinnedBlocks(pinned); err != nil {
+		log.Error(self.logInfo, "删除区块", "保存保留区块失败", "err", err)
+		return
+	}
+	log.Debug(self.logInfo, "删除区块", "更新最低区块高度索引", "old", minNumber, "new", newMinNumber, "pinned", len(pinned))
 	if newMinNumber != minNumber {
 		for i := minNumber; i < newMinNumber; i++ {
 			if err := self.indexOperator.deleteBlkIndex(i); err != nil {
@@ -176,6 +270,109 @@ func (self *Server) delBlk() {
 		}
 		self.indexOperator.writeMinNumberIndex(newMinNumber)
 	}
+	report.NewMinNumber = newMinNumber
+}
+
+// stateKeepList returns the blocks whose state is kept by the state pruning
+// after the report: the pinned blocks and the blocks of the index. It returns
+// nil when the state is not pruned this round.
+func (self *Server) stateKeepList(report *PruneReport, dryRun bool) []common.Hash {
+	if _, ok := self.chain.(StatePruner); !ok || self.config.PruneState == false {
+		return nil
+	}
+	if dryRun == false && (len(report.Blocks) == 0 || report.Time-self.lastStatePrune < self.config.PruneStateInterval) {
+		return nil
+	}
+	keep := make([]common.Hash, 0)
+	for _, blk := range self.indexOperator.readPinnedBlocks(0, self.indexOperator.readPinnedCount()) {
+		keep = append(keep, blk.Hash)
+	}
+	for _, blk := range report.Pinned {
+		keep = append(keep, blk.Hash)
+	}
+	for i := report.NewMinNumber; i <= report.CurNumber; i++ {
+		for _, blk := range self.indexOperator.readBlkIndex(i) {
+			keep = append(keep, blk.Hash)
+		}
+	}
+	return keep
+}
+
+// pruneState deletes the state not reachable from the blocks of keep, or
+// counts it in a dry run.
+func (self *Server) pruneState(report *PruneReport, keep []common.Hash) {
+	nodes, size, err := self.chain.(StatePruner).PruneState(keep, report.DryRun)
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if report.DryRun == false {
+		self.lastStatePrune = report.Time
+	}
+	if err != nil {
+		log.Error(self.logInfo, "裁剪状态", "失败", "err", err, "dryRun", report.DryRun)
+		report.StateErr = err.Error()
+		return
+	}
+	log.Info(self.logInfo, "裁剪状态", "完成", "dryRun", report.DryRun, "keep", len(keep), "nodes", nodes, "size", size)
+	report.StateNodes, report.StateSize = nodes, size
+}
+
+// DryRun returns what the next pruning round would delete, counting the state
+// nodes too if withState is set. Nothing is deleted.
+func (self *Server) DryRun(withState bool) (*PruneReport, error) {
+	self.mu.Lock()
+	report, err := self.plan(true)
+	if err != nil {
+		self.mu.Unlock()
+		return nil, err
+	}
+	var keep []common.Hash
+	if withState {
+		keep = self.stateKeepList(report, true)
+	}
+	self.mu.Unlock()
+
+	if keep != nil {
+		self.pruneState(report, keep)
+	}
+	return report, nil
+}
+
+// Status is the state of the service.
+type Status struct {
+	Enabled        bool                  `json:"enabled"`
+	Config         params.LessDiskConfig `json:"config"`
+	MinNumber      uint64                `json:"minNumber"`
+	PinnedCount    uint64                `json:"pinnedCount"`
+	LastPrune      *PruneReport          `json:"lastPrune"`
+	LastStatePrune int64                 `json:"lastStatePrune"`
+}
+
+// Status returns the switch, the config, the count of the pinned blocks below
+// the lowest height and the last pruning round.
+func (self *Server) Status() *Status {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	status := &Status{
+		Enabled:        self.funcSwitch,
+		Config:         *self.config,
+		MinNumber:      self.indexOperator.readMinNumberIndex(),
+		PinnedCount:    self.indexOperator.readPinnedCount(),
+		LastStatePrune: self.lastStatePrune,
+	}
+	if self.lastReport != nil {
+		last := *self.lastReport
+		status.LastPrune = &last
+	}
+	return status
+}
+
+// PinnedBlocks returns at most count pinned blocks below the lowest height from
+// the index start, in height order.
+func (self *Server) PinnedBlocks(start uint64, count uint64) []PinnedBlock {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.indexOperator.readPinnedBlocks(start, count)
 }
 
 func updateIndexSlice(hash common.Hash, insertTime uint64, index []dbBlkIndex) ([]dbBlkIndex, bool) {
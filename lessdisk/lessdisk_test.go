@@ -486,7 +486,7 @@ func (db *simDB) checkState(cacheSize int, min uint64, indexTargets map[uint64][
 		return errors.Errorf("db数据: 获取最低高度索引失败: %v", err)
 	} else {
 		if minNumber, err := decodeUint64(data); err != nil {
-			return errors.Errorf("db数据: 最低高度索引解码失败 err=%v", minNumber, err)
+			return errors.Errorf("db数据: 最低高度索引解码失败 err=%v", err)
 		} else {
 			if minNumber != min {
 				return errors.Errorf("db数据: 最低高度索引不匹配 db=%d target=%d", minNumber, min)
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lessdisk
+
+import (
+	"math"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+// Reasons the pruning keeps a block of the main chain.
+const (
+	PinSuper      = "super"      // 超级区块
+	PinReelection = "reelection" // 换届区块
+	PinBroadcast  = "broadcast"  // 广播区块
+	PinElectGen   = "electGen"   // 选举生成区块
+	PinSnapshot   = "snapshot"   // 快照区块
+)
+
+// Reasons a pruning round stops below the height threshold.
+const (
+	StopTimeThreshold = "timeThreshold" // 区块未满足时间阈值
+	StopReelection    = "reelection"    // 上一选举周期的区块
+	StopRecovery      = "recovery"      // 已验证区块恢复所需的父区块
+)
+
+// PinParams is the chain data the pinned blocks are computed from.
+type PinParams struct {
+	BCInterval     *mc.BCIntervalInfo
+	ElectGenTime   *mc.ElectGenTimeStruct
+	SnapshotPeriod uint64         // 0 if the node saves no snapshot
+	Recovery       []mc.BlockInfo // parents of the verified blocks saved for the recovery
+}
+
+// PinnedBlock is a block the pruning keeps.
+type PinnedBlock struct {
+	Hash   common.Hash `json:"hash"`
+	Number uint64      `json:"number"`
+	Reason string      `json:"reason"`
+}
+
+// PruneReport is what a pruning round deletes, or would delete in a dry run.
+type PruneReport struct {
+	DryRun       bool               `json:"dryRun"`
+	Time         int64              `json:"time"`
+	CurNumber    uint64             `json:"curNumber"`
+	MinNumber    uint64             `json:"minNumber"`
+	TargetNumber uint64             `json:"targetNumber"`
+	NewMinNumber uint64             `json:"newMinNumber"`
+	StopReason   string             `json:"stopReason"`
+	Blocks       []*mc.BlockInfo    `json:"blocks"`
+	Pinned       []PinnedBlock      `json:"pinned"`
+	StateNodes   int                `json:"stateNodes"`
+	StateSize    common.StorageSize `json:"stateSize"`
+	StateErr     string             `json:"stateErr,omitempty"`
+}
+
+// pinRules decides the blocks the pruning keeps. A nil rules keeps nothing.
+type pinRules struct {
+	reader PinReader
+	params *PinParams
+}
+
+// floor returns the height the pruning stops at and why: the blocks of the last
+// election period stay for the reelection, and the parents of the verified
+// blocks for their recovery.
+func (r *pinRules) floor() (uint64, string) {
+	if r == nil {
+		return math.MaxUint64, ""
+	}
+	floor, reason := uint64(math.MaxUint64), ""
+	if bc := r.params.BCInterval; bc != nil {
+		floor, reason = 0, StopReelection
+		if period := bc.GetReElectionInterval(); bc.LastReelectNumber > period {
+			floor = bc.LastReelectNumber - period
+		}
+	}
+	for _, blk := range r.params.Recovery {
+		if blk.Number < floor {
+			floor, reason = blk.Number, StopRecovery
+		}
+	}
+	return floor, reason
+}
+
+// pinned returns why the block is kept, "" if it may be deleted. Only the blocks
+// of the main chain are kept.
+func (r *pinRules) pinned(blk *mc.BlockInfo) string {
+	if r == nil {
+		return ""
+	}
+	header := r.reader.GetHeaderByNumber(blk.Number)
+	if header == nil || header.Hash() != blk.Hash {
+		return ""
+	}
+	if header.IsSuperHeader() {
+		return PinSuper
+	}
+	if bc := r.params.BCInterval; bc != nil {
+		reelection := bc.GetReElectionInterval()
+		if onPeriod(blk.Number, bc.LastReelectNumber, reelection) {
+			return PinReelection
+		}
+		if onPeriod(blk.Number, bc.LastBCNumber, bc.BCInterval) {
+			return PinBroadcast
+		}
+		if gen := r.params.ElectGenTime; gen != nil {
+			for _, offset := range []uint16{gen.MinerGen, gen.ValidatorGen} {
+				if onPeriod(blk.Number+uint64(offset), bc.LastReelectNumber, reelection) {
+					return PinElectGen
+				}
+			}
+		}
+	}
+	if period := r.params.SnapshotPeriod; period != 0 && blk.Number%period == 0 {
+		return PinSnapshot
+	}
+	return ""
+}
+
+// onPeriod reports whether number is a multiple of period away from last, on
+// either side. The current interval is assumed for the older heights.
+func onPeriod(number, last, period uint64) bool {
+	if period == 0 {
+		return false
+	}
+	if number >= last {
+		return (number-last)%period == 0
+	}
+	return (last-number)%period == 0
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lessdisk
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// simPinChain is a main chain of headers with a side block, keeping the blocks
+// of the pin rules.
+type simPinChain struct {
+	headers map[common.Hash]*types.Header
+	main    map[uint64]*types.Header
+	cur     uint64
+	deleted []*mc.BlockInfo
+	keep    []common.Hash
+	dryRun  []bool
+}
+
+func newSimPinChain(cur uint64, super uint64) *simPinChain {
+	chain := &simPinChain{headers: make(map[common.Hash]*types.Header), main: make(map[uint64]*types.Header), cur: cur}
+	for i := uint64(0); i <= cur; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i))}
+		if i == super {
+			header.Leader = common.HexToAddress("0x8111111111111111111111111111111111111111")
+		}
+		chain.main[i] = header
+		chain.headers[header.Hash()] = header
+	}
+	return chain
+}
+
+func (chain *simPinChain) CurrentHeader() *types.Header {
+	return chain.main[chain.cur]
+}
+
+func (chain *simPinChain) DelLocalBlocks(blocks []*mc.BlockInfo) ([]*mc.BlockInfo, error) {
+	chain.deleted = append(chain.deleted, blocks...)
+	return nil, nil
+}
+
+func (chain *simPinChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return chain.headers[hash]
+}
+
+func (chain *simPinChain) GetHeaderByNumber(number uint64) *types.Header {
+	return chain.main[number]
+}
+
+func (chain *simPinChain) PinParams() (*PinParams, error) {
+	return &PinParams{
+		BCInterval:   &mc.BCIntervalInfo{LastBCNumber: 60, LastReelectNumber: 60, BCInterval: 5},
+		ElectGenTime: &mc.ElectGenTimeStruct{MinerGen: 3, ValidatorGen: 4},
+		Recovery:     []mc.BlockInfo{{Number: 40}},
+	}, nil
+}
+
+func (chain *simPinChain) PruneState(keep []common.Hash, dryRun bool) (int, common.StorageSize, error) {
+	chain.keep = keep
+	chain.dryRun = append(chain.dryRun, dryRun)
+	return len(chain.headers) - len(keep), 100, nil
+}
+
+func TestPrunePinnedBlocks(t *testing.T) {
+	chain := newSimPinChain(60, 7)
+	side := common.HexToHash("0x0a")
+	blkIndex := make(map[uint64][]dbBlkIndex)
+	for i := uint64(1); i <= chain.cur; i++ {
+		blkIndex[i] = []dbBlkIndex{{Hash: chain.main[i].Hash(), InsertTime: 1}}
+	}
+	blkIndex[10] = append(blkIndex[10], dbBlkIndex{Hash: side, InsertTime: 1})
+	db := newSimDB()
+	if err := db.initDB(1, blkIndex); err != nil {
+		t.Fatal(err)
+	}
+	config := &params.LessDiskConfig{OptInterval: 3600, HeightThreshold: 10, TimeThreshold: 10, PinBlocks: true, PruneState: true}
+	svr := NewLessDiskSvr(config, db, chain)
+	defer svr.Stop()
+
+	// reelection 15, 30; broadcast 5, 10, 20, 25, 35; election generation 11,
+	// 12, 26, 27; super 7. The recovery keeps 40 and above.
+	wantPinned := map[uint64]string{
+		5: PinBroadcast, 7: PinSuper, 10: PinBroadcast, 11: PinElectGen, 12: PinElectGen, 15: PinReelection,
+		20: PinBroadcast, 25: PinBroadcast, 26: PinElectGen, 27: PinElectGen, 30: PinReelection, 35: PinBroadcast,
+	}
+	report, err := svr.DryRun(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.NewMinNumber != 40 || report.StopReason != StopRecovery {
+		t.Fatalf("dry run stop mismatch: have %d (%s), want 40 (%s)", report.NewMinNumber, report.StopReason, StopRecovery)
+	}
+	if len(report.Pinned) != len(wantPinned) {
+		t.Fatalf("pinned count mismatch: have %d, want %d", len(report.Pinned), len(wantPinned))
+	}
+	for _, blk := range report.Pinned {
+		if wantPinned[blk.Number] != blk.Reason || blk.Hash != chain.main[blk.Number].Hash() {
+			t.Errorf("block %d pinned as %q, want %q", blk.Number, blk.Reason, wantPinned[blk.Number])
+		}
+	}
+	if len(report.Blocks) != 39-len(wantPinned)+1 {
+		t.Fatalf("deleted count mismatch: have %d, want %d", len(report.Blocks), 39-len(wantPinned)+1)
+	}
+	if len(chain.deleted) != 0 || len(chain.dryRun) != 1 || !chain.dryRun[0] || report.StateSize != 100 {
+		t.Fatalf("dry run changed the chain")
+	}
+	if len(chain.keep) != len(wantPinned)+21 {
+		t.Fatalf("state keep count mismatch: have %d, want %d", len(chain.keep), len(wantPinned)+21)
+	}
+
+	svr.delBlk()
+	if len(chain.deleted) != 0 {
+		t.Fatalf("blocks deleted while switched off")
+	}
+	if err := svr.SetSwitch(true); err != nil {
+		t.Fatal(err)
+	}
+	svr.delBlk()
+	if len(chain.deleted) != len(report.Blocks) {
+		t.Fatalf("deleted count mismatch: have %d, want %d", len(chain.deleted), len(report.Blocks))
+	}
+	for _, blk := range chain.deleted {
+		if _, pinned := wantPinned[blk.Number]; pinned && blk.Hash != side {
+			t.Fatalf("pinned block %d deleted", blk.Number)
+		}
+	}
+	status := svr.Status()
+	if !status.Enabled || status.MinNumber != 40 || status.PinnedCount != uint64(len(wantPinned)) || status.LastPrune == nil || status.LastPrune.DryRun {
+		t.Fatalf("status mismatch: %+v", status)
+	}
+	if page := svr.PinnedBlocks(10, 5); len(page) != 2 || page[0].Number != 30 || page[1].Number != 35 {
+		t.Fatalf("pinned page mismatch: %+v", page)
+	}
+	// the blocks pinned again after a failed deletion are not stored twice
+	if err := svr.indexOperator.addPinnedBlocks(report.Pinned); err != nil || svr.Status().PinnedCount != uint64(len(wantPinned)) {
+		t.Fatalf("pinned blocks stored twice: %v", err)
+	}
+	if len(chain.dryRun) != 2 || chain.dryRun[1] {
+		t.Fatalf("state not pruned with the blocks")
+	}
+
+	// the switch set is restored after a restart
+	restarted := NewLessDiskSvr(config, db, chain)
+	defer restarted.Stop()
+	restarted.RestoreSwitch(false)
+	if !restarted.Status().Enabled {
+		t.Fatalf("switch not restored")
+	}
+}
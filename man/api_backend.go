@@ -81,6 +81,10 @@ func (b *ManAPIBackend) Genesis() *types.Block {
 	return b.man.blockchain.Genesis()
 }
 
+func (b *ManAPIBackend) ShardSubscription() *core.ShardSubscription {
+	return b.man.blockchain.ShardSubscription()
+}
+
 func (b *ManAPIBackend) SetHead(number uint64) {
 	b.man.protocolManager.downloader.Cancel()
 	b.man.blockchain.SetHead(number)
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package man
+
+import (
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
+	"github.com/MatrixAINetwork/go-matrix/reward/whatif"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// WhatIfOverrides are the alternate inputs of a dry run, UpTimes is keyed by
+// base58 account.
+type WhatIfOverrides struct {
+	SlashCfg    *mc.SlashCfg      `json:"slashCfg"`
+	InterestCfg *mc.InterestCfg   `json:"interestCfg"`
+	UpTimes     map[string]uint64 `json:"upTimes"`
+}
+
+// WhatIfEntry is an interest or slash entry of a dry run.
+type WhatIfEntry struct {
+	Source  string         `json:"source"`
+	Type    string         `json:"type"`
+	Account string         `json:"account"`
+	Amount  *hexutil.Big   `json:"amount"`
+	Rule    string         `json:"rule"`
+	Inputs  []ledger.Input `json:"inputs"`
+}
+
+// WhatIfDelta is the change of an account amount caused by the overrides.
+type WhatIfDelta struct {
+	Account string       `json:"account"`
+	Type    string       `json:"type"`
+	Actual  *hexutil.Big `json:"actual"`
+	WhatIf  *hexutil.Big `json:"whatIf"`
+	Delta   *hexutil.Big `json:"delta"`
+}
+
+// WhatIfResult is the result of a dry run.
+type WhatIfResult struct {
+	Number hexutil.Uint64 `json:"number"`
+	Actual []*WhatIfEntry `json:"actual"`
+	WhatIf []*WhatIfEntry `json:"whatIf"`
+	Deltas []*WhatIfDelta `json:"deltas"`
+}
+
+func newWhatIfEntries(entries []*ledger.Entry) []*WhatIfEntry {
+	result := make([]*WhatIfEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, &WhatIfEntry{
+			Source:  entry.Source,
+			Type:    entry.Type,
+			Account: base58.Base58EncodeToString(params.MAN_COIN, entry.Account),
+			Amount:  (*hexutil.Big)(entry.Amount),
+			Rule:    entry.Rule,
+			Inputs:  entry.Inputs,
+		})
+	}
+	return result
+}
+
+// DryRunInterestSlash replays the interest and slash calculation of the block
+// with the overrides, and returns the per account deltas versus the actual
+// configuration. The pending block evaluates the next calculation before it
+// happens. The transactions of the block are not replayed.
+func (api *PublicMatrixAPI) DryRunInterestSlash(blockNr rpc.BlockNumber, overrides *WhatIfOverrides) (*WhatIfResult, error) {
+	var block *types.Block
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		block = api.e.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		block = api.e.blockchain.CurrentBlock()
+	default:
+		block = api.e.blockchain.GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+
+	var whatIfOverrides *whatif.Overrides
+	if overrides != nil {
+		whatIfOverrides = &whatif.Overrides{SlashCfg: overrides.SlashCfg, InterestCfg: overrides.InterestCfg}
+		if len(overrides.UpTimes) != 0 {
+			whatIfOverrides.UpTimes = make(map[common.Address]uint64, len(overrides.UpTimes))
+			for strAddress, upTime := range overrides.UpTimes {
+				address, err := base58.Base58DecodeToAddress(strAddress)
+				if err != nil {
+					return nil, fmt.Errorf("uptime account %s: %v", strAddress, err)
+				}
+				whatIfOverrides.UpTimes[address] = upTime
+			}
+		}
+	}
+
+	result, err := api.e.blockchain.DryRunInterestSlash(block, whatIfOverrides)
+	if err != nil {
+		return nil, err
+	}
+	deltas := make([]*WhatIfDelta, 0, len(result.Deltas))
+	for _, delta := range result.Deltas {
+		deltas = append(deltas, &WhatIfDelta{
+			Account: base58.Base58EncodeToString(params.MAN_COIN, delta.Account),
+			Type:    delta.Type,
+			Actual:  (*hexutil.Big)(delta.Actual),
+			WhatIf:  (*hexutil.Big)(delta.WhatIf),
+			Delta:   (*hexutil.Big)(delta.Delta),
+		})
+	}
+	return &WhatIfResult{
+		Number: hexutil.Uint64(block.NumberU64()),
+		Actual: newWhatIfEntries(result.Actual),
+		WhatIf: newWhatIfEntries(result.WhatIf),
+		Deltas: deltas,
+	}, nil
+}
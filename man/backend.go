@@ -13,6 +13,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/aidigger"
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
 	"github.com/MatrixAINetwork/go-matrix/blkgenor"
+	"github.com/MatrixAINetwork/go-matrix/blkprofile"
 	"github.com/MatrixAINetwork/go-matrix/blkverify"
 	"github.com/MatrixAINetwork/go-matrix/broadcastTx"
 	"github.com/MatrixAINetwork/go-matrix/ca"
@@ -55,7 +56,6 @@ import (
 	"math/big"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"sync"
 	"time"
 )
@@ -168,7 +168,9 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 		bloomRequests: make(chan chan *bloombits.Retrieval),
 		bloomIndexer:  NewBloomIndexer(chainDb, params.BloomBitsBlocks),
 	}
-	man.engine, man.dposEngine = CreateConsensusEngineMap(ctx, &config.Manash, chainConfig, chainDb)
+	if man.engine, man.dposEngine, err = CreateConsensusEngineMap(ctx, &config.Manash, config.AIDigger, chainConfig, chainDb); err != nil {
+		return nil, err
+	}
 	log.Info("Initialising Matrix protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
 	if !config.SkipBcVersionCheck {
@@ -186,6 +188,14 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.ShardConfig != "" {
+		shards, err := core.ReadShardSubscription(config.ShardConfig)
+		if err != nil {
+			return nil, fmt.Errorf("shard config: %v", err)
+		}
+		man.blockchain.SetShardSubscription(shards)
+		log.Info("Partial node shards", "coins", shards.Coins())
+	}
 
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -225,6 +235,7 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	topNodeInstance := olconsensus.NewTopNodeInstance(man.signHelper, man.hd)
 	man.olConsensus.SetValidatorReader(man.blockchain)
 	man.olConsensus.SetStateReaderInterface(man.blockchain.GetTopologyStore())
+	man.olConsensus.SetHistoryDB(chainDb)
 	man.olConsensus.SetTopNodeStateInterface(topNodeInstance)
 	man.olConsensus.SetValidatorAccountInterface(topNodeInstance)
 	man.olConsensus.SetMessageSendInterface(topNodeInstance)
@@ -261,6 +272,9 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.LeaderTimeline {
+		man.leaderServerV2.Timeline().SetDatabase(chainDb)
+	}
 	man.manBlkManage, err = blkmanage.New(man)
 	if err != nil {
 		return nil, err
@@ -273,8 +287,10 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	if err != nil {
 		return nil, err
 	}
-	man.lessDiskSvr = lessdisk.NewLessDiskSvr(params.DefLessDiskConfig, chainDb, man.blockchain)
-	man.lessDiskSvr.FuncSwitch(ctx.GetConfig().LessDisk)
+	lessDiskCfg := *params.DefLessDiskConfig
+	lessDiskCfg.PruneState = config.LessDiskPruneState
+	man.lessDiskSvr = lessdisk.NewLessDiskSvr(&lessDiskCfg, chainDb, &lessDiskChain{man.blockchain, chainDb})
+	man.lessDiskSvr.RestoreSwitch(ctx.GetConfig().LessDisk)
 
 	return man, nil
 }
@@ -309,34 +325,56 @@ func CreateDB(ctx *pod.ServiceContext, config *Config, name string) (mandb.Datab
 }
 
 // CreateConsensusEngine creates the required type of consensus engine instance for an Matrix service
-func CreateConsensusEngineMap(ctx *pod.ServiceContext, config *manash.Config, chainConfig *params.ChainConfig, db mandb.Database) (map[string]consensus.Engine, map[string]consensus.DPOSEngine) {
+func CreateConsensusEngineMap(ctx *pod.ServiceContext, config *manash.Config, aiDigger string, chainConfig *params.ChainConfig, db mandb.Database) (map[string]consensus.Engine, map[string]consensus.DPOSEngine, error) {
 	pictureStorePath := filepath.Join(ctx.GetConfig().DataDir, "picstore")
-	pictureList := make([]string, 0)
-	for i := 0; i < 16; i++ {
-		pictureList = append(pictureList, filepath.Join(pictureStorePath, "test_"+strconv.Itoa(i)+".jpg"))
-	}
-	aidigger.Init(ctx.GetConfig().DataDir, pictureList)
 
-	engineMap := make(map[string]consensus.Engine)
 	alphaEngine := CreateConsensusEngine(ctx, config, chainConfig, db)
-	aiMineEngine := amhash.New(amhash.Config{PowMode: amhash.ModeNormal, PictureStorePath: pictureStorePath})
+	aiMineEngine := NewAIMineEngine(pictureStorePath, aiDigger, ctx.GetConfig().DataDir)
 	aiMineEngine.SetThreads(-1) // Disable CPU mining
 
-	engineMap[manversion.VersionAlpha] = alphaEngine
-	engineMap[manversion.VersionBeta] = alphaEngine
-	engineMap[manversion.VersionGamma] = alphaEngine
-	engineMap[manversion.VersionDelta] = alphaEngine
-	engineMap[manversion.VersionAIMine] = aiMineEngine
+	engines := map[string]consensus.Engine{manversion.EngineManash: alphaEngine, manversion.EngineAIMine: aiMineEngine}
+	dposEngines := map[string]consensus.DPOSEngine{manversion.EngineMtxDPOS: mtxdpos.NewMtxDPOS(chainConfig.SimpleMode)}
+	return BuildEngineMaps(engines, dposEngines)
+}
+
+// NewAIMineEngine creates the amhash engine with the AI digger backend aiDigger
+// and loads its model data from dataDir. It falls back to the reference backend
+// when the data of aiDigger can't be loaded.
+func NewAIMineEngine(pictureStorePath string, aiDigger string, dataDir string) *amhash.Amhash {
+	engine := amhash.New(amhash.Config{PowMode: amhash.ModeNormal, PictureStorePath: pictureStorePath, AIDigger: aiDigger})
+	err := engine.InitAIDigger(dataDir)
+	if err == nil {
+		return engine
+	}
+	if aiDigger == aidigger.DiggerReference {
+		log.Crit("init ai digger failed", "digger", aiDigger, "err", err)
+	}
+	log.Error("init ai digger failed, fall back to the reference digger", "digger", aiDigger, "err", err)
+	engine = amhash.New(amhash.Config{PowMode: amhash.ModeNormal, PictureStorePath: pictureStorePath, AIDigger: aidigger.DiggerReference})
+	if err := engine.InitAIDigger(dataDir); err != nil {
+		log.Crit("init ai digger failed", "digger", aidigger.DiggerReference, "err", err)
+	}
+	return engine
+}
 
+// BuildEngineMaps maps the versions of the registered upgrades to the engines
+// they select. It fails if an engine of an upgrade is missing.
+func BuildEngineMaps(engines map[string]consensus.Engine, dposEngines map[string]consensus.DPOSEngine) (map[string]consensus.Engine, map[string]consensus.DPOSEngine, error) {
+	engineMap := make(map[string]consensus.Engine)
 	dposEngineMap := make(map[string]consensus.DPOSEngine)
-	alphaDposEngine := mtxdpos.NewMtxDPOS(chainConfig.SimpleMode)
-	dposEngineMap[manversion.VersionAlpha] = alphaDposEngine
-	dposEngineMap[manversion.VersionBeta] = alphaDposEngine
-	dposEngineMap[manversion.VersionGamma] = alphaDposEngine
-	dposEngineMap[manversion.VersionDelta] = alphaDposEngine
-	dposEngineMap[manversion.VersionAIMine] = alphaDposEngine
-
-	return engineMap, dposEngineMap
+	for _, up := range manversion.Upgrades() {
+		engine, ok := engines[up.Engine]
+		if !ok {
+			return nil, nil, fmt.Errorf("no consensus engine %q for version %s", up.Engine, up.Version)
+		}
+		dposEngine, ok := dposEngines[up.DPOSEngine]
+		if !ok {
+			return nil, nil, fmt.Errorf("no dpos engine %q for version %s", up.DPOSEngine, up.Version)
+		}
+		engineMap[up.Version] = engine
+		dposEngineMap[up.Version] = dposEngine
+	}
+	return engineMap, dposEngineMap, nil
 }
 
 func CreateConsensusEngine(ctx *pod.ServiceContext, config *manash.Config, chainConfig *params.ChainConfig, db mandb.Database) consensus.Engine {
@@ -443,6 +481,29 @@ func (s *Matrix) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   s.netRPCService,
 			Public:    true,
+		}, {
+			Namespace: "leader",
+			Version:   "1.0",
+			Service:   leaderelect2.NewPublicLeaderAPI(s.leaderServerV2),
+			Public:    true,
+		}, {
+			Namespace: "profile",
+			Version:   "1.0",
+			Service:   blkprofile.NewPublicProfileAPI(blkprofile.DefaultProfiler),
+			Public:    true,
+		}, {
+			Namespace: "online",
+			Version:   "1.0",
+			Service:   olconsensus.NewPublicOnlineConsensusAPI(s.olConsensus),
+			Public:    true,
+		}, {
+			Namespace: "lottery",
+			Version:   "1.0",
+			Service:   NewPrivateLotteryAPI(s),
+		}, {
+			Namespace: "lessdisk",
+			Version:   "1.0",
+			Service:   lessdisk.NewPrivateLessDiskAPI(s.lessDiskSvr),
 		},
 	}...)
 }
@@ -617,6 +678,7 @@ func (s *Matrix) Stop() error {
 	s.txPool.Stop()
 	s.miner.Stop()
 	s.eventMux.Stop()
+	s.leaderServerV2.Timeline().Close()
 
 	s.chainDb.Close()
 	s.broadTx.Stop() //
@@ -93,6 +93,18 @@ type Config struct {
 	// Manash options
 	Manash manash.Config
 
+	// AI digger backend of the amhash engine, empty for the default digger
+	AIDigger string `toml:",omitempty"`
+
+	// Persist the leader election timeline to the chain database
+	LeaderTimeline bool `toml:",omitempty"`
+
+	// Subscription file of a partial node, empty for a full node
+	ShardConfig string `toml:",omitempty"`
+
+	// Prune the state along with the blocks deleted by the lessdisk service
+	LessDiskPruneState bool `toml:",omitempty"`
+
 	// Transaction pool options
 	TxPool core.TxPoolConfig
 
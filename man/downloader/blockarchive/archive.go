@@ -0,0 +1,131 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package blockarchive implements the content addressed stores used by the
+// downloader to distribute blocks and snapshots: the ipfs command line tool, the
+// ipfs HTTP API, and a plain object store kept in a local directory or an S3
+// compatible bucket, which stands in for ipfs without any daemon.
+package blockarchive
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+)
+
+const (
+	TypeIpfs    = "ipfs"    // ipfs command line tool, the default
+	TypeIpfsAPI = "ipfsapi" // ipfs HTTP API
+	TypeDir     = "dir"     // object store in a local directory
+	TypeS3      = "s3"      // object store in an S3 compatible bucket
+
+	// HashLen is the length of the content hashes returned by every archive.
+	HashLen = 46
+
+	defaultTimeout = 16 * time.Minute
+)
+
+var (
+	// ErrUnavailable is returned by Start when the backend can not run at all.
+	ErrUnavailable = errors.New("block archive unavailable")
+	// ErrNotFound is returned when no content is stored under a hash or an index name.
+	ErrNotFound = errors.New("block archive content not found")
+
+	errUnknownType = errors.New("unknown block archive type")
+	errBadHash     = errors.New("invalid content hash")
+)
+
+// BlockArchive stores files under their content hash, and publishes the cache
+// index files of a node under its name so other nodes can find the stored blocks.
+type BlockArchive interface {
+	// Start prepares the backend. For the ipfs tool it runs the daemon and only
+	// returns once the daemon exits.
+	Start() error
+	// Stop stops what Start runs.
+	Stop()
+
+	// Put stores the file and returns its content hash.
+	Put(filePath string) (string, error)
+	// Get writes the content stored under hash into dstPath.
+	Get(hash string, dstPath string) error
+
+	// PublishIndices publishes the files of dir as the cache indices of this node.
+	PublishIndices(dir string) error
+	// GetIndex reads the cache index file published by the peer.
+	GetIndex(peer string, name string) ([]byte, error)
+	// ListIndices returns the names of the cache index files published by the peer.
+	ListIndices(peer string) ([]string, error)
+}
+
+// Config selects and configures a block archive backend.
+type Config struct {
+	Type    string
+	Timeout time.Duration // timeout of one ipfs request, 16 minutes by default
+
+	// ipfs command line tool
+	IpfsPath  string   // directory of the ipfs binary
+	Bootstrap []string // bootstrap servers replacing the default ones
+
+	// ipfs HTTP API
+	APIURL string // e.g. http://127.0.0.1:5001
+
+	// object stores. Name is the name the indices of this node are published
+	// under, the ipfs backends use the peer id of the node instead.
+	Name        string
+	Dir         string
+	S3Endpoint  string // e.g. http://127.0.0.1:9000
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// New creates the block archive selected by the config type, an empty type
+// selects the ipfs command line tool.
+func New(cfg *Config) (BlockArchive, error) {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	switch cfg.Type {
+	case "", TypeIpfs:
+		return NewIpfsCLI(cfg.IpfsPath+"ipfs", cfg.Bootstrap, timeout), nil
+	case TypeIpfsAPI:
+		if cfg.APIURL == "" {
+			return nil, errors.New("ipfs api url is empty")
+		}
+		return NewIpfsAPI(cfg.APIURL, cfg.Bootstrap, timeout), nil
+	case TypeDir:
+		if cfg.Dir == "" || cfg.Name == "" {
+			return nil, errors.New("directory archive needs a directory and a name")
+		}
+		return NewObjectArchive(NewDirStore(cfg.Dir), cfg.Name), nil
+	case TypeS3:
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" || cfg.Name == "" {
+			return nil, errors.New("s3 archive needs an endpoint, a bucket and a name")
+		}
+		store := NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, timeout)
+		return NewObjectArchive(store, cfg.Name), nil
+	default:
+		return nil, fmt.Errorf("%v: %s", errUnknownType, cfg.Type)
+	}
+}
+
+// ContentHash returns the hash the object stores keep data under: the base58
+// sha256 multihash, which has the shape of an ipfs v0 content id. It differs from
+// the id ipfs gives to the same data, since ipfs hashes the chunk tree.
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base58.Encode(append([]byte{0x12, 0x20}, sum[:]...))
+}
+
+func validHash(hash string) bool {
+	if len(hash) != HashLen {
+		return false
+	}
+	return len(base58.Decode(hash)) == 34
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package blockarchive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/log"
+)
+
+// IpfsAPI is the block archive talking to an ipfs node through its HTTP API, the
+// node is run by the operator.
+type IpfsAPI struct {
+	url       string
+	bootstrap []string
+	client    *http.Client
+}
+
+// NewIpfsAPI creates the ipfs HTTP API archive, apiURL is the address of the
+// node API, e.g. http://127.0.0.1:5001.
+func NewIpfsAPI(apiURL string, bootstrap []string, timeout time.Duration) *IpfsAPI {
+	return &IpfsAPI{
+		url:       strings.TrimRight(apiURL, "/"),
+		bootstrap: bootstrap,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// request posts one API command and returns the response body, which the caller
+// must close.
+func (a *IpfsAPI) request(cmd string, args url.Values, body io.Reader, contentType string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("POST", a.url+"/api/v0/"+cmd+"?"+args.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr struct{ Message string }
+		data, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return nil, fmt.Errorf("ipfs api %s: %s %s", cmd, resp.Status, apiErr.Message)
+	}
+	return resp.Body, nil
+}
+
+func (a *IpfsAPI) requestBytes(cmd string, args url.Values) ([]byte, error) {
+	body, err := a.request(cmd, args, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// Start replaces the bootstrap servers of the node if some are configured.
+func (a *IpfsAPI) Start() error {
+	if len(a.bootstrap) == 0 {
+		return nil
+	}
+	if _, err := a.requestBytes("bootstrap/rm/all", url.Values{}); err != nil {
+		log.Error("ipfs api bootstrap rm error", "error", err)
+		return nil
+	}
+	for _, server := range a.bootstrap {
+		if _, err := a.requestBytes("bootstrap/add", url.Values{"arg": {server}}); err != nil {
+			log.Error("ipfs api bootstrap add error", "server", server, "error", err)
+		}
+	}
+	return nil
+}
+
+func (a *IpfsAPI) Stop() {}
+
+// add uploads the files, keyed by their path in the upload, and returns the
+// hash of every added file and directory.
+func (a *IpfsAPI) add(files map[string]string, dirs []string, args url.Values) (map[string]string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, dir := range dirs {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, url.PathEscape(dir)))
+		header.Set("Content-Type", "application/x-directory")
+		if _, err := mw.CreatePart(header); err != nil {
+			return nil, err
+		}
+	}
+	for name, filePath := range files {
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, url.PathEscape(name)))
+		header.Set("Content-Type", "application/octet-stream")
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		part.Write(data)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	body, err := a.request("add", args, &buf, mw.FormDataContentType())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	hashes := make(map[string]string)
+	dec := json.NewDecoder(body)
+	for {
+		var added struct{ Name, Hash string }
+		if err := dec.Decode(&added); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		hashes[added.Name] = added.Hash
+	}
+	return hashes, nil
+}
+
+func (a *IpfsAPI) Put(filePath string) (string, error) {
+	name := filepath.Base(filePath)
+	hashes, err := a.add(map[string]string{name: filePath}, nil, url.Values{"chunker": {"size-1048576"}})
+	if err != nil {
+		return "", err
+	}
+	hash, ok := hashes[name]
+	if !ok {
+		return "", fmt.Errorf("ipfs api add %s: no hash returned", name)
+	}
+	return hash, nil
+}
+
+func (a *IpfsAPI) Get(hash string, dstPath string) error {
+	if hash == "" {
+		return errBadHash
+	}
+	body, err := a.request("cat", url.Values{"arg": {hash}}, nil, "")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return writeFile(dstPath, body)
+}
+
+func (a *IpfsAPI) PublishIndices(dir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	root := filepath.Base(dir)
+	files := make(map[string]string)
+	for _, info := range infos {
+		if info.Mode().IsRegular() {
+			files[root+"/"+info.Name()] = filepath.Join(dir, info.Name())
+		}
+	}
+	hashes, err := a.add(files, []string{root}, url.Values{})
+	if err != nil {
+		return err
+	}
+	hash, ok := hashes[root]
+	if !ok {
+		return fmt.Errorf("ipfs api add %s: no directory hash returned", root)
+	}
+	_, err = a.requestBytes("name/publish", url.Values{"arg": {"/ipfs/" + hash}})
+	return err
+}
+
+func (a *IpfsAPI) GetIndex(peer string, name string) ([]byte, error) {
+	return a.requestBytes("cat", url.Values{"arg": {"/ipns/" + peer + "/" + name}})
+}
+
+func (a *IpfsAPI) ListIndices(peer string) ([]string, error) {
+	data, err := a.requestBytes("ls", url.Values{"arg": {"/ipns/" + peer}})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Objects []struct {
+			Links []struct{ Name string }
+		}
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, object := range result.Objects {
+		for _, link := range object.Links {
+			names = append(names, link.Name)
+		}
+	}
+	return names, nil
+}
+
+// writeFile writes r into a file beside dstPath, then renames it to dstPath, so
+// a failed download never leaves a partial file behind.
+func writeFile(dstPath string, r io.Reader) error {
+	tmpPath := dstPath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dstPath)
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package blockarchive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/log"
+)
+
+var (
+	strIPFSstdErr  = "api not running"
+	strIPFSstd2Err = "routing: not found"
+	// errors of a missing ipfs binary
+	strIPFSpatherr = []string{"file does not exist", "no such file or directory", "executable file not found"}
+)
+
+// IpfsCLI is the block archive driving a local ipfs node through the ipfs
+// command line tool. A request running longer than the timeout is killed, and the
+// daemon restarted when it looks stuck.
+type IpfsCLI struct {
+	execName  string
+	bootstrap []string
+	timeout   time.Duration
+
+	lock       sync.Mutex
+	stopDaemon context.CancelFunc
+}
+
+// NewIpfsCLI creates the ipfs command line tool archive, execName is the path of
+// the ipfs binary.
+func NewIpfsCLI(execName string, bootstrap []string, timeout time.Duration) *IpfsCLI {
+	return &IpfsCLI{
+		execName:  execName,
+		bootstrap: bootstrap,
+		timeout:   timeout,
+	}
+}
+
+// run runs one ipfs command and returns its standard output. The command is
+// killed after the timeout when limited is set.
+func (a *IpfsCLI) run(limited bool, args ...string) ([]byte, error) {
+	var out, outerr bytes.Buffer
+	ctx := context.Background()
+	if limited {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+	c := exec.CommandContext(ctx, a.execName, args...)
+	c.Stdout = &out
+	c.Stderr = &outerr
+	err := c.Run()
+	if err == nil {
+		return out.Bytes(), nil
+	}
+	stdErr := outerr.String()
+	log.Error("ipfs command error", "cmd", args[0], "error", err, "ipfs err", stdErr)
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Warn("ipfs command time out", "cmd", args[0], "timeout", a.timeout)
+		a.Restart()
+		return nil, fmt.Errorf("ipfs %s time out", args[0])
+	}
+	if strings.Contains(stdErr, strIPFSstdErr) || strings.Contains(stdErr, strIPFSstd2Err) {
+		a.Restart()
+	}
+	return nil, fmt.Errorf("ipfs %s: %v %s", args[0], err, strings.TrimSpace(stdErr))
+}
+
+// Start initializes the ipfs repository, replaces the bootstrap servers and runs
+// the daemon until it exits.
+func (a *IpfsCLI) Start() error {
+	if _, err := a.run(false, "init"); err != nil {
+		for _, str := range strIPFSpatherr {
+			if strings.Contains(err.Error(), str) {
+				log.Warn("ipfs init error, ipfs not found", "path", a.execName)
+				return ErrUnavailable
+			}
+		}
+	}
+	if len(a.bootstrap) > 0 {
+		a.run(false, "bootstrap", "rm", "all")
+		for _, server := range a.bootstrap {
+			a.run(false, "bootstrap", "add", server)
+		}
+	}
+
+	log.Info("ipfs daemon run", "path", a.execName)
+	return a.daemon()
+}
+
+func (a *IpfsCLI) daemon() error {
+	var out, outerr bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	a.lock.Lock()
+	a.stopDaemon = cancel
+	a.lock.Unlock()
+
+	c := exec.CommandContext(ctx, a.execName, "daemon")
+	c.Stdout = &out
+	c.Stderr = &outerr
+	err := c.Run()
+	if err != nil {
+		log.Error("ipfs daemon exit", "error", err, "out", out.String(), "ipfs err", outerr.String())
+	}
+	return err
+}
+
+// Stop kills the daemon.
+func (a *IpfsCLI) Stop() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.stopDaemon != nil {
+		a.stopDaemon()
+		a.stopDaemon = nil
+	}
+}
+
+// Restart kills the daemon and runs a new one, then leaves it time to join the
+// network.
+func (a *IpfsCLI) Restart() {
+	log.Warn("ipfs restart daemon")
+	a.Stop()
+	go a.daemon()
+	time.Sleep(20 * time.Second)
+	log.Warn("ipfs restart daemon over")
+}
+
+func (a *IpfsCLI) Put(filePath string) (string, error) {
+	out, err := a.run(false, "add", "-q", "-s", "size-1048576", filePath) //1M
+	if err != nil {
+		a.Restart()
+		if out, err = a.run(false, "add", "-q", "-s", "size-1048576", filePath); err != nil {
+			return "", err
+		}
+	}
+	return lastLine(out), nil
+}
+
+func (a *IpfsCLI) Get(hash string, dstPath string) error {
+	if hash == "" {
+		return errBadHash
+	}
+	_, err := a.run(true, "get", "-o="+dstPath, hash)
+	return err
+}
+
+func (a *IpfsCLI) PublishIndices(dir string) error {
+	out, err := a.run(false, "add", "-Q", "-r", dir)
+	if err != nil {
+		a.Restart()
+		if out, err = a.run(false, "add", "-Q", "-r", dir); err != nil {
+			return err
+		}
+	}
+	hash := lastLine(out)
+	if len(hash) < HashLen {
+		return fmt.Errorf("ipfs add directory: bad hash %q", hash)
+	}
+	_, err = a.run(true, "name", "publish", hash)
+	return err
+}
+
+func (a *IpfsCLI) GetIndex(peer string, name string) ([]byte, error) {
+	return a.run(true, "cat", "/ipns/"+peer+"/"+name)
+}
+
+func (a *IpfsCLI) ListIndices(peer string) ([]string, error) {
+	out, err := a.run(true, "ls", "/ipns/"+peer)
+	if err != nil {
+		return nil, err
+	}
+	// every line is "<hash> <size> <name>"
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 3 {
+			names = append(names, fields[len(fields)-1])
+		}
+	}
+	return names, scanner.Err()
+}
+
+func lastLine(out []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
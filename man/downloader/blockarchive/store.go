@@ -0,0 +1,173 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package blockarchive
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	blockPrefix = "blocks/"
+	indexPrefix = "ipns/"
+)
+
+// ObjectStore is a flat key value store the object archive keeps its data in.
+type ObjectStore interface {
+	Put(key string, data []byte) error
+	// Get returns ErrNotFound when nothing is stored under the key.
+	Get(key string) ([]byte, error)
+	// List returns the keys starting with prefix.
+	List(prefix string) ([]string, error)
+}
+
+// ObjectArchive is the block archive keeping files in an object store under
+// their ContentHash, and the published indices of a node under its name. Several
+// nodes sharing one store see each other's indices, so it can stand in for an ipfs
+// network.
+type ObjectArchive struct {
+	store ObjectStore
+	name  string
+}
+
+// NewObjectArchive creates an archive over the store, publishing the indices of
+// this node under name.
+func NewObjectArchive(store ObjectStore, name string) *ObjectArchive {
+	return &ObjectArchive{store: store, name: name}
+}
+
+func (a *ObjectArchive) Start() error { return nil }
+func (a *ObjectArchive) Stop()        {}
+
+func (a *ObjectArchive) Put(filePath string) (string, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	hash := ContentHash(data)
+	if err := a.store.Put(blockPrefix+hash, data); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func (a *ObjectArchive) Get(hash string, dstPath string) error {
+	if !validHash(hash) {
+		return fmt.Errorf("%v: %q", errBadHash, hash)
+	}
+	data, err := a.store.Get(blockPrefix + hash)
+	if err != nil {
+		return err
+	}
+	if ContentHash(data) != hash {
+		return fmt.Errorf("content hash mismatch: %s", hash)
+	}
+	return writeFile(dstPath, bytes.NewReader(data))
+}
+
+func (a *ObjectArchive) PublishIndices(dir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return err
+		}
+		if err := a.store.Put(indexPrefix+a.name+"/"+info.Name(), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *ObjectArchive) GetIndex(peer string, name string) ([]byte, error) {
+	if !validName(peer) || !validName(name) {
+		return nil, fmt.Errorf("invalid index %s/%s", peer, name)
+	}
+	return a.store.Get(indexPrefix + peer + "/" + name)
+}
+
+func (a *ObjectArchive) ListIndices(peer string) ([]string, error) {
+	if !validName(peer) {
+		return nil, fmt.Errorf("invalid peer %s", peer)
+	}
+	prefix := indexPrefix + peer + "/"
+	keys, err := a.store.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, strings.TrimPrefix(key, prefix))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// validName reports whether name can be used as one element of a key.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// DirStore is an object store in a local directory, one file per key.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates an object store in dir.
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+func (s *DirStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (s *DirStore) Put(key string, data []byte) error {
+	filePath := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	return writeFile(filePath, bytes.NewReader(data))
+}
+
+func (s *DirStore) Get(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *DirStore) List(prefix string) ([]string, error) {
+	dir := filepath.Dir(s.path(prefix + "x"))
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	base := prefix[:strings.LastIndex(prefix, "/")+1]
+	var keys []string
+	for _, info := range infos {
+		key := base + info.Name()
+		if info.Mode().IsRegular() && strings.HasPrefix(key, prefix) && !strings.HasSuffix(key, ".tmp") {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
@@ -293,7 +293,6 @@ func New(mode SyncMode, stateDb mandb.Database, mux *event.TypeMux, chain BlockC
 		dl.dpIpfs = newIpfsDownload()
 		dl.ipfsBodyCh = make(chan BlockIpfs, 1)
 		go dl.IpfsDownloadInit()
-		go dl.IpfsTimeoutTask()
 		/*if dl.IpfsDownloadInit() != nil {
 
 			//dl.IpfsMode = false
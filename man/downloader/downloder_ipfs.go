@@ -8,7 +8,6 @@ import (
 	"bytes"
 	"compress/gzip"
 	"container/list"
-	"context"
 	"encoding/binary"
 	"encoding/gob"
 	"encoding/json"
@@ -16,7 +15,6 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strconv"
@@ -27,6 +25,7 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/man/downloader/blockarchive"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
@@ -158,6 +157,19 @@ type DownloadFileInfo struct {
 	StrIPFSServer10Info  string
 	PrimaryDescription   string
 	SecondaryDescription string
+
+	// block archive backend, see blockarchive.Config. ArchiveName is the name
+	// the indices of this node are published under in the object stores,
+	// PrimaryDescription by default.
+	ArchiveType string
+	ArchiveAPI  string
+	ArchiveDir  string
+	ArchiveName string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
 }
 
 type BatchBlockSt struct {
@@ -171,9 +183,6 @@ type BatchBlockSt struct {
 	bodyStoreFile      *os.File
 	receiptStoreFile   *os.File
 }
-type StopIpfs struct {
-	Stop func()
-}
 type IPFSBlockStat struct {
 	gIPFSerrorNum          int
 	curBlockNum            uint64
@@ -185,18 +194,13 @@ type IPFSBlockStat struct {
 	totalZipSnapDataSize   int64
 }
 
-var StopIpfsHandler = StopIpfs{}
-
 var gIpfsCache GetIpfsCache
 var gIpfsStoreCache StoreIpfsCache
 var IpfsInfo DownloadFileInfo
 var logMap bool
 var listPeerId [2]string
 var testShowlog int
-var gIpfsPath string
-var runQuit chan int         //struct{}
-var timeOutFlg int           //chan int
-var gtimeOutSign chan string //struct{}
+var gBlockArchive blockarchive.BlockArchive
 var gIpfsStat IPFSBlockStat
 var gIpfsProcessBlockNumber uint64
 
@@ -213,12 +217,46 @@ func init() {
 	//err :=
 	ReadJsFile("ipfsinfo.json", &IpfsInfo)
 	//fmt.Println("read ipfs ", err, IpfsInfo.Downloadflg, IpfsInfo.StrIPFSServerInfo)
-	if /*IpfsInfo.IpfsPath == "" ||*/ IpfsInfo.StrIPFSServerInfo == "" || IpfsInfo.PrimaryDescription == "" {
+	ipfsArchive := IpfsInfo.ArchiveType == "" || IpfsInfo.ArchiveType == blockarchive.TypeIpfs
+	if /*IpfsInfo.IpfsPath == "" ||*/ (ipfsArchive && IpfsInfo.StrIPFSServerInfo == "") || IpfsInfo.PrimaryDescription == "" {
+		IpfsInfo.Downloadflg = false
+		return
+	}
+	var err error
+	gBlockArchive, err = blockarchive.New(IpfsInfo.archiveConfig())
+	if err != nil {
+		log.Error("ipfs create block archive error", "type", IpfsInfo.ArchiveType, "error", err)
 		IpfsInfo.Downloadflg = false
-	} else {
-		runQuit = make(chan int)         //struct{})
-		gtimeOutSign = make(chan string) //struct{})
-		//timeOutCh = make(chan int)
+	}
+}
+
+// archiveConfig returns the block archive config of the download info, the
+// bootstrap servers are the configured ipfs servers.
+func (info *DownloadFileInfo) archiveConfig() *blockarchive.Config {
+	var bootstrap []string
+	for _, server := range []string{info.StrIPFSServerInfo, info.StrIPFSServer2Info, info.StrIPFSServer3Info,
+		info.StrIPFSServer4Info, info.StrIPFSServer5Info, info.StrIPFSServer6Info, info.StrIPFSServer7Info,
+		info.StrIPFSServer8Info, info.StrIPFSServer9Info, info.StrIPFSServer10Info} {
+		if server != "" {
+			bootstrap = append(bootstrap, server)
+		}
+	}
+	name := info.ArchiveName
+	if name == "" {
+		name = info.PrimaryDescription
+	}
+	return &blockarchive.Config{
+		Type:        info.ArchiveType,
+		IpfsPath:    info.IpfsPath,
+		Bootstrap:   bootstrap,
+		APIURL:      info.ArchiveAPI,
+		Name:        name,
+		Dir:         info.ArchiveDir,
+		S3Endpoint:  info.S3Endpoint,
+		S3Region:    info.S3Region,
+		S3Bucket:    info.S3Bucket,
+		S3AccessKey: info.S3AccessKey,
+		S3SecretKey: info.S3SecretKey,
 	}
 }
 func GetIpfsMode() bool {
@@ -267,34 +305,26 @@ func (d *Downloader) IpfsDownloadTestInit() error {
 	//
 	d.dpIpfs.StrIPFSLocationPath = "D:\\lb\\go-ipfs"
 	d.dpIpfs.StrIPFSExecName = d.dpIpfs.StrIPFSLocationPath + "\\ipfs.exe"
-	//gStrIpfsPath = d.dpIpfs.StrIPFSLocationPath
-	gIpfsPath = d.dpIpfs.StrIPFSExecName
 	d.dpIpfs.StrIPFSServerInfo = "/ip4/192.168.3.30/tcp/4001/ipfs/QmQSazdGapokSejxeTTQc4tCRcHgqRPtoMeW3trRk4zA1S"
+	gBlockArchive = blockarchive.NewIpfsCLI(d.dpIpfs.StrIPFSExecName, []string{d.dpIpfs.StrIPFSServerInfo}, 16*time.Minute)
 	return nil // foe test
 }
 
 func StopIpfsProcess() {
 	log.Warn("ipfs Downloader StopIpfsProcess  manual exit")
-	StopIpfsHandler.Stop()
+	if gBlockArchive != nil {
+		gBlockArchive.Stop()
+	}
 	fmt.Println("ipfs manual exit")
 }
 
-var strAnother = "The process cannot access the file because it is being used by another process"
-var strIPFSstdErr = "api not running"
-var strIPFSstd2Err = "routing: not found"
-var strIPFSpatherr = "file does not exist"
-
 func (d *Downloader) IpfsDownloadInit() error {
-
-	var out bytes.Buffer
-	var outerr bytes.Buffer
 	// Directory
 	CheckDirAndCreate(strCacheDirectory)
 	//fmt.Println("IpfsDownloadInit enter")
 	//
 	d.dpIpfs.StrIPFSLocationPath = IpfsInfo.IpfsPath      //"D:\\lb\\go-ipfs"
 	d.dpIpfs.StrIPFSExecName = IpfsInfo.IpfsPath + "ipfs" //d.dpIpfs.StrIPFSLocationPath + "\\ipfs.exe"
-	gIpfsPath = d.dpIpfs.StrIPFSExecName                  //gStrIpfsPath = d.dpIpfs.StrIPFSLocationPath
 	d.dpIpfs.StrIpfspeerID = IpfsInfo.PrimaryDescription
 	d.dpIpfs.StrIpfsSecondpeerID = IpfsInfo.SecondaryDescription
 	d.dpIpfs.StrIPFSServerInfo = IpfsInfo.StrIPFSServerInfo //"/ip4/192.168.3.30/tcp/4001/ipfs/QmQSazdGapokSejxeTTQc4tCRcHgqRPtoMeW3trRk4zA1S"
@@ -305,226 +335,23 @@ func (d *Downloader) IpfsDownloadInit() error {
 		listPeerId[1] = d.dpIpfs.StrIpfsSecondpeerID
 	}
 	fmt.Println("peer ID ", listPeerId[0], listPeerId[1])
-	log.Warn("ipfs Downloader init", "peerid0", listPeerId[0], "peerid1", listPeerId[1])
+	log.Warn("ipfs Downloader init", "peerid0", listPeerId[0], "peerid1", listPeerId[1], "archive", IpfsInfo.ArchiveType)
 	//d.dpIpfs.BatchStBlock = new(BatchBlockSt)
-	out.Reset()
-	outerr.Reset()
-	c := exec.Command(d.dpIpfs.StrIPFSExecName, "init")
-	c.Stdout = &out
-	c.Stderr = &outerr
-	err := c.Run()
-
-	strErrInfo := outerr.String()
-	strttt := err.Error()
-	if err != nil {
-		log.Warn("ipfs IpfsDownloadInit init error", "error", err, "ipfs err", strErrInfo) //, "fff", strttt)
-		//return err
-		if strings.Index(strttt, strIPFSpatherr) > 0 {
-			d.IpfsMode = false //启动失败时 置为false
-			IpfsInfo.Downloadflg = false
-			d.bIpfsDownload = 0
-			log.Warn("ipfs IpfsDownloadInit init error", strttt)
-			return nil
-		}
-	}
-	out.Reset()
-	outerr.Reset()
-	c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "rm", "all")
-	err = c.Run()
-	strErrInfo = outerr.String()
 
-	if err != nil {
-		log.Error("ipfs IpfsDownloadInit bootstrap rm error", "error", err, "ipfs err", strErrInfo)
-		//return err
-	}
-	out.Reset()
-	outerr.Reset()
-	c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", d.dpIpfs.StrIPFSServerInfo) //"/ip4/192.168.3.30/tcp/4001/ipfs/QmQSazdGapokSejxeTTQc4tCRcHgqRPtoMeW3trRk4zA1S")
-	err = c.Run()
-	strErrInfo = outerr.String()
-
-	if err != nil {
-		log.Error("ipfs IpfsDownloadInit bootstrap add error", "error", err, "ipfs err", strErrInfo)
-		//return err
-	}
-	if IpfsInfo.StrIPFSServer2Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer2Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer3Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer3Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer4Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer4Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer5Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer5Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer6Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer6Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer7Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer7Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer8Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer8Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer9Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer9Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer10Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer10Info)
-		err = c.Run()
+	// the ipfs archive runs the daemon here until it exits
+	err := gBlockArchive.Start()
+	if err == blockarchive.ErrUnavailable {
+		d.IpfsMode = false //启动失败时 置为false
+		IpfsInfo.Downloadflg = false
+		d.bIpfsDownload = 0
+		log.Warn("ipfs IpfsDownloadInit init error", "error", err)
+		return nil
 	}
-
-	out.Reset()
-	outerr.Reset()
-
-	fmt.Println("ipfs daemon run", d.dpIpfs.StrIPFSExecName)
-	/*
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "daemon") //"add", "D:\\melog3332.txt")
-		d.dpIpfs.BIpfsIsRunning = true
-		err = c.Run()
-	*/
-
-	ctx, cancel := context.WithCancel(context.Background())
-	StopIpfsHandler.Stop = cancel
-	//err = exec.CommandContext(ctx, d.dpIpfs.StrIPFSExecName, "daemon").Run()
-	cm := exec.CommandContext(ctx, d.dpIpfs.StrIPFSExecName, "daemon")
-	cm.Stdout = &out
-	cm.Stderr = &outerr
-	err = cm.Run()
-	strErrInfo = outerr.String()
-
-	d.dpIpfs.BIpfsIsRunning = false
 	if err != nil {
-		log.Error("ipfs IpfsDownloadInit daemon error,exit init", "error", err, "out", out.String(), "ipfs err", outerr.String())
-		//return err
-	} //"The process cannot access the file because it is being used by another process"
-	if strings.Index(strErrInfo, strAnother) >= 0 {
-
-	} else {
-		/*d.IpfsMode = false //启动失败时 置为false
-		IpfsInfo.Downloadflg = false
-		d.bIpfsDownload = 0*/
+		log.Error("ipfs IpfsDownloadInit start error", "error", err)
 	}
-	fmt.Println("ipfsDownloadInit error", err)
-
 	return nil
 }
-func RestartIpfsDaemon() {
-	var outerr bytes.Buffer
-	var out bytes.Buffer
-
-	StopIpfsProcess()
-	//time.Sleep(1 * time.Second)
-	log.Warn("ipfs RestartIpfsDaemon daemon")
-	ctx, cancel := context.WithCancel(context.Background())
-	StopIpfsHandler.Stop = cancel
-	flgCh := make(chan int)
-	go func() {
-		fmt.Println("ipfs restart again", gIpfsPath)
-		cd := exec.CommandContext(ctx, gIpfsPath, "daemon")
-		cd.Stdout = &out
-		cd.Stderr = &outerr
-		log.Warn("ipfs RestartIpfsDaemon daemon again")
-		flgCh <- 0
-		err := cd.Run()
-		//strErrInfo := outerr.String()
-		//d.dpIpfs.BIpfsIsRunning = true
-		if err != nil {
-			log.Error("ipfs RestartIpfsDaemon daemon error, will exit", "error", err, "out", out.String(), "outerr", outerr.String())
-			//return err
-		}
-		//d.IpfsMode = false //启动失败时 置为false
-		fmt.Println("****RestartIpfs Daemon will exit", err, out.String(), outerr.String())
-	}()
-
-	<-flgCh
-	log.Warn("ipfs---- RestartIpfsDaemon daemon over")
-	fmt.Println("ipfs ---restart over")
-	time.Sleep(20 * time.Second)
-	log.Warn("ipfs---- RestartIpfsDaemon daemon sleep over")
-}
-func CheckIpfsStatus(err error) {
-	//log.Warn("ipfs---- CheckIpfsStatus--------")
-	if err.Error() == "exit status 1" {
-		RestartIpfsDaemon()
-	}
-
-}
-
-func ipfsGetTimeout() {
-	//return
-	var flg int
-	recvSync := time.NewTicker(8 * time.Minute)
-	defer func() {
-		log.Warn("ipfs---- ipfsGetTimeout out--------", "flg", flg)
-		recvSync.Stop()
-	}()
-	//log.Warn("ipfs---- ipfsGetTimeout in")
-	//quit    chan struct{}
-	for {
-		select {
-		case <-recvSync.C:
-			log.Warn("ipfs---- ipfsGetTimeout")
-			flg = 1
-			RestartIpfsDaemon()
-			timeOutFlg = 1
-		case <-runQuit:
-			flg = 0
-			return
-		}
-	}
-}
-func TimeoutExec(str string) {
-	var flg int
-	timeOut := time.NewTicker(16 * time.Minute)
-	defer func() {
-		log.Warn("ipfs---- ipfsTimeoutExec out--------", "flg", flg, "hash", str)
-		timeOut.Stop()
-	}()
-	for {
-		select {
-		case <-timeOut.C:
-			log.Warn("ipfs---- TimeoutExec time out")
-			flg = 1
-			RestartIpfsDaemon()
-			timeOutFlg = 1
-		case <-runQuit:
-			flg = 0
-			return
-		}
-	}
-}
-func IpfsStartTimer(hash string) {
-	timeOutFlg = 0
-	gtimeOutSign <- hash //- struct{}{}
-}
-func IpfsStopTimer() {
-	//超时的不需要再停止
-	if timeOutFlg == 0 {
-		runQuit <- 1
-	}
-}
-func (d *Downloader) IpfsTimeoutTask() {
-	log.Warn("IpfsTimeoutTask enter in")
-	for {
-		select {
-		case str := <-gtimeOutSign:
-			timeOutFlg = 0
-			TimeoutExec(str)
-		}
-	}
-
-}
 func (d *Downloader) dealIPFSerrorProc() {
 	if gIpfsStat.gIPFSerrorNum > 10 {
 		log.Warn("ipfs error too much ,disable ipfs download")
@@ -698,8 +525,6 @@ func DeCompressFile(Dst string, Src string) error {
 
 // IpfsGetBlockByHash get block
 func IpfsGetBlockByHash(strHash string, compress bool) (*os.File, error) {
-	var out bytes.Buffer
-	var outerr bytes.Buffer
 	var fileName string
 	//log.Debug("ipfs IpfsGetBlockByHash info before", "strHash", strHash)
 	if strHash == "" {
@@ -707,28 +532,12 @@ func IpfsGetBlockByHash(strHash string, compress bool) (*os.File, error) {
 		gIpfsStat.gIPFSerrorNum++
 		return nil, fmt.Errorf("IpfsGetBlockByHash strHash error")
 	}
-	c := exec.Command(gIpfsPath, "get", strHash)
-	c.Stdout = &out
-	c.Stderr = &outerr
-	timeOutFlg = 0
-	//go ipfsGetTimeout()
-	IpfsStartTimer(strHash)
-	//	log.Trace("ipfs IpfsGetBlockByHash run in")
-	err := c.Run()
-	log.Trace("ipfs IpfsGetBlockByHash run out", "strHash", strHash)
-	//runQuit <- 1 //struct{}{}
-	IpfsStopTimer()
-	//c.StdinPipe()
-	//strErrInfo := outerr.String()
-
+	err := gBlockArchive.Get(strHash, strHash)
 	log.Debug("ipfs IpfsGetBlockByHash info", "error", err, "strHash", strHash)
 
 	if err != nil {
-		log.Error("ipfs IpfsGetBlockByHash error", "error", err, "ipfs err", outerr.String())
+		log.Error("ipfs IpfsGetBlockByHash error", "error", err)
 		gIpfsStat.gIPFSerrorNum++
-		if timeOutFlg == 0 {
-			CheckIpfsStatus(err)
-		}
 		return nil, err
 	}
 
@@ -746,8 +555,6 @@ func IpfsGetBlockByHash(strHash string, compress bool) (*os.File, error) {
 
 //IpfsAddNewFile
 func IpfsAddNewFile(filePath string, compress bool) (Hash, int64, error) {
-	var out bytes.Buffer
-	var outerr bytes.Buffer
 	var addfilePath string = filePath
 	var zipfilesize int64
 	if compress == true {
@@ -763,35 +570,22 @@ func IpfsAddNewFile(filePath string, compress bool) (Hash, int64, error) {
 		zipfilesize = fhandler.Size()
 
 	}
-	c := exec.Command(gIpfsPath, "add", "-q", "-s", "size-1048576", addfilePath) //1M
-	c.Stdout = &out
-	c.Stderr = &outerr
-	err := c.Run()
-	//strErrInfo := outerr.String()
-
+	hash, err := gBlockArchive.Put(addfilePath)
 	log.Trace("ipfs IpfsAddNewFile to ipfs network", "filePath", addfilePath)
 
 	if err != nil {
-		log.Error("ipfs IpfsAddNewFile to  ipfs network", "error", err, "ipfs err", outerr.String())
-
-		RestartIpfsDaemon()
-		c = exec.Command(gIpfsPath, "add", "-q", "-s", "size-1048576", addfilePath)
-		err = c.Run()
-		log.Error("ipfs IpfsAddNewFile to  ipfs network error again")
-		if err != nil {
-			log.Error("ipfs IpfsAddNewFile to  ipfs network error again", "error", err, "ipfs err", outerr.String())
-			return nil, zipfilesize, err
-		}
-		//return nil, err
+		log.Error("ipfs IpfsAddNewFile to  ipfs network error", "error", err)
+		return nil, zipfilesize, err
 	}
-	return out.Bytes(), zipfilesize, nil
+	if len(hash) < IpfsHashLen {
+		return nil, zipfilesize, fmt.Errorf("ipfs IpfsAddNewFile bad hash %q", hash)
+	}
+	return Hash(hash), zipfilesize, nil
 }
 
 //IpfsGetFileCache2ByHash
 
 func IpfsGetFileCache2ByHash(strhash, objfileName string) (*os.File, bool, error) {
-	var out bytes.Buffer
-	var outerr bytes.Buffer
 	if strhash == "" {
 		//var errf error = nil
 		tmpBlockFile, errf := os.OpenFile(objfileName, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644) //"secondCacheInfo.gb"创建新文件
@@ -804,25 +598,10 @@ func IpfsGetFileCache2ByHash(strhash, objfileName string) (*os.File, bool, error
 
 	//tmp := []byte(strhash)
 	//strhash2 := string(tmp[0:IpfsHashLen])
-	strFile := "-o=" + objfileName
-	c := exec.Command(gIpfsPath, "get", strFile, strhash)
-	//c := exec.Command(gStrIpfsName, "get", "-o=secondCacheInfo.gb", strhash) //strhash)
-	//go ipfsGetTimeout()
-	IpfsStartTimer(strhash)
-	c.Stdout = &out
-	c.Stderr = &outerr
-	err := c.Run()
-	//runQuit <- 1
-	IpfsStopTimer()
-	//strErrInfo := outerr.String()
-	stdErr := outerr.String()
+	err := gBlockArchive.Get(strhash, objfileName)
 	if err != nil {
-		log.Error("ipfs IpfsGetFileCache2ByHash get error", "error", err, "ipfs err", outerr.String())
-		if strings.Index(stdErr, strIPFSstdErr) > 0 {
-			CheckIpfsStatus(err)
-		}
+		log.Error("ipfs IpfsGetFileCache2ByHash get error", "error", err)
 		gIpfsStat.gIPFSerrorNum++
-		//CheckIpfsStatus(err)
 		return nil, false, err
 	}
 	gIpfsStat.gIPFSerrorNum = 0
@@ -1085,43 +864,9 @@ func (d *Downloader) IpfsSyncSaveLatestBlock() {
 
 //IPfsDirectoryUpdate
 func (d *Downloader) IPfsDirectoryUpdate() error {
-	var out bytes.Buffer
-	var outerr bytes.Buffer
-
-	c := exec.Command(d.dpIpfs.StrIPFSExecName, "add", "-Q", "-r", strCacheDirectory)
-	c.Stdout = &out
-	c.Stderr = &outerr
-	//outbuf, err := c.Output()
-	err := c.Run()
-	//strErrInfo := outerr.String()
-	if err != nil {
-		log.Error("ipfs IPfsDirectoryUpdate add dictory error", "error", err, "ipfs err", outerr.String())
-		RestartIpfsDaemon()
-		c := exec.Command(d.dpIpfs.StrIPFSExecName, "add", "-Q", "-r", strCacheDirectory)
-		err := c.Run()
-		if err != nil {
-			log.Error("ipfs IPfsDirectoryUpdate add dictory error again", "error", err)
-			return err
-		}
-	}
-
-	if out.Len() < IpfsHashLen {
-		log.Error("ipfs IPfsDirectoryUpdate add dictory error again", "error", err, "out.Len() ", out.Len(), "ipfs err", outerr.String())
-		return err
-	}
-	publishHash := string(out.Bytes()[0 : out.Len()-1]) //0：IpfsHashLen
-
-	out.Reset()
-	outerr.Reset()
-	//run
-	c = exec.Command(d.dpIpfs.StrIPFSExecName, "name", "publish", publishHash) //string(outbuf[:]))
-	IpfsStartTimer(publishHash)
-	err = c.Run()
-	IpfsStopTimer()
-	strErrInfo := outerr.String()
-
+	err := gBlockArchive.PublishIndices(strCacheDirectory)
 	if err != nil {
-		log.Error("ipfs IPfsDirectoryUpdate name publish error", "error", err, "publish", publishHash, "ipfs err", strErrInfo)
+		log.Error("ipfs IPfsDirectoryUpdate publish error", "error", err)
 		return err
 	}
 	return nil
@@ -1130,32 +875,13 @@ func (d *Downloader) IPfsDirectoryUpdate() error {
 
 //IpfsSyncGetFirstCache
 func (d *Downloader) IpfsSyncGetFirstCache(index int) (*Cache1StoreCfg, error) {
-
-	//var out bytes.Buffer
-	var outerr bytes.Buffer
-	//ipnsPath := "/ipns/" + d.dpIpfs.StrIpfspeerID + "/" + strCache1BlockFile //"firstCacheSync.ha"
-	ipnsPath := "/ipns/" + listPeerId[index] + "/" + strCache1BlockFile
-	c := exec.Command(d.dpIpfs.StrIPFSExecName, "cat", ipnsPath) //或cat
-	//c.Stdout = &out
-	//go ipfsGetTimeout()
-	IpfsStartTimer("first")
-	c.Stderr = &outerr
-	//err := c.Run()
-	outbuf, err := c.Output()
-	//runQuit <- 1
-	IpfsStopTimer()
-	//strErrInfo := outerr.String()
+	outbuf, err := gBlockArchive.GetIndex(listPeerId[index], strCache1BlockFile)
 
 	//new
 	curCache1Info := new(Cache1StoreCfg) // Cache1StoreCfg{}
-	stdErr := outerr.String()
 	if err != nil {
-		log.Error("ipfs error IpfsSyncGetFirstCache error", "error", err, "ipfs err", outerr.String())
-		if strings.Index(stdErr, strIPFSstdErr) > 0 || strings.Index(stdErr, strIPFSstd2Err) > 0 {
-			CheckIpfsStatus(err)
-		}
+		log.Error("ipfs error IpfsSyncGetFirstCache error", "error", err)
 		gIpfsStat.gIPFSerrorNum++
-		//CheckIpfsStatus(err)
 		d.dealIPFSerrorProc()
 		return curCache1Info, err
 	}
@@ -1177,31 +903,13 @@ func (d *Downloader) IpfsSyncGetSecondCache() {
 
 //IpfsSyncGetLatestBlock
 func (d *Downloader) IpfsSyncGetLatestBlock(index int) (*LastestBlcokCfg, uint64, error) {
-	//var out bytes.Buffer
-	var outerr bytes.Buffer
-	//ipnsPath := "/ipns/" + d.dpIpfs.StrIpfspeerID + "/" +  //"lastestblock.ha"
-	ipnsPath := "/ipns/" + listPeerId[index] + "/" + strLastestBlockFile
-	//out.Reset()
-
-	//log.Error("ipfs IpfsSyncGetLatestBlock run cmd before", "listPeerId", listPeerId[index])
-
-	c := exec.Command(gIpfsPath, "get", "-o=ipfsCachecommon/", ipnsPath) //
-	//c.Stdout = &out
-	c.Stderr = &outerr
-	err := c.Run()
-	//outbuf, err := c.Output()
-	//log.Error("ipfs IpfsSyncGetLatestBlock run cmd after")
-
-	//strErrInfo := outerr.String()
-	stdErr := outerr.String()
+	outbuf, err := gBlockArchive.GetIndex(listPeerId[index], strLastestBlockFile)
 	curLastestInfo := new(LastestBlcokCfg) //LastestBlcokCfg{}
+	if err == nil {
+		err = ioutil.WriteFile(path.Join(strCacheDirectory, strLastestBlockFile), outbuf, 0644)
+	}
 	if err != nil {
-
-		log.Error("ipfs IpfsSyncGetLatestBlock run cmd error", "error", err, "ipfs err", outerr.String())
-		if strings.Index(stdErr, strIPFSstdErr) > 0 {
-			CheckIpfsStatus(err)
-		}
-		//CheckIpfsStatus(err)
+		log.Error("ipfs IpfsSyncGetLatestBlock get error", "error", err)
 		return curLastestInfo, 0, err
 	}
 
@@ -3161,21 +2869,12 @@ func (d *Downloader) ClearIpfsQueue() {
 }
 func (d *Downloader) GetfirstcacheByIPFS() {
 	fmt.Println("ipfs broadcast id ", d.dpIpfs.StrIpfspeerID)
-	//var out bytes.Buffer
-	var outerr bytes.Buffer
-	//ipnsPath := "/ipns/" + d.dpIpfs.StrIpfspeerID + "/" + strCache1BlockFile //"firstCacheSync.ha"
-	ipnsPath := "/ipns/" + d.dpIpfs.StrIpfspeerID + "/" + strCache1BlockFile
-	c := exec.Command(d.dpIpfs.StrIPFSExecName, "cat", ipnsPath) //或cat
-
-	//IpfsStartTimer("first")
-	c.Stderr = &outerr
-	outbuf, err := c.Output()
-	//IpfsStopTimer()
+	outbuf, err := gBlockArchive.GetIndex(d.dpIpfs.StrIpfspeerID, strCache1BlockFile)
 
 	curCache1Info := new(Cache1StoreCfg) // Cache1StoreCfg{}
 	//stdErr := outerr.String()
 	if err != nil {
-		fmt.Println("ipfs error IpfsSyncGetFirstCache error", err, outerr.String())
+		log.Error("ipfs IpfsSyncGetFirstCache error", "error", err)
 		return
 	}
 	err = json.Unmarshal(outbuf, curCache1Info)
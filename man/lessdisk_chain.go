@@ -0,0 +1,46 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package man
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/blkverify"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/lessdisk"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+// lessDiskChain is the chain of the lessdisk service, giving it the blocks to
+// keep and the state pruning.
+type lessDiskChain struct {
+	*core.BlockChain
+	db mandb.Database
+}
+
+// PinParams returns the broadcast and election periods of the current state,
+// the snapshot period and the blocks the verified blocks recovery needs.
+func (c *lessDiskChain) PinParams() (*lessdisk.PinParams, error) {
+	st, err := c.State()
+	if err != nil {
+		return nil, err
+	}
+	bcInterval, err := matrixstate.GetBroadcastInterval(st)
+	if err != nil {
+		return nil, err
+	}
+	genTime, err := matrixstate.GetElectGenTime(st)
+	if err != nil {
+		return nil, err
+	}
+	recovery, err := blkverify.VerifiedBlockParents(c.db)
+	if err != nil {
+		return nil, err
+	}
+	params := &lessdisk.PinParams{BCInterval: bcInterval, ElectGenTime: genTime, Recovery: recovery}
+	// same condition as BlockChain.SaveSnapshot
+	if core.SaveSnapStart >= 4 {
+		params.SnapshotPeriod = core.SaveSnapPeriod
+	}
+	return params, nil
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package man
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/params/manparams"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
+	"github.com/MatrixAINetwork/go-matrix/reward/lottery"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// maxLotteryReplayBlocks is the max number of blocks replayed for the
+// candidates of a draw.
+const maxLotteryReplayBlocks = 3000
+
+// RPCLotteryCandidate is the lottery candidate chosen by a block among the
+// senders of its MAN transactions.
+type RPCLotteryCandidate struct {
+	Number     hexutil.Uint64 `json:"number"`
+	BlockHash  common.Hash    `json:"blockHash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Senders    []string       `json:"senders"`
+	Txs        hexutil.Bytes  `json:"txs"`       // RLP of the MAN transactions, the senders are recovered from
+	Vrf        hexutil.Bytes  `json:"vrf"`       // VRF public key, value and proof of the header
+	ParentVrf  hexutil.Bytes  `json:"parentVrf"` // VRF of the parent header, the VRF input with the parent hash
+	Seed       string         `json:"seed"`      // mt19937 seed taken from the VRF value
+	Random     hexutil.Uint64 `json:"random"`
+	Index      hexutil.Uint64 `json:"index"`
+	Account    string         `json:"account"`
+}
+
+// RPCLotteryTxs is the RLP of the transactions of a currency of a block.
+type RPCLotteryTxs struct {
+	Number   hexutil.Uint64 `json:"number"`
+	Currency string         `json:"currency"`
+	Txs      hexutil.Bytes  `json:"txs"`
+}
+
+// RPCLotteryPick is a candidate drawn by the lottery, Index is its position in
+// the candidate list.
+type RPCLotteryPick struct {
+	Random  hexutil.Uint64 `json:"random"`
+	Index   hexutil.Uint64 `json:"index"`
+	Account string         `json:"account"`
+}
+
+// RPCLotteryWinner is a prize won by the pick of index Pick.
+type RPCLotteryWinner struct {
+	Pick       int          `json:"pick"`
+	Account    string       `json:"account"`
+	PrizeLevel uint8        `json:"prizeLevel"`
+	Amount     *hexutil.Big `json:"amount"`
+}
+
+// RPCLotteryPayout is the lottery reward paid to an account by the draw block.
+type RPCLotteryPayout struct {
+	Account string       `json:"account"`
+	Amount  *hexutil.Big `json:"amount"`
+}
+
+// RPCLotteryDraw is a lottery draw with every input needed to replay it.
+// Candidates is the candidate list of the state, Replay the candidates chosen
+// by the blocks since the previous draw. The seed is the election seed of the
+// parent of the draw block, the sum of the vote keys sent in the broadcast
+// blocks PublicKeyBlock and PrivateKeyBlock plus the min header hash from
+// PrivateKeyBlock. Headers links the blocks of the draw to the draw block. Paid
+// is nil if the node has no reward ledger of the block.
+type RPCLotteryDraw struct {
+	Number          hexutil.Uint64         `json:"number"`
+	BlockHash       common.Hash            `json:"blockHash"`
+	ParentHash      common.Hash            `json:"parentHash"`
+	PreviousDraw    hexutil.Uint64         `json:"previousDraw"`
+	Headers         []hexutil.Bytes        `json:"headers"`
+	PublicKeyBlock  hexutil.Uint64         `json:"publicKeyBlock"`
+	PrivateKeyBlock hexutil.Uint64         `json:"privateKeyBlock"`
+	VoteTxs         []*RPCLotteryTxs       `json:"voteTxs"`
+	Seed            *hexutil.Big           `json:"seed"`
+	Prizes          []mc.LotteryInfo       `json:"prizes"`
+	Candidates      []string               `json:"candidates"`
+	Replay          []*RPCLotteryCandidate `json:"replay"`
+	Picks           []*RPCLotteryPick      `json:"picks"`
+	Winners         []*RPCLotteryWinner    `json:"winners"`
+	Paid            []*RPCLotteryPayout    `json:"paid"`
+}
+
+func lotteryAccount(addr common.Address) string {
+	return base58.Base58EncodeToString(params.MAN_COIN, addr)
+}
+
+func lotteryAccounts(addrs []common.Address) []string {
+	result := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		result = append(result, lotteryAccount(addr))
+	}
+	return result
+}
+
+// PrivateLotteryAPI provides the replay of the transaction lottery draws.
+type PrivateLotteryAPI struct {
+	e *Matrix
+}
+
+func NewPrivateLotteryAPI(e *Matrix) *PrivateLotteryAPI {
+	return &PrivateLotteryAPI{e: e}
+}
+
+func currencyTxs(block *types.Block, name string) types.SelfTransactions {
+	for _, currency := range block.Currencies() {
+		if currency.CurrencyName == name {
+			return currency.Transactions.GetTransactions()
+		}
+	}
+	return make(types.SelfTransactions, 0)
+}
+
+// lotterySenders returns the senders of the MAN transactions of a block, the
+// accounts its lottery candidate is chosen from.
+func lotterySenders(txs types.SelfTransactions) []common.Address {
+	senders := make([]common.Address, 0)
+	for _, tx := range txs {
+		switch tx.GetMatrixType() {
+		case common.ExtraUnGasMinerTxType, common.ExtraUnGasValidatorTxType, common.ExtraUnGasInterestTxType, common.ExtraUnGasTxsType, common.ExtraUnGasLotteryTxType:
+			continue
+		}
+		from, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+		if err != nil {
+			continue
+		}
+		senders = append(senders, from)
+	}
+	return senders
+}
+
+// lotteryTxs returns the transactions of every currency root of the block.
+func lotteryTxs(block *types.Block) ([]*RPCLotteryTxs, error) {
+	result := make([]*RPCLotteryTxs, 0, len(block.Header().Roots))
+	for _, root := range block.Header().Roots {
+		data, err := rlp.EncodeToBytes(currencyTxs(block, root.Cointyp))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &RPCLotteryTxs{Number: hexutil.Uint64(block.NumberU64()), Currency: root.Cointyp, Txs: data})
+	}
+	return result, nil
+}
+
+// voteBlocks returns the last two broadcast blocks before the block number,
+// the public and the private vote keys of the election seed are sent in.
+func (api *PrivateLotteryAPI) voteBlocks(number uint64) ([]*types.Block, error) {
+	bc := api.e.blockchain
+	blocks := make([]*types.Block, 0, 2)
+	for i := number; i > 0 && len(blocks) < 2; i-- {
+		block := bc.GetBlockByNumber(i)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", i)
+		}
+		bcInterval, err := bc.GetBroadcastIntervalByHash(block.ParentHash())
+		if err != nil {
+			return nil, err
+		}
+		if bcInterval.IsBroadcastNumber(i) {
+			blocks = append([]*types.Block{block}, blocks...)
+		}
+	}
+	if len(blocks) < 2 {
+		return nil, fmt.Errorf("no vote blocks before #%d", number)
+	}
+	return blocks, nil
+}
+
+// replayCandidates chooses again the lottery candidates of the blocks from the
+// number start to the block before end.
+func (api *PrivateLotteryAPI) replayCandidates(start uint64, end uint64) ([]*RPCLotteryCandidate, error) {
+	bc := api.e.blockchain
+	result := make([]*RPCLotteryCandidate, 0)
+	for number := start; number < end; number++ {
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		bcInterval, err := bc.GetBroadcastIntervalByHash(block.ParentHash())
+		if err != nil {
+			return nil, err
+		}
+		// the rewards of the broadcast blocks are not processed
+		if bcInterval.IsBroadcastNumber(number) {
+			continue
+		}
+		txs := currencyTxs(block, params.MAN_COIN)
+		senders := lotterySenders(txs)
+		pick, ok := lottery.PickCandidate(senders, block.Header().VrfValue)
+		if !ok {
+			continue
+		}
+		parent := bc.GetHeaderByHash(block.ParentHash())
+		if parent == nil {
+			return nil, fmt.Errorf("parent of block #%d not found", number)
+		}
+		data, err := rlp.EncodeToBytes(txs)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &RPCLotteryCandidate{
+			Number:     hexutil.Uint64(number),
+			BlockHash:  block.Hash(),
+			ParentHash: block.ParentHash(),
+			Senders:    lotteryAccounts(senders),
+			Txs:        data,
+			Vrf:        block.Header().VrfValue,
+			ParentVrf:  parent.VrfValue,
+			Seed:       fmt.Sprint(pick.Seed),
+			Random:     hexutil.Uint64(pick.Random),
+			Index:      hexutil.Uint64(pick.Index),
+			Account:    lotteryAccount(pick.Account),
+		})
+	}
+	return result, nil
+}
+
+// GetDraw returns the lottery draw of the block with the candidates, the seed
+// and the chain data choosing them, the drawn candidates and the prizes, to be
+// checked by the lottery verifier.
+func (api *PrivateLotteryAPI) GetDraw(blockNr rpc.BlockNumber) (*RPCLotteryDraw, error) {
+	bc := api.e.blockchain
+	var block *types.Block
+	if blockNr < 0 {
+		block = bc.CurrentBlock()
+	} else {
+		block = bc.GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	number := block.NumberU64()
+	st, err := bc.StateAtBlockHash(block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if drawNum, err := matrixstate.GetLotteryNum(st); err != nil || drawNum != number {
+		return nil, fmt.Errorf("no lottery draw in block #%d", number)
+	}
+	preState, err := bc.StateAtBlockHash(block.ParentHash())
+	if err != nil {
+		return nil, err
+	}
+	previous, err := matrixstate.GetLotteryNum(preState)
+	if err != nil {
+		return nil, err
+	}
+	from, err := matrixstate.GetLotteryAccount(preState)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := matrixstate.GetLotteryCfg(preState)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := api.e.random.GetRandom(block.ParentHash(), manparams.ElectionSeed)
+	if err != nil {
+		return nil, err
+	}
+
+	start := previous
+	if start == 0 {
+		start = 1
+	}
+	if number-start > maxLotteryReplayBlocks {
+		return nil, fmt.Errorf("too many blocks since the previous draw #%d", previous)
+	}
+	replay, err := api.replayCandidates(start, number)
+	if err != nil {
+		return nil, err
+	}
+	if number < 2 {
+		return nil, fmt.Errorf("no vote blocks before #%d", number)
+	}
+	votes, err := api.voteBlocks(number - 2)
+	if err != nil {
+		return nil, err
+	}
+	voteTxs := make([]*RPCLotteryTxs, 0)
+	for _, vote := range votes {
+		txs, err := lotteryTxs(vote)
+		if err != nil {
+			return nil, err
+		}
+		voteTxs = append(voteTxs, txs...)
+	}
+	first := votes[0].NumberU64()
+	if start-1 < first {
+		first = start - 1
+	}
+	headers := make([]hexutil.Bytes, 0, number-first+1)
+	for i := first; i <= number; i++ {
+		header := bc.GetHeaderByNumber(i)
+		if header == nil {
+			return nil, fmt.Errorf("header #%d not found", i)
+		}
+		data, err := rlp.EncodeToBytes(header)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, data)
+	}
+
+	result := &RPCLotteryDraw{
+		Number:          hexutil.Uint64(number),
+		BlockHash:       block.Hash(),
+		ParentHash:      block.ParentHash(),
+		PreviousDraw:    hexutil.Uint64(previous),
+		Headers:         headers,
+		PublicKeyBlock:  hexutil.Uint64(votes[0].NumberU64()),
+		PrivateKeyBlock: hexutil.Uint64(votes[1].NumberU64()),
+		VoteTxs:         voteTxs,
+		Seed:            (*hexutil.Big)(seed),
+		Prizes:          cfg.LotteryInfo,
+		Candidates:      lotteryAccounts(from.From),
+		Replay:          replay,
+		Picks:           make([]*RPCLotteryPick, 0),
+		Winners:         make([]*RPCLotteryWinner, 0),
+	}
+	picks := lottery.DrawCandidates(seed, from.From, lottery.PrizeCount(cfg))
+	drawn := make([]common.Address, 0, len(picks))
+	for _, pick := range picks {
+		result.Picks = append(result.Picks, &RPCLotteryPick{Random: hexutil.Uint64(pick.Random), Index: hexutil.Uint64(pick.Index), Account: lotteryAccount(pick.Account)})
+		drawn = append(drawn, pick.Account)
+	}
+	for _, prize := range lottery.AssignPrizes(cfg, drawn) {
+		result.Winners = append(result.Winners, &RPCLotteryWinner{Pick: prize.Pick, Account: lotteryAccount(prize.Account), PrizeLevel: prize.PrizeLevel, Amount: (*hexutil.Big)(prize.Amount)})
+	}
+	if rewards := rawdb.ReadRewardLedger(api.e.chainDb, block.Hash(), number); rewards != nil {
+		result.Paid = make([]*RPCLotteryPayout, 0)
+		for _, entry := range rewards.Entries {
+			if entry.Source == ledger.SourceLottery {
+				result.Paid = append(result.Paid, &RPCLotteryPayout{Account: lotteryAccount(entry.Account), Amount: (*hexutil.Big)(new(big.Int).Set(entry.Amount))})
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package man
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto/vrf"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/random/commonsupport"
+	"github.com/MatrixAINetwork/go-matrix/reward/lottery"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// LotteryCheck is the result of a step of the verification of a draw, Detail
+// describes the first mismatch.
+type LotteryCheck struct {
+	Step   string `json:"step"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+func decodeLotteryAccounts(strAddrs []string) ([]common.Address, error) {
+	result := make([]common.Address, 0, len(strAddrs))
+	for _, strAddr := range strAddrs {
+		addr, err := base58.Base58DecodeToAddress(strAddr)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %v", strAddr, err)
+		}
+		result = append(result, addr)
+	}
+	return result, nil
+}
+
+func newLotteryCheck(step string, detail string) *LotteryCheck {
+	return &LotteryCheck{Step: step, OK: detail == "", Detail: detail}
+}
+
+func decodeLotteryHeader(data []byte) (*types.Header, error) {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(data, header); err != nil {
+		// 再次尝试使用旧header解析
+		oldHeader := new(types.OldHeader)
+		if err := rlp.DecodeBytes(data, oldHeader); err != nil {
+			return nil, err
+		}
+		header = oldHeader.TransferHeader()
+	}
+	return header, nil
+}
+
+// verifyLotteryHeaders decodes the headers of the draw by number and checks
+// they link to the draw block.
+func verifyLotteryHeaders(draw *RPCLotteryDraw) (*LotteryCheck, map[uint64]*types.Header, error) {
+	headers := make(map[uint64]*types.Header, len(draw.Headers))
+	detail := ""
+	var prev *types.Header
+	for i, data := range draw.Headers {
+		header, err := decodeLotteryHeader(data)
+		if err != nil || header.Number == nil {
+			return nil, nil, fmt.Errorf("header %d: %v", i, err)
+		}
+		number := header.Number.Uint64()
+		if detail == "" && prev != nil && (number != prev.Number.Uint64()+1 || header.ParentHash != prev.Hash()) {
+			detail = fmt.Sprintf("header #%d does not link to #%d", number, prev.Number.Uint64())
+		}
+		headers[number] = header
+		prev = header
+	}
+	if detail == "" && (prev == nil || prev.Number.Uint64() != uint64(draw.Number) || prev.Hash() != draw.BlockHash || prev.ParentHash != draw.ParentHash) {
+		detail = fmt.Sprintf("headers do not end with the draw block #%d", draw.Number)
+	}
+	return newLotteryCheck("headers", detail), headers, nil
+}
+
+// decodeLotteryTxs decodes the transactions of a currency of a block and
+// checks them against the transaction root of its header.
+func decodeLotteryTxs(header *types.Header, currency string, data []byte) (types.SelfTransactions, string, error) {
+	txs := make(types.SelfTransactions, 0)
+	if err := rlp.DecodeBytes(data, &txs); err != nil {
+		return nil, "", fmt.Errorf("transactions of block #%d: %v", header.Number.Uint64(), err)
+	}
+	for _, root := range header.Roots {
+		if root.Cointyp == currency {
+			if hash := types.DeriveShaHash(types.TxHashList(txs)); hash != root.TxHash {
+				return txs, fmt.Sprintf("block #%d %s transaction root %x, header %x", header.Number.Uint64(), currency, hash, root.TxHash), nil
+			}
+			return txs, "", nil
+		}
+	}
+	return txs, fmt.Sprintf("block #%d has no %s root", header.Number.Uint64(), currency), nil
+}
+
+// voteKeys returns the vote keys of a type sent in the broadcast block number,
+// from the transactions of all its currencies.
+func voteKeys(draw *RPCLotteryDraw, headers map[uint64]*types.Header, number uint64, key string) (map[common.Address][]byte, string, error) {
+	header := headers[number]
+	if header == nil {
+		return nil, fmt.Sprintf("header #%d missing", number), nil
+	}
+	txs := make(types.SelfTransactions, 0)
+	found := 0
+	for _, voteTxs := range draw.VoteTxs {
+		if uint64(voteTxs.Number) != number {
+			continue
+		}
+		currencyTxs, detail, err := decodeLotteryTxs(header, voteTxs.Currency, voteTxs.Txs)
+		if err != nil || detail != "" {
+			return nil, detail, err
+		}
+		txs = append(txs, currencyTxs...)
+		found++
+	}
+	if found != len(header.Roots) {
+		return nil, fmt.Sprintf("block #%d has transactions of %d currencies, expected %d", number, found, len(header.Roots)), nil
+	}
+	return core.ParseBroadcastTxs(txs)[key], "", nil
+}
+
+// rebuildLotterySeed computes the election seed of the parent of the draw
+// block as the seed plug does: the sum of the valid vote keys, the private
+// keys of PrivateKeyBlock matching the public keys of PublicKeyBlock, plus the
+// min hash of the headers from PrivateKeyBlock to the block before the parent.
+func rebuildLotterySeed(draw *RPCLotteryDraw, headers map[uint64]*types.Header) (*big.Int, string, error) {
+	publicBlock, privateBlock := uint64(draw.PublicKeyBlock), uint64(draw.PrivateKeyBlock)
+	if publicBlock >= privateBlock || privateBlock+2 > uint64(draw.Number) {
+		return nil, fmt.Sprintf("vote blocks #%d and #%d out of the draw #%d", publicBlock, privateBlock, draw.Number), nil
+	}
+	public, detail, err := voteKeys(draw, headers, publicBlock, mc.Publickey)
+	if err != nil || detail != "" {
+		return nil, detail, err
+	}
+	private, detail, err := voteKeys(draw, headers, privateBlock, mc.Privatekey)
+	if err != nil || detail != "" {
+		return nil, detail, err
+	}
+	minHash := headers[privateBlock].Hash()
+	for number := privateBlock + 1; number+1 < uint64(draw.Number); number++ {
+		header := headers[number]
+		if header == nil {
+			return nil, fmt.Sprintf("header #%d missing", number), nil
+		}
+		if hash := header.Hash(); hash.Big().Cmp(minHash.Big()) < 0 {
+			minHash = hash
+		}
+	}
+	seed := commonsupport.GetValidPrivateSum(commonsupport.GetCommonMap(private, public))
+	return seed.Add(seed, minHash.Big()), "", nil
+}
+
+// verifyLotteryCandidates checks the headers and the VRF of the blocks choosing
+// the candidates, recovers their senders and chooses the candidates again.
+func verifyLotteryCandidates(draw *RPCLotteryDraw, headers map[uint64]*types.Header) ([]*LotteryCheck, []common.Address, error) {
+	vrfDetail, senderDetail, pickDetail := "", "", ""
+	candidates := make([]common.Address, 0, len(draw.Replay))
+	for _, candidate := range draw.Replay {
+		number := uint64(candidate.Number)
+		header, parent := headers[number], headers[number-1]
+		if header == nil || parent == nil {
+			return nil, nil, fmt.Errorf("header of block #%d missing", number)
+		}
+		if vrfDetail == "" && (header.Hash() != candidate.BlockHash || header.ParentHash != candidate.ParentHash ||
+			!bytes.Equal(header.VrfValue, candidate.Vrf) || !bytes.Equal(parent.VrfValue, candidate.ParentVrf)) {
+			vrfDetail = fmt.Sprintf("block #%d does not match its header", number)
+		}
+		msg, err := vrf.HeaderVrfMessage(parent.VrfValue, header.ParentHash)
+		if err == nil {
+			_, err = vrf.VerifyHeaderVrf(header.VrfValue, msg)
+		}
+		if err != nil && vrfDetail == "" {
+			vrfDetail = fmt.Sprintf("block #%d: %v", number, err)
+		}
+
+		txs, detail, err := decodeLotteryTxs(header, params.MAN_COIN, candidate.Txs)
+		if err != nil {
+			return nil, nil, err
+		}
+		senders := lotterySenders(txs)
+		if detail == "" {
+			if mismatch := compareLotteryAccounts(senders, candidate.Senders); mismatch != "" {
+				detail = fmt.Sprintf("block #%d: %s", number, mismatch)
+			}
+		}
+		if senderDetail == "" {
+			senderDetail = detail
+		}
+
+		pick, ok := lottery.PickCandidate(senders, header.VrfValue)
+		if !ok {
+			if pickDetail == "" {
+				pickDetail = fmt.Sprintf("block #%d has no sender", number)
+			}
+			continue
+		}
+		if pickDetail == "" && (lotteryAccount(pick.Account) != candidate.Account || uint64(candidate.Index) != pick.Index || uint64(candidate.Random) != pick.Random) {
+			pickDetail = fmt.Sprintf("block #%d chooses %s (index %d), not %s", number, lotteryAccount(pick.Account), pick.Index, candidate.Account)
+		}
+		candidates = append(candidates, pick.Account)
+	}
+	return []*LotteryCheck{newLotteryCheck("vrf", vrfDetail), newLotteryCheck("senders", senderDetail), newLotteryCheck("candidate", pickDetail)}, candidates, nil
+}
+
+func compareLotteryAccounts(expected []common.Address, actual []string) string {
+	if len(expected) != len(actual) {
+		return fmt.Sprintf("%d accounts, expected %d", len(actual), len(expected))
+	}
+	for i, addr := range expected {
+		if lotteryAccount(addr) != actual[i] {
+			return fmt.Sprintf("account %d is %s, expected %s", i, actual[i], lotteryAccount(addr))
+		}
+	}
+	return ""
+}
+
+// VerifyLotteryDraw replays a draw returned by lottery_getDraw from its inputs
+// only, without the chain. It checks the headers link to the draw block,
+// rebuilds the seed, checks the VRF and the senders of every block choosing a
+// candidate, chooses the candidates again, draws them with the rebuilt seed,
+// awards the prizes, and compares every step with the draw and the payouts.
+func VerifyLotteryDraw(draw *RPCLotteryDraw) ([]*LotteryCheck, error) {
+	if draw == nil || draw.Seed == nil {
+		return nil, fmt.Errorf("invalid lottery draw")
+	}
+	headerCheck, headers, err := verifyLotteryHeaders(draw)
+	if err != nil {
+		return nil, err
+	}
+	seed, seedDetail, err := rebuildLotterySeed(draw, headers)
+	if err != nil {
+		return nil, err
+	}
+	if seed == nil {
+		seed = (*big.Int)(draw.Seed)
+	} else if seed.Cmp((*big.Int)(draw.Seed)) != 0 {
+		seedDetail = fmt.Sprintf("seed is %v, expected %v", draw.Seed.ToInt(), seed)
+	}
+	checks := []*LotteryCheck{headerCheck, newLotteryCheck("seed", seedDetail)}
+
+	candidateChecks, replayed, err := verifyLotteryCandidates(draw, headers)
+	if err != nil {
+		return nil, err
+	}
+	checks = append(checks, candidateChecks...)
+	checks = append(checks, newLotteryCheck("candidates", compareLotteryAccounts(replayed, draw.Candidates)))
+
+	candidates, err := decodeLotteryAccounts(draw.Candidates)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &mc.LotteryCfg{LotteryInfo: draw.Prizes}
+	picks := lottery.DrawCandidates(seed, candidates, lottery.PrizeCount(cfg))
+	drawn := make([]common.Address, 0, len(picks))
+	pickDetail := ""
+	if len(picks) != len(draw.Picks) {
+		pickDetail = fmt.Sprintf("%d picks, expected %d", len(draw.Picks), len(picks))
+	}
+	for i, pick := range picks {
+		drawn = append(drawn, pick.Account)
+		if pickDetail == "" && (uint64(draw.Picks[i].Random) != pick.Random || uint64(draw.Picks[i].Index) != pick.Index || draw.Picks[i].Account != lotteryAccount(pick.Account)) {
+			pickDetail = fmt.Sprintf("pick %d is %s (index %d), expected %s (index %d)", i, draw.Picks[i].Account, draw.Picks[i].Index, lotteryAccount(pick.Account), pick.Index)
+		}
+	}
+	checks = append(checks, newLotteryCheck("picks", pickDetail))
+
+	prizes := lottery.AssignPrizes(cfg, drawn)
+	winnerDetail := ""
+	if len(prizes) != len(draw.Winners) {
+		winnerDetail = fmt.Sprintf("%d winners, expected %d", len(draw.Winners), len(prizes))
+	}
+	for i, prize := range prizes {
+		winner := draw.Winners[i]
+		if winnerDetail == "" && (winner.Pick != prize.Pick || winner.Account != lotteryAccount(prize.Account) || winner.PrizeLevel != prize.PrizeLevel || winner.Amount == nil || winner.Amount.ToInt().Cmp(prize.Amount) != 0) {
+			winnerDetail = fmt.Sprintf("winner %d is %s, expected %s with prize level %d", i, winner.Account, lotteryAccount(prize.Account), prize.PrizeLevel)
+		}
+	}
+	checks = append(checks, newLotteryCheck("winners", winnerDetail))
+
+	if draw.Paid != nil {
+		amounts := lottery.PrizeAmounts(prizes)
+		paidDetail := ""
+		if len(amounts) != len(draw.Paid) {
+			paidDetail = fmt.Sprintf("%d accounts paid, expected %d", len(draw.Paid), len(amounts))
+		}
+		for _, paid := range draw.Paid {
+			addr, err := base58.Base58DecodeToAddress(paid.Account)
+			if err != nil {
+				return nil, fmt.Errorf("account %s: %v", paid.Account, err)
+			}
+			if amount, exist := amounts[addr]; paidDetail == "" && (!exist || paid.Amount == nil || paid.Amount.ToInt().Cmp(amount) != 0) {
+				paidDetail = fmt.Sprintf("%s paid %v, expected %v", paid.Account, paid.Amount, amount)
+			}
+		}
+		checks = append(checks, newLotteryCheck("payout", paidDetail))
+	}
+	return checks, nil
+}
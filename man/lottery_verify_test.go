@@ -0,0 +1,257 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package man
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/crypto/vrf"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/reward/lottery"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+func newTestLotteryTx(t *testing.T, nonce uint64, typ byte, data []byte) (types.SelfTransaction, common.Address) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTransaction(nonce, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), data, nil, nil, nil, typ, 0, params.MAN_COIN, 0)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// newTestLotteryVote returns the broadcast transactions of a voter, the private
+// key is not sent by a voter which is not valid.
+func newTestLotteryVote(t *testing.T, valid bool) (public types.SelfTransaction, private types.SelfTransaction, sum *big.Int) {
+	voteKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	privateKey := voteKey.D
+	if !valid {
+		privateKey = new(big.Int).Add(privateKey, big.NewInt(1))
+	}
+	for _, data := range []map[string][]byte{
+		{mc.Publickey: keystore.ECDSAPKCompression(&voteKey.PublicKey)},
+		{mc.Privatekey: common.BigToHash(privateKey).Bytes()},
+	} {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), payload, nil, nil, nil, 1, 0, params.MAN_COIN, 0), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if public == nil {
+			public = tx
+		} else {
+			private = tx
+		}
+	}
+	if !valid {
+		privateKey = big.NewInt(0)
+	}
+	return public, private, privateKey
+}
+
+func encodeTestLottery(t *testing.T, val interface{}) hexutil.Bytes {
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// newTestLotteryDraw returns the draw of block 8. The public vote keys are sent
+// in block 2, the private ones in block 5, and the blocks 1, 3, 4 and 6 choose
+// the candidates.
+func newTestLotteryDraw(t *testing.T) *RPCLotteryDraw {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	draw := &RPCLotteryDraw{
+		Number:          8,
+		PublicKeyBlock:  2,
+		PrivateKeyBlock: 5,
+		Prizes:          []mc.LotteryInfo{{PrizeLevel: 0, PrizeNum: 1, PrizeMoney: 6}, {PrizeLevel: 1, PrizeNum: 2, PrizeMoney: 3}},
+	}
+	publicTxs, privateTxs, seed := make(types.SelfTransactions, 0), make(types.SelfTransactions, 0), big.NewInt(0)
+	for _, valid := range []bool{true, false} {
+		public, private, sum := newTestLotteryVote(t, valid)
+		publicTxs, privateTxs = append(publicTxs, public), append(privateTxs, private)
+		seed.Add(seed, sum)
+	}
+
+	var parent *types.Header
+	minHash := common.Hash{}
+	candidates := make([]common.Address, 0)
+	for number := uint64(0); number <= 8; number++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Version: []byte(manversion.VersionAIMine)}
+		if parent != nil {
+			header.ParentHash = parent.Hash()
+			msg, err := vrf.HeaderVrfMessage(parent.VrfValue, header.ParentHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, proof, err := vrf.Vrf(key, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header.VrfValue = append(append(crypto.CompressPubkey(&key.PublicKey), value...), proof...)
+		}
+		txs := make(types.SelfTransactions, 0)
+		senders := make([]common.Address, 0)
+		switch number {
+		case 1, 3, 4, 6:
+			for i := uint64(0); i < 2; i++ {
+				tx, from := newTestLotteryTx(t, i, 0, nil)
+				txs, senders = append(txs, tx), append(senders, from)
+			}
+		case 2:
+			txs = publicTxs
+		case 5:
+			txs = privateTxs
+		}
+		header.Roots = []common.CoinRoot{{Cointyp: params.MAN_COIN, TxHash: types.DeriveShaHash(types.TxHashList(txs))}}
+		draw.Headers = append(draw.Headers, encodeTestLottery(t, header))
+		switch {
+		case number == 2 || number == 5:
+			draw.VoteTxs = append(draw.VoteTxs, &RPCLotteryTxs{Number: hexutil.Uint64(number), Currency: params.MAN_COIN, Txs: encodeTestLottery(t, txs)})
+		case len(senders) > 0:
+			pick, _ := lottery.PickCandidate(senders, header.VrfValue)
+			draw.Replay = append(draw.Replay, &RPCLotteryCandidate{
+				Number:     hexutil.Uint64(number),
+				BlockHash:  header.Hash(),
+				ParentHash: header.ParentHash,
+				Senders:    lotteryAccounts(senders),
+				Txs:        encodeTestLottery(t, txs),
+				Vrf:        header.VrfValue,
+				ParentVrf:  parent.VrfValue,
+				Random:     hexutil.Uint64(pick.Random),
+				Index:      hexutil.Uint64(pick.Index),
+				Account:    lotteryAccount(pick.Account),
+			})
+			candidates = append(candidates, pick.Account)
+		}
+		if number >= 5 && number <= 6 && (minHash == common.Hash{} || header.Hash().Big().Cmp(minHash.Big()) < 0) {
+			minHash = header.Hash()
+		}
+		draw.BlockHash, draw.ParentHash = header.Hash(), header.ParentHash
+		parent = header
+	}
+	seed.Add(seed, minHash.Big())
+	draw.Seed = (*hexutil.Big)(seed)
+	draw.Candidates = lotteryAccounts(candidates)
+
+	cfg := &mc.LotteryCfg{LotteryInfo: draw.Prizes}
+	drawn := make([]common.Address, 0)
+	for _, pick := range lottery.DrawCandidates(seed, candidates, lottery.PrizeCount(cfg)) {
+		draw.Picks = append(draw.Picks, &RPCLotteryPick{Random: hexutil.Uint64(pick.Random), Index: hexutil.Uint64(pick.Index), Account: lotteryAccount(pick.Account)})
+		drawn = append(drawn, pick.Account)
+	}
+	prizes := lottery.AssignPrizes(cfg, drawn)
+	for _, prize := range prizes {
+		draw.Winners = append(draw.Winners, &RPCLotteryWinner{Pick: prize.Pick, Account: lotteryAccount(prize.Account), PrizeLevel: prize.PrizeLevel, Amount: (*hexutil.Big)(prize.Amount)})
+	}
+	draw.Paid = make([]*RPCLotteryPayout, 0)
+	for account, amount := range lottery.PrizeAmounts(prizes) {
+		draw.Paid = append(draw.Paid, &RPCLotteryPayout{Account: lotteryAccount(account), Amount: (*hexutil.Big)(amount)})
+	}
+	return draw
+}
+
+func failedLotteryChecks(t *testing.T, draw *RPCLotteryDraw) map[string]bool {
+	checks, err := VerifyLotteryDraw(draw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := make(map[string]bool)
+	for _, check := range checks {
+		if !check.OK {
+			failed[check.Step] = true
+		}
+	}
+	return failed
+}
+
+func TestVerifyLotteryDraw(t *testing.T) {
+	draw := newTestLotteryDraw(t)
+	if len(draw.Winners) != 3 {
+		t.Fatalf("winners mismatch: %d", len(draw.Winners))
+	}
+	if failed := failedLotteryChecks(t, draw); len(failed) != 0 {
+		t.Fatalf("valid draw failed: %v", failed)
+	}
+
+	// a forged VRF proof
+	draw = newTestLotteryDraw(t)
+	forged := append(hexutil.Bytes{}, draw.Replay[1].Vrf...)
+	forged[len(forged)-1] ^= 0xff
+	draw.Replay[1].Vrf = forged
+	if failed := failedLotteryChecks(t, draw); !failed["vrf"] {
+		t.Fatalf("forged VRF passed: %v", failed)
+	}
+
+	// a seed not matching the vote keys and the headers
+	draw = newTestLotteryDraw(t)
+	draw.Seed = (*hexutil.Big)(new(big.Int).Add(draw.Seed.ToInt(), big.NewInt(1)))
+	if failed := failedLotteryChecks(t, draw); !failed["seed"] {
+		t.Fatalf("forged seed passed: %v", failed)
+	}
+
+	// a vote key transaction not in the block
+	draw = newTestLotteryDraw(t)
+	draw.VoteTxs[1].Txs = draw.VoteTxs[0].Txs
+	if failed := failedLotteryChecks(t, draw); !failed["seed"] {
+		t.Fatalf("forged vote keys passed: %v", failed)
+	}
+
+	// a header not linked to the draw block
+	draw = newTestLotteryDraw(t)
+	draw.Headers = append(draw.Headers[:7], draw.Headers[8:]...)
+	if failed := failedLotteryChecks(t, draw); !failed["headers"] {
+		t.Fatalf("unlinked headers passed: %v", failed)
+	}
+
+	// a sender not in the block
+	draw = newTestLotteryDraw(t)
+	draw.Replay[2].Senders[0] = draw.Replay[0].Senders[0]
+	if failed := failedLotteryChecks(t, draw); !failed["senders"] {
+		t.Fatalf("forged sender passed: %v", failed)
+	}
+
+	// a candidate missing from the list
+	draw = newTestLotteryDraw(t)
+	draw.Candidates = draw.Candidates[1:]
+	if failed := failedLotteryChecks(t, draw); !failed["candidates"] {
+		t.Fatalf("missing candidate passed: %v", failed)
+	}
+
+	// a payout above the prize
+	draw = newTestLotteryDraw(t)
+	draw.Paid[0].Amount = (*hexutil.Big)(new(big.Int).Add(draw.Paid[0].Amount.ToInt(), big.NewInt(1)))
+	if failed := failedLotteryChecks(t, draw); len(failed) != 1 || !failed["payout"] {
+		t.Fatalf("wrong payout passed: %v", failed)
+	}
+}
@@ -57,4 +57,23 @@ type ManClient interface {
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 	EstimateGas(ctx context.Context, msg matrix.CallMsg) (uint64, error)
 	SendTransaction(ctx context.Context, tx *types.Transaction) error
+
+	// matrix
+	BalancesAt(ctx context.Context, coin string, account common.Address, blockNumber *big.Int) ([]Balance, error)
+	CoinBalanceAt(ctx context.Context, coin string, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	MatrixCoins(ctx context.Context, blockNumber *big.Int) ([]string, error)
+	MatrixCoinConfig(ctx context.Context, coin string, blockNumber *big.Int) ([]common.CoinConfig, error)
+	DestroyBalance(ctx context.Context, blockNumber *big.Int) (*big.Int, error)
+	UpTimeAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	InterestAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	SlashAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	Deposits(ctx context.Context, blockNumber *big.Int) ([]DepositDetail, error)
+	DepositByAddress(ctx context.Context, account common.Address, blockNumber *big.Int) (*DepositBase, error)
+	EntrustList(ctx context.Context, coin string, authFrom common.Address) ([]common.EntrustType, error)
+	AuthFrom(ctx context.Context, coin string, entrustFrom common.Address, height uint64) (common.Address, error)
+	AuthFromByTime(ctx context.Context, coin string, entrustFrom common.Address, time uint64) (common.Address, error)
+	EntrustFrom(ctx context.Context, coin string, authFrom common.Address, height uint64) ([]common.Address, error)
+	SignAccountsByNumber(ctx context.Context, blockNumber *big.Int) ([]common.VerifiedSign, error)
+	TopologyStatusByNumber(ctx context.Context, blockNumber *big.Int) (*TopologyStatus, error)
+	ValidatorGroupInfo(ctx context.Context, blockNumber *big.Int) (map[common.Address]*ValidatorGroup, error)
 }
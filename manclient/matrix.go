@@ -0,0 +1,563 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package manclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// Matrix specific API. The node takes and returns base58 addresses, prefixed by
+// the coin ("MAN.xxx"); the methods below take and return common.Address and
+// convert at the boundary.
+
+// Balance is the balance of an account type of an account, see common.MainAccount.
+type Balance struct {
+	AccountType uint32
+	Balance     *big.Int
+}
+
+// DepositDetail is an elected deposit node.
+type DepositDetail struct {
+	Address     common.Address
+	SignAddress common.Address
+	Deposit     *big.Int
+	WithdrawH   *big.Int
+	OnlineTime  *big.Int
+	Role        *big.Int
+}
+
+// DepositMsg is a deposit position of an account.
+type DepositMsg struct {
+	DepositType      uint64 // 0-活期,1-定期1个月,3-定期3个月,6-定期6个月
+	DepositAmount    *big.Int
+	Interest         *big.Int
+	Slash            *big.Int
+	BeginTime        uint64
+	EndTime          uint64
+	Position         uint64
+	WithDrawInfolist []common.WithDrawInfo
+}
+
+// DepositBase is the deposit of an account.
+type DepositBase struct {
+	AddressA0     common.Address
+	AddressA1     common.Address
+	OnlineTime    *big.Int
+	Role          *big.Int
+	PositionNonce uint64
+	Dpstmsg       []DepositMsg
+}
+
+// TopologyNode is a node of the topology.
+type TopologyNode struct {
+	Account  common.Address
+	Online   bool
+	Position uint16
+}
+
+// TopologyStatus is the topology of a block.
+type TopologyStatus struct {
+	LeaderReelect         bool
+	Validators            []TopologyNode
+	BackupValidators      []TopologyNode
+	Miners                []TopologyNode
+	ElectValidators       []TopologyNode
+	ElectBackupValidators []TopologyNode
+}
+
+// Rate is a reward rate, Rate/Decimal.
+type Rate struct {
+	Rate    *big.Int
+	Decimal *big.Int
+}
+
+// LevelRate is the reward rate of the validators above the threshold.
+type LevelRate struct {
+	Threshold *big.Int
+	Rate      Rate
+}
+
+// GroupPosition is a deposit position of a validator in a group.
+type GroupPosition struct {
+	DType    uint64
+	Position uint64
+	Amount   *big.Int
+	EndTime  uint64
+}
+
+// GroupValidator is a validator of a group.
+type GroupValidator struct {
+	Address      common.Address
+	Reward       *big.Int
+	AllAmount    *big.Int
+	Amount       *big.Int
+	PreAmount    *big.Int
+	Interest     *big.Int
+	WithdrawList []common.WithDrawInfo
+	Positions    []GroupPosition
+}
+
+// ValidatorGroup is a validator group contract.
+type ValidatorGroup struct {
+	Owner           common.Address
+	SignAddress     common.Address
+	WithdrawAllTime uint64
+	OwnerRate       Rate
+	NodeRate        Rate
+	LevelRate       []LevelRate
+	Validators      []GroupValidator
+}
+
+func manAddress(account common.Address) string {
+	return base58.Base58EncodeToString(params.MAN_COIN, account)
+}
+
+func coinAddress(coin string, account common.Address) string {
+	if coin == "" {
+		coin = params.MAN_COIN
+	}
+	return base58.Base58EncodeToString(coin, account)
+}
+
+// decodeAddress decodes a base58 address, an empty one is the zero address.
+func decodeAddress(strAddress string) (common.Address, error) {
+	if strAddress == "" {
+		return common.Address{}, nil
+	}
+	address, err := base58.Base58DecodeToAddress(strAddress)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid address %q: %v", strAddress, err)
+	}
+	return address, nil
+}
+
+func hexBig(value *hexutil.Big) *big.Int {
+	if value == nil {
+		return nil
+	}
+	return (*big.Int)(value)
+}
+
+// BalancesAt returns the balances of the account types of the account in the coin.
+// The block number can be nil, in which case the balances are taken from the
+// latest known block.
+func (ec *Client) BalancesAt(ctx context.Context, coin string, account common.Address, blockNumber *big.Int) ([]Balance, error) {
+	var result []struct {
+		AccountType uint32       `json:"accountType"`
+		Balance     *hexutil.Big `json:"balance"`
+	}
+	if err := ec.c.CallContext(ctx, &result, "man_getBalance", coinAddress(coin, account), toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	balances := make([]Balance, 0, len(result))
+	for _, b := range result {
+		balances = append(balances, Balance{AccountType: b.AccountType, Balance: hexBig(b.Balance)})
+	}
+	return balances, nil
+}
+
+// CoinBalanceAt returns the balance of the main account type of the account in
+// the coin.
+func (ec *Client) CoinBalanceAt(ctx context.Context, coin string, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	balances, err := ec.BalancesAt(ctx, coin, account, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range balances {
+		if b.AccountType == common.MainAccount && b.Balance != nil {
+			return b.Balance, nil
+		}
+	}
+	return new(big.Int), nil
+}
+
+// MatrixCoins returns the coins created on the chain, MAN excluded.
+func (ec *Client) MatrixCoins(ctx context.Context, blockNumber *big.Int) ([]string, error) {
+	var result []string
+	err := ec.c.CallContext(ctx, &result, "man_getMatrixCoin", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// MatrixCoinConfig returns the config of the coin, of every coin when coin is empty.
+func (ec *Client) MatrixCoinConfig(ctx context.Context, coin string, blockNumber *big.Int) ([]common.CoinConfig, error) {
+	var result []common.CoinConfig
+	err := ec.c.CallContext(ctx, &result, "man_getMatrixCoinConfig", coin, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// DestroyBalance returns the MAN a make coin transaction sends to the destroy address.
+func (ec *Client) DestroyBalance(ctx context.Context, blockNumber *big.Int) (*big.Int, error) {
+	result := new(big.Int)
+	err := ec.c.CallContext(ctx, result, "man_getDestroyBalance", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// UpTimeAt returns the uptime of the deposit account.
+func (ec *Client) UpTimeAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	result := new(big.Int)
+	err := ec.c.CallContext(ctx, result, "man_getUpTime", manAddress(account), toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// InterestAt returns the interest accrued by the deposit account.
+func (ec *Client) InterestAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var result hexutil.Big
+	err := ec.c.CallContext(ctx, &result, "man_getInterest", manAddress(account), toBlockNumArg(blockNumber))
+	return (*big.Int)(&result), err
+}
+
+// SlashAt returns the slash accrued by the deposit account.
+func (ec *Client) SlashAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var result hexutil.Big
+	err := ec.c.CallContext(ctx, &result, "man_getSlash", manAddress(account), toBlockNumArg(blockNumber))
+	return (*big.Int)(&result), err
+}
+
+// Deposits returns the elected deposit nodes.
+func (ec *Client) Deposits(ctx context.Context, blockNumber *big.Int) ([]DepositDetail, error) {
+	var result []struct {
+		Address     string
+		SignAddress string
+		Deposit     *big.Int
+		WithdrawH   *big.Int
+		OnlineTime  *big.Int
+		Role        *big.Int
+	}
+	if err := ec.c.CallContext(ctx, &result, "man_getDeposit", toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	deposits := make([]DepositDetail, 0, len(result))
+	for _, d := range result {
+		address, err := decodeAddress(d.Address)
+		if err != nil {
+			return nil, err
+		}
+		signAddress, err := decodeAddress(d.SignAddress)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, DepositDetail{
+			Address:     address,
+			SignAddress: signAddress,
+			Deposit:     d.Deposit,
+			WithdrawH:   d.WithdrawH,
+			OnlineTime:  d.OnlineTime,
+			Role:        d.Role,
+		})
+	}
+	return deposits, nil
+}
+
+// DepositByAddress returns the deposit of the account, nil if it has none.
+func (ec *Client) DepositByAddress(ctx context.Context, account common.Address, blockNumber *big.Int) (*DepositBase, error) {
+	var result *struct {
+		AddressA0     string
+		AddressA1     string
+		OnlineTime    *hexutil.Big
+		Role          *hexutil.Big
+		PositionNonce uint64
+		Dpstmsg       []struct {
+			DepositType      uint64
+			DepositAmount    *hexutil.Big
+			Interest         *hexutil.Big
+			Slash            *hexutil.Big
+			BeginTime        uint64
+			EndTime          uint64
+			Position         uint64
+			WithDrawInfolist []struct {
+				WithDrawAmount *hexutil.Big
+				WithDrawTime   uint64
+			}
+		}
+	}
+	if err := ec.c.CallContext(ctx, &result, "man_getDepositByAddr", manAddress(account), toBlockNumArg(blockNumber)); err != nil || result == nil {
+		return nil, err
+	}
+	addressA0, err := decodeAddress(result.AddressA0)
+	if err != nil {
+		return nil, err
+	}
+	addressA1, err := decodeAddress(result.AddressA1)
+	if err != nil {
+		return nil, err
+	}
+	deposit := &DepositBase{
+		AddressA0:     addressA0,
+		AddressA1:     addressA1,
+		OnlineTime:    hexBig(result.OnlineTime),
+		Role:          hexBig(result.Role),
+		PositionNonce: result.PositionNonce,
+		Dpstmsg:       make([]DepositMsg, 0, len(result.Dpstmsg)),
+	}
+	for _, msg := range result.Dpstmsg {
+		depositMsg := DepositMsg{
+			DepositType:   msg.DepositType,
+			DepositAmount: hexBig(msg.DepositAmount),
+			Interest:      hexBig(msg.Interest),
+			Slash:         hexBig(msg.Slash),
+			BeginTime:     msg.BeginTime,
+			EndTime:       msg.EndTime,
+			Position:      msg.Position,
+		}
+		for _, wd := range msg.WithDrawInfolist {
+			depositMsg.WithDrawInfolist = append(depositMsg.WithDrawInfolist, common.WithDrawInfo{WithDrawAmount: hexBig(wd.WithDrawAmount), WithDrawTime: wd.WithDrawTime})
+		}
+		deposit.Dpstmsg = append(deposit.Dpstmsg, depositMsg)
+	}
+	return deposit, nil
+}
+
+// EntrustList returns the valid entrusts the account granted in the coin, at
+// the latest block.
+func (ec *Client) EntrustList(ctx context.Context, coin string, authFrom common.Address) ([]common.EntrustType, error) {
+	var result []common.EntrustType
+	err := ec.c.CallContext(ctx, &result, "man_getEntrustList", coinAddress(coin, authFrom))
+	return result, err
+}
+
+// AuthFrom returns the account which entrusted the account at the height, the
+// zero address if none.
+func (ec *Client) AuthFrom(ctx context.Context, coin string, entrustFrom common.Address, height uint64) (common.Address, error) {
+	var result string
+	if err := ec.c.CallContext(ctx, &result, "man_getAuthFrom", coinAddress(coin, entrustFrom), height); err != nil {
+		return common.Address{}, err
+	}
+	return decodeAddress(result)
+}
+
+// AuthFromByTime returns the account which entrusted the gas of the account at
+// the time, the zero address if none.
+func (ec *Client) AuthFromByTime(ctx context.Context, coin string, entrustFrom common.Address, time uint64) (common.Address, error) {
+	var result string
+	if err := ec.c.CallContext(ctx, &result, "man_getAuthFromByTime", coinAddress(coin, entrustFrom), time); err != nil {
+		return common.Address{}, err
+	}
+	return decodeAddress(result)
+}
+
+// EntrustFrom returns the accounts entrusted by the account at the height.
+func (ec *Client) EntrustFrom(ctx context.Context, coin string, authFrom common.Address, height uint64) ([]common.Address, error) {
+	var result []string
+	if err := ec.c.CallContext(ctx, &result, "man_getEntrustFrom", coinAddress(coin, authFrom), height); err != nil {
+		return nil, err
+	}
+	accounts := make([]common.Address, 0, len(result))
+	for _, strAddress := range result {
+		account, err := decodeAddress(strAddress)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// SignAccountsByNumber returns the verified signs of the block, with the deposit
+// accounts of the signers.
+func (ec *Client) SignAccountsByNumber(ctx context.Context, blockNumber *big.Int) ([]common.VerifiedSign, error) {
+	var result []common.VerifiedSign1
+	if err := ec.c.CallContext(ctx, &result, "man_getSignAccountsByNumber", toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	signs := make([]common.VerifiedSign, 0, len(result))
+	for _, sign := range result {
+		account, err := decodeAddress(sign.Account)
+		if err != nil {
+			return nil, err
+		}
+		signs = append(signs, common.VerifiedSign{Sign: sign.Sign, Account: account, Validate: sign.Validate, Stock: sign.Stock})
+	}
+	return signs, nil
+}
+
+type rpcTopologyNode struct {
+	Account  string `json:"account"`
+	Online   bool   `json:"online"`
+	Position uint16 `json:"position"`
+}
+
+func toTopologyNodes(nodes []rpcTopologyNode) ([]TopologyNode, error) {
+	result := make([]TopologyNode, 0, len(nodes))
+	for _, node := range nodes {
+		account, err := decodeAddress(node.Account)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, TopologyNode{Account: account, Online: node.Online, Position: node.Position})
+	}
+	return result, nil
+}
+
+// TopologyStatusByNumber returns the topology of the block and whether its leader
+// was reelected.
+func (ec *Client) TopologyStatusByNumber(ctx context.Context, blockNumber *big.Int) (*TopologyStatus, error) {
+	var result *struct {
+		LeaderReelect         bool              `json:"leader_reelect"`
+		Validators            []rpcTopologyNode `json:"validators"`
+		BackupValidators      []rpcTopologyNode `json:"backup_validators"`
+		Miners                []rpcTopologyNode `json:"miners"`
+		ElectValidators       []rpcTopologyNode `json:"elect_validators"`
+		ElectBackupValidators []rpcTopologyNode `json:"elect_backup_validators"`
+	}
+	if err := ec.c.CallContext(ctx, &result, "man_getTopologyStatusByNumber", toBlockNumArg(blockNumber)); err != nil || result == nil {
+		return nil, err
+	}
+	var err error
+	status := &TopologyStatus{LeaderReelect: result.LeaderReelect}
+	if status.Validators, err = toTopologyNodes(result.Validators); err != nil {
+		return nil, err
+	}
+	if status.BackupValidators, err = toTopologyNodes(result.BackupValidators); err != nil {
+		return nil, err
+	}
+	if status.Miners, err = toTopologyNodes(result.Miners); err != nil {
+		return nil, err
+	}
+	if status.ElectValidators, err = toTopologyNodes(result.ElectValidators); err != nil {
+		return nil, err
+	}
+	if status.ElectBackupValidators, err = toTopologyNodes(result.ElectBackupValidators); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// decimalBig decodes the big integers of the validator group info, encoded as
+// decimal strings, or 0 when nil.
+type decimalBig struct {
+	big.Int
+}
+
+func (d *decimalBig) UnmarshalJSON(input []byte) error {
+	var str string
+	if err := json.Unmarshal(input, &str); err != nil {
+		str = string(input)
+	}
+	if _, ok := d.SetString(str, 10); !ok {
+		return fmt.Errorf("invalid decimal %s", input)
+	}
+	return nil
+}
+
+func (d *decimalBig) big() *big.Int {
+	if d == nil {
+		return nil
+	}
+	return new(big.Int).Set(&d.Int)
+}
+
+type rpcRate struct {
+	Rate    *decimalBig
+	Decimal *decimalBig
+}
+
+func (r rpcRate) rate() Rate {
+	return Rate{Rate: r.Rate.big(), Decimal: r.Decimal.big()}
+}
+
+type rpcWithdraw struct {
+	WithDrawAmount *decimalBig
+	WithDrawTime   uint64
+}
+
+func toWithDrawInfos(list []rpcWithdraw) []common.WithDrawInfo {
+	result := make([]common.WithDrawInfo, 0, len(list))
+	for _, wd := range list {
+		result = append(result, common.WithDrawInfo{WithDrawAmount: wd.WithDrawAmount.big(), WithDrawTime: wd.WithDrawTime})
+	}
+	return result
+}
+
+type rpcValidatorGroup struct {
+	OwnerInfo struct {
+		Owner           string
+		WithdrawAllTime uint64
+		SignAddress     string
+	}
+	Reward struct {
+		OwnerRate rpcRate
+		NodeRate  rpcRate
+		LevelRate []struct {
+			Threshold *decimalBig
+			Rate      rpcRate
+		}
+	}
+	ValidatorMap []struct {
+		Address   string
+		Reward    *decimalBig
+		AllAmount *decimalBig
+		Current   struct {
+			Amount       *decimalBig
+			PreAmount    *decimalBig
+			Interest     *decimalBig
+			WithdrawList []rpcWithdraw
+		}
+		Positions []struct {
+			DType    uint64
+			Position uint64
+			Amount   *decimalBig
+			EndTime  uint64
+		}
+	}
+}
+
+// ValidatorGroupInfo returns the validator group contracts, by contract address.
+func (ec *Client) ValidatorGroupInfo(ctx context.Context, blockNumber *big.Int) (map[common.Address]*ValidatorGroup, error) {
+	var result map[string]*rpcValidatorGroup
+	if err := ec.c.CallContext(ctx, &result, "man_getValidatorGroupInfo", toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	groups := make(map[common.Address]*ValidatorGroup, len(result))
+	for strAddress, info := range result {
+		if info == nil {
+			continue
+		}
+		contract, err := decodeAddress(strAddress)
+		if err != nil {
+			return nil, err
+		}
+		group := &ValidatorGroup{
+			WithdrawAllTime: info.OwnerInfo.WithdrawAllTime,
+			OwnerRate:       info.Reward.OwnerRate.rate(),
+			NodeRate:        info.Reward.NodeRate.rate(),
+		}
+		if group.Owner, err = decodeAddress(info.OwnerInfo.Owner); err != nil {
+			return nil, err
+		}
+		if group.SignAddress, err = decodeAddress(info.OwnerInfo.SignAddress); err != nil {
+			return nil, err
+		}
+		for _, level := range info.Reward.LevelRate {
+			group.LevelRate = append(group.LevelRate, LevelRate{Threshold: level.Threshold.big(), Rate: level.Rate.rate()})
+		}
+		for _, v := range info.ValidatorMap {
+			validator := GroupValidator{
+				Reward:       v.Reward.big(),
+				AllAmount:    v.AllAmount.big(),
+				Amount:       v.Current.Amount.big(),
+				PreAmount:    v.Current.PreAmount.big(),
+				Interest:     v.Current.Interest.big(),
+				WithdrawList: toWithDrawInfos(v.Current.WithdrawList),
+			}
+			if validator.Address, err = decodeAddress(v.Address); err != nil {
+				return nil, err
+			}
+			for _, pos := range v.Positions {
+				validator.Positions = append(validator.Positions, GroupPosition{DType: pos.DType, Position: pos.Position, Amount: pos.Amount.big(), EndTime: pos.EndTime})
+			}
+			group.Validators = append(group.Validators, validator)
+		}
+		groups[contract] = group
+	}
+	return groups, nil
+}
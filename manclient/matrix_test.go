@@ -0,0 +1,150 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package manclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+var (
+	testAccount  = common.BytesToAddress([]byte{0x01})
+	testSigner   = common.BytesToAddress([]byte{0x02})
+	testContract = common.BytesToAddress([]byte{0x03})
+)
+
+// ManAPIStub serves the man methods the way the node encodes them.
+type ManAPIStub struct{}
+
+func (s *ManAPIStub) GetTopologyStatusByNumber(ctx context.Context, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	node := map[string]interface{}{"account": base58.Base58EncodeToString(params.MAN_COIN, testAccount), "online": true, "position": 8192}
+	return map[string]interface{}{
+		"leader_reelect": true,
+		"validators":     []interface{}{node},
+		"miners":         []interface{}{},
+	}, nil
+}
+
+func (s *ManAPIStub) GetValidatorGroupInfo(ctx context.Context, blockNr rpc.BlockNumber) (json.RawMessage, error) {
+	return json.RawMessage(`{"` + base58.Base58EncodeToString(params.MAN_COIN, testContract) + `": {
+		"OwnerInfo": {"Owner": "` + base58.Base58EncodeToString(params.MAN_COIN, testAccount) + `", "WithdrawAllTime": 0, "SignAddress": "` + base58.Base58EncodeToString(params.MAN_COIN, testSigner) + `"},
+		"Reward": {"OwnerRate": {"Rate": "1", "Decimal": "10"}, "NodeRate": {"Rate": "0", "Decimal": "10"}, "LevelRate": [{"Threshold": "1000000000000000000000", "Rate": {"Rate": "2", "Decimal": "10"}}]},
+		"ValidatorMap": [{"Address": "` + base58.Base58EncodeToString(params.MAN_COIN, testAccount) + `", "Reward": 0, "AllAmount": "500",
+			"Current": {"Amount": "300", "PreAmount": "0", "Interest": "7", "WithdrawList": [{"WithDrawAmount": "100", "WithDrawTime": 1546300800}]},
+			"Positions": [{"DType": 1, "Position": 1, "Amount": "200", "EndTime": 1548979200}]}]
+	}}`), nil
+}
+
+func newTestClient(t *testing.T) *Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName("man", new(ManAPIStub)); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	return NewClient(rpc.DialInProc(server))
+}
+
+func TestTopologyStatusByNumber(t *testing.T) {
+	ec := newTestClient(t)
+	defer ec.Close()
+
+	status, err := ec.TopologyStatusByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to get topology status: %v", err)
+	}
+	if !status.LeaderReelect || len(status.Validators) != 1 || len(status.Miners) != 0 {
+		t.Fatalf("topology status mismatch: %+v", status)
+	}
+	if node := status.Validators[0]; node.Account != testAccount || !node.Online || node.Position != 8192 {
+		t.Fatalf("topology node mismatch: %+v", node)
+	}
+}
+
+func TestValidatorGroupInfo(t *testing.T) {
+	ec := newTestClient(t)
+	defer ec.Close()
+
+	groups, err := ec.ValidatorGroupInfo(context.Background(), big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to get validator groups: %v", err)
+	}
+	group := groups[testContract]
+	if len(groups) != 1 || group == nil {
+		t.Fatalf("validator groups mismatch: %v", groups)
+	}
+	if group.Owner != testAccount || group.SignAddress != testSigner {
+		t.Fatalf("group owner mismatch: %+v", group)
+	}
+	if group.OwnerRate.Rate.Int64() != 1 || len(group.LevelRate) != 1 || group.LevelRate[0].Threshold.String() != "1000000000000000000000" {
+		t.Fatalf("group rates mismatch: %+v", group)
+	}
+	if len(group.Validators) != 1 {
+		t.Fatalf("group validators mismatch: %+v", group.Validators)
+	}
+	v := group.Validators[0]
+	if v.Address != testAccount || v.Reward.Sign() != 0 || v.Amount.Int64() != 300 || v.Interest.Int64() != 7 {
+		t.Fatalf("group validator mismatch: %+v", v)
+	}
+	if len(v.WithdrawList) != 1 || v.WithdrawList[0].WithDrawAmount.Int64() != 100 || len(v.Positions) != 1 || v.Positions[0].Amount.Int64() != 200 {
+		t.Fatalf("group validator positions mismatch: %+v", v)
+	}
+}
+
+func TestMatrixTxBuilders(t *testing.T) {
+	from := testAccount
+	to := common.BytesToAddress([]byte{0x04})
+
+	tx, err := NewRevocableTx(TxOptions{Nonce: 1}, []Transfer{{to, big.NewInt(10)}, {from, big.NewInt(20)}}, 1600000000)
+	if err != nil {
+		t.Fatalf("failed to build revocable tx: %v", err)
+	}
+	if tx.GetMatrixType() != common.ExtraRevocable || tx.GetCreateTime() != 1600000000 || tx.GetTxCurrency() != params.MAN_COIN {
+		t.Fatalf("revocable tx mismatch: %v", tx)
+	}
+	if ex := tx.GetMatrix_EX(); len(ex) != 1 || len(ex[0].ExtraTo) != 1 || ex[0].ExtraTo[0].Amount.Int64() != 20 {
+		t.Fatalf("revocable tx recipients mismatch: %v", ex)
+	}
+	if tx.Gas() != 2*params.TxGas {
+		t.Fatalf("revocable tx gas mismatch: have %d, want %d", tx.Gas(), 2*params.TxGas)
+	}
+	if _, err := NewTimeTx(TxOptions{}, []Transfer{{to, big.NewInt(10)}}, 0); err == nil {
+		t.Fatalf("time tx without due time built")
+	}
+
+	entrust := common.EntrustType{EntrustAddres: base58.Base58EncodeToString(params.MAN_COIN, to), IsEntrustGas: true, StartHeight: 10, EndHeight: 20}
+	if tx, err = NewAuthTx(TxOptions{}, from, []common.EntrustType{entrust}); err != nil {
+		t.Fatalf("failed to build auth tx: %v", err)
+	}
+	var entrusts []common.EntrustType
+	if err := json.Unmarshal(tx.Data(), &entrusts); err != nil || len(entrusts) != 1 || entrusts[0] != entrust {
+		t.Fatalf("auth tx data mismatch: %s", tx.Data())
+	}
+	if _, err := NewAuthTx(TxOptions{Currency: "ABC"}, from, []common.EntrustType{entrust}); err == nil {
+		t.Fatalf("auth tx with mismatched currency built")
+	}
+
+	if tx, err = NewMakeCoinTx(TxOptions{}, MakeCoin{Name: "ABC", Amounts: map[common.Address]*big.Int{to: big.NewInt(1000)}}, big.NewInt(5)); err != nil {
+		t.Fatalf("failed to build make coin tx: %v", err)
+	}
+	if *tx.To() != common.DestroyAddress || tx.Value().Int64() != 5 {
+		t.Fatalf("make coin tx mismatch: %v", tx)
+	}
+	var makecoin common.SMakeCoin
+	if err := json.Unmarshal(tx.Data(), &makecoin); err != nil || makecoin.CoinName != "ABC" {
+		t.Fatalf("make coin tx data mismatch: %s", tx.Data())
+	}
+	if amount := makecoin.AddrAmount[base58.Base58EncodeToString("ABC", to)]; amount == nil || amount.ToInt().Int64() != 1000 {
+		t.Fatalf("make coin balances mismatch: %v", makecoin.AddrAmount)
+	}
+	if _, err := NewMakeCoinTx(TxOptions{}, MakeCoin{Name: "abc", Amounts: map[common.Address]*big.Int{to: big.NewInt(1)}}, nil); err == nil {
+		t.Fatalf("make coin tx with invalid name built")
+	}
+}
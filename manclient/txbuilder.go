@@ -0,0 +1,225 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package manclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// Builders of the unsigned Matrix special transactions. The transactions are
+// to be signed with types.SignTx and sent with SendTransaction.
+
+// TxOptions are the common fields of the built transactions.
+type TxOptions struct {
+	Nonce    uint64
+	Currency string   // MAN when empty
+	GasLimit uint64   // the intrinsic gas of the transaction when 0
+	GasPrice *big.Int // params.TxGasPrice when nil
+}
+
+// Transfer is a recipient of a time or revocable transaction.
+type Transfer struct {
+	To    common.Address
+	Value *big.Int
+}
+
+func (opts *TxOptions) currency() string {
+	if opts.Currency == "" {
+		return params.MAN_COIN
+	}
+	return opts.Currency
+}
+
+// intrinsicGas is core.IntrinsicGas of the payloads of the transaction.
+func intrinsicGas(payloads ...[]byte) uint64 {
+	var gas uint64
+	for _, data := range payloads {
+		gas += params.TxGas
+		for _, b := range data {
+			if b != 0 {
+				gas += params.TxDataNonZeroGas
+			} else {
+				gas += params.TxDataZeroGas
+			}
+		}
+	}
+	return gas
+}
+
+func newMatrixTx(opts TxOptions, txType byte, to common.Address, amount *big.Int, data []byte, extra []*types.ExtraTo_tr, commitTime uint64) *types.Transaction {
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		payloads := [][]byte{data}
+		for _, ex := range extra {
+			if ex.Input_tr != nil {
+				payloads = append(payloads, *ex.Input_tr)
+			} else {
+				payloads = append(payloads, nil)
+			}
+		}
+		gasLimit = intrinsicGas(payloads...)
+	}
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice = new(big.Int).SetUint64(params.TxGasPrice)
+	}
+	if amount == nil {
+		amount = new(big.Int)
+	}
+	return types.NewTransactions(opts.Nonce, to, amount, gasLimit, gasPrice, data, nil, nil, nil, extra, 0, txType, 0, opts.currency(), commitTime)
+}
+
+func transfersTx(opts TxOptions, txType byte, transfers []Transfer, dueTime uint64) (*types.Transaction, error) {
+	if len(transfers) == 0 {
+		return nil, errors.New("no recipient")
+	}
+	if uint64(len(transfers)) > params.TxCount {
+		return nil, fmt.Errorf("too many recipients, max %d", params.TxCount)
+	}
+	if dueTime == 0 {
+		return nil, errors.New("no due time")
+	}
+	extra := make([]*types.ExtraTo_tr, 0, len(transfers)-1)
+	for _, t := range transfers[1:] {
+		to, value := t.To, t.Value
+		if value == nil {
+			value = new(big.Int)
+		}
+		extra = append(extra, &types.ExtraTo_tr{To_tr: &to, Value_tr: (*hexutil.Big)(value)})
+	}
+	return newMatrixTx(opts, txType, transfers[0].To, transfers[0].Value, nil, extra, dueTime), nil
+}
+
+// NewTimeTx returns a time transaction (common.ExtraTimeTxType), transferring
+// to the recipients at the first block reaching the due time, unix seconds.
+func NewTimeTx(opts TxOptions, transfers []Transfer, dueTime uint64) (*types.Transaction, error) {
+	return transfersTx(opts, common.ExtraTimeTxType, transfers, dueTime)
+}
+
+// NewRevocableTx returns a revocable transaction (common.ExtraRevocable),
+// transferring to the recipients at the first block reaching the due time,
+// unix seconds. The sender can revert it with NewRevertTx until then.
+func NewRevocableTx(opts TxOptions, transfers []Transfer, dueTime uint64) (*types.Transaction, error) {
+	return transfersTx(opts, common.ExtraRevocable, transfers, dueTime)
+}
+
+// NewRevertTx returns the transaction of the sender reverting its pending
+// revocable transactions (common.ExtraRevertTxType), of the same currency.
+func NewRevertTx(opts TxOptions, from common.Address, hashes []common.Hash) (*types.Transaction, error) {
+	if len(hashes) == 0 {
+		return nil, errors.New("no transaction to revert")
+	}
+	if uint64(len(hashes)) > params.TxCount {
+		return nil, fmt.Errorf("too many transactions to revert, max %d", params.TxCount)
+	}
+	extra := make([]*types.ExtraTo_tr, 0, len(hashes)-1)
+	for _, hash := range hashes[1:] {
+		to, input := from, hexutil.Bytes(hash.Bytes())
+		extra = append(extra, &types.ExtraTo_tr{To_tr: &to, Value_tr: new(hexutil.Big), Input_tr: &input})
+	}
+	return newMatrixTx(opts, common.ExtraRevertTxType, from, nil, hashes[0].Bytes(), extra, 0), nil
+}
+
+// NewAuthTx returns the transaction of the sender entrusting its gas or signature
+// to other accounts (common.ExtraAuthTx). The EntrustAddres of the entrusts are
+// base58 addresses in the currency of the transaction.
+func NewAuthTx(opts TxOptions, from common.Address, entrusts []common.EntrustType) (*types.Transaction, error) {
+	if len(entrusts) == 0 {
+		return nil, errors.New("no entrust")
+	}
+	coin := opts.currency()
+	for i, entrust := range entrusts {
+		address, err := base58.Base58DecodeToAddress(entrust.EntrustAddres)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entrust address %q: %v", entrust.EntrustAddres, err)
+		}
+		if address == from {
+			return nil, fmt.Errorf("entrust %d: the sender can't entrust itself", i)
+		}
+		if !entrust.IsEntrustGas && !entrust.IsEntrustSign {
+			return nil, fmt.Errorf("entrust %d: neither gas nor signature entrusted", i)
+		}
+		if entrust.EnstrustSetType == params.EntrustByHeight && entrust.StartHeight > entrust.EndHeight ||
+			entrust.EnstrustSetType == params.EntrustByTime && entrust.StartTime > entrust.EndTime {
+			return nil, fmt.Errorf("entrust %d: invalid range", i)
+		}
+		if prefix := strings.Split(entrust.EntrustAddres, ".")[0]; prefix != coin {
+			return nil, fmt.Errorf("entrust %d: address currency %s mismatch with %s", i, prefix, coin)
+		}
+	}
+	data, err := json.Marshal(entrusts)
+	if err != nil {
+		return nil, err
+	}
+	return newMatrixTx(opts, common.ExtraAuthTx, from, nil, data, nil, 0), nil
+}
+
+// NewCancelEntrustTx returns the transaction of the sender cancelling its
+// entrusts (common.ExtraCancelEntrust), by index in its entrust list, see
+// Client.EntrustList.
+func NewCancelEntrustTx(opts TxOptions, from common.Address, indexes []uint32) (*types.Transaction, error) {
+	if len(indexes) == 0 {
+		return nil, errors.New("no entrust to cancel")
+	}
+	data, err := json.Marshal(indexes)
+	if err != nil {
+		return nil, err
+	}
+	return newMatrixTx(opts, common.ExtraCancelEntrust, from, nil, data, nil, 0), nil
+}
+
+// MakeCoin describes the coin a make coin transaction creates.
+type MakeCoin struct {
+	Name        string
+	Amounts     map[common.Address]*big.Int // initial balances, the total supply
+	Unit        *big.Int                    // params.CoinTypeUnit when nil
+	PackNum     uint64                      // params.CallTxPachNum when 0
+	GasAddress  common.Address              // receives the gas of the coin, common.TxGasRewardAddress when zero
+	PayCoinType string
+}
+
+// NewMakeCoinTx returns the transaction creating a coin (common.ExtraMakeCoinType).
+// It's a MAN transaction sending the fee, see Client.DestroyBalance, to the
+// destroy address.
+func NewMakeCoinTx(opts TxOptions, coin MakeCoin, fee *big.Int) (*types.Transaction, error) {
+	if !common.IsValidityCurrency(coin.Name) {
+		return nil, fmt.Errorf("invalid coin name %q", coin.Name)
+	}
+	if len(coin.Amounts) == 0 {
+		return nil, errors.New("no initial balance")
+	}
+	if opts.currency() != params.MAN_COIN {
+		return nil, errors.New("make coin transaction must be a MAN transaction")
+	}
+	makecoin := common.SMakeCoin{
+		CoinName:    coin.Name,
+		AddrAmount:  make(map[string]*hexutil.Big, len(coin.Amounts)),
+		CoinUnit:    (*hexutil.Big)(coin.Unit),
+		PackNum:     coin.PackNum,
+		CoinAddress: coin.GasAddress,
+		PayCoinType: coin.PayCoinType,
+	}
+	for address, amount := range coin.Amounts {
+		if amount == nil || amount.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid initial balance of %s", address.Hex())
+		}
+		makecoin.AddrAmount[base58.Base58EncodeToString(coin.Name, address)] = (*hexutil.Big)(amount)
+	}
+	data, err := json.Marshal(&makecoin)
+	if err != nil {
+		return nil, err
+	}
+	return newMatrixTx(opts, common.ExtraMakeCoinType, common.DestroyAddress, fee, data, nil, 0), nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 )
 
 type ChainReader interface {
@@ -73,6 +74,7 @@ type Work struct {
 	random   *baseinterface.Random
 	txs      []types.CoinSelfTransaction
 	Receipts []types.CoinReceipts
+	Rewards  []*ledger.Entry // reward ledger entries of the block
 
 	transer []types.SelfTransaction
 	recpts  []*types.Receipt
@@ -394,7 +396,8 @@ func (env *Work) ProcessTransactions(mux *event.TypeMux, tp txPoolReader, upTime
 	}
 
 	log.Info("work", "关键时间点", "执行交易完成，开始执行奖励", "time", time.Now(), "块高", env.header.Number, "tx num ", len(originalTxs))
-	rewart := env.bc.Processor(env.header.Version).ProcessReward(env.State, env.header, upTime, from, env.mapcoingasUse.mapcoin)
+	rewart, rewards := env.bc.Processor(env.header.Version).ProcessReward(env.State, env.header, upTime, from, env.mapcoingasUse.mapcoin)
+	env.Rewards = rewards
 	rewardTxmap := env.makeTransaction(rewart)
 	allfinalTxs := make([]types.CoinSelfTransaction, 0, len(coins)) //按币种存放的所有交易切片(先放分区币种的奖励交易，然后存该币种的普通交易)
 	allfinalRecpets := make([]types.CoinReceipts, 0, len(coins))    //按币种存放的所有收据切片(先放分区币种的奖励收据，然后存该币种的普通收据)
@@ -605,7 +608,8 @@ func (env *Work) ProcessBroadcastTransactions(mux *event.TypeMux, txs []types.Co
 		CoinsMap[cointxs.CoinType] = true
 	}
 
-	rewart := env.bc.Processor(env.header.Version).ProcessReward(env.State, env.header, nil, nil, nil)
+	rewart, rewards := env.bc.Processor(env.header.Version).ProcessReward(env.State, env.header, nil, nil, nil)
+	env.Rewards = rewards
 	rewardTxmap := env.makeTransaction(rewart)
 
 	allfinalTxs := make([]types.CoinSelfTransaction, 0, len(coins)) //按币种存放的所有交易切片(先放分区币种的奖励交易，然后存该币种的普通交易)
@@ -780,7 +784,8 @@ func (env *Work) ConsensusTransactions(mux *event.TypeMux, txs []types.CoinSelfT
 	}
 
 	log.Info("work", "关键时间点", "执行交易完成，开始执行奖励", "time", time.Now(), "块高", env.header.Number)
-	rewart := env.bc.Processor(env.header.Version).ProcessReward(env.State, env.header, upTime, from, env.mapcoingasUse.mapcoin)
+	rewart, rewards := env.bc.Processor(env.header.Version).ProcessReward(env.State, env.header, upTime, from, env.mapcoingasUse.mapcoin)
+	env.Rewards = rewards
 	rewardTxmap := env.makeTransaction(rewart)
 
 	allfinalTxs := make([]types.CoinSelfTransaction, 0, len(coins)) //按币种存放的所有交易切片(先放分区币种的奖励交易，然后存该币种的普通交易)
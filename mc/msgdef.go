@@ -11,6 +11,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/core/vm"
 	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 )
 
 //common
@@ -181,6 +182,7 @@ type LocalBlockVerifyConsensusReq struct {
 	FinalTxs              []types.CoinSelfTransaction // 最终交易列表(含奖励交易)
 	Receipts              []types.CoinReceipts        // 收据
 	State                 *state.StateDBManage        // apply state changes here 状态数据库
+	Rewards               []*ledger.Entry             // 奖励账本
 }
 
 type BlockPOSFinishedNotify struct {
@@ -197,6 +199,7 @@ type BlockLocalVerifyOK struct {
 	FinalTxs    []types.CoinSelfTransaction // 最终交易列表(含奖励交易)
 	Receipts    []types.CoinReceipts        // 收据
 	State       *state.StateDBManage        // apply state changes here 状态数据库
+	Rewards     []*ledger.Entry             // 奖励账本
 }
 
 //BolckGenor
@@ -0,0 +1,212 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package olconsensus
+
+import (
+	"errors"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// maxHistoryPageSize is the max number of entries returned at once.
+const maxHistoryPageSize = 1000
+
+var errHistoryDisabled = errors.New("online consensus history is not enabled")
+
+// RPCOnlineVote is a vote for an online consensus proposal, Voter is the sign
+// account of the validator.
+type RPCOnlineVote struct {
+	Voter   string `json:"voter"`
+	Approve bool   `json:"approve"`
+}
+
+// RPCOnlineProposal is an online consensus proposal with its votes and result.
+type RPCOnlineProposal struct {
+	Hash       common.Hash      `json:"hash"`
+	Number     uint64           `json:"number"`
+	LeaderTurn uint32           `json:"leaderTurn"`
+	Leader     string           `json:"leader"`
+	Node       string           `json:"node"`
+	State      string           `json:"state"`
+	Proposer   string           `json:"proposer"`
+	Votes      []*RPCOnlineVote `json:"votes"`
+	Approvals  int              `json:"approvals"`
+	Rejections int              `json:"rejections"`
+	Finished   bool             `json:"finished"`
+	ResultFrom string           `json:"resultFrom"`
+	Signers    []*RPCOnlineVote `json:"signers"`
+}
+
+// RPCOnlineStateChange is a change of the online state of a node in a block,
+// Proposal is the consensus result causing it, nil if this node didn't see it.
+type RPCOnlineStateChange struct {
+	Number      uint64             `json:"number"`
+	BlockHash   common.Hash        `json:"blockHash"`
+	OldState    string             `json:"oldState"`
+	NewState    string             `json:"newState"`
+	OldPosition uint16             `json:"oldPosition"`
+	NewPosition uint16             `json:"newPosition"`
+	Proposal    *RPCOnlineProposal `json:"proposal"`
+}
+
+// RPCNodeOnlineHistory is a page of the online state changes of a node.
+type RPCNodeOnlineHistory struct {
+	Node    string                  `json:"node"`
+	Total   uint64                  `json:"total"`
+	Changes []*RPCOnlineStateChange `json:"changes"`
+}
+
+// RPCNodeOnlineProposals is a page of the online consensus proposals of a node.
+type RPCNodeOnlineProposals struct {
+	Node      string               `json:"node"`
+	Total     uint64               `json:"total"`
+	Proposals []*RPCOnlineProposal `json:"proposals"`
+}
+
+// PublicOnlineConsensusAPI provides the online consensus history, to dispute the
+// offline marks reducing the uptime of a node.
+type PublicOnlineConsensusAPI struct {
+	serv *TopNodeService
+}
+
+func NewPublicOnlineConsensusAPI(serv *TopNodeService) *PublicOnlineConsensusAPI {
+	return &PublicOnlineConsensusAPI{serv: serv}
+}
+
+func rpcAccount(addr common.Address) string {
+	if addr == (common.Address{}) {
+		return ""
+	}
+	return base58.Base58EncodeToString(params.MAN_COIN, addr)
+}
+
+func rpcOnlineVotes(votes []rawdb.OnlineConsensusVote) ([]*RPCOnlineVote, int) {
+	result := make([]*RPCOnlineVote, 0, len(votes))
+	approvals := 0
+	for _, vote := range votes {
+		if vote.Approve {
+			approvals++
+		}
+		result = append(result, &RPCOnlineVote{Voter: rpcAccount(vote.Voter), Approve: vote.Approve})
+	}
+	return result, approvals
+}
+
+func newRPCOnlineProposal(record *rawdb.OnlineConsensusRecord) *RPCOnlineProposal {
+	votes, approvals := rpcOnlineVotes(record.Votes)
+	signers, _ := rpcOnlineVotes(record.Signers)
+	return &RPCOnlineProposal{
+		Hash:       record.Hash,
+		Number:     record.Number,
+		LeaderTurn: record.LeaderTurn,
+		Leader:     rpcAccount(record.Leader),
+		Node:       rpcAccount(record.Node),
+		State:      mc.OnlineState(record.State).String(),
+		Proposer:   rpcAccount(record.Proposer),
+		Votes:      votes,
+		Approvals:  approvals,
+		Rejections: len(votes) - approvals,
+		Finished:   record.Finished,
+		ResultFrom: rpcAccount(record.ResultFrom),
+		Signers:    signers,
+	}
+}
+
+func historyLimit(limit uint64) uint64 {
+	if limit == 0 || limit > maxHistoryPageSize {
+		return maxHistoryPageSize
+	}
+	return limit
+}
+
+func (api *PublicOnlineConsensusAPI) db() (mandb.Database, error) {
+	if api.serv == nil || api.serv.history == nil {
+		return nil, errHistoryDisabled
+	}
+	return api.serv.history.db, nil
+}
+
+// GetNodeOnlineHistory returns at most limit online state changes of the node
+// from the index offset, in block order, each with the proposal and the votes
+// leading to it.
+func (api *PublicOnlineConsensusAPI) GetNodeOnlineHistory(strAddr string, offset uint64, limit uint64) (*RPCNodeOnlineHistory, error) {
+	db, err := api.db()
+	if err != nil {
+		return nil, err
+	}
+	node, err := base58.Base58DecodeToAddress(strAddr)
+	if err != nil {
+		return nil, err
+	}
+	changes := rawdb.ReadOnlineStateChanges(db, node, offset, historyLimit(limit))
+	result := &RPCNodeOnlineHistory{
+		Node:    rpcAccount(node),
+		Total:   rawdb.ReadOnlineStateChangeCount(db, node),
+		Changes: make([]*RPCOnlineStateChange, 0, len(changes)),
+	}
+	for _, change := range changes {
+		item := &RPCOnlineStateChange{
+			Number:      change.Number,
+			BlockHash:   change.BlockHash,
+			OldState:    positionState(change.OldPosition).String(),
+			NewState:    positionState(change.NewPosition).String(),
+			OldPosition: change.OldPosition,
+			NewPosition: change.NewPosition,
+		}
+		if change.Proposal != (common.Hash{}) {
+			if record := rawdb.ReadOnlineConsensusRecord(db, change.Proposal); record != nil {
+				item.Proposal = newRPCOnlineProposal(record)
+			}
+		}
+		result.Changes = append(result.Changes, item)
+	}
+	return result, nil
+}
+
+// GetNodeOnlineProposals returns at most limit online consensus proposals of
+// the node from the index offset, in the order they were seen.
+func (api *PublicOnlineConsensusAPI) GetNodeOnlineProposals(strAddr string, offset uint64, limit uint64) (*RPCNodeOnlineProposals, error) {
+	db, err := api.db()
+	if err != nil {
+		return nil, err
+	}
+	node, err := base58.Base58DecodeToAddress(strAddr)
+	if err != nil {
+		return nil, err
+	}
+	hashes := rawdb.ReadOnlineConsensusHashesByNode(db, node, offset, historyLimit(limit))
+	result := &RPCNodeOnlineProposals{
+		Node:      rpcAccount(node),
+		Total:     rawdb.ReadOnlineConsensusCountByNode(db, node),
+		Proposals: make([]*RPCOnlineProposal, 0, len(hashes)),
+	}
+	for _, hash := range hashes {
+		if record := rawdb.ReadOnlineConsensusRecord(db, hash); record != nil {
+			result.Proposals = append(result.Proposals, newRPCOnlineProposal(record))
+		}
+	}
+	return result, nil
+}
+
+// GetOnlineProposalsByNumber returns the online consensus proposals of the
+// height, in the order they were seen.
+func (api *PublicOnlineConsensusAPI) GetOnlineProposalsByNumber(number uint64) ([]*RPCOnlineProposal, error) {
+	db, err := api.db()
+	if err != nil {
+		return nil, err
+	}
+	hashes := rawdb.ReadOnlineConsensusHashesByNumber(db, number)
+	result := make([]*RPCOnlineProposal, 0, len(hashes))
+	for _, hash := range hashes {
+		if record := rawdb.ReadOnlineConsensusRecord(db, hash); record != nil {
+			result = append(result, newRPCOnlineProposal(record))
+		}
+	}
+	return result, nil
+}
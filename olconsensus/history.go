@@ -0,0 +1,198 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package olconsensus
+
+import (
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+const (
+	// historySearchLimit is the number of the last proposals of a node searched
+	// for the result causing a change of its online state.
+	historySearchLimit = 64
+	// historyKeepBlocks is the number of the last heights whose proposals are kept.
+	historyKeepBlocks = 100000
+	// historyPruneLimit is the max number of heights pruned per block.
+	historyPruneLimit = 1000
+)
+
+// onlineHistory persists the online consensus proposals, votes and results seen
+// by the node, and the online state changes of the blocks they led to.
+type onlineHistory struct {
+	mu        sync.Mutex
+	db        mandb.Database
+	extraInfo string
+}
+
+func newOnlineHistory(db mandb.Database, info string) *onlineHistory {
+	return &onlineHistory{db: db, extraInfo: info}
+}
+
+func (h *onlineHistory) record(hash common.Hash) *rawdb.OnlineConsensusRecord {
+	if record := rawdb.ReadOnlineConsensusRecord(h.db, hash); record != nil {
+		return record
+	}
+	return &rawdb.OnlineConsensusRecord{Hash: hash}
+}
+
+// fill sets the proposal of a record and indexes it, unless it's done already.
+func (h *onlineHistory) fill(record *rawdb.OnlineConsensusRecord, req *mc.OnlineConsensusReq, proposer common.Address) {
+	if record.Node != (common.Address{}) {
+		return
+	}
+	record.Number = req.Number
+	record.LeaderTurn = req.LeaderTurn
+	record.Leader = req.Leader
+	record.Node = req.Node
+	record.State = uint8(req.OnlineState)
+	record.Proposer = proposer
+	rawdb.IndexOnlineConsensusRecord(h.db, h.db, record)
+}
+
+func addConsensusVote(votes []rawdb.OnlineConsensusVote, hash common.Hash, sign common.Signature) []rawdb.OnlineConsensusVote {
+	voter, approve, err := crypto.VerifySignWithValidate(hash.Bytes(), sign.Bytes())
+	if err != nil {
+		return votes
+	}
+	for i := range votes {
+		if votes[i].Voter == voter {
+			votes[i].Approve = approve
+			return votes
+		}
+	}
+	return append(votes, rawdb.OnlineConsensusVote{Voter: voter, Approve: approve})
+}
+
+// addProposal records a proposal accepted from the proposer.
+func (h *onlineHistory) addProposal(hash common.Hash, req *mc.OnlineConsensusReq, proposer common.Address) {
+	if h == nil || req == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	record := h.record(hash)
+	if record.Node != (common.Address{}) {
+		return
+	}
+	h.fill(record, req, proposer)
+	rawdb.WriteOnlineConsensusRecord(h.db, record)
+}
+
+// addVotes records the votes of a recorded proposal passing the POS
+// verification, the voters and their choices are recovered from the signatures.
+func (h *onlineHistory) addVotes(hash common.Hash, signs []common.Signature) {
+	if h == nil || len(signs) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	record := rawdb.ReadOnlineConsensusRecord(h.db, hash)
+	if record == nil || record.Node == (common.Address{}) {
+		return
+	}
+	for _, sign := range signs {
+		record.Votes = addConsensusVote(record.Votes, hash, sign)
+	}
+	rawdb.WriteOnlineConsensusRecord(h.db, record)
+}
+
+// setResult records a result passing the POS verification with its signatures.
+func (h *onlineHistory) setResult(hash common.Hash, msg *mc.HD_OnlineConsensusVoteResultMsg, signs []common.Signature) {
+	if h == nil || msg == nil || msg.Req == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	record := h.record(hash)
+	h.fill(record, msg.Req, msg.Req.Leader)
+	record.Finished = true
+	record.ResultFrom = msg.From
+	record.Signers = make([]rawdb.OnlineConsensusVote, 0, len(signs))
+	for _, sign := range signs {
+		record.Signers = addConsensusVote(record.Signers, hash, sign)
+	}
+	rawdb.WriteOnlineConsensusRecord(h.db, record)
+}
+
+func positionState(position uint16) mc.OnlineState {
+	if position == common.PosOffline {
+		return mc.OffLine
+	}
+	return mc.OnLine
+}
+
+// findResult returns the hash of the last finished proposal of the node for the
+// state, proposed before the height.
+func (h *onlineHistory) findResult(node common.Address, state mc.OnlineState, number uint64) common.Hash {
+	count := rawdb.ReadOnlineConsensusCountByNode(h.db, node)
+	start := uint64(0)
+	if count > historySearchLimit {
+		start = count - historySearchLimit
+	}
+	hashes := rawdb.ReadOnlineConsensusHashesByNode(h.db, node, start, count-start)
+	for i := len(hashes) - 1; i >= 0; i-- {
+		record := rawdb.ReadOnlineConsensusRecord(h.db, hashes[i])
+		if record != nil && record.Finished && record.State == uint8(state) && record.Number <= number {
+			return record.Hash
+		}
+	}
+	return common.Hash{}
+}
+
+// updatePositions records the online state changes of the elected nodes at the
+// block, against the positions of the last recorded block. The changes of the
+// blocks reorganised out are dropped, the positions of the first block after a
+// gap are only recorded as the new reference.
+func (h *onlineHistory) updatePositions(number uint64, blockHash common.Hash, electOnline *mc.ElectOnlineStatus) {
+	if h == nil || electOnline == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	last := rawdb.ReadOnlinePositions(h.db)
+	positions := &rawdb.OnlinePositions{Number: number, BlockHash: blockHash, Nodes: make([]rawdb.OnlineNodePosition, 0, len(electOnline.ElectOnline))}
+	for _, elect := range electOnline.ElectOnline {
+		positions.Nodes = append(positions.Nodes, rawdb.OnlineNodePosition{Node: elect.Account, Position: elect.Position})
+	}
+	if last != nil && last.Number >= number {
+		for _, node := range positions.Nodes {
+			rawdb.TruncateOnlineStateChanges(h.db, h.db, node.Node, number)
+		}
+	}
+	if last != nil && last.Number+1 == number {
+		old := make(map[common.Address]uint16, len(last.Nodes))
+		for _, node := range last.Nodes {
+			old[node.Node] = node.Position
+		}
+		for _, node := range positions.Nodes {
+			oldPosition, exist := old[node.Node]
+			if !exist || positionState(oldPosition) == positionState(node.Position) {
+				continue
+			}
+			state := positionState(node.Position)
+			change := &rawdb.OnlineStateChange{
+				Number:      number,
+				BlockHash:   blockHash,
+				Node:        node.Node,
+				OldPosition: oldPosition,
+				NewPosition: node.Position,
+				Proposal:    h.findResult(node.Node, state, number),
+			}
+			rawdb.WriteOnlineStateChange(h.db, h.db, change)
+			log.Info(h.extraInfo, "记录在线状态变化", state.String(), "节点", node.Node.Hex(), "高度", number, "共识请求", change.Proposal.TerminalString())
+		}
+	}
+	rawdb.WriteOnlinePositions(h.db, positions)
+	if number > historyKeepBlocks {
+		rawdb.PruneOnlineConsensusRecords(h.db, number-historyKeepBlocks, historyPruneLimit)
+	}
+}
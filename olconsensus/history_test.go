@@ -0,0 +1,96 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package olconsensus
+
+import (
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+func TestOnlineHistory(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	history := newOnlineHistory(db, "test")
+
+	node := common.BytesToAddress([]byte{0x01})
+	leader := common.BytesToAddress([]byte{0x02})
+	req := &mc.OnlineConsensusReq{Number: 10, LeaderTurn: 1, Leader: leader, Node: node, OnlineState: mc.OffLine}
+	hash := types.RlpHash(req)
+
+	keys := make([]common.Address, 0)
+	signs := make([]common.Signature, 0)
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		sign, err := crypto.SignWithValidate(hash.Bytes(), i != 2, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, crypto.PubkeyToAddress(key.PublicKey))
+		signs = append(signs, common.BytesToSignature(sign))
+	}
+
+	// votes are only recorded for a recorded proposal
+	history.addVotes(hash, signs[:1])
+	if rawdb.ReadOnlineConsensusRecord(db, hash) != nil {
+		t.Fatalf("vote without proposal recorded")
+	}
+	history.addProposal(hash, req, leader)
+	history.addVotes(hash, signs[:2])
+	history.addVotes(hash, signs)
+	history.setResult(hash, &mc.HD_OnlineConsensusVoteResultMsg{Req: req, From: keys[0]}, signs[:2])
+
+	record := rawdb.ReadOnlineConsensusRecord(db, hash)
+	if record == nil || record.Node != node || record.Proposer != leader || !record.Finished {
+		t.Fatalf("record mismatch: %+v", record)
+	}
+	if len(record.Votes) != 3 || record.Votes[0].Voter != keys[0] || !record.Votes[0].Approve || record.Votes[2].Approve {
+		t.Fatalf("votes mismatch: %+v", record.Votes)
+	}
+	if len(record.Signers) != 2 || record.Signers[1].Voter != keys[1] {
+		t.Fatalf("signers mismatch: %+v", record.Signers)
+	}
+	if hashes := rawdb.ReadOnlineConsensusHashesByNumber(db, 10); len(hashes) != 1 {
+		t.Fatalf("proposal indexed %d times", len(hashes))
+	}
+
+	online := &mc.ElectOnlineStatus{ElectOnline: []mc.ElectNodeInfo{{Account: node, Position: common.PosOnline}}}
+	offline := &mc.ElectOnlineStatus{ElectOnline: []mc.ElectNodeInfo{{Account: node, Position: common.PosOffline}}}
+	history.updatePositions(11, common.Hash{0x0b}, online)
+	history.updatePositions(12, common.Hash{0x0c}, offline)
+	history.updatePositions(13, common.Hash{0x0d}, offline)
+	changes := rawdb.ReadOnlineStateChanges(db, node, 0, 10)
+	if len(changes) != 1 || changes[0].Number != 12 || changes[0].Proposal != hash {
+		t.Fatalf("changes mismatch: %+v", changes)
+	}
+
+	// a reorganisation back to the height drops the change
+	history.updatePositions(12, common.Hash{0x1c}, online)
+	if count := rawdb.ReadOnlineStateChangeCount(db, node); count != 0 {
+		t.Fatalf("change of the reorganised block kept")
+	}
+
+	api := NewPublicOnlineConsensusAPI(&TopNodeService{history: history})
+	proposals, err := api.GetOnlineProposalsByNumber(10)
+	if err != nil || len(proposals) != 1 || proposals[0].Approvals != 2 || proposals[0].Rejections != 1 || proposals[0].State != mc.OffLine.String() {
+		t.Fatalf("rpc proposals mismatch: %+v, %v", proposals, err)
+	}
+
+	// the proposals are pruned once out of the kept heights
+	history.updatePositions(historyKeepBlocks+10, common.Hash{0x1d}, online)
+	if rawdb.ReadOnlineConsensusRecord(db, hash) == nil {
+		t.Fatalf("proposal of a kept height pruned")
+	}
+	history.updatePositions(historyKeepBlocks+11, common.Hash{0x1e}, online)
+	if rawdb.ReadOnlineConsensusRecord(db, hash) != nil || rawdb.ReadOnlineConsensusPruned(db) != 11 {
+		t.Fatalf("proposal out of the kept heights not pruned")
+	}
+	if _, err := NewPublicOnlineConsensusAPI(&TopNodeService{}).GetOnlineProposalsByNumber(10); err != errHistoryDisabled {
+		t.Fatalf("disabled history error mismatch: %v", err)
+	}
+}
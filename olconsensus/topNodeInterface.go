@@ -25,7 +25,7 @@ type TopNodeStateInterface interface {
 }
 
 type ValidatorAccountInterface interface {
-	SignWithValidate(hash []byte, validate bool, blkhash common.Hash) (sig common.Signature, err error)
+	SignVoteWithValidate(hash []byte, validate bool, blkhash common.Hash, vote signhelper.VoteInfo) (sig common.Signature, err error)
 	IsSelfAddress(addr common.Address) bool
 }
 
@@ -78,8 +78,8 @@ func (self *TopNodeInstance) GetTopNodeOnlineState() []NodeOnLineInfo {
 	return onlineStat
 }
 
-func (self *TopNodeInstance) SignWithValidate(hash []byte, validate bool, blkhash common.Hash) (sig common.Signature, err error) {
-	return self.signHelper.SignHashWithValidate(hash, validate, blkhash)
+func (self *TopNodeInstance) SignVoteWithValidate(hash []byte, validate bool, blkhash common.Hash, vote signhelper.VoteInfo) (sig common.Signature, err error) {
+	return self.signHelper.SignVoteWithValidate(hash, validate, blkhash, vote)
 }
 
 func (self *TopNodeInstance) IsSelfAddress(addr common.Address) bool {
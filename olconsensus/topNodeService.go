@@ -6,12 +6,14 @@ package olconsensus
 import (
 	"errors"
 
+	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params/manparams"
 )
@@ -28,6 +30,7 @@ type TopNodeService struct {
 	stateMap *topNodeState
 	msgCheck *messageCheck
 	dposRing *DPosVoteRing
+	history  *onlineHistory
 
 	validatorReader consensus.StateReader
 	topNodeState    TopNodeStateInterface
@@ -76,6 +79,11 @@ func (serv *TopNodeService) SetValidatorReader(reader consensus.StateReader) {
 	serv.validatorReader = reader
 }
 
+// SetHistoryDB enables the online consensus history, persisted to the database.
+func (serv *TopNodeService) SetHistoryDB(db mandb.Database) {
+	serv.history = newOnlineHistory(db, serv.extraInfo)
+}
+
 func (serv *TopNodeService) SetTopNodeStateInterface(inter TopNodeStateInterface) {
 	serv.topNodeState = inter
 }
@@ -170,6 +178,7 @@ func (serv *TopNodeService) update() {
 
 				//log.Debug(serv.extraInfo, "处理CA通知消息", "", "块高", data.BlockNum)
 				serv.stateMap.SetCurStates(data.BlockNum+1, topology, electOnline)
+				serv.history.updatePositions(data.BlockNum, data.BlockHash, electOnline)
 				go serv.LeaderChangeNotifyHandler(serv.msgCheck.GetCurLeader())
 			}
 		case data := <-serv.leaderChangeCh:
@@ -320,6 +329,9 @@ func (serv *TopNodeService) consensusReqMsgHandler(msg *mc.HD_OnlineConsensusReq
 					if have {
 						ds.setVoted()
 					}
+					if item.Leader == serv.msgCheck.GetCurLeader() {
+						serv.history.addProposal(reqHash, item, msg.From)
+					}
 				} else {
 					log.Error(serv.extraInfo, "处理共识请求", "签名失败", "请求hash", reqHash.TerminalString(), "error", err)
 				}
@@ -360,12 +372,14 @@ func (serv *TopNodeService) OnlineConsensusVoteResultMsgHandler(msg *mc.HD_Onlin
 		return
 	}
 	//todo:从状态树获取版本号
-	tempSigns, err := serv.cr.DPOSEngine([]byte(serv.cr.CurrentBlock().Version())).VerifyHash(serv.validatorReader, types.RlpHash(msg.Req), msg.SignList)
+	reqHash := types.RlpHash(msg.Req)
+	tempSigns, err := serv.cr.DPOSEngine([]byte(serv.cr.CurrentBlock().Version())).VerifyHash(serv.validatorReader, reqHash, msg.SignList)
 	if err != nil {
 		log.Error(serv.extraInfo, "处理共识结果消息", "POS验证失败", "err", err)
 	} else {
 		log.Debug(serv.extraInfo, "处理共识结果消息", "验证通过，缓存状态", "状态", msg.Req.OnlineState.String(), "投票数", len(tempSigns))
 		serv.stateMap.SaveConsensusResult(msg)
+		serv.history.setResult(reqHash, msg, tempSigns)
 	}
 }
 
@@ -397,6 +411,7 @@ func (serv *TopNodeService) consensusVotes(proposal interface{}, votes []voteInf
 		log.Debug(serv.extraInfo, "处理共识投票", "POS失败", "节点", prop.Node.Hex(), "状态", prop.OnlineState.String(), "投票数", len(signList), "err", err)
 		return
 	}
+	serv.history.addVotes(votes[0].data.SignHash, rightSigns)
 	log.Trace(serv.extraInfo, "处理共识投票", "POS通过，发送共识结果消息", "节点", prop.Node.Hex(), "状态", prop.OnlineState.String())
 	//send DPos Success message
 	result := mc.HD_OnlineConsensusVoteResultMsg{
@@ -428,12 +443,20 @@ func (serv *TopNodeService) voteToReq(tempReq *mc.OnlineConsensusReq) (common.Si
 	}
 	// TODO 优化，一次获取一个节点的在线状态 GetTopNodeOnlineState
 	ok = serv.stateMap.checkNodeState(tempReq.Node, serv.topNodeState.GetTopNodeOnlineState(), tempReq.OnlineState)
+	// 赞成票与反对票签名同一hash，防双签时区分投票结果
+	vote := signhelper.VoteInfo{
+		Kind:    signhelper.VoteOnline,
+		Number:  tempReq.Number,
+		Turn:    tempReq.LeaderTurn,
+		Target:  tempReq.Node,
+		Subject: types.RlpHash([]interface{}{reqHash, ok}),
+	}
 	log.Trace(serv.extraInfo, "处理共识请求", "对共识请求进行投票", "高度", tempReq.Number, "轮次", tempReq.LeaderTurn,
 		"检查状态", tempReq.OnlineState.String(), "ok", ok, "node", tempReq.Node.Hex(), "hash", reqHash.TerminalString(), "leader", tempReq.Leader.Hex())
 
 	if ok {
 		//投赞成票
-		sign, err = serv.validatorSign.SignWithValidate(reqHash.Bytes(), true, serv.msgCheck.blockHash)
+		sign, err = serv.validatorSign.SignVoteWithValidate(reqHash.Bytes(), true, serv.msgCheck.blockHash, vote)
 		if err != nil {
 			log.Error(serv.extraInfo, "处理共识请求", "对共识请求进行投票", "投票失败", err)
 			return common.Signature{}, common.Hash{}, voteFailed
@@ -441,7 +464,7 @@ func (serv *TopNodeService) voteToReq(tempReq *mc.OnlineConsensusReq) (common.Si
 		log.Trace(serv.extraInfo, "处理共识请求", "对共识请求进行投票", "投赞成票", "", "reqNode", tempReq.Node.String(), "onlinestate", tempReq.OnlineState.String())
 	} else {
 		//投反对票
-		sign, err = serv.validatorSign.SignWithValidate(reqHash.Bytes(), false, serv.msgCheck.blockHash)
+		sign, err = serv.validatorSign.SignVoteWithValidate(reqHash.Bytes(), false, serv.msgCheck.blockHash, vote)
 		if err != nil {
 			log.Error(serv.extraInfo, "处理共识请求", "对共识请求进行投票", "投票失败", err)
 			return common.Signature{}, common.Hash{}, voteFailed
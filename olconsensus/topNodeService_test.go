@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
@@ -170,7 +171,7 @@ func (ts *testNodeState) SendNodeMsg(subCode mc.EventCode, msg interface{}, Role
 	//	}
 }
 
-func (ts *testNodeState) SignWithValidate(hash []byte, validate bool) (common.Signature, error) {
+func (ts *testNodeState) SignVoteWithValidate(hash []byte, validate bool, blkhash common.Hash, vote signhelper.VoteInfo) (common.Signature, error) {
 	sigByte, err := crypto.SignWithValidate(hash, validate, ts.self.PrivateKey)
 	if err != nil {
 		return common.Signature{}, err
@@ -4,13 +4,19 @@
 package params
 
 type LessDiskConfig struct {
-	OptInterval     int64  // 操作间隔，单位秒
-	HeightThreshold uint64 // 高度阈值
-	TimeThreshold   int64  // 事件阈值，单位秒
+	OptInterval        int64  // 操作间隔，单位秒
+	HeightThreshold    uint64 // 高度阈值
+	TimeThreshold      int64  // 事件阈值，单位秒
+	PinBlocks          bool   // 保留换届、广播、超级区块、选举生成及快照区块
+	PruneState         bool   // 删除区块后裁剪不再被引用的状态
+	PruneStateInterval int64  // 状态裁剪间隔，单位秒
 }
 
 var DefLessDiskConfig = &LessDiskConfig{
-	OptInterval:     120,
-	HeightThreshold: 30000,
-	TimeThreshold:   2 * 60 * 60,
+	OptInterval:        120,
+	HeightThreshold:    30000,
+	TimeThreshold:      2 * 60 * 60,
+	PinBlocks:          true,
+	PruneState:         false,
+	PruneStateInterval: 24 * 60 * 60,
 }
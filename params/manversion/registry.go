@@ -0,0 +1,183 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manversion
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// Block types of the blkmanage plugins.
+const (
+	BlkPlugCommon    = "common"
+	BlkPlugBroadcast = "broadcast"
+)
+
+// Consensus engines of the upgrades.
+const (
+	EngineManash  = "manash"  // manash proof of work
+	EngineAIMine  = "amhash"  // ai mining proof of work
+	EngineMtxDPOS = "mtxdpos" // matrix dpos
+)
+
+// Version is a protocol version "major.minor.patch.build", compared field by
+// field, so 1.0.0.10 is above 1.0.0.4.
+type Version [4]uint64
+
+// ParseVersion parses a version of four numbers separated by dots.
+func ParseVersion(version string) (Version, error) {
+	var v Version
+	fields := strings.Split(version, ".")
+	if len(fields) != len(v) {
+		return v, fmt.Errorf("invalid version %q", version)
+	}
+	for i, field := range fields {
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid version %q", version)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// Cmp returns 1 if v is above other, -1 if below and 0 if equal.
+func (v Version) Cmp(other Version) int {
+	for i := range v {
+		if v[i] > other[i] {
+			return 1
+		}
+		if v[i] < other[i] {
+			return -1
+		}
+	}
+	return 0
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v[0], v[1], v[2], v[3])
+}
+
+// Upgrade is a protocol version of the network and what it enables.
+type Upgrade struct {
+	Name       string
+	Version    string
+	Number     uint64             // height the blocks switch to the version, 0 if set by the genesis or a super block
+	Signatures []common.Signature // signatures of the version by the super version accounts
+
+	MatrixState string   // version whose matrix state operators the version uses
+	Rewards     string   // version whose reward variant the version uses
+	BlkPlugins  []string // block types with a blkmanage plugin
+	Engine      string   // consensus engine verifying the blocks of the version
+	DPOSEngine  string   // dpos engine verifying the signatures of the version
+}
+
+var (
+	errUpgradeVersion = errors.New("upgrade version is not above the previous upgrade")
+	errUpgradeNumber  = errors.New("upgrade number is not above the previous upgrade")
+	errUpgradeBase    = errors.New("upgrade uses the components of an unknown version")
+	errUpgradeEngine  = errors.New("upgrade has no consensus engine")
+)
+
+var (
+	upgradesMu sync.RWMutex
+	upgrades   []*Upgrade
+)
+
+// RegisterUpgrade adds an upgrade after the registered ones. Its version and
+// its number, when set, must be above those of the previous upgrades, and it
+// can only use the components of itself or of a registered version.
+func RegisterUpgrade(up *Upgrade) error {
+	version, err := ParseVersion(up.Version)
+	if err != nil {
+		return err
+	}
+	if up.Engine == "" || up.DPOSEngine == "" {
+		return errUpgradeEngine
+	}
+	upgradesMu.Lock()
+	defer upgradesMu.Unlock()
+
+	known := map[string]bool{up.Version: true}
+	for _, prev := range upgrades {
+		if version.Cmp(mustParseVersion(prev.Version)) <= 0 {
+			return errUpgradeVersion
+		}
+		if up.Number != 0 && up.Number <= prev.Number {
+			return errUpgradeNumber
+		}
+		known[prev.Version] = true
+	}
+	if !known[up.MatrixState] || !known[up.Rewards] {
+		return errUpgradeBase
+	}
+	upgrades = append(upgrades, up)
+	return nil
+}
+
+func mustParseVersion(version string) Version {
+	v, err := ParseVersion(version)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Upgrades returns the registered upgrades, in version order.
+func Upgrades() []*Upgrade {
+	upgradesMu.RLock()
+	defer upgradesMu.RUnlock()
+	return append([]*Upgrade{}, upgrades...)
+}
+
+// FindUpgrade returns the upgrade of the version, nil if unknown.
+func FindUpgrade(version string) *Upgrade {
+	upgradesMu.RLock()
+	defer upgradesMu.RUnlock()
+	for _, up := range upgrades {
+		if up.Version == version {
+			return up
+		}
+	}
+	return nil
+}
+
+// UpgradeAt returns the upgrade switching the blocks to its version at the
+// height, nil if none.
+func UpgradeAt(num uint64) *Upgrade {
+	if num == 0 {
+		return nil
+	}
+	upgradesMu.RLock()
+	defer upgradesMu.RUnlock()
+	for _, up := range upgrades {
+		if up.Number == num {
+			return up
+		}
+	}
+	return nil
+}
+
+// MatrixStateVersion returns the version whose matrix state operators the
+// version uses, empty if unknown.
+func MatrixStateVersion(version string) string {
+	if up := FindUpgrade(version); up != nil {
+		return up.MatrixState
+	}
+	return ""
+}
+
+// RewardsVersion returns the version whose reward variant the version uses,
+// empty if unknown.
+func RewardsVersion(version string) string {
+	if up := FindUpgrade(version); up != nil {
+		return up.Rewards
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manversion
+
+import "testing"
+
+func TestVersionCmp(t *testing.T) {
+	tests := []struct {
+		v1, v2 string
+		want   int
+	}{
+		{VersionAlpha, VersionBeta, -1},
+		{VersionAIMine, VersionGamma, 1},
+		{"1.0.0.10", "1.0.0.4", 1},
+		{"1.2.0.0", "1.10.0.0", -1},
+		{VersionDelta, VersionDelta, 0},
+		{"", VersionAlpha, -1},
+	}
+	for _, test := range tests {
+		if have := VersionCmp(test.v1, test.v2); have != test.want {
+			t.Errorf("VersionCmp(%q, %q) = %d, want %d", test.v1, test.v2, have, test.want)
+		}
+	}
+	if _, err := ParseVersion("1.0.0"); err == nil {
+		t.Errorf("short version parsed")
+	}
+}
+
+func TestUpgradeRegistry(t *testing.T) {
+	if !IsCorrectVersion([]byte(VersionAIMine)) || IsCorrectVersion([]byte("1.0.0.40")) {
+		t.Fatalf("correct version mismatch")
+	}
+	if len(GetVersionSignature([]byte(VersionDelta))) != 1 || GetVersionSignature([]byte(VersionBeta)) != nil {
+		t.Fatalf("version signature mismatch")
+	}
+	if up := UpgradeAt(VersionNumAIMine); up == nil || up.Version != VersionAIMine {
+		t.Fatalf("upgrade at %d mismatch: %v", VersionNumAIMine, up)
+	}
+	if UpgradeAt(0) != nil || UpgradeAt(VersionNumAIMine+1) != nil {
+		t.Fatalf("upgrade without height found")
+	}
+	if MatrixStateVersion(VersionAIMine) != VersionDelta || RewardsVersion(VersionGamma) != VersionBeta {
+		t.Fatalf("upgrade components mismatch")
+	}
+
+	saved := upgrades
+	defer func() { upgrades = saved }()
+	if err := RegisterUpgrade(&Upgrade{Name: "NoEngine", Version: "1.0.0.10", MatrixState: VersionDelta, Rewards: VersionBeta}); err != errUpgradeEngine {
+		t.Fatalf("upgrade without engine registered: %v", err)
+	}
+	if err := RegisterUpgrade(&Upgrade{Name: "Old", Version: VersionDelta, MatrixState: VersionDelta, Rewards: VersionBeta, Engine: EngineManash, DPOSEngine: EngineMtxDPOS}); err != errUpgradeVersion {
+		t.Fatalf("older version registered: %v", err)
+	}
+	if err := RegisterUpgrade(&Upgrade{Name: "Low", Version: "1.0.0.10", Number: VersionNumDelta, MatrixState: VersionDelta, Rewards: VersionBeta, Engine: EngineManash, DPOSEngine: EngineMtxDPOS}); err != errUpgradeNumber {
+		t.Fatalf("lower height registered: %v", err)
+	}
+	if err := RegisterUpgrade(&Upgrade{Name: "Unknown", Version: "1.0.0.10", MatrixState: "1.0.0.9", Rewards: VersionBeta, Engine: EngineManash, DPOSEngine: EngineMtxDPOS}); err != errUpgradeBase {
+		t.Fatalf("unknown components registered: %v", err)
+	}
+	if err := RegisterUpgrade(&Upgrade{Name: "Next", Version: "1.0.0.10", Number: VersionNumAIMine + 100, MatrixState: VersionDelta, Rewards: VersionBeta, Engine: EngineAIMine, DPOSEngine: EngineMtxDPOS}); err != nil {
+		t.Fatal(err)
+	}
+	if up := UpgradeAt(VersionNumAIMine + 100); up == nil || up.Name != "Next" || !IsCorrectVersion([]byte("1.0.0.10")) {
+		t.Fatalf("registered upgrade not found")
+	}
+}
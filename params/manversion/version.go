@@ -4,7 +4,8 @@
 package manversion
 
 import (
-	"bytes"
+	"fmt"
+
 	"github.com/MatrixAINetwork/go-matrix/common"
 )
 
@@ -30,20 +31,50 @@ var VersionList [][]byte
 var VersionSignatureMap map[string][]common.Signature
 
 func init() {
-	VersionList = [][]byte{[]byte(VersionAlpha), []byte(VersionBeta), []byte(VersionGamma), []byte(VersionDelta), []byte(VersionAIMine)}
+	commonPlugs := []string{BlkPlugCommon, BlkPlugBroadcast}
+	for _, up := range []*Upgrade{
+		{Name: "Alpha", Version: VersionAlpha, MatrixState: VersionAlpha, Rewards: VersionAlpha, BlkPlugins: commonPlugs,
+			Engine: EngineManash, DPOSEngine: EngineMtxDPOS},
+		{Name: "Beta", Version: VersionBeta, MatrixState: VersionBeta, Rewards: VersionBeta, BlkPlugins: commonPlugs,
+			Engine: EngineManash, DPOSEngine: EngineMtxDPOS},
+		{Name: "Gamma", Version: VersionGamma, Number: VersionNumGamma, Signatures: []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureGamma))},
+			MatrixState: VersionGamma, Rewards: VersionBeta, BlkPlugins: commonPlugs,
+			Engine: EngineManash, DPOSEngine: EngineMtxDPOS},
+		{Name: "Delta", Version: VersionDelta, Number: VersionNumDelta, Signatures: []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureDelta))},
+			MatrixState: VersionDelta, Rewards: VersionBeta, BlkPlugins: commonPlugs,
+			Engine: EngineManash, DPOSEngine: EngineMtxDPOS},
+		{Name: "AIMine", Version: VersionAIMine, Number: VersionNumAIMine, Signatures: []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureAIMine))},
+			MatrixState: VersionDelta, Rewards: VersionBeta, BlkPlugins: commonPlugs,
+			Engine: EngineAIMine, DPOSEngine: EngineMtxDPOS},
+	} {
+		if err := RegisterUpgrade(up); err != nil {
+			panic(fmt.Sprintf("register upgrade %s: %v", up.Name, err))
+		}
+	}
+
+	VersionList = make([][]byte, 0)
 	VersionSignatureMap = make(map[string][]common.Signature)
-	VersionSignatureMap[VersionGamma] = []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureGamma))}
-	VersionSignatureMap[VersionDelta] = []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureDelta))}
-	VersionSignatureMap[VersionAIMine] = []common.Signature{common.BytesToSignature(common.FromHex(VersionSignatureAIMine))}
+	for _, up := range Upgrades() {
+		VersionList = append(VersionList, []byte(up.Version))
+		if len(up.Signatures) > 0 {
+			VersionSignatureMap[up.Version] = up.Signatures
+		}
+	}
 }
 
 // version1 > version2 return 1
 // version1 = version2 return 0
 // version1 < version2 return -1
+// Versions are compared by their numbers, lexically if one is not valid.
 func VersionCmp(version1 string, version2 string) int {
 	if version1 == version2 {
 		return 0
 	}
+	v1, err1 := ParseVersion(version1)
+	v2, err2 := ParseVersion(version2)
+	if err1 == nil && err2 == nil {
+		return v1.Cmp(v2)
+	}
 	if version1 > version2 {
 		return 1
 	} else {
@@ -55,20 +86,15 @@ func IsCorrectVersion(version []byte) bool {
 	if len(version) == 0 {
 		return false
 	}
-	for _, item := range VersionList {
-		if bytes.Equal(version, item) {
-			return true
-		}
-	}
-	return false
+	return FindUpgrade(string(version)) != nil
 }
 
 func GetVersionSignature(version []byte) []common.Signature {
 	if len(version) == 0 {
 		return nil
 	}
-	if sig, ok := VersionSignatureMap[string(version)]; ok {
-		return sig
+	if up := FindUpgrade(string(version)); up != nil && len(up.Signatures) > 0 {
+		return up.Signatures
 	}
 	return nil
 }
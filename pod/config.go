@@ -31,6 +31,7 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirSlashProtection = "slashprotection"    // Path within the datadir to the signed consensus votes
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -75,6 +76,11 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the endpoint of the signer daemon holding the keys of the
+	// sign accounts, an IPC path or an http(s) URL. Empty signs with the keystore
+	// and the entrust file.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -174,6 +180,15 @@ func (c *Config) NodeDB() string {
 	return c.resolvePath(datadirNodeDatabase)
 }
 
+// SlashProtectionDB returns the path to the database of the signed consensus
+// votes. If the node is ephemeral, an empty string is returned.
+func (c *Config) SlashProtectionDB() string {
+	if c.DataDir == "" {
+		return "" // ephemeral
+	}
+	return c.resolvePath(datadirSlashProtection)
+}
+
 // DefaultIPCEndpoint returns the IPC path used by default.
 func DefaultIPCEndpoint(clientIdentifier string) string {
 	if clientIdentifier == "" {
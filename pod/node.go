@@ -69,6 +69,7 @@ type Node struct {
 	MsgCenter  *mc.Center
 	hd         *msgsend.HD
 	signHelper *signhelper.SignHelper
+	voteGuard  *signhelper.VoteGuard // slashing protection of the consensus votes
 
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
@@ -120,6 +121,9 @@ func New(conf *Config) (*Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	if conf.ExternalSigner != "" {
+		signHelper.SetRemoteSigner(signhelper.NewRemoteSigner(conf.ExternalSigner))
+	}
 
 	return &Node{
 		accman:            am,
@@ -263,6 +267,19 @@ func (n *Node) Start() error {
 	if err := n.openDataDir(); err != nil {
 		return err
 	}
+	voteGuard, err := signhelper.NewVoteGuard(n.config.SlashProtectionDB())
+	if err != nil {
+		return err
+	}
+	n.voteGuard = voteGuard
+	n.signHelper.SetVoteGuard(voteGuard)
+	// Release the slashing protection if any later step fails.
+	ok := false
+	defer func() {
+		if !ok {
+			n.closeVoteGuard()
+		}
+	}()
 
 	// Initialize the p2p server. This creates the node key and
 	// discovery databases.
@@ -357,10 +374,21 @@ func (n *Node) Start() error {
 	n.services = services
 	n.server = running
 	n.stop = make(chan struct{})
+	ok = true
 
 	return nil
 }
 
+// closeVoteGuard closes the slashing protection once nothing can vote.
+func (n *Node) closeVoteGuard() {
+	if n.voteGuard == nil {
+		return
+	}
+	n.signHelper.SetVoteGuard(nil)
+	n.voteGuard.Close()
+	n.voteGuard = nil
+}
+
 func (n *Node) openDataDir() error {
 	//fmt.Println("*************DataDir",n.config.DataDir)
 	if n.config.DataDir == "" {
@@ -561,6 +589,8 @@ func (n *Node) Stop() error {
 
 	// stop ca
 	ca.Stop()
+	// Close the slashing protection after the services stopped voting.
+	n.closeVoteGuard()
 	// Release instance directory lock.
 	if n.instanceDirLock != nil {
 		if err := n.instanceDirLock.Release(); err != nil {
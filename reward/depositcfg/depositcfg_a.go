@@ -35,6 +35,7 @@ type DepositCfger interface {
 	CheckAmountDeposit(addr common.Address, deposit *common.DepositBase, wdAm *big.Int) (bool, error)
 	CalcDepositTime(index uint64, deposit *common.DepositBase, wdAm *big.Int, t uint64) error
 	GetRate() uint64
+	GetConfig() DepositPositionConfig
 }
 
 const (
@@ -122,6 +123,9 @@ func (cur *Depositcurrent) CheckAmountDeposit(addr common.Address, deposit *comm
 func (cur *Depositcurrent) GetRate() uint64 {
 	return cur.DepositCur.DepositRate
 }
+func (cur *Depositcurrent) GetConfig() DepositPositionConfig {
+	return cur.DepositCur
+}
 func (drg *Depositregular) CheckwithdrawDeposit(index uint64, deposit *common.DepositBase, wdAm *big.Int) (bool, error) {
 	depositmsg := deposit.Dpstmsg[index]
 	if drg.Depositreg.DepositType == depositmsg.DepositType {
@@ -169,3 +173,13 @@ func (drg *Depositregular) CalcDepositTime(index uint64, deposit *common.Deposit
 func (dc *Depositregular) GetRate() uint64 {
 	return dc.Depositreg.DepositRate
 }
+func (dc *Depositregular) GetConfig() DepositPositionConfig {
+	return dc.Depositreg
+}
+
+// FixedTermEnd returns the end of the term of a fixed deposit begun at begin
+// running at t, the deposit renews itself until withdrawn. It is the end time
+// CalcDepositTime sets on a withdrawal, t must not be before begin.
+func FixedTermEnd(begin uint64, duration uint64, t uint64) uint64 {
+	return (t-begin)/duration*duration + duration + begin
+}
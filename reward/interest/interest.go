@@ -9,6 +9,7 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/reward/util"
 
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -30,6 +31,7 @@ type interest struct {
 	VIPConfig      []mc.VIPConfig
 	InterestConfig *mc.InterestCfg
 	Calc           string
+	ledger         *ledger.Recorder
 }
 
 type DepositInterestRate struct {
@@ -135,6 +137,7 @@ func (ic *interest) PayInterest(state vm.StateDBManager, num uint64, time uint64
 
 	AllInterestMap := depoistInfo.GetAllInterest(state)
 	allInterest := big.NewInt(0)
+	slashes := make(map[common.Address]*big.Int)
 
 	for account, originInterest := range AllInterestMap {
 		if originInterest.Cmp(big.NewInt(0)) <= 0 {
@@ -158,12 +161,16 @@ func (ic *interest) PayInterest(state vm.StateDBManager, num uint64, time uint64
 		AllInterestMap[account] = finalInterest
 		allInterest = new(big.Int).Add(allInterest, finalInterest)
 		depoistInfo.ResetSlash(state, account)
+		slashes[account] = slash
 	}
 	balance := state.GetBalance(params.MAN_COIN, common.InterestRewardAddress)
 	if balance[common.MainAccount].Balance.Cmp(allInterest) < 0 {
 		log.ERROR(PackageName, "利息账户余额不足，余额为", balance[common.MainAccount].Balance.String())
 		return nil
 	}
+	for _, account := range ledger.SortedAccounts(slashes) {
+		ic.ledger.Record(ledger.SourceInterest, ledger.TypeInterestPay, account, params.MAN_COIN, AllInterestMap[account], ledger.In("slash", slashes[account]))
+	}
 	AllInterestMap[common.ContractAddress] = allInterest
 	return AllInterestMap
 }
@@ -203,9 +210,15 @@ func (ic *interest) GetReward(state vm.StateDBManager, num uint64, parentHash co
 
 func (ic *interest) CalcReward(state vm.StateDBManager, num uint64, parentHash common.Hash) {
 	RewardMap := ic.GetReward(state, num, parentHash)
+	ic.ledger.RecordMap(ledger.SourceInterest, ledger.TypeInterestCalc, params.MAN_COIN, RewardMap)
 	ic.SetReward(RewardMap, state)
 }
 
+// SetLedger sets the recorder of the computed interests.
+func (ic *interest) SetLedger(recorder *ledger.Recorder) {
+	ic.ledger = recorder
+}
+
 func (ic *interest) SetReward(InterestMap map[common.Address]*big.Int, state vm.StateDBManager) {
 	for k, v := range InterestMap {
 		depoistInfo.AddInterest(state, k, v)
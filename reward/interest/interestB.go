@@ -9,6 +9,7 @@ import (
 	"strconv"
 
 	"github.com/MatrixAINetwork/go-matrix/reward/depositcfg"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 
 	"github.com/pkg/errors"
 
@@ -25,6 +26,7 @@ import (
 type interestDelta struct {
 	interestConfig *mc.InterestCfg
 	depositCfg     depositcfg.DepositCfgInterface
+	ledger         *ledger.Recorder
 }
 
 const INTERESTDIR = "./interestdir"
@@ -56,6 +58,11 @@ func DeltaNew(st util.StateDB, preSt util.StateDB, depositCfgVersion string) *in
 	return &interestDelta{interestConfig: IC, depositCfg: depositcfg.GetDepositCfg(depositCfgVersion)}
 }
 
+// SetLedger sets the recorder of the computed interests.
+func (ic *interestDelta) SetLedger(recorder *ledger.Recorder) {
+	ic.ledger = recorder
+}
+
 func (ic *interestDelta) calcWeightDeposit(deposit *big.Int, blockInterest uint64) *big.Int {
 
 	if deposit.Cmp(big.NewInt(0)) <= 0 {
@@ -87,6 +94,7 @@ func (ic *interestDelta) payAllInterest(num uint64, state vm.StateDBManager, tim
 	allInterest := big.NewInt(0)
 	outputPayInterest := make(map[common.Address][]common.OperationalInterestSlash, 0)
 	outputSlash := make(map[common.Address][]common.OperationalInterestSlash, 0)
+	paid := make([]*ledger.Entry, 0)
 	for account, originAccountInterest := range AllInterestMap {
 		accountSlash, _ := depoistInfo.GetSlash_v2(state, account)
 		finalInterestData := make([]common.OperationalInterestSlash, 0)
@@ -118,6 +126,8 @@ func (ic *interestDelta) payAllInterest(num uint64, state vm.StateDBManager, tim
 				return errors.New("余额不足")
 			}
 			depoistInfo.PayInterest(state, time, account, originInterest.Position, finalInterest.OperAmount)
+			paid = append(paid, &ledger.Entry{Account: account, Amount: new(big.Int).Set(finalInterest.OperAmount), Inputs: []ledger.Input{
+				ledger.In("position", originInterest.Position), ledger.In("interest", originInterest.OperAmount), ledger.In("slash", positionSlash.OperAmount)}})
 			finalInterestData = append(finalInterestData, finalInterest)
 		}
 		outputPayInterest[account] = finalInterestData
@@ -128,6 +138,7 @@ func (ic *interestDelta) payAllInterest(num uint64, state vm.StateDBManager, tim
 	util.PrintLog2File(INTERESTDIR+"/slash_"+strconv.FormatUint(num, 10)+".json", outputSlash)
 	state.SubBalance(params.MAN_COIN, common.MainAccount, common.InterestRewardAddress, allInterest)
 	state.AddBalance(params.MAN_COIN, common.MainAccount, common.ContractAddress, allInterest)
+	ic.ledger.RecordEntries(ledger.SourceInterest, ledger.TypeInterestPay, params.MAN_COIN, paid)
 	return nil
 }
 
@@ -178,11 +189,14 @@ func (ic *interestDelta) CalcInterestReward(totalWeightDeposit, reward *big.Int,
 	}
 
 	rewards := make(map[common.Address][]common.OperationalInterestSlash)
+	accrued := make([]*ledger.Entry, 0)
 	for k, node := range weightDepositMap {
 		nodeReward := make([]common.OperationalInterestSlash, 0)
 		for _, v := range node {
 			temp := new(big.Int).Mul(reward, v.DepositAmount)
 			blockAmount := new(big.Int).Div(temp, totalWeightDeposit)
+			accrued = append(accrued, &ledger.Entry{Account: k, Amount: blockAmount, Inputs: []ledger.Input{ledger.In("position", v.Position),
+				ledger.In("depositType", v.DepositType), ledger.In("weightDeposit", v.DepositAmount), ledger.In("totalWeightDeposit", totalWeightDeposit), ledger.In("pool", reward)}})
 			v.OperAmount.Add(v.OperAmount, blockAmount)
 			nodeReward = append(nodeReward, v)
 			//util.LogExtraDebug(PackageName, "账户", k.String(), "仓位", v.Position, "加权抵押结果", v.DepositAmount.String(), "奖励金额", blockAmount.String(), "累计的抵押奖励金额", v.OperAmount)
@@ -190,6 +204,7 @@ func (ic *interestDelta) CalcInterestReward(totalWeightDeposit, reward *big.Int,
 		rewards[k] = nodeReward
 
 	}
+	ic.ledger.RecordEntries(ledger.SourceInterest, ledger.TypeInterestCalc, params.MAN_COIN, accrued)
 	return rewards
 }
 
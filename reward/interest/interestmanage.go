@@ -11,12 +11,14 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/vm"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/reward/depositcfg"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/reward/util"
 )
 
 type InterestOperator interface {
 	PayInterest(state vm.StateDBManager, num uint64, time uint64) map[common.Address]*big.Int
 	CalcReward(state vm.StateDBManager, num uint64, parentHash common.Hash)
+	SetLedger(recorder *ledger.Recorder)
 }
 
 func ManageNew(st util.StateDB, preSt util.StateDB) InterestOperator {
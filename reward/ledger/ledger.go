@@ -0,0 +1,215 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package ledger records the reward and slash computations of a block as
+// structured entries, so that the payouts can be explained and reconciled.
+package ledger
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// Sources of the entries, the rule of an entry is the calc engine of its source.
+const (
+	SourceBlock    = "block"    // 固定区块奖励
+	SourceTxs      = "txs"      // 交易费奖励
+	SourceLottery  = "lottery"  // 彩票奖励
+	SourceInterest = "interest" // 利息
+	SourceSlash    = "slash"    // 惩罚
+)
+
+// Types of the entries.
+const (
+	TypeMinerOut            = "miner_out"
+	TypeMinerElected        = "miner_elected"
+	TypeMinerFoundation     = "miner_foundation"
+	TypeLeader              = "leader"
+	TypeValidatorElected    = "validator_elected"
+	TypeValidatorFoundation = "validator_foundation"
+	TypeLottery             = "lottery"
+	TypeInterestCalc        = "interest_calc" // interest accrued in the deposit contract
+	TypeInterestPay         = "interest_pay"  // accrued interest paid, minus the slash
+	TypeSlash               = "slash"         // interest slashed for a low uptime
+)
+
+// Input is a named input of a computation, e.g. the stock or the uptime.
+type Input struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// In returns the input name with value formatted by %v.
+func In(name string, value interface{}) Input {
+	return Input{Name: name, Value: fmt.Sprintf("%v", value)}
+}
+
+// Entry is one reward or slash computed for an account.
+type Entry struct {
+	Source  string
+	Type    string
+	Account common.Address
+	Coin    string
+	Amount  *big.Int
+	Rule    string
+	Inputs  []Input
+}
+
+// Ledger is the entries of a block.
+type Ledger struct {
+	Number  uint64
+	Hash    common.Hash
+	Version string
+	Entries []*Entry
+}
+
+// Accounts returns the entries of the ledger grouped by account.
+func (l *Ledger) Accounts() map[common.Address][]*Entry {
+	accounts := make(map[common.Address][]*Entry)
+	for _, entry := range l.Entries {
+		accounts[entry.Account] = append(accounts[entry.Account], entry)
+	}
+	return accounts
+}
+
+// Recorder collects the entries while the rewards of a block are computed. All
+// its methods do nothing on a nil recorder, so the computations record
+// unconditionally.
+type Recorder struct {
+	lock    sync.Mutex
+	rules   map[string]string
+	entries []*Entry
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{rules: make(map[string]string)}
+}
+
+// SetRule sets the calc engine of the source, stamped on its entries.
+func (r *Recorder) SetRule(source string, rule string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.rules[source] = rule
+}
+
+// Record adds an entry, a nil or zero amount is skipped.
+func (r *Recorder) Record(source string, typ string, account common.Address, coin string, amount *big.Int, inputs ...Input) {
+	if r == nil || amount == nil || amount.Sign() == 0 {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.entries = append(r.entries, &Entry{
+		Source:  source,
+		Type:    typ,
+		Account: account,
+		Coin:    coin,
+		Amount:  new(big.Int).Set(amount),
+		Rule:    r.rules[source],
+		Inputs:  inputs,
+	})
+}
+
+// RecordMap adds an entry for every reward of the map, in account order.
+func (r *Recorder) RecordMap(source string, typ string, coin string, rewards map[common.Address]*big.Int, inputs ...Input) {
+	if r == nil {
+		return
+	}
+	for _, account := range SortedAccounts(rewards) {
+		r.Record(source, typ, account, coin, rewards[account], inputs...)
+	}
+}
+
+// RecordEntries adds the account, amount and inputs of every entry, in account
+// order, the entries of an account kept in their order. The computations
+// iterating a map collect their entries and record them here.
+func (r *Recorder) RecordEntries(source string, typ string, coin string, entries []*Entry) {
+	if r == nil {
+		return
+	}
+	sorted := append([]*Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Account[:], sorted[j].Account[:]) < 0
+	})
+	for _, entry := range sorted {
+		r.Record(source, typ, entry.Account, coin, entry.Amount, entry.Inputs...)
+	}
+}
+
+// KeepPaid drops the entries of the reward categories missing from paid, the
+// reward transactions left once the balances of the pools are checked. The
+// interest accrued and the slashes are not paid by a reward transaction and
+// are kept.
+func (r *Recorder) KeepPaid(paid []common.RewarTx) {
+	if r == nil {
+		return
+	}
+	type category struct {
+		typ  byte
+		coin string
+	}
+	kept := make(map[category]bool, len(paid))
+	for _, tx := range paid {
+		kept[category{tx.RewardTyp, tx.CoinRange}] = true
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	entries := r.entries[:0]
+	for _, entry := range r.entries {
+		if typ, byTx := rewardType(entry); !byTx || kept[category{typ, entry.Coin}] {
+			entries = append(entries, entry)
+		}
+	}
+	r.entries = entries
+}
+
+// rewardType returns the type of the reward transaction paying the entry, false
+// if no reward transaction pays it.
+func rewardType(entry *Entry) (byte, bool) {
+	switch entry.Source {
+	case SourceBlock:
+		switch entry.Type {
+		case TypeMinerOut, TypeMinerElected, TypeMinerFoundation:
+			return common.RewardMinerType, true
+		}
+		return common.RewardValidatorType, true
+	case SourceTxs:
+		return common.RewardTxsType, true
+	case SourceLottery:
+		return common.RewardLotteryType, true
+	case SourceInterest:
+		return common.RewardInterestType, entry.Type == TypeInterestPay
+	}
+	return 0, false
+}
+
+// Entries returns the recorded entries, in record order.
+func (r *Recorder) Entries() []*Entry {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*Entry(nil), r.entries...)
+}
+
+// SortedAccounts returns the accounts of the rewards, sorted.
+func SortedAccounts(rewards map[common.Address]*big.Int) []common.Address {
+	accounts := make([]common.Address, 0, len(rewards))
+	for account := range rewards {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i][:], accounts[j][:]) < 0
+	})
+	return accounts
+}
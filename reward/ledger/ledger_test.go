@@ -0,0 +1,103 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package ledger
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+func TestNilRecorder(t *testing.T) {
+	var recorder *Recorder
+	recorder.SetRule(SourceBlock, "1")
+	recorder.Record(SourceBlock, TypeLeader, common.Address{}, params.MAN_COIN, big.NewInt(1))
+	recorder.RecordMap(SourceLottery, TypeLottery, params.MAN_COIN, map[common.Address]*big.Int{{}: big.NewInt(1)})
+	if entries := recorder.Entries(); entries != nil {
+		t.Fatalf("nil recorder returned entries %v", entries)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	a, b, c := common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{2}), common.BytesToAddress([]byte{3})
+	recorder := NewRecorder()
+	recorder.SetRule(SourceBlock, "1")
+	amount := big.NewInt(5)
+	recorder.Record(SourceBlock, TypeLeader, c, params.MAN_COIN, amount, In("pool", 100))
+	amount.SetInt64(6)
+	recorder.Record(SourceBlock, TypeLeader, c, params.MAN_COIN, nil)
+	recorder.Record(SourceBlock, TypeLeader, c, params.MAN_COIN, new(big.Int))
+	recorder.RecordMap(SourceLottery, TypeLottery, params.MAN_COIN, map[common.Address]*big.Int{b: big.NewInt(2), a: big.NewInt(1)})
+
+	entries := recorder.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("entries count mismatch: have %d, want 3", len(entries))
+	}
+	if entries[0].Amount.Int64() != 5 || entries[0].Rule != "1" || entries[0].Inputs[0] != (Input{Name: "pool", Value: "100"}) {
+		t.Fatalf("entry mismatch: %v", entries[0])
+	}
+	if entries[1].Account != a || entries[2].Account != b || entries[1].Rule != "" {
+		t.Fatalf("map entries are not sorted: %v %v", entries[1], entries[2])
+	}
+
+	accounts := (&Ledger{Entries: entries}).Accounts()
+	if len(accounts) != 3 || len(accounts[c]) != 1 {
+		t.Fatalf("accounts mismatch: %v", accounts)
+	}
+}
+
+func TestKeepPaid(t *testing.T) {
+	a := common.BytesToAddress([]byte{1})
+	recorder := NewRecorder()
+	recorder.Record(SourceBlock, TypeMinerOut, a, params.MAN_COIN, big.NewInt(1))
+	recorder.Record(SourceBlock, TypeLeader, a, params.MAN_COIN, big.NewInt(2))
+	recorder.Record(SourceTxs, TypeLeader, a, params.MAN_COIN, big.NewInt(3))
+	recorder.Record(SourceTxs, TypeLeader, a, "BTC", big.NewInt(4))
+	recorder.Record(SourceLottery, TypeLottery, a, params.MAN_COIN, big.NewInt(5))
+	recorder.Record(SourceInterest, TypeInterestCalc, a, params.MAN_COIN, big.NewInt(6))
+	recorder.Record(SourceInterest, TypeInterestPay, a, params.MAN_COIN, big.NewInt(7))
+	recorder.Record(SourceSlash, TypeSlash, a, params.MAN_COIN, big.NewInt(8))
+
+	// the validator, lottery and interest pools could not pay
+	recorder.KeepPaid([]common.RewarTx{
+		{CoinRange: params.MAN_COIN, RewardTyp: common.RewardMinerType},
+		{CoinRange: "BTC", RewardTyp: common.RewardTxsType},
+	})
+	want := []int64{1, 4, 6, 8}
+	entries := recorder.Entries()
+	if len(entries) != len(want) {
+		t.Fatalf("entries count mismatch: have %d, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Amount.Int64() != want[i] {
+			t.Errorf("entry %d mismatch: have %v, want %d", i, entry.Amount, want[i])
+		}
+	}
+}
+
+func TestRecordEntries(t *testing.T) {
+	a, b := common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{2})
+	recorder := NewRecorder()
+	recorder.RecordEntries(SourceInterest, TypeInterestPay, params.MAN_COIN, []*Entry{
+		{Account: b, Amount: big.NewInt(1)},
+		{Account: a, Amount: big.NewInt(2)},
+		{Account: b, Amount: big.NewInt(3)},
+		{Account: a, Amount: big.NewInt(4), Inputs: []Input{In("position", 1)}},
+	})
+	want := []int64{2, 4, 1, 3}
+	entries := recorder.Entries()
+	if len(entries) != len(want) {
+		t.Fatalf("entries count mismatch: have %d, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Amount.Int64() != want[i] || entry.Source != SourceInterest || entry.Type != TypeInterestPay {
+			t.Errorf("entry %d mismatch: have %v, want amount %d", i, entry, want[i])
+		}
+	}
+	if len(entries[1].Inputs) != 1 {
+		t.Fatalf("inputs mismatch: %v", entries[1].Inputs)
+	}
+}
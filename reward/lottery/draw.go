@@ -0,0 +1,105 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lottery
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mt19937"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/reward/util"
+)
+
+// CandidatePick is the choice of the lottery candidate of a block among the
+// senders of its MAN transactions, drawn with the VRF value of its header.
+type CandidatePick struct {
+	Seed    int64
+	Random  uint64
+	Index   uint64
+	Account common.Address
+}
+
+// DrawPick is a candidate drawn by the lottery, Index is its position in the
+// candidate list.
+type DrawPick struct {
+	Random  uint64
+	Index   uint64
+	Account common.Address
+}
+
+// Prize is a prize won by a drawn candidate.
+type Prize struct {
+	Pick       int // index of the pick winning the prize
+	Account    common.Address
+	PrizeLevel uint8
+	Amount     *big.Int
+}
+
+// PickCandidate chooses the lottery candidate of a block from the senders of
+// its MAN transactions, vrfInfo is the VRF of the block header. It returns
+// false if the block has no sender.
+func PickCandidate(senders []common.Address, vrfInfo []byte) (CandidatePick, bool) {
+	if len(senders) == 0 {
+		return CandidatePick{}, false
+	}
+	_, vrfValue, _ := baseinterface.NewVrf().GetVrfInfoFromHeader(vrfInfo)
+	pick := CandidatePick{Seed: common.BytesToHash(vrfValue).Big().Int64()}
+	randObj := mt19937.New()
+	randObj.Seed(pick.Seed)
+	pick.Random = randObj.Uint64()
+	pick.Index = pick.Random % uint64(len(senders))
+	pick.Account = senders[pick.Index]
+	return pick, true
+}
+
+// PrizeCount returns the number of prizes of the lottery config.
+func PrizeCount(cfg *mc.LotteryCfg) uint64 {
+	count := uint64(0)
+	for _, info := range cfg.LotteryInfo {
+		count += info.PrizeNum
+	}
+	return count
+}
+
+// DrawCandidates draws count candidates, with repetition, seeded by the
+// election seed of the parent of the draw block.
+func DrawCandidates(seed *big.Int, candidates []common.Address, count uint64) []DrawPick {
+	rand := mt19937.RandUniformInit(seed.Int64())
+	picks := make([]DrawPick, 0)
+	for i := 0; i < int(count) && i < len(candidates); i++ {
+		randomData := uint64(rand.Uniform(0, float64(^uint64(0))))
+		index := randomData % uint64(len(candidates))
+		picks = append(picks, DrawPick{Random: randomData, Index: index, Account: candidates[index]})
+	}
+	return picks
+}
+
+// AssignPrizes awards the prizes of the config to the drawn accounts in order,
+// every account takes the first prize level not given out yet.
+func AssignPrizes(cfg *mc.LotteryCfg, accounts []common.Address) []Prize {
+	record := make(map[uint8]uint64)
+	prizes := make([]Prize, 0)
+	for pick, account := range accounts {
+		for _, info := range cfg.LotteryInfo {
+			if record[info.PrizeLevel] < info.PrizeNum {
+				amount := new(big.Int).Mul(new(big.Int).SetUint64(info.PrizeMoney), util.ManPrice)
+				prizes = append(prizes, Prize{Pick: pick, Account: account, PrizeLevel: info.PrizeLevel, Amount: amount})
+				record[info.PrizeLevel]++
+				break
+			}
+		}
+	}
+	return prizes
+}
+
+// PrizeAmounts sums the prizes per account, as paid by the lottery.
+func PrizeAmounts(prizes []Prize) map[common.Address]*big.Int {
+	amounts := make(map[common.Address]*big.Int)
+	for _, prize := range prizes {
+		util.SetAccountRewards(amounts, prize.Account, new(big.Int).Set(prize.Amount))
+	}
+	return amounts
+}
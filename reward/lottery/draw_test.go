@@ -0,0 +1,50 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lottery
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	_ "github.com/MatrixAINetwork/go-matrix/crypto/vrf"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/reward/util"
+)
+
+func TestDrawReplay(t *testing.T) {
+	if _, ok := PickCandidate(nil, []byte{1, 2, 3}); ok {
+		t.Fatalf("candidate picked without sender")
+	}
+	senders := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")}
+	pick, ok := PickCandidate(senders, make([]byte, 33+65+64))
+	if !ok || pick.Account != senders[pick.Index] || pick.Index != pick.Random%3 {
+		t.Fatalf("candidate pick mismatch: %+v", pick)
+	}
+
+	cfg := &mc.LotteryCfg{LotteryInfo: []mc.LotteryInfo{{PrizeLevel: 0, PrizeNum: 1, PrizeMoney: 6}, {PrizeLevel: 1, PrizeNum: 2, PrizeMoney: 3}}}
+	if PrizeCount(cfg) != 3 {
+		t.Fatalf("prize count mismatch: %d", PrizeCount(cfg))
+	}
+	picks := DrawCandidates(big.NewInt(2000), senders[:2], PrizeCount(cfg))
+	if len(picks) != 2 {
+		t.Fatalf("picks are limited by the candidates: %d", len(picks))
+	}
+	if again := DrawCandidates(big.NewInt(2000), senders[:2], PrizeCount(cfg)); again[0] != picks[0] || again[1] != picks[1] {
+		t.Fatalf("draw is not reproducible")
+	}
+
+	// the same account may win twice, the first prize goes to the first pick
+	prizes := AssignPrizes(cfg, []common.Address{senders[0], senders[1], senders[0], senders[2]})
+	if len(prizes) != 3 || prizes[0].PrizeLevel != 0 || prizes[1].PrizeLevel != 1 || prizes[2].PrizeLevel != 1 || prizes[2].Pick != 2 {
+		t.Fatalf("prizes mismatch: %+v", prizes)
+	}
+	amounts := PrizeAmounts(prizes)
+	if expected := new(big.Int).Mul(big.NewInt(9), util.ManPrice); amounts[senders[0]].Cmp(expected) != 0 {
+		t.Fatalf("amount mismatch: %v, expected %v", amounts[senders[0]], expected)
+	}
+	if prizes[0].Amount.Cmp(new(big.Int).Mul(big.NewInt(6), util.ManPrice)) != 0 {
+		t.Fatalf("prize amount changed by the sum")
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"errors"
 	"math/big"
 
-	"github.com/MatrixAINetwork/go-matrix/baseinterface"
-	"github.com/MatrixAINetwork/go-matrix/common/mt19937"
 	"github.com/MatrixAINetwork/go-matrix/params"
 
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
@@ -140,22 +138,14 @@ func (tlr *TxsLottery) ResetAccountToState() {
 
 }
 func (tlr *TxsLottery) LotterySaveAccount(accounts []common.Address, vrfInfo []byte) {
-	if 0 == len(accounts) {
+	pick, ok := PickCandidate(accounts, vrfInfo)
+	if !ok {
 		//log.INFO(PackageName, "当前区块没有普通交易", "")
 		return
 	}
-
-	randObj := mt19937.New()
-	vrf := baseinterface.NewVrf()
-	_, vrfValue, _ := vrf.GetVrfInfoFromHeader(vrfInfo)
-	seed := common.BytesToHash(vrfValue).Big().Int64()
-	log.Debug(PackageName, "随机数种子", seed)
-	randObj.Seed(seed)
-	rand := randObj.Uint64()
-	index := rand % uint64(len(accounts))
-	account := accounts[index]
-	tlr.AddAccountToState(tlr.state, account)
-	log.Debug(PackageName, "候选彩票账户", account)
+	log.Debug(PackageName, "随机数种子", pick.Seed)
+	tlr.AddAccountToState(tlr.state, pick.Account)
+	log.Debug(PackageName, "候选彩票账户", pick.Account)
 
 }
 func (tlr *TxsLottery) LotteryCalc(parentHash common.Hash, num uint64) map[common.Address]*big.Int {
@@ -167,10 +157,7 @@ func (tlr *TxsLottery) LotteryCalc(parentHash common.Hash, num uint64) map[commo
 	if 0 == len(tlr.lotteryCfg.LotteryInfo) {
 		return nil
 	}
-	lotteryNum := uint64(0)
-	for i := 0; i < len(tlr.lotteryCfg.LotteryInfo); i++ {
-		lotteryNum += tlr.lotteryCfg.LotteryInfo[i].PrizeNum
-	}
+	lotteryNum := PrizeCount(tlr.lotteryCfg)
 
 	txsCmpResultList := tlr.getLotteryList(parentHash, num, lotteryNum)
 	if 0 == len(txsCmpResultList) {
@@ -256,42 +243,17 @@ func (tlr *TxsLottery) getLotteryList(parentHash common.Hash, num uint64, lotter
 	}
 
 	log.Debug(PackageName, "随机数种子", randSeed.Int64())
-	rand := mt19937.RandUniformInit(randSeed.Int64())
-
-	//sort.Sort(txsCmpResultList)
 	chooseResultList := make([]common.Address, 0)
-	//log.Debug(PackageName, "交易数目", len(tlr.accountList))
-	for i := 0; i < int(lotteryNum) && i < len(tlr.accountList); i++ {
-		randomData := uint64(rand.Uniform(0, float64(^uint64(0))))
-		//log.Trace(PackageName, "随机数", randomData)
-		index := randomData % (uint64(len(tlr.accountList)))
-		//log.Trace(PackageName, "交易序号", index)
-		chooseResultList = append(chooseResultList, tlr.accountList[index])
+	for _, pick := range DrawCandidates(randSeed, tlr.accountList, lotteryNum) {
+		chooseResultList = append(chooseResultList, pick.Account)
 	}
 
 	return chooseResultList
 }
 
 func (tlr *TxsLottery) lotteryChoose(txsCmpResultList []common.Address, LotteryMap map[common.Address]*big.Int) {
-
-	RecordMap := make(map[uint8]uint64)
-	for i := 0; i < len(tlr.lotteryCfg.LotteryInfo); i++ {
-		RecordMap[uint8(i)] = 0
-	}
-
-	for _, from := range txsCmpResultList {
-
-		//抽取一等奖
-		for i := 0; i < len(tlr.lotteryCfg.LotteryInfo); i++ {
-			prizeLevel := tlr.lotteryCfg.LotteryInfo[i].PrizeLevel
-			prizeNum := tlr.lotteryCfg.LotteryInfo[i].PrizeNum
-			prizeMoney := tlr.lotteryCfg.LotteryInfo[i].PrizeMoney
-			if RecordMap[prizeLevel] < prizeNum {
-				util.SetAccountRewards(LotteryMap, from, new(big.Int).Mul(new(big.Int).SetUint64(prizeMoney), util.ManPrice))
-				RecordMap[prizeLevel]++
-				log.Debug(PackageName, "奖励地址", from, "金额MAN", prizeMoney)
-				break
-			}
-		}
+	for _, prize := range AssignPrizes(tlr.lotteryCfg, txsCmpResultList) {
+		util.SetAccountRewards(LotteryMap, prize.Account, prize.Amount)
+		log.Debug(PackageName, "奖励地址", prize.Account, "奖励级别", prize.PrizeLevel, "金额", prize.Amount)
 	}
 }
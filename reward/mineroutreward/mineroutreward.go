@@ -62,10 +62,10 @@ func SetPreMinerReward(state util.StateDB, reward *big.Int, rewardType uint8, co
 	var err error
 	if util.TxsReward == rewardType {
 		version := matrixstate.GetVersionInfo(state)
-		switch version {
+		switch manversion.RewardsVersion(version) {
 		case manversion.VersionAlpha:
 			err = matrixstate.SetPreMinerTxsReward(state, minerOutReward)
-		case manversion.VersionBeta, manversion.VersionGamma, manversion.VersionDelta, manversion.VersionAIMine:
+		case manversion.VersionBeta:
 			multiCoinMinerOut, err := matrixstate.GetPreMinerMultiCoinTxsReward(state)
 			if err != nil {
 				log.Error(PackageName, "获取前矿工奖励值错误", err)
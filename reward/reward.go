@@ -8,6 +8,7 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/reward/cfg"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 )
 
 type Reward interface {
@@ -17,4 +18,5 @@ type Reward interface {
 	CalcMinerRateMount(blockReward *big.Int) (*big.Int, *big.Int, *big.Int)
 	CalcValidatorRateMount(blockReward *big.Int) (*big.Int, *big.Int, *big.Int)
 	GetRewardCfg() *cfg.RewardCfg
+	SetLedger(recorder *ledger.Recorder)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/params"
 
 	"github.com/MatrixAINetwork/go-matrix/reward/cfg"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/reward/util"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
@@ -29,6 +30,7 @@ type BlockReward struct {
 	bcInterval         *mc.BCIntervalInfo
 	topology           *mc.TopologyGraph
 	elect              *mc.ElectGraph
+	ledger             *ledger.Recorder
 }
 
 func New(chain util.ChainReader, rewardCfg *cfg.RewardCfg, st util.StateDB, interval *mc.BCIntervalInfo, foundationAccount common.Address, top *mc.TopologyGraph, elect *mc.ElectGraph) *BlockReward {
@@ -94,16 +96,61 @@ func (br *BlockReward) CalcValidatorRewards(Leader common.Address, num uint64) m
 		return nil
 	}
 
-	return br.getValidatorRewards(blockReward, Leader, num)
+	return br.getValidatorRewards(blockReward, Leader, num, util.BlkReward, params.MAN_COIN)
 }
 
-func (br *BlockReward) getValidatorRewards(blockReward *big.Int, Leader common.Address, num uint64) map[common.Address]*big.Int {
+// SetLedger sets the recorder of the computed rewards.
+func (br *BlockReward) SetLedger(recorder *ledger.Recorder) {
+	br.ledger = recorder
+}
+
+func ledgerSource(rewardType uint8) string {
+	if rewardType == util.TxsReward {
+		return ledger.SourceTxs
+	}
+	return ledger.SourceBlock
+}
+
+// recordElected records the elected rewards with the stock of the nodes in the
+// elect graph and whether they are in the topology.
+func (br *BlockReward) recordElected(source string, typ string, coinType string, pool *big.Int, rewards map[common.Address]*big.Int) {
+	if br.ledger == nil {
+		return
+	}
+	stocks := make(map[common.Address]uint16)
+	if br.elect != nil {
+		for _, node := range br.elect.ElectList {
+			stocks[node.Account] = node.Stock
+		}
+	}
+	inTopology := make(map[common.Address]bool)
+	if br.topology != nil {
+		for _, node := range br.topology.NodeList {
+			inTopology[node.Account] = true
+		}
+	}
+	for _, account := range ledger.SortedAccounts(rewards) {
+		reward := rewards[account]
+		stock, elected := stocks[account]
+		if !elected {
+			stock = 1 //二级节点股权默认值为1
+		}
+		br.ledger.Record(source, typ, account, coinType, reward, ledger.In("pool", pool), ledger.In("stock", stock),
+			ledger.In("elected", elected), ledger.In("topology", inTopology[account]))
+	}
+}
+
+func (br *BlockReward) getValidatorRewards(blockReward *big.Int, Leader common.Address, num uint64, rewardType uint8, coinType string) map[common.Address]*big.Int {
 	//广播区块不给矿工发钱
 	rewards := make(map[common.Address]*big.Int, 0)
 	leaderBlkMount, electedMount, FoundationsMount := br.CalcValidatorRateMount(blockReward)
 	leaderReward := br.rewardCfg.SetReward.SetLeaderRewards(leaderBlkMount, Leader, num)
 	electReward := br.rewardCfg.SetReward.GetSelectedRewards(electedMount, br.st, common.RoleValidator|common.RoleBackupValidator, num, br.rewardCfg.RewardMount.RewardRate.BackupRewardRate, br.topology, br.elect)
 	foundationReward := br.calcFoundationRewards(FoundationsMount, num)
+	source := ledgerSource(rewardType)
+	br.ledger.RecordMap(source, ledger.TypeLeader, coinType, leaderReward, ledger.In("pool", leaderBlkMount))
+	br.recordElected(source, ledger.TypeValidatorElected, coinType, electedMount, electReward)
+	br.ledger.RecordMap(source, ledger.TypeValidatorFoundation, coinType, foundationReward, ledger.In("pool", FoundationsMount))
 	util.MergeReward(rewards, leaderReward)
 	util.MergeReward(rewards, electReward)
 	util.MergeReward(rewards, foundationReward)
@@ -117,6 +164,10 @@ func (br *BlockReward) getMinerRewards(blockReward *big.Int, num uint64, rewardT
 	minerOutReward := br.rewardCfg.SetReward.SetMinerOutRewards(minerOutAmount, br.st, br.chain, num, parentHash, coinType)
 	electReward := br.rewardCfg.SetReward.GetSelectedRewards(electedMount, br.st, common.RoleMiner|common.RoleBackupMiner, num, br.rewardCfg.RewardMount.RewardRate.BackupRewardRate, br.topology, br.elect)
 	foundationReward := br.calcFoundationRewards(FoundationsMount, num)
+	source := ledgerSource(rewardType)
+	br.ledger.RecordMap(source, ledger.TypeMinerOut, coinType, minerOutReward, ledger.In("pool", minerOutAmount))
+	br.recordElected(source, ledger.TypeMinerElected, coinType, electedMount, electReward)
+	br.ledger.RecordMap(source, ledger.TypeMinerFoundation, coinType, foundationReward, ledger.In("pool", FoundationsMount))
 	util.MergeReward(rewards, minerOutReward)
 	util.MergeReward(rewards, electReward)
 	util.MergeReward(rewards, foundationReward)
@@ -189,7 +240,7 @@ func (br *BlockReward) CalcNodesRewards(blockReward *big.Int, Leader common.Addr
 	}
 
 	validatorsBlkReward := util.CalcRateReward(blockReward, br.rewardCfg.ValidatorsRate)
-	validatorReward := br.getValidatorRewards(validatorsBlkReward, Leader, num)
+	validatorReward := br.getValidatorRewards(validatorsBlkReward, Leader, num, util.TxsReward, coinType)
 
 	util.MergeReward(rewards, validatorReward)
 	util.MergeReward(rewards, minerRewards)
@@ -15,6 +15,8 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/vm"
 	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/reward/util"
 )
 
@@ -26,6 +28,7 @@ type BlockSlash struct {
 	SlashRate        uint64
 	bcInterval       *mc.BCIntervalInfo
 	preBroadcastRoot *mc.PreBroadStateRoot
+	ledger           *ledger.Recorder
 }
 
 func New(chain util.ChainReader, st util.StateDB, preSt util.StateDB) *BlockSlash {
@@ -177,11 +180,18 @@ func (bp *BlockSlash) CalcSlash(currentState *state.StateDBManage, num uint64, u
 				log.Debug(PackageName, "惩罚账户", v.Account, "惩罚金额", slash)
 			}
 			depoistInfo.AddSlash(currentState, v.Account, slash)
+			bp.ledger.Record(ledger.SourceSlash, ledger.TypeSlash, v.Account, params.MAN_COIN, slash, ledger.In("upTime", upTime),
+				ledger.In("maxUpTime", bp.eleMaxOnlineTime), ledger.In("interest", interest))
 		}
 
 	}
 }
 
+// SetLedger sets the recorder of the computed slashes.
+func (bp *BlockSlash) SetLedger(recorder *ledger.Recorder) {
+	bp.ledger = recorder
+}
+
 func (bp *BlockSlash) getSlash(upTime uint64, accountReward *big.Int) *big.Int {
 	rate := uint64((bp.eleMaxOnlineTime - upTime) * util.RewardFullRate / (bp.eleMaxOnlineTime))
 
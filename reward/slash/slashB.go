@@ -15,6 +15,8 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/reward/util"
 )
 
@@ -25,6 +27,7 @@ type SlashDelta struct {
 	bcInterval       *mc.BCIntervalInfo
 	preBroadcastRoot *mc.PreBroadStateRoot
 	preSt            *state.StateDBManage
+	ledger           *ledger.Recorder
 }
 
 func DeltaNew(chain util.ChainReader, st util.StateDB, preSt *state.StateDBManage) *SlashDelta {
@@ -50,6 +53,11 @@ func DeltaNew(chain util.ChainReader, st util.StateDB, preSt *state.StateDBManag
 	return &SlashDelta{chain: chain, eleMaxOnlineTime: bcInterval.GetBroadcastInterval() - 3, SlashRate: SlashRate, bcInterval: bcInterval, preSt: preSt} // 周期固定3倍关系
 }
 
+// SetLedger sets the recorder of the computed slashes.
+func (bp *SlashDelta) SetLedger(recorder *ledger.Recorder) {
+	bp.ledger = recorder
+}
+
 func (bp *SlashDelta) compareVersion(preState *state.StateDBManage, currentState vm.StateDBManager) bool {
 	preVersion, err := matrixstate.GetSlashCalc(preState)
 	if nil != err {
@@ -168,19 +176,21 @@ func (bp *SlashDelta) SetSlash(electGraph *mc.ElectGraph, upTimeMap map[common.A
 			}
 			slashRate := bp.getSlashRate(upTime)
 
-			bp.addSlash(v.Account, bcInterest, currentState, slashRate)
+			bp.addSlash(v.Account, bcInterest, currentState, slashRate, ledger.In("upTime", upTime), ledger.In("maxUpTime", bp.eleMaxOnlineTime))
 
 		}
 
 	}
 }
 
-func (bp *SlashDelta) addSlash(account common.Address, accountInterest []common.OperationalInterestSlash, currentState *state.StateDBManage, rate uint64) {
+func (bp *SlashDelta) addSlash(account common.Address, accountInterest []common.OperationalInterestSlash, currentState *state.StateDBManage, rate uint64, inputs ...ledger.Input) {
 
 	accountSlash, _ := depoistInfo.GetSlash_v2(currentState, account)
 	newSlashData := make([]common.OperationalInterestSlash, 0)
 	for _, bcInterest := range accountInterest {
 		slash := bp.getSlash(rate, bcInterest.OperAmount)
+		bp.ledger.Record(ledger.SourceSlash, ledger.TypeSlash, account, params.MAN_COIN, slash, append([]ledger.Input{ledger.In("position", bcInterest.Position),
+			ledger.In("rate", rate), ledger.In("interest", bcInterest.OperAmount)}, inputs...)...)
 		for _, slashData := range accountSlash.CalcDeposit {
 			if bcInterest.Position == slashData.Position {
 				slash = slashData.OperAmount.Add(slashData.OperAmount, slash)
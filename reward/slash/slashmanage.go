@@ -8,11 +8,13 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
 	"github.com/MatrixAINetwork/go-matrix/reward/util"
 )
 
 type SlashOperator interface {
 	CalcSlash(currentState *state.StateDBManage, num uint64, upTimeMap map[common.Address]uint64, parentHash common.Hash, time uint64)
+	SetLedger(recorder *ledger.Recorder)
 }
 
 func ManageNew(chain util.ChainReader, st util.StateDB, preSt *state.StateDBManage) SlashOperator {
@@ -394,14 +394,14 @@ func GetPreMinerReward(state StateDB, rewardType uint8) ([]mc.MultiCoinMinerOutR
 	var err error
 	if TxsReward == rewardType {
 		version := matrixstate.GetVersionInfo(state)
-		switch version {
+		switch manversion.RewardsVersion(version) {
 		case manversion.VersionAlpha:
 			currentReward, err = matrixstate.GetPreMinerTxsReward(state)
 			if err != nil {
 				log.Error(PackageName, "获取矿工交易奖励金额错误", err)
 				return nil, errors.New("获取矿工交易金额错误")
 			}
-		case manversion.VersionBeta, manversion.VersionGamma, manversion.VersionDelta, manversion.VersionAIMine:
+		case manversion.VersionBeta:
 			multiCoin, err := matrixstate.GetPreMinerMultiCoinTxsReward(state)
 			if err != nil {
 				log.Error(PackageName, "获取矿工交易奖励金额错误", err)
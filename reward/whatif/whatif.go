@@ -0,0 +1,200 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package whatif replays the interest and slash calculation of a block with an
+// alternate configuration or alternate uptimes, and compares the result with the
+// calculation of the actual configuration.
+package whatif
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/reward/interest"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
+	"github.com/MatrixAINetwork/go-matrix/reward/slash"
+	"github.com/MatrixAINetwork/go-matrix/reward/util"
+)
+
+var (
+	ErrBroadcastBlock      = errors.New("no interest and slash at a broadcast block")
+	ErrSlashRate           = errors.New("slash rate exceeds the full rate")
+	ErrInterestPayInterval = errors.New("interest pay interval is zero")
+)
+
+// Overrides are the alternate inputs of the calculation, a nil field keeps the
+// actual one. UpTimes replaces the uptime of the listed accounts only.
+type Overrides struct {
+	SlashCfg    *mc.SlashCfg
+	InterestCfg *mc.InterestCfg
+	UpTimes     map[common.Address]uint64
+}
+
+// Delta is the difference of an account amount of an entry type between the
+// actual and the alternate calculation.
+type Delta struct {
+	Account common.Address
+	Type    string
+	Actual  *big.Int
+	WhatIf  *big.Int
+	Delta   *big.Int
+}
+
+// Result is the entries of both calculations and their deltas, sorted by account
+// and type. Accounts whose amounts are equal have no delta.
+type Result struct {
+	Actual []*ledger.Entry
+	WhatIf []*ledger.Entry
+	Deltas []*Delta
+}
+
+func (o *Overrides) validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.SlashCfg != nil && o.SlashCfg.SlashRate > util.RewardFullRate {
+		return ErrSlashRate
+	}
+	if o.InterestCfg != nil && o.InterestCfg.PayInterval == 0 {
+		return ErrInterestPayInterval
+	}
+	return nil
+}
+
+// Run replays the interest and slash calculation of the block on copies of st,
+// the state the rewards of the block are computed on, and of preSt, the state
+// of the parent block. The states passed in are not modified.
+func Run(chain util.ChainReader, st *state.StateDBManage, preSt *state.StateDBManage, header *types.Header, upTime map[common.Address]uint64, overrides *Overrides) (*Result, error) {
+	if err := overrides.validate(); err != nil {
+		return nil, err
+	}
+	bcInterval, err := matrixstate.GetBroadcastInterval(st)
+	if err != nil {
+		return nil, err
+	}
+	if bcInterval.IsBroadcastNumber(header.Number.Uint64()) {
+		return nil, ErrBroadcastBlock
+	}
+
+	actual := calc(chain, st.Copy(), preSt.Copy(), header, upTime)
+
+	altPreSt := preSt.Copy()
+	altUpTime := upTime
+	if overrides != nil {
+		if overrides.SlashCfg != nil {
+			if err := matrixstate.SetSlashCfg(altPreSt, overrides.SlashCfg); err != nil {
+				return nil, err
+			}
+		}
+		if overrides.InterestCfg != nil {
+			if err := matrixstate.SetInterestCfg(altPreSt, overrides.InterestCfg); err != nil {
+				return nil, err
+			}
+		}
+		if len(overrides.UpTimes) != 0 {
+			altUpTime = make(map[common.Address]uint64, len(upTime)+len(overrides.UpTimes))
+			for account, value := range upTime {
+				altUpTime[account] = value
+			}
+			for account, value := range overrides.UpTimes {
+				altUpTime[account] = value
+			}
+		}
+	}
+	whatIf := calc(chain, st.Copy(), altPreSt, header, altUpTime)
+
+	return &Result{Actual: actual, WhatIf: whatIf, Deltas: diff(actual, whatIf)}, nil
+}
+
+// calc runs the interest and slash engines in the order of the block processing.
+func calc(chain util.ChainReader, st *state.StateDBManage, preSt *state.StateDBManage, header *types.Header, upTime map[common.Address]uint64) []*ledger.Entry {
+	recorder := ledger.NewRecorder()
+	if rule, err := matrixstate.GetInterestCalc(preSt); err == nil {
+		recorder.SetRule(ledger.SourceInterest, rule)
+	}
+	if rule, err := matrixstate.GetSlashCalc(preSt); err == nil {
+		recorder.SetRule(ledger.SourceSlash, rule)
+	}
+
+	num := header.Number.Uint64()
+	interestReward := interest.ManageNew(st, preSt)
+	if nil == interestReward {
+		return nil
+	}
+	interestReward.SetLedger(recorder)
+	interestReward.CalcReward(st, num, header.ParentHash)
+
+	slashOperator := slash.ManageNew(chain, st, preSt)
+	if nil != slashOperator {
+		slashOperator.SetLedger(recorder)
+		slashOperator.CalcSlash(st, num, upTime, header.ParentHash, header.Time.Uint64())
+	}
+	interestReward.PayInterest(st, num, header.Time.Uint64())
+	return recorder.Entries()
+}
+
+type deltaKey struct {
+	account common.Address
+	typ     string
+}
+
+func sum(entries []*ledger.Entry) map[deltaKey]*big.Int {
+	sums := make(map[deltaKey]*big.Int)
+	for _, entry := range entries {
+		key := deltaKey{entry.Account, entry.Type}
+		if _, exist := sums[key]; !exist {
+			sums[key] = new(big.Int)
+		}
+		sums[key].Add(sums[key], entry.Amount)
+	}
+	return sums
+}
+
+func diff(actual, whatIf []*ledger.Entry) []*Delta {
+	actualSums, whatIfSums := sum(actual), sum(whatIf)
+	keys := make([]deltaKey, 0, len(actualSums)+len(whatIfSums))
+	for key := range actualSums {
+		keys = append(keys, key)
+	}
+	for key := range whatIfSums {
+		if _, exist := actualSums[key]; !exist {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if cmp := bytes.Compare(keys[i].account[:], keys[j].account[:]); cmp != 0 {
+			return cmp < 0
+		}
+		return keys[i].typ < keys[j].typ
+	})
+
+	deltas := make([]*Delta, 0)
+	for _, key := range keys {
+		actualSum, whatIfSum := actualSums[key], whatIfSums[key]
+		if actualSum == nil {
+			actualSum = new(big.Int)
+		}
+		if whatIfSum == nil {
+			whatIfSum = new(big.Int)
+		}
+		if actualSum.Cmp(whatIfSum) == 0 {
+			continue
+		}
+		deltas = append(deltas, &Delta{
+			Account: key.account,
+			Type:    key.typ,
+			Actual:  actualSum,
+			WhatIf:  whatIfSum,
+			Delta:   new(big.Int).Sub(whatIfSum, actualSum),
+		})
+	}
+	return deltas
+}
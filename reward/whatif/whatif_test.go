@@ -0,0 +1,74 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package whatif
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/reward/ledger"
+	"github.com/MatrixAINetwork/go-matrix/reward/util"
+)
+
+func entry(account common.Address, typ string, amount int64) *ledger.Entry {
+	return &ledger.Entry{Type: typ, Account: account, Amount: big.NewInt(amount)}
+}
+
+func TestDiff(t *testing.T) {
+	a, b, c := common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{2}), common.BytesToAddress([]byte{3})
+	actual := []*ledger.Entry{
+		entry(b, ledger.TypeSlash, 10),
+		entry(b, ledger.TypeSlash, 5),
+		entry(a, ledger.TypeInterestPay, 100),
+		entry(c, ledger.TypeSlash, 7),
+	}
+	whatIf := []*ledger.Entry{
+		entry(b, ledger.TypeSlash, 20),
+		entry(a, ledger.TypeInterestPay, 100),
+		entry(a, ledger.TypeSlash, 3),
+	}
+
+	deltas := diff(actual, whatIf)
+	want := []struct {
+		account common.Address
+		typ     string
+		actual  int64
+		whatIf  int64
+		delta   int64
+	}{
+		{a, ledger.TypeSlash, 0, 3, 3},
+		{b, ledger.TypeSlash, 15, 20, 5},
+		{c, ledger.TypeSlash, 7, 0, -7},
+	}
+	if len(deltas) != len(want) {
+		t.Fatalf("deltas count mismatch: have %d, want %d", len(deltas), len(want))
+	}
+	for i, w := range want {
+		d := deltas[i]
+		if d.Account != w.account || d.Type != w.typ || d.Actual.Int64() != w.actual || d.WhatIf.Int64() != w.whatIf || d.Delta.Int64() != w.delta {
+			t.Errorf("delta %d mismatch: have %x %s %v %v %v", i, d.Account, d.Type, d.Actual, d.WhatIf, d.Delta)
+		}
+	}
+}
+
+func TestOverridesValidate(t *testing.T) {
+	var overrides *Overrides
+	if err := overrides.validate(); err != nil {
+		t.Fatalf("nil overrides: %v", err)
+	}
+	overrides = &Overrides{SlashCfg: &mc.SlashCfg{SlashRate: util.RewardFullRate + 1}}
+	if err := overrides.validate(); err != ErrSlashRate {
+		t.Fatalf("slash rate error mismatch: have %v, want %v", err, ErrSlashRate)
+	}
+	overrides = &Overrides{InterestCfg: &mc.InterestCfg{PayInterval: 0}}
+	if err := overrides.validate(); err != ErrInterestPayInterval {
+		t.Fatalf("pay interval error mismatch: have %v, want %v", err, ErrInterestPayInterval)
+	}
+	overrides = &Overrides{SlashCfg: &mc.SlashCfg{SlashRate: util.RewardFullRate}, InterestCfg: &mc.InterestCfg{PayInterval: 3600}}
+	if err := overrides.validate(); err != nil {
+		t.Fatalf("valid overrides: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/election/simulate"
+	"github.com/MatrixAINetwork/go-matrix/internal/manapi"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/pod"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	electPluginFlag = cli.StringFlag{
+		Name:  "plugin",
+		Usage: "Election plug to run, overrides the one of the scenario",
+	}
+	electRunsFlag = cli.IntFlag{
+		Name:  "runs",
+		Usage: "Number of seeds to run the election with",
+		Value: 1,
+	}
+	electJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the report as JSON",
+	}
+	electAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: pod.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+	electCyclesFlag = cli.Uint64Flag{
+		Name:  "cycles",
+		Usage: "Number of election cycles to show the stats of",
+		Value: 4,
+	}
+
+	electCommand = cli.Command{
+		Name:     "elect",
+		Usage:    "Election tools",
+		Category: "MISCELLANEOUS COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "simulate",
+				Usage:     "Run an election plug on a JSON scenario",
+				ArgsUsage: "<scenario.json>",
+				Action:    utils.MigrateFlags(simulateElect),
+				Flags: []cli.Flag{
+					electPluginFlag,
+					electRunsFlag,
+					electJSONFlag,
+				},
+				Description: `
+The simulate command reads the deposits, the VIP config, the election config
+with its black and white lists and the seed from <scenario.json>, and runs the
+miner and validator elections offline. It prints the topology of the first
+seed, then how often every node was elected over --runs seeds, the seed being
+incremented by one every run. See election/simulate/testdata/scenario.json for
+an example scenario.`,
+			},
+			{
+				Name:      "blacklist",
+				Usage:     "Show the block produce blacklist of a running node",
+				ArgsUsage: "[address]",
+				Action:    utils.MigrateFlags(showBlockProduceBlackList),
+				Flags: []cli.Flag{
+					electAttachFlag,
+					electCyclesFlag,
+					electJSONFlag,
+				},
+				Description: `
+The blacklist command prints the blocks produced by the validators in the
+current election cycle, and the validators banned from the validator election
+for producing too few blocks, with the cycle banning them and the election they
+can be elected by again. With an [address], it prints the blocks produced by the
+validator in the last --cycles election cycles instead.`,
+			},
+		},
+	}
+)
+
+func simulateElect(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	scenario, err := simulate.LoadScenario(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Load scenario error: %v", err)
+	}
+	if plugin := ctx.String(electPluginFlag.Name); plugin != "" {
+		scenario.Plugin = plugin
+	}
+	report, err := simulate.Run(scenario, ctx.Int(electRunsFlag.Name))
+	if err != nil {
+		utils.Fatalf("Simulate error: %v", err)
+	}
+	if ctx.Bool(electJSONFlag.Name) {
+		return printJSON(report)
+	}
+
+	fmt.Printf("Election plug: %s (registered: %s)\n", report.Plugin, strings.Join(baseinterface.ElectPlugNames(), ", "))
+	fmt.Printf("Runs:          %d, first seed %v\n", report.Runs, report.First.Seed)
+	printElectNodes("Miners", report.First.Miners)
+	printElectNodes("Validators", report.First.Validators)
+	printElectNodes("Backup validators", report.First.Backups)
+	printElectNodes("Candidate validators", report.First.Candidates)
+	printStockDistribution(report.First.Validators)
+	printNodeStats("Miner", report.Miners, report.Runs)
+	printNodeStats("Validator", report.Validators, report.Runs)
+	return nil
+}
+
+func printElectNodes(title string, nodes []mc.ElectNodeInfo) {
+	if len(nodes) == 0 {
+		return
+	}
+	fmt.Printf("\n%s (%d):\n", title, len(nodes))
+	for _, node := range nodes {
+		fmt.Printf("  %-4d %s stock %-5d vip %d %s\n", node.Position, node.Account.Hex(), node.Stock, node.VIPLevel, node.Type)
+	}
+}
+
+func printStockDistribution(nodes []mc.ElectNodeInfo) {
+	total := 0
+	for _, node := range nodes {
+		total += int(node.Stock)
+	}
+	if total == 0 {
+		return
+	}
+	fmt.Printf("\nStock distribution (total %d):\n", total)
+	for _, node := range nodes {
+		fmt.Printf("  %s %6.2f%%\n", node.Account.Hex(), float64(node.Stock)*100/float64(total))
+	}
+}
+
+func printNodeStats(title string, stats []*simulate.NodeStat, runs int) {
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Printf("\n%s selection frequency over %d runs:\n", title, runs)
+	fmt.Printf("  %-42s %-16s %-16s %-16s %s\n", "address", "master", "backup", "candidate", "avg stock")
+	percent := func(count int) string {
+		return fmt.Sprintf("%d (%.1f%%)", count, float64(count)*100/float64(runs))
+	}
+	for _, stat := range stats {
+		avgStock := 0.0
+		if stat.Master > 0 {
+			avgStock = float64(stat.Stock) / float64(stat.Master)
+		}
+		fmt.Printf("  %-42s %-16s %-16s %-16s %.1f\n", stat.Address.Hex(), percent(stat.Master), percent(stat.Backup), percent(stat.Candidate), avgStock)
+	}
+}
+
+func showBlockProduceBlackList(ctx *cli.Context) error {
+	client, err := dialRPC(ctx.String(electAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to gman node: %v", err)
+	}
+	defer client.Close()
+
+	if addr := ctx.Args().First(); addr != "" {
+		var cycles []*manapi.RPCBlockProduceCycle
+		if err := client.Call(&cycles, "man_getBlockProduceSlashHistory", addr, ctx.Uint64(electCyclesFlag.Name), "latest"); err != nil {
+			utils.Fatalf("Failed to retrieve the block produce stats: %v", err)
+		}
+		if ctx.Bool(electJSONFlag.Name) {
+			return printJSON(cycles)
+		}
+		fmt.Printf("Block produce stats of %s:\n", addr)
+		fmt.Printf("  %-12s %-12s %-10s %-9s %-10s %-8s %s\n", "reelection", "stats from", "finished", "produced", "threshold", "slashed", "counter")
+		for _, cycle := range cycles {
+			produced, counter := "-", "-"
+			if cycle.Validator {
+				produced = fmt.Sprint(cycle.ProduceNum)
+			}
+			if cycle.InBlackList {
+				counter = fmt.Sprint(cycle.ProhibitCycleCounter)
+			}
+			fmt.Printf("  %-12d %-12d %-10t %-9s %-10d %-8t %s\n", cycle.ReElectionNumber, cycle.StatsStart, cycle.Finished, produced, cycle.LowTHR, cycle.Slashed, counter)
+		}
+		return nil
+	}
+
+	var slash manapi.RPCBlockProduceSlash
+	if err := client.Call(&slash, "man_getBlockProduceSlash", "latest"); err != nil {
+		utils.Fatalf("Failed to retrieve the block produce blacklist: %v", err)
+	}
+	if ctx.Bool(electJSONFlag.Name) {
+		return printJSON(slash)
+	}
+	fmt.Printf("Block:          %d\n", slash.Number)
+	fmt.Printf("Slash:          switcher %t, lowTHR %d, prohibitCycleNum %d\n", slash.Switcher, slash.LowTHR, slash.ProhibitCycleNum)
+	fmt.Printf("Rule %s:         %s\n", slash.Rule, slash.RuleDescription)
+	fmt.Printf("Election cycle: stats from %d, blacklist update %d, reelection %d\n", slash.StatsStart, slash.SlashNumber, slash.ReElectionNumber)
+	fmt.Printf("\nBlocks produced (%d):\n", len(slash.Stats))
+	for _, stats := range slash.Stats {
+		mark := ""
+		if stats.BelowThreshold {
+			mark = " below threshold"
+		}
+		fmt.Printf("  %s %d%s\n", stats.Address, stats.ProduceNum, mark)
+	}
+	fmt.Printf("\nBlacklist (%d):\n", len(slash.BlackList))
+	for _, item := range slash.BlackList {
+		projection := "at"
+		if !item.Exact {
+			projection = "not before"
+		}
+		fmt.Printf("  %s counter %d, eligible %s reelection %d", item.Address, item.ProhibitCycleCounter, projection, item.EligibleElection)
+		if item.Cause != nil {
+			fmt.Printf(", produced %d < %d blocks in the cycle of reelection %d", item.Cause.ProduceNum, item.Cause.LowTHR, item.Cause.ReElectionNumber)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/man"
+	"github.com/MatrixAINetwork/go-matrix/pod"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	lotteryAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: pod.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+
+	lotteryCommand = cli.Command{
+		Name:     "lottery",
+		Usage:    "Transaction lottery tools",
+		Category: "MISCELLANEOUS COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "getdraw",
+				Usage:     "Export the lottery draw of a block from a running node",
+				ArgsUsage: "<number> [<filename>]",
+				Action:    utils.MigrateFlags(getLotteryDraw),
+				Flags: []cli.Flag{
+					lotteryAttachFlag,
+				},
+				Description: `
+The getdraw command writes the lottery draw of the block <number> returned by
+lottery_getDraw into <filename>, or prints it. The draw holds the candidates
+with the blocks choosing them, the seed, the picks, the prizes and the payouts.`,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Verify a lottery draw offline",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(verifyLotteryDraw),
+				Description: `
+The verify command replays the lottery draw of <filename> without any node. It
+checks the headers of the draw link to the draw block, rebuilds the seed from
+the vote keys of the broadcast blocks and the header hashes, verifies the VRF
+proof of every block choosing a candidate, recovers the senders of the blocks
+and chooses the candidates again, draws them with the seed, awards the prizes
+and compares every step with the draw and the payouts. The block hash of the
+draw can be checked against any other node.`,
+			},
+		},
+	}
+)
+
+func getLotteryDraw(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	number, err := strconv.ParseUint(ctx.Args().First(), 0, 64)
+	if err != nil {
+		utils.Fatalf("Invalid block number: %v", err)
+	}
+	client, err := dialRPC(ctx.String(lotteryAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to gman node: %v", err)
+	}
+	defer client.Close()
+
+	var draw json.RawMessage
+	if err := client.Call(&draw, "lottery_getDraw", hexutil.Uint64(number)); err != nil {
+		utils.Fatalf("Failed to retrieve the lottery draw: %v", err)
+	}
+	out := os.Stdout
+	if len(ctx.Args()) > 1 {
+		if out, err = os.OpenFile(ctx.Args().Get(1), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+			utils.Fatalf("Export error: %v", err)
+		}
+		defer out.Close()
+	}
+	_, err = fmt.Fprintln(out, string(draw))
+	return err
+}
+
+func verifyLotteryDraw(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	f, err := os.Open(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to open the lottery draw: %v", err)
+	}
+	defer f.Close()
+	draw := new(man.RPCLotteryDraw)
+	if err := json.NewDecoder(f).Decode(draw); err != nil {
+		utils.Fatalf("Invalid lottery draw: %v", err)
+	}
+	checks, err := man.VerifyLotteryDraw(draw)
+	if err != nil {
+		utils.Fatalf("Invalid lottery draw: %v", err)
+	}
+
+	fmt.Printf("Lottery draw of block %d, seed %v, %d candidates, %d winners\n", draw.Number, draw.Seed.ToInt(), len(draw.Candidates), len(draw.Winners))
+	failed := 0
+	for _, check := range checks {
+		if check.OK {
+			fmt.Printf("  %-11s ok\n", check.Step)
+		} else {
+			failed++
+			fmt.Printf("  %-11s FAILED: %s\n", check.Step, check.Detail)
+		}
+	}
+	if draw.Paid == nil {
+		fmt.Println("  payout      not checked, the node has no reward ledger of the block")
+	}
+	if failed > 0 {
+		utils.Fatalf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
@@ -59,6 +59,7 @@ var (
 		utils.PasswordFileFlag,
 		utils.AccountPasswordFileFlag,
 		utils.TestEntrustFlag,
+		utils.ExternalSignerFlag,
 		utils.BootnodesFlag,
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
@@ -77,6 +78,7 @@ var (
 		utils.ManashDatasetDirFlag,
 		utils.ManashDatasetsInMemoryFlag,
 		utils.ManashDatasetsOnDiskFlag,
+		utils.AIDiggerFlag,
 		utils.TxPoolNoLocalsFlag,
 		//utils.TxPoolJournalFlag, //Y
 		//utils.TxPoolRejournalFlag,
@@ -129,6 +131,8 @@ var (
 		utils.ExtraDataFlag,
 		configFileFlag,
 		utils.GetCommitFlag,
+		utils.LeaderTimelineFlag,
+		utils.ShardConfigFlag,
 		utils.ManAddressFlag,
 		utils.SuperBlockElectGenFlag,
 		utils.SynSnapshootNumFlg,
@@ -139,6 +143,7 @@ var (
 		utils.DbTableSizeFlag,
 		utils.GetGenesisFlag,
 		utils.LessDiskEnabledFlag,
+		utils.LessDiskPruneStateFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -171,6 +176,8 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		snapshotCommand,
+		electCommand,
 		rollbackCommand,
 		genBlockCommand,
 		genBlockRootsCommand,
@@ -183,6 +190,14 @@ func init() {
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
+		// See signercmd.go:
+		signerCommand,
+		// See slashprotectcmd.go:
+		slashProtectCommand,
+		// See lotterycmd.go:
+		lotteryCommand,
+		// See superblockcmd.go:
+		superBlockCommand,
 		// See consolecmd.go:
 		consoleCommand,
 		attachCommand,
@@ -375,15 +390,23 @@ func aiDiggingTest() {
 		pictureList = append(pictureList, "/root/aitest/picstore/test_"+strconv.Itoa(i)+".jpg")
 	}
 
-	aidigger.Init("/root/aitest", pictureList)
+	digger, err := aidigger.NewAIDigger(aidigger.DiggerGold)
+	if err != nil {
+		log.Error("test log", "create ai digger err", err)
+		return
+	}
+	if err := digger.Init("/root/aitest", pictureList); err != nil {
+		log.Error("test log", "init ai digger err", err)
+		return
+	}
 
 	for {
-		aiDiggingOnce()
+		aiDiggingOnce(digger)
 		time.Sleep(time.Second)
 	}
 }
 
-func aiDiggingOnce() {
+func aiDiggingOnce(digger aidigger.AIDigger) {
 	log.Info("test log", "once digging", "begin")
 	defer log.Info("test log", "once digging", "end")
 
@@ -395,7 +418,7 @@ func aiDiggingOnce() {
 	foundCh := make(chan []byte, 1)
 	errCh := make(chan error, 1)
 
-	go aidigger.AIDigging(12345, pictureList, abortCh, foundCh, errCh)
+	go digger.Digging(12345, pictureList, abortCh, foundCh, errCh)
 
 	for {
 		select {
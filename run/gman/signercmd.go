@@ -0,0 +1,98 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params/enstrust"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	signerCommand = cli.Command{
+		Action:    utils.MigrateFlags(runSigner),
+		Name:      "signer",
+		Usage:     "Run a signer daemon holding the sign account keys",
+		ArgsUsage: "",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.KeyStoreDirFlag,
+			utils.LightKDFFlag,
+			utils.AccountPasswordFileFlag,
+			utils.TestEntrustFlag,
+			utils.SignerIPCFlag,
+			utils.SignerHTTPFlag,
+		},
+		Category: "ACCOUNT COMMANDS",
+		Description: `
+    gman signer --datadir /path/to/signer --entrust /path/to/entrust.json
+
+loads the sign accounts of the entrust file from the keystore, and serves the
+signer API over IPC, and over HTTP with --signer.http. Nodes sign with it when
+started with --signer <endpoint>, their keys then stay off the node process.
+
+The signer refuses to sign two different subjects for the same consensus vote,
+the signed votes are recorded in the slashing protection database of the
+datadir, see 'gman slashprotect'.`,
+	}
+)
+
+func runSigner(ctx *cli.Context) error {
+	stack, cfg := makeConfigNode(ctx)
+	backends := stack.AccountManager().Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		utils.Fatalf("Keystore is not available")
+	}
+	ks := backends[0].(*keystore.KeyStore)
+
+	if err := CheckEntrust(ctx); err != nil {
+		utils.Fatalf("Failed to load the entrust file: %v", err)
+	}
+	passwords := entrust.EntrustAccountValue.GetEntrustValue()
+	if len(passwords) == 0 {
+		utils.Fatalf("No sign account, the entrust file must be given with --%s", utils.AccountPasswordFileFlag.Name)
+	}
+
+	guard, err := signhelper.NewVoteGuard(cfg.Node.SlashProtectionDB())
+	if err != nil {
+		utils.Fatalf("Failed to load the signed votes: %v", err)
+	}
+	defer guard.Close()
+	service, err := signhelper.NewSignerService(ks, passwords, guard)
+	if err != nil {
+		utils.Fatalf("Failed to start the signer: %v", err)
+	}
+
+	ipcPath := stack.ResolvePath(ctx.GlobalString(utils.SignerIPCFlag.Name))
+	ipcListener, _, err := rpc.StartIPCEndpoint(ipcPath, service.APIs())
+	if err != nil {
+		utils.Fatalf("Failed to start the signer IPC endpoint: %v", err)
+	}
+	defer ipcListener.Close()
+	log.Info("Signer IPC endpoint opened", "url", ipcPath, "accounts", len(passwords))
+
+	if addr := ctx.GlobalString(utils.SignerHTTPFlag.Name); addr != "" {
+		httpListener, _, err := rpc.StartHTTPEndpoint(addr, service.APIs(), []string{"signer"}, nil, []string{"localhost"})
+		if err != nil {
+			utils.Fatalf("Failed to start the signer HTTP endpoint: %v", err)
+		}
+		defer httpListener.Close()
+		log.Info("Signer HTTP endpoint opened", "url", "http://"+addr)
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	<-sigc
+	log.Info("Signer stopped")
+	return nil
+}
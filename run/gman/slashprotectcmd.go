@@ -0,0 +1,103 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	slashProtectCommand = cli.Command{
+		Name:     "slashprotect",
+		Usage:    "Manage the slashing protection of the consensus votes",
+		Category: "ACCOUNT COMMANDS",
+		Description: `
+The node, and the signer daemon, record every block verify, leader reelection
+and online consensus vote they sign in the slashing protection database of the
+datadir, and refuse to sign another subject for the same vote. Export the
+records before moving a validator to another machine, and import them there
+before starting it. The node must be stopped while the database is used.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the signed votes into a json file",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(exportSlashProtection),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+			},
+			{
+				Name:      "import",
+				Usage:     "Import the signed votes of a json file",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(importSlashProtection),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+				Description: `
+The import command merges the records of the file with the local ones, a record
+conflicting with a local one is reported and skipped.`,
+			},
+		},
+	}
+)
+
+func openSlashProtection(ctx *cli.Context) *signhelper.VoteGuard {
+	_, cfg := makeConfigNode(ctx)
+	path := cfg.Node.SlashProtectionDB()
+	if path == "" {
+		utils.Fatalf("The slashing protection requires a datadir")
+	}
+	guard, err := signhelper.NewVoteGuard(path)
+	if err != nil {
+		utils.Fatalf("Failed to open the slashing protection database: %v", err)
+	}
+	return guard
+}
+
+func exportSlashProtection(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	guard := openSlashProtection(ctx)
+	defer guard.Close()
+
+	f, err := os.OpenFile(ctx.Args().First(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	defer f.Close()
+	if err := guard.Export(f); err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	fmt.Printf("Exported %d votes\n", len(guard.Records()))
+	return nil
+}
+
+func importSlashProtection(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	guard := openSlashProtection(ctx)
+	defer guard.Close()
+
+	f, err := os.Open(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Import error: %v", err)
+	}
+	defer f.Close()
+	imported, conflicts, err := guard.Import(f)
+	if err != nil {
+		utils.Fatalf("Import error: %v", err)
+	}
+	fmt.Printf("Imported %d votes, %d conflicting votes skipped\n", imported, conflicts)
+	return nil
+}
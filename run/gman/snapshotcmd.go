@@ -0,0 +1,168 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"github.com/MatrixAINetwork/go-matrix/snapshot"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Manage state snapshot files",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Export, verify, import and inspect snapshot files. A snapshot file holds the
+states and blocks needed to bootstrap a node at a given block, split in chunks
+checked by hash against a manifest tied to the snapshot block hash.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the snapshot of a block into a file",
+				ArgsUsage: "<filename> <blockNum>",
+				Action:    utils.MigrateFlags(exportSnapshot),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+The export command streams the snapshot of <blockNum> into <filename>, the
+pre broadcast states are taken from the current block.`,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Check every chunk of a snapshot file against its manifest",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(verifySnapshot),
+			},
+			{
+				Name:      "import",
+				Usage:     "Verify a snapshot file, then load it into the chain database",
+				ArgsUsage: "<filename> [<blockNum> [<blockHash>]]",
+				Action:    utils.MigrateFlags(importSnapshot),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+The import command refuses a file whose manifest does not match <blockNum> or
+<blockHash> when they are given.`,
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Print the manifest of a snapshot file",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(inspectSnapshot),
+			},
+		},
+	}
+)
+
+func exportSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	number, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if err != nil {
+		utils.Fatalf("Invalid block number: %v", err)
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	start := time.Now()
+	manifest, err := chain.ExportSnapshotFile(ctx.Args().First(), number)
+	if err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	printManifest(manifest, false)
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+func verifySnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	start := time.Now()
+	manifest, err := snapshot.VerifyFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Verify error: %v", err)
+	}
+	printManifest(manifest, false)
+	fmt.Printf("Verify done in %v\n", time.Since(start))
+	return nil
+}
+
+func importSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	var (
+		number uint64
+		hash   string
+		err    error
+	)
+	if len(ctx.Args()) > 1 {
+		if number, err = strconv.ParseUint(ctx.Args().Get(1), 10, 64); err != nil {
+			utils.Fatalf("Invalid block number: %v", err)
+		}
+	}
+	if len(ctx.Args()) > 2 {
+		hash = ctx.Args().Get(2)
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	start := time.Now()
+	if err := chain.ImportSnapshotFile(ctx.Args().First(), number, hash); err != nil {
+		utils.Fatalf("Import error: %v", err)
+	}
+	fmt.Printf("Import done in %v, current block %d\n", time.Since(start), chain.CurrentBlock().NumberU64())
+	return nil
+}
+
+func inspectSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	manifest, err := snapshot.ReadManifest(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Inspect error: %v", err)
+	}
+	printManifest(manifest, true)
+	return nil
+}
+
+func printManifest(manifest *snapshot.Manifest, sections bool) {
+	fmt.Printf("Format version: %d\n", manifest.Version)
+	fmt.Printf("Block:          %d %s\n", manifest.BlockNumber, manifest.BlockHash.Hex())
+	fmt.Printf("Manifest hash:  %s\n", manifest.Hash().Hex())
+	fmt.Printf("Sections:       %d, chunks: %d\n", len(manifest.Sections), manifest.Chunks())
+	if !sections {
+		return
+	}
+	kinds := map[uint8]string{
+		snapshot.SectionOtherTrie: "pre broadcast state",
+		snapshot.SectionBlockTrie: "block state",
+		snapshot.SectionBlock:     "block",
+	}
+	for _, section := range manifest.Sections {
+		header := section.Header
+		if header.Kind == snapshot.SectionBlock {
+			fmt.Printf("  %-19s %-8d items %-9d chunks %d\n", kinds[header.Kind], header.Group, section.Items, len(section.Chunks))
+			continue
+		}
+		fmt.Printf("  %-19s %-8d coin %-6s range %-3d root %s items %-9d chunks %d\n",
+			kinds[header.Kind], header.Group, header.Coin, header.Index, header.Root.TerminalString(), section.Items, len(section.Chunks))
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	superBlockJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the result as JSON",
+	}
+
+	superBlockCommand = cli.Command{
+		Name:     "superblock",
+		Usage:    "Coordinate the signing of a super block",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The super block commands pass a proposal file between the super block accounts.
+The proposal holds the super block genesis with its roots, the hash to sign and
+the signatures collected so far. Once enough super block accounts signed it, the
+genesis is exported and imported with importSuperBlock.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "propose",
+				Usage:     "Compute the roots of a super block and create its proposal",
+				ArgsUsage: "<genesisPath> <proposalPath>",
+				Action:    utils.MigrateFlags(proposeSuperBlock),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+				Description: `
+The propose command applies the super block genesis on the state of its parent
+in the local chain, like genblockroots, and writes the proposal to sign.`,
+			},
+			{
+				Name:      "sign",
+				Usage:     "Sign a super block proposal",
+				ArgsUsage: "<proposalPath> <privateKey>",
+				Action:    utils.MigrateFlags(signSuperBlockProposal),
+				Description: `
+The sign command checks the hash of the proposal, signs it with the hex private
+key and adds the signature to the proposal file.`,
+			},
+			{
+				Name:      "addsig",
+				Usage:     "Add a signature to a super block proposal",
+				ArgsUsage: "<proposalPath> <signature>",
+				Action:    utils.MigrateFlags(addSuperBlockSignature),
+				Description: `
+The addsig command adds a hex signature of the proposal hash made elsewhere, for
+example by a remote signer, to the proposal file.`,
+			},
+			{
+				Name:      "status",
+				Usage:     "Show the super block accounts which signed a proposal",
+				ArgsUsage: "<proposalPath>",
+				Action:    utils.MigrateFlags(superBlockProposalStatus),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					superBlockJSONFlag,
+				},
+				Description: `
+The status command reads the super block accounts of the current block of the
+local chain, lists the accounts which signed the proposal and the missing ones,
+and tells whether the signatures reach the count the consensus engine needs.`,
+			},
+			{
+				Name:      "dryrun",
+				Usage:     "Apply a super block proposal on a copy of the chain state",
+				ArgsUsage: "<proposalPath>",
+				Action:    utils.MigrateFlags(dryRunSuperBlock),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					superBlockJSONFlag,
+				},
+				Description: `
+The dryrun command applies the super block on the state of its parent in the
+local chain without writing anything, and prints the matrix state keys the super
+block changes. It is meant to be run before anyone signs.`,
+			},
+			{
+				Name:      "export",
+				Usage:     "Export the signed super block genesis of a proposal",
+				ArgsUsage: "<proposalPath> <genesisPath>",
+				Action:    utils.MigrateFlags(exportSuperBlockProposal),
+				Description: `
+The export command writes the genesis of the proposal with its signatures, the
+file taken by importSuperBlock.`,
+			},
+		},
+	}
+)
+
+func readSuperBlockProposal(path string) *core.SuperBlockProposal {
+	file, err := os.Open(path)
+	if err != nil {
+		utils.Fatalf("Failed to read proposal file: %v", err)
+	}
+	defer file.Close()
+
+	proposal := new(core.SuperBlockProposal)
+	if err := json.NewDecoder(file).Decode(proposal); err != nil {
+		utils.Fatalf("invalid proposal file: %v", err)
+	}
+	if err := proposal.Validate(); err != nil {
+		utils.Fatalf("invalid proposal file: %v", err)
+	}
+	return proposal
+}
+
+func writeSuperBlockFile(path string, v interface{}) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode file: %v", err)
+	}
+	if err := ioutil.WriteFile(path, out, 0644); err != nil {
+		utils.Fatalf("Failed to save file, err = %v", err)
+	}
+}
+
+func proposeSuperBlock(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires 2 arguments.")
+	}
+	file, err := os.Open(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read genesis file: %v", err)
+	}
+	defer file.Close()
+	genesis := new(core.Genesis)
+	if err := json.NewDecoder(file).Decode(genesis); err != nil {
+		utils.Fatalf("invalid genesis file: %v", err)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDB := utils.MakeChain(ctx, stack)
+	if chain == nil {
+		utils.Fatalf("make chain err")
+	}
+	defer chainDB.Close()
+	parent := chain.GetHeaderByHash(genesis.ParentHash)
+	if nil == parent {
+		utils.Fatalf("get parent header err")
+	}
+	superBlock := genesis.GenSuperBlock(parent, chainDB, state.NewDatabase(chainDB), chain.Config())
+	if nil == superBlock {
+		utils.Fatalf("genesis super block err")
+	}
+	genesis.Roots = make([]common.CoinRoot, len(superBlock.Root()))
+	copy(genesis.Roots, superBlock.Root())
+	genesis.Sharding = make([]common.Coinbyte, len(superBlock.Sharding()))
+	copy(genesis.Sharding, superBlock.Sharding())
+	genesis.Signatures = make([]common.Signature, 0)
+
+	proposal, err := core.NewSuperBlockProposal(genesis)
+	if err != nil {
+		utils.Fatalf("Failed to create proposal: %v", err)
+	}
+	writeSuperBlockFile(ctx.Args().Get(1), proposal)
+	fmt.Printf("Proposal of super block #%d, hash to sign %s, exported to %s\n", genesis.Number, proposal.Hash.Hex(), ctx.Args().Get(1))
+	return nil
+}
+
+func signSuperBlockProposal(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires 2 arguments.")
+	}
+	proposal := readSuperBlockProposal(ctx.Args().First())
+	key, err := crypto.HexToECDSA(ctx.Args().Get(1))
+	if err != nil {
+		utils.Fatalf("input private key error")
+	}
+	account, err := proposal.Sign(key)
+	if err != nil {
+		utils.Fatalf("Failed to sign proposal: %v", err)
+	}
+	writeSuperBlockFile(ctx.Args().First(), proposal)
+	fmt.Printf("Signed by %s, %d signatures\n", base58.Base58EncodeToString(params.MAN_COIN, account), len(proposal.Genesis.Signatures))
+	return nil
+}
+
+func addSuperBlockSignature(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires 2 arguments.")
+	}
+	proposal := readSuperBlockProposal(ctx.Args().First())
+	signBytes, err := hexutil.Decode(ctx.Args().Get(1))
+	if err != nil || len(signBytes) != common.SignatureLength {
+		utils.Fatalf("invalid signature")
+	}
+	account, err := proposal.AddSignature(common.BytesToSignature(signBytes))
+	if err != nil {
+		utils.Fatalf("Failed to add signature: %v", err)
+	}
+	writeSuperBlockFile(ctx.Args().First(), proposal)
+	fmt.Printf("Signed by %s, %d signatures\n", base58.Base58EncodeToString(params.MAN_COIN, account), len(proposal.Genesis.Signatures))
+	return nil
+}
+
+func superBlockProposalStatus(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	proposal := readSuperBlockProposal(ctx.Args().First())
+
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDB := utils.MakeChain(ctx, stack)
+	if chain == nil {
+		utils.Fatalf("make chain err")
+	}
+	defer chainDB.Close()
+	accounts, err := chain.GetBlockSuperAccounts(chain.GetCurrentHash())
+	if err != nil {
+		utils.Fatalf("Failed to get super block accounts: %v", err)
+	}
+	// same target as the DPOS engine checking the super block
+	target := mtxdpos.NewMtxDPOS(chain.Config().SimpleMode).SuperNodeTarget(len(accounts))
+	status := proposal.SignStatus(accounts, target)
+	if ctx.Bool(superBlockJSONFlag.Name) {
+		return printJSON(status)
+	}
+
+	fmt.Printf("Super block #%d, hash %s\n", proposal.Genesis.Number, status.Hash.Hex())
+	fmt.Printf("Signed by %d of %d super block accounts, %d needed\n", status.Signed, len(status.Accounts), status.Target)
+	for _, signer := range status.Signers {
+		if signer.Super {
+			fmt.Printf("  signed   %s\n", base58.Base58EncodeToString(params.MAN_COIN, signer.Account))
+		} else {
+			fmt.Printf("  unknown  %s, not a super block account\n", base58.Base58EncodeToString(params.MAN_COIN, signer.Account))
+		}
+	}
+	for _, account := range status.Missing {
+		fmt.Printf("  missing  %s\n", base58.Base58EncodeToString(params.MAN_COIN, account))
+	}
+	if len(status.Invalid) > 0 {
+		fmt.Printf("  %d invalid signatures\n", len(status.Invalid))
+	}
+	if status.Ready {
+		fmt.Println("Threshold reached, the super block can be exported and imported")
+	} else {
+		fmt.Printf("Threshold not reached, %d more signatures needed\n", status.Target-status.Signed)
+	}
+	return nil
+}
+
+func dryRunSuperBlock(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	proposal := readSuperBlockProposal(ctx.Args().First())
+
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDB := utils.MakeChain(ctx, stack)
+	if chain == nil {
+		utils.Fatalf("make chain err")
+	}
+	defer chainDB.Close()
+	result, err := chain.DryRunSuperBlock(proposal)
+	if err != nil {
+		utils.Fatalf("Failed to apply super block: %v", err)
+	}
+	if ctx.Bool(superBlockJSONFlag.Name) {
+		return printJSON(result)
+	}
+
+	fmt.Printf("Super block #%d on parent %s, seq %d (current %d)\n", result.Number, result.ParentHash.Hex(), result.Seq, result.CurrentSeq)
+	if !result.RootsMatch {
+		fmt.Println("  WARNING: the roots of the proposal differ from the applied state")
+	}
+	if result.Seq <= result.CurrentSeq {
+		fmt.Println("  WARNING: the super block seq is not above the current seq, the import will fail")
+	}
+	if result.FromVersion != result.ToVersion {
+		fmt.Printf("  version %s -> %s\n", result.FromVersion, result.ToVersion)
+	}
+	fmt.Printf("%d matrix state keys changed\n", len(result.Changes))
+	for _, change := range result.Changes {
+		switch {
+		case change.From == nil:
+			fmt.Printf("  + %s\n", change.Key)
+		case change.To == nil:
+			fmt.Printf("  - %s\n", change.Key)
+		case len(change.Fields) > 0:
+			fmt.Printf("  ~ %s %v\n", change.Key, change.Fields)
+		default:
+			fmt.Printf("  ~ %s\n", change.Key)
+		}
+	}
+	return nil
+}
+
+func exportSuperBlockProposal(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires 2 arguments.")
+	}
+	proposal := readSuperBlockProposal(ctx.Args().First())
+	if _, err := proposal.Signers(); err != nil {
+		utils.Fatalf("invalid proposal file: %v", err)
+	}
+	writeSuperBlockFile(ctx.Args().Get(1), proposal.Genesis)
+	fmt.Printf("Exported super block with %d signatures to %s\n", len(proposal.Genesis.Signatures), ctx.Args().Get(1))
+	return nil
+}
@@ -78,7 +78,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.SnapModeFlg,
 			utils.GetGenesisFlag,
 			utils.LessDiskEnabledFlag,
+			utils.LessDiskPruneStateFlag,
 			utils.DbTableSizeFlag,
+			utils.ShardConfigFlag,
 		},
 	},
 	/*	{Name: "DEVELOPER CHAIN",
@@ -96,6 +98,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ManashDatasetDirFlag,
 			utils.ManashDatasetsInMemoryFlag,
 			utils.ManashDatasetsOnDiskFlag,
+			utils.AIDiggerFlag,
 		},
 	},
 	//{
@@ -212,6 +215,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.FakePoWFlag,
 			utils.NoCompactionFlag,
 			utils.GetCommitFlag,
+			utils.LeaderTimelineFlag,
 		}, debug.Flags...),
 	},
 	{
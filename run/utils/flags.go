@@ -15,6 +15,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/MatrixAINetwork/go-matrix/aidigger"
 	"github.com/MatrixAINetwork/go-matrix/base58"
 
 	"encoding/json"
@@ -24,7 +25,6 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/common/fdlimit"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
-	"github.com/MatrixAINetwork/go-matrix/consensus/amhash"
 	"github.com/MatrixAINetwork/go-matrix/consensus/clique"
 	"github.com/MatrixAINetwork/go-matrix/consensus/manash"
 	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
@@ -248,6 +248,19 @@ var (
 		Usage: "Number of recent manash mining DAGs to keep on disk (1+GB each)",
 		Value: man.DefaultConfig.Manash.DatasetsOnDisk,
 	}
+	AIDiggerFlag = cli.StringFlag{
+		Name:  "aidigger",
+		Usage: `AI digger backend of the amhash engine ("gold" or "reference")`,
+		Value: aidigger.DefaultDigger,
+	}
+	LeaderTimelineFlag = cli.BoolFlag{
+		Name:  "leader.timeline",
+		Usage: "Persist the leader election timeline to the database",
+	}
+	ShardConfigFlag = cli.StringFlag{
+		Name:  "shardconfig",
+		Usage: `Run as a partial node, not validating blocks, keeping the coins and address ranges of the file, one "COIN=0,1,8-15" per line`,
+	}
 	// Transaction pool settings
 	TxPoolNoLocalsFlag = cli.BoolFlag{
 		Name:  "txpool.nolocals",
@@ -385,6 +398,21 @@ var (
 		Usage: "Password file to entrustment transaction",
 		Value: "",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer endpoint holding the sign account keys (IPC path or http(s) URL)",
+		Value: "",
+	}
+	SignerIPCFlag = cli.StringFlag{
+		Name:  "signer.ipc",
+		Usage: "IPC path of the signer daemon (default = signer.ipc inside the datadir)",
+		Value: "signer.ipc",
+	}
+	SignerHTTPFlag = cli.StringFlag{
+		Name:  "signer.http",
+		Usage: "HTTP listening address of the signer daemon, e.g. 127.0.0.1:8548 (disabled by default)",
+		Value: "",
+	}
 	TestEntrustFlag = cli.StringFlag{
 		Name:  "testmode",
 		Usage: "",
@@ -586,6 +614,10 @@ var (
 		Name:  "lessdisk",
 		Usage: "Enable the Less Disk Server",
 	}
+	LessDiskPruneStateFlag = cli.BoolFlag{
+		Name:  "lessdiskprunestate",
+		Usage: "Prune the state no longer referenced by the blocks kept by the Less Disk Server",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1072,6 +1104,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *pod.Config) {
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 	if ctx.GlobalIsSet(LightKDFFlag.Name) {
 		cfg.UseLightweightKDF = ctx.GlobalBool(LightKDFFlag.Name)
 	}
@@ -1152,6 +1187,9 @@ func setManash(ctx *cli.Context, cfg *man.Config) {
 	if ctx.GlobalIsSet(ManashDatasetsOnDiskFlag.Name) {
 		cfg.Manash.DatasetsOnDisk = ctx.GlobalInt(ManashDatasetsOnDiskFlag.Name)
 	}
+	if ctx.GlobalIsSet(AIDiggerFlag.Name) {
+		cfg.AIDigger = ctx.GlobalString(AIDiggerFlag.Name)
+	}
 }
 
 // checkExclusive verifies that only a single isntance of the provided flags was
@@ -1256,6 +1294,21 @@ func SetManConfig(ctx *cli.Context, stack *pod.Node, cfg *man.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(LeaderTimelineFlag.Name) {
+		cfg.LeaderTimeline = ctx.GlobalBool(LeaderTimelineFlag.Name)
+	}
+	if ctx.GlobalIsSet(ShardConfigFlag.Name) {
+		// A partial node can't validate blocks, so it can't take a validator or miner role
+		for _, flag := range []cli.Flag{MiningEnabledFlag, ManAddressFlag, AccountPasswordFileFlag, ExternalSignerFlag, TestEntrustFlag} {
+			if ctx.GlobalIsSet(flag.GetName()) {
+				Fatalf("Option %q cannot be used on a validator or miner node (--%s)", ShardConfigFlag.Name, flag.GetName())
+			}
+		}
+		cfg.ShardConfig = ctx.GlobalString(ShardConfigFlag.Name)
+	}
+	if ctx.GlobalIsSet(LessDiskPruneStateFlag.Name) {
+		cfg.LessDiskPruneState = ctx.GlobalBool(LessDiskPruneStateFlag.Name)
+	}
 
 	// Override any default configs for hard coded networks.
 	/*switch {
@@ -1408,7 +1461,6 @@ func MakeChain(ctx *cli.Context, stack *pod.Node) (chain *core.BlockChain, chain
 }
 
 func createEngineMap(ctx *cli.Context, stack *pod.Node, config *params.ChainConfig, chainDb mandb.Database) (map[string]consensus.Engine, map[string]consensus.DPOSEngine) {
-	engineMap := make(map[string]consensus.Engine)
 	var alphaEngine consensus.Engine
 	if config.Clique != nil {
 		alphaEngine = clique.New(config.Clique, chainDb)
@@ -1425,23 +1477,15 @@ func createEngineMap(ctx *cli.Context, stack *pod.Node, config *params.ChainConf
 			})
 		}
 	}
-	aiMineEngine := amhash.New(amhash.Config{PowMode: amhash.ModeNormal, PictureStorePath: stack.ResolvePath("picstore")})
+	aiMineEngine := man.NewAIMineEngine(stack.ResolvePath("picstore"), ctx.GlobalString(AIDiggerFlag.Name), stack.DataDir())
 	aiMineEngine.SetThreads(-1) // Disable CPU mining
 
-	engineMap[manversion.VersionAlpha] = alphaEngine
-	engineMap[manversion.VersionBeta] = alphaEngine
-	engineMap[manversion.VersionGamma] = alphaEngine
-	engineMap[manversion.VersionDelta] = alphaEngine
-	engineMap[manversion.VersionAIMine] = aiMineEngine
-
-	dposEngineMap := make(map[string]consensus.DPOSEngine)
-	alphaDposEngine := mtxdpos.NewMtxDPOS(config.SimpleMode)
-	dposEngineMap[manversion.VersionAlpha] = alphaDposEngine
-	dposEngineMap[manversion.VersionBeta] = alphaDposEngine
-	dposEngineMap[manversion.VersionGamma] = alphaDposEngine
-	dposEngineMap[manversion.VersionDelta] = alphaDposEngine
-	dposEngineMap[manversion.VersionAIMine] = alphaDposEngine
-
+	engines := map[string]consensus.Engine{manversion.EngineManash: alphaEngine, manversion.EngineAIMine: aiMineEngine}
+	dposEngines := map[string]consensus.DPOSEngine{manversion.EngineMtxDPOS: mtxdpos.NewMtxDPOS(config.SimpleMode)}
+	engineMap, dposEngineMap, err := man.BuildEngineMaps(engines, dposEngines)
+	if err != nil {
+		Fatalf("Can't create consensus engines: %v", err)
+	}
 	return engineMap, dposEngineMap
 }
 
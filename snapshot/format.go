@@ -0,0 +1,484 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// Snapshot file layout:
+//
+//   header:  magic (8 bytes) | version (uint32)
+//   records: type (1 byte) | payload length (uint32) | payload | keccak256(payload)
+//   trailer: manifest record offset (uint64) | magic (8 bytes)
+//
+// A section record opens a section, it is followed by the chunk records of the
+// section. The manifest record is the last record, it lists the keccak256 hash of
+// every chunk of every section. All integers are big endian.
+
+const (
+	// FormatVersion is the version of the snapshot file format written by Writer.
+	FormatVersion uint32 = 1
+
+	// DefaultChunkItems is the max count of state items in one chunk.
+	DefaultChunkItems = 4096
+
+	maxRecordSize = 256 * 1024 * 1024
+	trailerSize   = 8 + 8
+)
+
+// Record types.
+const (
+	recordSection byte = iota + 1
+	recordChunk
+	recordManifest
+)
+
+// Section kinds.
+const (
+	SectionOtherTrie uint8 = iota // state trie referenced by the pre broadcast roots
+	SectionBlockTrie              // state trie of a snapshot block
+	SectionBlock                  // snapshot block and its total difficulty
+)
+
+var fileMagic = []byte{'M', 'A', 'N', 'S', 'N', 'A', 'P', 0}
+
+var (
+	ErrNotSnapshotFile   = errors.New("not a snapshot file")
+	ErrUnsupportedFormat = errors.New("unsupported snapshot format version")
+	ErrChunkHashMismatch = errors.New("snapshot chunk hash mismatch")
+	ErrManifestMismatch  = errors.New("snapshot content does not match the manifest")
+	ErrTruncated         = errors.New("snapshot file truncated")
+	errSectionNotOpen    = errors.New("no open snapshot section")
+	errWriterFinished    = errors.New("snapshot writer already finished")
+)
+
+// SectionHeader identifies a section of a snapshot file. Group is the index of
+// the pre broadcast trie for SectionOtherTrie and the block number otherwise.
+type SectionHeader struct {
+	Kind  uint8
+	Group uint64
+	Coin  string
+	Index uint64      // address range index of the coin
+	Root  common.Hash // root of the state trie, empty for SectionBlock
+}
+
+// SectionInfo is the manifest entry of a section.
+type SectionInfo struct {
+	Header SectionHeader
+	Items  uint64
+	Chunks []common.Hash
+}
+
+// Manifest describes the content of a snapshot file.
+type Manifest struct {
+	Version     uint32
+	BlockNumber uint64
+	BlockHash   common.Hash
+	Sections    []SectionInfo
+}
+
+// Hash returns the hash of the manifest.
+func (m *Manifest) Hash() common.Hash {
+	return types.RlpHash(m)
+}
+
+// Chunks returns the count of chunks of all the sections.
+func (m *Manifest) Chunks() int {
+	count := 0
+	for _, section := range m.Sections {
+		count += len(section.Chunks)
+	}
+	return count
+}
+
+type blockData struct {
+	Block *types.Block
+	Td    *big.Int
+}
+
+// IsSnapshotFile reports whether the file starts with the snapshot file magic.
+// Files written before the versioned format are plain RLP encoded SnapshotDatas.
+func IsSnapshotFile(filePath string) bool {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, len(fileMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return bytes.Equal(magic, fileMagic)
+}
+
+// Writer streams a snapshot into an io.Writer, only the current chunk is kept in memory.
+type Writer struct {
+	w          *bufio.Writer
+	offset     uint64
+	chunkItems int
+	manifest   Manifest
+	section    *SectionInfo
+	items      []state.DumpItem
+	finished   bool
+}
+
+// NewWriter writes the snapshot file header and returns the writer.
+func NewWriter(w io.Writer, chunkItems int) (*Writer, error) {
+	if chunkItems <= 0 {
+		chunkItems = DefaultChunkItems
+	}
+	sw := &Writer{
+		w:          bufio.NewWriter(w),
+		chunkItems: chunkItems,
+		manifest:   Manifest{Version: FormatVersion},
+	}
+	header := make([]byte, len(fileMagic)+4)
+	copy(header, fileMagic)
+	binary.BigEndian.PutUint32(header[len(fileMagic):], FormatVersion)
+	if err := sw.write(header); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (w *Writer) write(data []byte) error {
+	n, err := w.w.Write(data)
+	w.offset += uint64(n)
+	return err
+}
+
+func (w *Writer) writeRecord(typ byte, payload []byte) (common.Hash, error) {
+	hash := crypto.Keccak256Hash(payload)
+	prefix := make([]byte, 5)
+	prefix[0] = typ
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(payload)))
+	if err := w.write(prefix); err != nil {
+		return hash, err
+	}
+	if err := w.write(payload); err != nil {
+		return hash, err
+	}
+	return hash, w.write(hash[:])
+}
+
+// BeginSection closes the current section and opens a new one.
+func (w *Writer) BeginSection(header SectionHeader) error {
+	if w.finished {
+		return errWriterFinished
+	}
+	if err := w.EndSection(); err != nil {
+		return err
+	}
+	payload, err := rlp.EncodeToBytes(&header)
+	if err != nil {
+		return err
+	}
+	if _, err := w.writeRecord(recordSection, payload); err != nil {
+		return err
+	}
+	w.manifest.Sections = append(w.manifest.Sections, SectionInfo{Header: header, Chunks: make([]common.Hash, 0)})
+	w.section = &w.manifest.Sections[len(w.manifest.Sections)-1]
+	return nil
+}
+
+// WriteItem adds a state item to the current section.
+func (w *Writer) WriteItem(item state.DumpItem) error {
+	if w.section == nil {
+		return errSectionNotOpen
+	}
+	w.items = append(w.items, item)
+	if len(w.items) >= w.chunkItems {
+		return w.flushChunk()
+	}
+	return nil
+}
+
+// WriteBlock writes a SectionBlock section holding the block and its total difficulty.
+func (w *Writer) WriteBlock(block *types.Block, td *big.Int) error {
+	if err := w.BeginSection(SectionHeader{Kind: SectionBlock, Group: block.NumberU64()}); err != nil {
+		return err
+	}
+	payload, err := rlp.EncodeToBytes(&blockData{Block: block, Td: td})
+	if err != nil {
+		return err
+	}
+	return w.writeChunk(payload, 1)
+}
+
+func (w *Writer) flushChunk() error {
+	if len(w.items) == 0 {
+		return nil
+	}
+	payload, err := rlp.EncodeToBytes(w.items)
+	if err != nil {
+		return err
+	}
+	count := len(w.items)
+	w.items = w.items[:0]
+	return w.writeChunk(payload, count)
+}
+
+func (w *Writer) writeChunk(payload []byte, count int) error {
+	hash, err := w.writeRecord(recordChunk, payload)
+	if err != nil {
+		return err
+	}
+	w.section.Chunks = append(w.section.Chunks, hash)
+	w.section.Items += uint64(count)
+	return nil
+}
+
+// EndSection flushes the pending items of the current section.
+func (w *Writer) EndSection() error {
+	if w.section == nil {
+		return nil
+	}
+	if err := w.flushChunk(); err != nil {
+		return err
+	}
+	w.section = nil
+	return nil
+}
+
+// Finish writes the manifest tied to the given block and the trailer, and returns the manifest.
+func (w *Writer) Finish(number uint64, hash common.Hash) (*Manifest, error) {
+	if w.finished {
+		return nil, errWriterFinished
+	}
+	if err := w.EndSection(); err != nil {
+		return nil, err
+	}
+	w.manifest.BlockNumber = number
+	w.manifest.BlockHash = hash
+	payload, err := rlp.EncodeToBytes(&w.manifest)
+	if err != nil {
+		return nil, err
+	}
+	manifestOffset := w.offset
+	if _, err := w.writeRecord(recordManifest, payload); err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, trailerSize)
+	binary.BigEndian.PutUint64(trailer, manifestOffset)
+	copy(trailer[8:], fileMagic)
+	if err := w.write(trailer); err != nil {
+		return nil, err
+	}
+	w.finished = true
+	return &w.manifest, w.w.Flush()
+}
+
+// Handler receives the content of a snapshot file read by Walk.
+type Handler interface {
+	BeginSection(header SectionHeader) error
+	Items(header SectionHeader, items []state.DumpItem) error
+	Block(block *types.Block, td *big.Int) error
+	EndSection(header SectionHeader) error
+}
+
+// Walk reads the snapshot from r, checks every chunk against its hash and the
+// manifest, and hands the content to h if not nil. When h returns an error, or
+// the content does not match, Walk stops and returns the error; h may already
+// have received part of the content, so run Walk with a nil handler first to
+// verify a file before loading it.
+func Walk(r io.Reader, h Handler) (*Manifest, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(fileMagic)+4)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, ErrNotSnapshotFile
+	}
+	if !bytes.Equal(header[:len(fileMagic)], fileMagic) {
+		return nil, ErrNotSnapshotFile
+	}
+	if version := binary.BigEndian.Uint32(header[len(fileMagic):]); version != FormatVersion {
+		return nil, fmt.Errorf("%v: %d", ErrUnsupportedFormat, version)
+	}
+
+	var (
+		sections []SectionInfo
+		current  *SectionInfo
+	)
+	endSection := func() error {
+		if current == nil || h == nil {
+			return nil
+		}
+		return h.EndSection(current.Header)
+	}
+	for {
+		typ, payload, hash, err := readRecord(br)
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case recordSection:
+			if err := endSection(); err != nil {
+				return nil, err
+			}
+			var sh SectionHeader
+			if err := rlp.DecodeBytes(payload, &sh); err != nil {
+				return nil, err
+			}
+			sections = append(sections, SectionInfo{Header: sh, Chunks: make([]common.Hash, 0)})
+			current = &sections[len(sections)-1]
+			if h != nil {
+				if err := h.BeginSection(sh); err != nil {
+					return nil, err
+				}
+			}
+
+		case recordChunk:
+			if current == nil {
+				return nil, errSectionNotOpen
+			}
+			current.Chunks = append(current.Chunks, hash)
+			if current.Header.Kind == SectionBlock {
+				var data blockData
+				if err := rlp.DecodeBytes(payload, &data); err != nil {
+					return nil, err
+				}
+				current.Items++
+				if h != nil {
+					if err := h.Block(data.Block, data.Td); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+			var items []state.DumpItem
+			if err := rlp.DecodeBytes(payload, &items); err != nil {
+				return nil, err
+			}
+			current.Items += uint64(len(items))
+			if h != nil {
+				if err := h.Items(current.Header, items); err != nil {
+					return nil, err
+				}
+			}
+
+		case recordManifest:
+			if err := endSection(); err != nil {
+				return nil, err
+			}
+			var manifest Manifest
+			if err := rlp.DecodeBytes(payload, &manifest); err != nil {
+				return nil, err
+			}
+			if err := checkManifest(&manifest, sections); err != nil {
+				return nil, err
+			}
+			return &manifest, nil
+
+		default:
+			return nil, fmt.Errorf("unknown snapshot record type %d", typ)
+		}
+	}
+}
+
+func readRecord(r io.Reader) (byte, []byte, common.Hash, error) {
+	prefix := make([]byte, 5)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return 0, nil, common.Hash{}, ErrTruncated
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxRecordSize {
+		return 0, nil, common.Hash{}, fmt.Errorf("snapshot record too large: %d", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, common.Hash{}, ErrTruncated
+	}
+	var hash common.Hash
+	if _, err := io.ReadFull(r, hash[:]); err != nil {
+		return 0, nil, common.Hash{}, ErrTruncated
+	}
+	if crypto.Keccak256Hash(payload) != hash {
+		return 0, nil, common.Hash{}, ErrChunkHashMismatch
+	}
+	return prefix[0], payload, hash, nil
+}
+
+func checkManifest(manifest *Manifest, sections []SectionInfo) error {
+	if manifest.Version != FormatVersion || len(manifest.Sections) != len(sections) {
+		return ErrManifestMismatch
+	}
+	for i, section := range manifest.Sections {
+		read := sections[i]
+		if section.Header != read.Header || section.Items != read.Items || len(section.Chunks) != len(read.Chunks) {
+			return ErrManifestMismatch
+		}
+		for j := range section.Chunks {
+			if section.Chunks[j] != read.Chunks[j] {
+				return ErrManifestMismatch
+			}
+		}
+	}
+	return nil
+}
+
+// VerifyFile checks the whole snapshot file and returns its manifest.
+func VerifyFile(filePath string) (*Manifest, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Walk(f, nil)
+}
+
+// ReadManifest reads the manifest of a snapshot file through the trailer, without
+// reading nor checking the sections.
+func ReadManifest(filePath string) (*Manifest, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < int64(len(fileMagic)+4+trailerSize) {
+		return nil, ErrNotSnapshotFile
+	}
+	trailer := make([]byte, trailerSize)
+	if _, err := f.ReadAt(trailer, info.Size()-trailerSize); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(trailer[8:], fileMagic) {
+		return nil, ErrTruncated
+	}
+	offset := binary.BigEndian.Uint64(trailer)
+	if offset >= uint64(info.Size()-trailerSize) {
+		return nil, ErrTruncated
+	}
+	if _, err := f.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+	typ, payload, _, err := readRecord(f)
+	if err != nil {
+		return nil, err
+	}
+	if typ != recordManifest {
+		return nil, ErrTruncated
+	}
+	var manifest Manifest
+	if err := rlp.DecodeBytes(payload, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
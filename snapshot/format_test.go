@@ -0,0 +1,131 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package snapshot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+type testHandler struct {
+	sections []SectionHeader
+	items    int
+	blocks   []uint64
+}
+
+func (h *testHandler) BeginSection(header SectionHeader) error {
+	h.sections = append(h.sections, header)
+	return nil
+}
+func (h *testHandler) Items(header SectionHeader, items []state.DumpItem) error {
+	h.items += len(items)
+	return nil
+}
+func (h *testHandler) Block(block *types.Block, td *big.Int) error {
+	h.blocks = append(h.blocks, block.NumberU64())
+	return nil
+}
+func (h *testHandler) EndSection(header SectionHeader) error { return nil }
+
+func writeTestSnapshot(t *testing.T, chunkItems int) ([]byte, *Manifest) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, chunkItems)
+	if err != nil {
+		t.Fatalf("new writer err: %v", err)
+	}
+	for i := uint64(0); i < 2; i++ {
+		if err := w.BeginSection(SectionHeader{Kind: SectionBlockTrie, Group: 10, Coin: "MAN", Index: i, Root: common.HexToHash("0x01")}); err != nil {
+			t.Fatalf("begin section err: %v", err)
+		}
+		for j := 0; j < 10; j++ {
+			item := state.DumpItem{Kind: state.DumpItemAccount, Value: state.DumpValue{Key: []byte{byte(i), byte(j)}, GetKey: []byte{byte(j)}, Value: []byte("value")}}
+			if err := w.WriteItem(item); err != nil {
+				t.Fatalf("write item err: %v", err)
+			}
+		}
+	}
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), Time: big.NewInt(1), Version: []byte(manversion.VersionAIMine)})
+	if err := w.WriteBlock(block, big.NewInt(100)); err != nil {
+		t.Fatalf("write block err: %v", err)
+	}
+	manifest, err := w.Finish(block.NumberU64(), block.Hash())
+	if err != nil {
+		t.Fatalf("finish err: %v", err)
+	}
+	return buf.Bytes(), manifest
+}
+
+func TestWriteAndWalk(t *testing.T) {
+	data, manifest := writeTestSnapshot(t, 4)
+	if len(manifest.Sections) != 3 {
+		t.Fatalf("section count mismatch: have %d, want 3", len(manifest.Sections))
+	}
+	if chunks := len(manifest.Sections[0].Chunks); chunks != 3 {
+		t.Errorf("chunk count mismatch: have %d, want 3", chunks)
+	}
+
+	h := new(testHandler)
+	read, err := Walk(bytes.NewReader(data), h)
+	if err != nil {
+		t.Fatalf("walk err: %v", err)
+	}
+	if read.Hash() != manifest.Hash() {
+		t.Errorf("manifest mismatch")
+	}
+	if h.items != 20 || len(h.sections) != 3 || len(h.blocks) != 1 || h.blocks[0] != 10 {
+		t.Errorf("content mismatch: items %d sections %d blocks %v", h.items, len(h.sections), h.blocks)
+	}
+}
+
+func TestWalkCorrupted(t *testing.T) {
+	data, _ := writeTestSnapshot(t, 4)
+
+	corrupted := common.CopyBytes(data)
+	corrupted[len(fileMagic)+4+40] ^= 0xff
+	if _, err := Walk(bytes.NewReader(corrupted), nil); err == nil {
+		t.Errorf("corrupted snapshot passed the verification")
+	}
+	if _, err := Walk(bytes.NewReader(data[:len(data)/2]), nil); err != ErrTruncated {
+		t.Errorf("truncated snapshot: have %v, want %v", err, ErrTruncated)
+	}
+	if _, err := Walk(bytes.NewReader([]byte("not a snapshot file")), nil); err != ErrNotSnapshotFile {
+		t.Errorf("legacy file: have %v, want %v", err, ErrNotSnapshotFile)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, manifest := writeTestSnapshot(t, 0)
+	path := filepath.Join(dir, "TrieData10")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !IsSnapshotFile(path) {
+		t.Errorf("snapshot file not recognized")
+	}
+	read, err := ReadManifest(path)
+	if err != nil {
+		t.Fatalf("read manifest err: %v", err)
+	}
+	if read.Hash() != manifest.Hash() || read.BlockNumber != 10 {
+		t.Errorf("manifest mismatch")
+	}
+	if _, err := VerifyFile(path); err != nil {
+		t.Errorf("verify err: %v", err)
+	}
+}